@@ -2,17 +2,101 @@ package config
 
 import (
 	"log"
+	"time"
 
 	"github.com/caarlos0/env/v10"
 	_ "github.com/joho/godotenv/autoload"
 )
 
+// Storage backends selected with STORAGE. The memory one keeps nothing
+// across restarts and is meant for local development.
+const (
+	StorageMongo    = "mongo"
+	StorageMemory   = "memory"
+	StorageSQLite   = "sqlite"
+	StoragePostgres = "postgres"
+)
+
 type Config struct {
 	Environment string `env:"ENVIRONMENT" envDefault:"dev"`
 	Port        string `env:"PORT" envDefault:"8080"`
 	SwaggerHost string `env:"SWAGGER_HOST" envDefault:"localhost:8080"`
 	MongoURI    string `env:"MONGO_URI" envDefault:""`
 	MongoDB     string `env:"MONGO_DB" envDefault:"secret-santa"`
+	Storage     string `env:"STORAGE" envDefault:"mongo"`
+
+	// Relational storage: SQLitePath is the database file of STORAGE=sqlite
+	// and PostgresURL the connection string of STORAGE=postgres.
+	SQLitePath  string `env:"SQLITE_PATH" envDefault:"secret-santa.db"`
+	PostgresURL string `env:"POSTGRES_URL" envDefault:""`
+
+	// Deadlines of single database operations, whatever the storage. A
+	// request that exceeds one is answered with 504. The MONGO_* names, from
+	// before there was more than one storage, are read when the STORAGE_*
+	// ones are not set.
+	StorageReadTimeout  time.Duration `env:"STORAGE_READ_TIMEOUT"`
+	StorageWriteTimeout time.Duration `env:"STORAGE_WRITE_TIMEOUT"`
+	MongoReadTimeout    time.Duration `env:"MONGO_READ_TIMEOUT" envDefault:"5s"`
+	MongoWriteTimeout   time.Duration `env:"MONGO_WRITE_TIMEOUT" envDefault:"10s"`
+
+	// Deleted groups stay in the trash for TrashRetention before they are
+	// removed for good. Mongo purges them with TTL indexes; the other storages
+	// look for expired groups every TrashPurgeInterval.
+	TrashRetention     time.Duration `env:"TRASH_RETENTION" envDefault:"720h"`
+	TrashPurgeInterval time.Duration `env:"TRASH_PURGE_INTERVAL" envDefault:"1h"`
+
+	// Participant names and emails are replaced by pseudonyms PIIRetention
+	// after the exchange date of a group. Owners are warned by email
+	// PIIRetentionWarning before, and the purge waits at least that long
+	// after the warning. The job runs every PIIRetentionInterval.
+	PIIRetention         time.Duration `env:"PII_RETENTION" envDefault:"2160h"`
+	PIIRetentionWarning  time.Duration `env:"PII_RETENTION_WARNING" envDefault:"168h"`
+	PIIRetentionInterval time.Duration `env:"PII_RETENTION_INTERVAL" envDefault:"1h"`
+
+	// Outgoing email. Without SMTPAddr (host:port) messages are only logged,
+	// without their recipient and body.
+	SMTPAddr     string `env:"SMTP_ADDR" envDefault:""`
+	SMTPUsername string `env:"SMTP_USERNAME" envDefault:""`
+	SMTPPassword string `env:"SMTP_PASSWORD" envDefault:""`
+	MailFrom     string `env:"MAIL_FROM" envDefault:"secret-santa@localhost"`
+
+	// Participant emails and matches are encrypted in MongoDB under the
+	// first of EncryptionKeys, a comma-separated list of id:key with 32-byte
+	// keys in base64; the other keys only decrypt. BlindIndexKey, 32 bytes in
+	// base64, keys the HMAC that emails are looked up by, and the subject
+	// hash of erasure certificates, which is left empty without it. Without
+	// EncryptionKeys emails and matches are stored in plaintext. Documents
+	// under an older key are encrypted again every ReencryptInterval.
+	EncryptionKeys    string        `env:"ENCRYPTION_KEYS" envDefault:""`
+	BlindIndexKey     string        `env:"BLIND_INDEX_KEY" envDefault:""`
+	ReencryptInterval time.Duration `env:"REENCRYPT_INTERVAL" envDefault:"1h"`
+
+	// AdminToken is the bearer token of the /admin routes, which export and
+	// erase personal data. The routes answer 401 while it is empty.
+	AdminToken string `env:"ADMIN_TOKEN" envDefault:""`
+
+	// GatewayToken is what the gateway sends in X-Gateway-Token along with
+	// the X-User-Email it authenticated. Without it, or with another token,
+	// the email is ignored and requests have no user.
+	GatewayToken string `env:"GATEWAY_TOKEN" envDefault:""`
+
+	// On SIGTERM the service reports not ready for ShutdownDelay, so load
+	// balancers stop sending requests, and then has DrainTimeout to finish
+	// the requests in flight and close its connections.
+	ShutdownDelay time.Duration `env:"SHUTDOWN_DELAY" envDefault:"5s"`
+	DrainTimeout  time.Duration `env:"DRAIN_TIMEOUT" envDefault:"20s"`
+
+	// DefaultLocale is used when Accept-Language names no bundled locale.
+	DefaultLocale string `env:"DEFAULT_LOCALE" envDefault:"en"`
+
+	// LogLevel is debug, info, warn or error; LogFormat is json or text.
+	LogLevel  string `env:"LOG_LEVEL" envDefault:"info"`
+	LogFormat string `env:"LOG_FORMAT" envDefault:"json"`
+
+	// TracingExporter is none, stdout or otlp; the OTLP endpoint comes from
+	// OTEL_EXPORTER_OTLP_ENDPOINT. TracingSampleRatio applies to new traces.
+	TracingExporter    string  `env:"TRACING_EXPORTER" envDefault:"none"`
+	TracingSampleRatio float64 `env:"TRACING_SAMPLE_RATIO" envDefault:"1"`
 }
 
 var Cfg *Config
@@ -22,4 +106,29 @@ func LoadConfig() {
 	if err := env.Parse(Cfg); err != nil {
 		log.Fatalf(`error on parse env variables due:[%v]`, err)
 	}
+
+	switch Cfg.Storage {
+	case StorageMongo, StorageMemory, StorageSQLite, StoragePostgres:
+	default:
+		log.Fatalf(`invalid STORAGE %q, use mongo, memory, sqlite or postgres`, Cfg.Storage)
+	}
+
+	if Cfg.StorageReadTimeout == 0 {
+		Cfg.StorageReadTimeout = Cfg.MongoReadTimeout
+	}
+	if Cfg.StorageWriteTimeout == 0 {
+		Cfg.StorageWriteTimeout = Cfg.MongoWriteTimeout
+	}
+
+	if Cfg.TrashRetention < time.Second || Cfg.TrashPurgeInterval <= 0 {
+		log.Fatalf(`TRASH_RETENTION must be at least 1s and TRASH_PURGE_INTERVAL positive`)
+	}
+
+	if Cfg.PIIRetention < time.Second || Cfg.PIIRetentionWarning < 0 || Cfg.PIIRetentionInterval <= 0 {
+		log.Fatalf(`PII_RETENTION must be at least 1s, PII_RETENTION_WARNING not negative and PII_RETENTION_INTERVAL positive`)
+	}
+
+	if Cfg.ReencryptInterval <= 0 {
+		log.Fatalf(`REENCRYPT_INTERVAL must be positive`)
+	}
 }
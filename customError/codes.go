@@ -0,0 +1,107 @@
+package customError
+
+import (
+	"net/http"
+
+	"service-secret-santa/i18n"
+)
+
+// ErrorCode is a stable, machine readable identifier of an error. Clients
+// should branch on it rather than on messages, which may change or be
+// translated.
+type ErrorCode string
+
+const (
+	BadRequest           ErrorCode = "BAD_REQUEST"
+	InvalidRequestBody   ErrorCode = "INVALID_REQUEST_BODY"
+	InvalidQuery         ErrorCode = "INVALID_QUERY"
+	ValidationFailed     ErrorCode = "VALIDATION_FAILED"
+	GroupIdInvalid       ErrorCode = "GROUP_ID_INVALID"
+	CursorInvalid        ErrorCode = "CURSOR_INVALID"
+	PreconditionInvalid  ErrorCode = "PRECONDITION_INVALID"
+	DrawInfeasible       ErrorCode = "DRAW_INFEASIBLE"
+	GroupNotFound        ErrorCode = "GROUP_NOT_FOUND"
+	ErasureNotFound      ErrorCode = "ERASURE_NOT_FOUND"
+	MatchNotFound        ErrorCode = "MATCH_NOT_FOUND"
+	NotFound             ErrorCode = "NOT_FOUND"
+	UsernameAmbiguous    ErrorCode = "USERNAME_AMBIGUOUS"
+	ParticipantDuplicate ErrorCode = "PARTICIPANT_DUPLICATE"
+	Conflict             ErrorCode = "CONFLICT"
+	AlreadyExists        ErrorCode = "ALREADY_EXISTS"
+	DocumentRejected     ErrorCode = "DOCUMENT_REJECTED"
+	GroupVersionMismatch ErrorCode = "GROUP_VERSION_MISMATCH"
+	UnsupportedMediaType ErrorCode = "UNSUPPORTED_MEDIA_TYPE"
+	PreconditionRequired ErrorCode = "PRECONDITION_REQUIRED"
+	Unauthorized         ErrorCode = "UNAUTHORIZED"
+	Forbidden            ErrorCode = "FORBIDDEN"
+	InternalError        ErrorCode = "INTERNAL_ERROR"
+	ServiceUnavailable   ErrorCode = "SERVICE_UNAVAILABLE"
+	DeadlineExceeded     ErrorCode = "DEADLINE_EXCEEDED"
+	RequestCanceled      ErrorCode = "REQUEST_CANCELED"
+
+	// Codes of the per-field errors of a request.
+	FieldRequired  ErrorCode = "FIELD_REQUIRED"
+	FieldInvalid   ErrorCode = "FIELD_INVALID"
+	FieldImmutable ErrorCode = "FIELD_IMMUTABLE"
+	FieldUnknown   ErrorCode = "FIELD_UNKNOWN"
+)
+
+// StatusClientClosedRequest is the non-standard status of requests abandoned
+// by the client before an answer was ready.
+const StatusClientClosedRequest = 499
+
+// Codes lists the catalog. The title and detail of every code are translated
+// in the i18n bundles under problem.<CODE>.title and problem.<CODE>.detail.
+var Codes = []ErrorCode{
+	BadRequest, InvalidRequestBody, InvalidQuery, ValidationFailed, GroupIdInvalid,
+	CursorInvalid, PreconditionInvalid, DrawInfeasible, GroupNotFound, ErasureNotFound, MatchNotFound,
+	NotFound, UsernameAmbiguous, ParticipantDuplicate, Conflict, AlreadyExists,
+	DocumentRejected, GroupVersionMismatch, UnsupportedMediaType, PreconditionRequired,
+	Unauthorized, Forbidden, InternalError, ServiceUnavailable, DeadlineExceeded, RequestCanceled,
+	FieldRequired, FieldInvalid, FieldImmutable, FieldUnknown,
+}
+
+// Title returns the title of code in locale.
+func (code ErrorCode) Title(locale i18n.Locale) string {
+	if title, in := i18n.Translate(locale, "problem."+string(code)+".title", nil); in {
+		return title
+	}
+	return string(code)
+}
+
+// Detail returns the generic description of code in locale.
+func (code ErrorCode) Detail(locale i18n.Locale) (string, bool) {
+	return i18n.Translate(locale, "problem."+string(code)+".detail", nil)
+}
+
+// defaultCode is the code of errors raised without an explicit one.
+func defaultCode(status int) ErrorCode {
+	switch status {
+	case http.StatusBadRequest:
+		return BadRequest
+	case http.StatusUnauthorized:
+		return Unauthorized
+	case http.StatusForbidden:
+		return Forbidden
+	case http.StatusNotFound:
+		return NotFound
+	case http.StatusConflict:
+		return Conflict
+	case http.StatusPreconditionFailed:
+		return GroupVersionMismatch
+	case http.StatusUnsupportedMediaType:
+		return UnsupportedMediaType
+	case http.StatusPreconditionRequired:
+		return PreconditionRequired
+	case http.StatusUnprocessableEntity:
+		return DocumentRejected
+	case http.StatusServiceUnavailable:
+		return ServiceUnavailable
+	case http.StatusGatewayTimeout:
+		return DeadlineExceeded
+	case StatusClientClosedRequest:
+		return RequestCanceled
+	default:
+		return InternalError
+	}
+}
@@ -0,0 +1,64 @@
+package customError
+
+import (
+	"testing"
+
+	"service-secret-santa/i18n"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEveryCodeIsTranslated(t *testing.T) {
+	for _, code := range Codes {
+		for _, locale := range i18n.Locales {
+			_, in := i18n.Translate(locale, "problem."+string(code)+".title", nil)
+			assert.True(t, in, "%s has no title", code)
+			_, in = code.Detail(locale)
+			assert.True(t, in, "%s has no detail", code)
+		}
+	}
+}
+
+func TestNewProblemHidesCauses(t *testing.T) {
+	err := NewCustomError(WithInternalServerError("connection refused", "Failed to create group"))
+
+	problem := NewProblem(err, "/secret-santa/group", i18n.Portuguese)
+	assert.Equal(t, InternalError, problem.Code)
+	assert.Equal(t, "Erro interno do servidor", problem.Title)
+	assert.NotContains(t, problem.Detail, "connection refused")
+}
+
+func TestNewProblemTranslatesFieldErrors(t *testing.T) {
+	err := NewCustomError(
+		WithBadRequest("name: cannot be blank.", "Validation error"),
+		WithCode(ValidationFailed),
+		WithFieldErrors([]FieldError{
+			{Field: "name", Code: FieldRequired, Detail: "cannot be blank", MessageKey: "validation_required"},
+			{Field: "matches", Code: FieldImmutable, Detail: "is immutable"},
+		}),
+	)
+
+	problem := NewProblem(err, "/secret-santa/group/1", i18n.Spanish)
+	assert.Equal(t, []FieldError{
+		{Field: "name", Code: FieldRequired, Detail: "no puede estar vacío", MessageKey: "validation_required"},
+		{Field: "matches", Code: FieldImmutable, Detail: "no se puede modificar"},
+	}, problem.Errors)
+	assert.Equal(t, "cannot be blank", err.FieldErrors[0].Detail)
+}
+
+func TestLocalizedTranslatesLegacyShape(t *testing.T) {
+	err := NewCustomError(
+		WithBadRequest("matches: is immutable.", "Validation error"),
+		WithCode(ValidationFailed),
+		WithFieldErrors([]FieldError{{Field: "matches", Code: FieldImmutable, Detail: "is immutable"}}),
+	)
+
+	localized := err.Localized(i18n.Portuguese)
+	detail, _ := ValidationFailed.Detail(i18n.Portuguese)
+	assert.Equal(t, detail, localized.Message)
+	assert.Equal(t, map[string]string{"matches": "não pode ser alterado"}, localized.Fields)
+	assert.Equal(t, "Validation error", err.Message)
+	assert.Equal(t, "is immutable", err.Fields["matches"])
+
+	assert.Same(t, err, err.Localized(i18n.English))
+}
@@ -1,15 +1,33 @@
 package customError
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
+	"sort"
+
+	"github.com/invopop/validation"
 )
 
 type CustomError struct {
-	Message string `json:"message"`
-	Causes  string `json:"causes"`
-	Status  int    `json:"status"`
-	Code    string `json:"code"`
+	Message     string            `json:"message"`
+	Causes      string            `json:"causes"`
+	Status      int               `json:"status"`
+	Code        string            `json:"code"`
+	Suggestions []string          `json:"suggestions,omitempty"`
+	Fields      map[string]string `json:"fields,omitempty"`
+	ErrorCode   ErrorCode         `json:"-"`
+	FieldErrors []FieldError      `json:"-"`
+}
+
+// FieldError is what is wrong with one field of a request. MessageKey and
+// Params select its translation under field.<MessageKey>.
+type FieldError struct {
+	Field      string                 `json:"field"`
+	Code       ErrorCode              `json:"code"`
+	Detail     string                 `json:"detail"`
+	MessageKey string                 `json:"-"`
+	Params     map[string]interface{} `json:"-"`
 }
 
 type CustomErrorOption func(customError *CustomError)
@@ -68,6 +86,24 @@ func WithUnauthorized(causes, message string) CustomErrorOption {
 	}
 }
 
+func WithForbidden(causes, message string) CustomErrorOption {
+	return func(e *CustomError) {
+		e.Causes = causes
+		e.Status = http.StatusForbidden
+		e.Message = message
+		e.Code = http.StatusText(http.StatusForbidden)
+	}
+}
+
+func WithPreconditionFailed(causes, message string) CustomErrorOption {
+	return func(e *CustomError) {
+		e.Causes = causes
+		e.Status = http.StatusPreconditionFailed
+		e.Message = message
+		e.Code = http.StatusText(http.StatusPreconditionFailed)
+	}
+}
+
 func WithCustomError(status int, causes, message string) CustomErrorOption {
 	return func(e *CustomError) {
 		e.Causes = causes
@@ -76,3 +112,81 @@ func WithCustomError(status int, causes, message string) CustomErrorOption {
 		e.Code = http.StatusText(status)
 	}
 }
+
+// WithCode sets the catalog code of the error. Errors without one get a
+// generic code derived from their status.
+func WithCode(code ErrorCode) CustomErrorOption {
+	return func(e *CustomError) {
+		e.ErrorCode = code
+	}
+}
+
+// Kind returns the catalog code of the error.
+func (e CustomError) Kind() ErrorCode {
+	if e.ErrorCode != "" {
+		return e.ErrorCode
+	}
+	return defaultCode(e.Status)
+}
+
+// WithSuggestions lists values the client may have meant, e.g. close participant names.
+func WithSuggestions(suggestions []string) CustomErrorOption {
+	return func(e *CustomError) {
+		e.Suggestions = suggestions
+	}
+}
+
+// WithFieldErrors attaches what is wrong with each offending field of a request.
+func WithFieldErrors(fields []FieldError) CustomErrorOption {
+	return func(e *CustomError) {
+		e.FieldErrors = fields
+		e.Fields = map[string]string{}
+		for _, field := range fields {
+			e.Fields[field.Field] = field.Detail
+		}
+	}
+}
+
+// WithValidationErrors attaches the field errors reported by validation,
+// with nested fields joined by dots, e.g. "participants.0.email".
+func WithValidationErrors(err error) CustomErrorOption {
+	return func(e *CustomError) {
+		var validationErrs validation.Errors
+		if !errors.As(err, &validationErrs) {
+			return
+		}
+		var fields []FieldError
+		flattenValidationErrors("", validationErrs, &fields)
+		sort.Slice(fields, func(i, j int) bool { return fields[i].Field < fields[j].Field })
+		WithFieldErrors(fields)(e)
+	}
+}
+
+func flattenValidationErrors(prefix string, errs validation.Errors, fields *[]FieldError) {
+	for field, err := range errs {
+		var nested validation.Errors
+		if errors.As(err, &nested) {
+			flattenValidationErrors(prefix+field+".", nested, fields)
+			continue
+		}
+		*fields = append(*fields, validationFieldError(prefix+field, err))
+	}
+}
+
+// validationFieldError maps a validation rule error to a field error, keeping
+// the rule code and params for translation.
+func validationFieldError(field string, err error) FieldError {
+	fieldErr := FieldError{Field: field, Code: FieldInvalid, Detail: err.Error()}
+
+	var ruleErr validation.Error
+	if errors.As(err, &ruleErr) {
+		fieldErr.MessageKey = ruleErr.Code()
+		fieldErr.Params = ruleErr.Params()
+		switch ruleErr.Code() {
+		case "validation_required", "validation_not_nil_required", "validation_nil_or_not_empty_required":
+			fieldErr.Code = FieldRequired
+		}
+	}
+
+	return fieldErr
+}
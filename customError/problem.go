@@ -0,0 +1,95 @@
+package customError
+
+import (
+	"net/http"
+
+	"service-secret-santa/i18n"
+)
+
+const (
+	// ProblemContentType is the media type of RFC 7807 error responses.
+	ProblemContentType = "application/problem+json"
+	// LegacyContentType selects the error shape used before problem details,
+	// for clients that still read message/causes/status.
+	LegacyContentType = "application/vnd.secret-santa.error.v1+json"
+
+	problemTypePrefix = "urn:secret-santa:problem:"
+)
+
+// Problem is an RFC 7807 problem details document. Type and Code identify the
+// error in the catalog and never change; Title and Detail are translated.
+type Problem struct {
+	Type        string       `json:"type"`
+	Title       string       `json:"title"`
+	Status      int          `json:"status"`
+	Detail      string       `json:"detail,omitempty"`
+	Instance    string       `json:"instance,omitempty"`
+	Code        ErrorCode    `json:"code"`
+	Errors      []FieldError `json:"errors,omitempty"`
+	Suggestions []string     `json:"suggestions,omitempty"`
+}
+
+// NewProblem renders err in locale as problem details for the request at
+// instance. Causes are never exposed: codes without a translated detail fall
+// back to the message, and only for client errors.
+func NewProblem(err *CustomError, instance string, locale i18n.Locale) Problem {
+	code := err.Kind()
+
+	detail, translated := code.Detail(locale)
+	if !translated && err.Status < http.StatusInternalServerError {
+		detail = err.Message
+	}
+
+	var fields []FieldError
+	for _, field := range err.FieldErrors {
+		field.Detail = field.localized(locale)
+		fields = append(fields, field)
+	}
+
+	return Problem{
+		Type:        problemTypePrefix + string(code),
+		Title:       code.Title(locale),
+		Status:      err.Status,
+		Detail:      detail,
+		Instance:    instance,
+		Code:        code,
+		Errors:      fields,
+		Suggestions: err.Suggestions,
+	}
+}
+
+func (f FieldError) localized(locale i18n.Locale) string {
+	if f.MessageKey != "" {
+		if message, in := i18n.Translate(locale, "field."+f.MessageKey, f.Params); in {
+			return message
+		}
+	}
+	if message, in := f.Code.Detail(locale); in {
+		return message
+	}
+	return f.Detail
+}
+
+// Localized returns a copy of err for the legacy shape with its message and
+// field messages in locale. English is what they are written in, so they are
+// left as they are for English clients; other locales get the catalog detail
+// of the code, and only for client errors.
+func (e *CustomError) Localized(locale i18n.Locale) *CustomError {
+	if locale == i18n.English {
+		return e
+	}
+
+	localized := *e
+	if detail, translated := e.Kind().Detail(locale); translated && e.Status < http.StatusInternalServerError {
+		localized.Message = detail
+	}
+
+	if len(e.FieldErrors) > 0 {
+		localized.Fields = make(map[string]string, len(e.FieldErrors))
+		for _, field := range e.FieldErrors {
+			localized.Fields[field.Field] = field.localized(locale)
+		}
+	}
+
+	return &localized
+}
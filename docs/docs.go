@@ -16,106 +16,270 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/group": {
+        "/admin/erasures/{id}": {
             "get": {
-                "description": "Retrieve a list of all groups",
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ],
+                "description": "Retrieve the record of an erasure by its ID.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "group"
+                    "admin"
+                ],
+                "summary": "Get an erasure certificate",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Certificate ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
-                "summary": "Get all groups",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/models.Group"
-                            }
+                            "$ref": "#/definitions/dto.ErasureCertificateResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/customError.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/customError.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/customError.Problem"
                         }
                     },
                     "500": {
-                        "description": "{\"error\": \"Internal Server Error.\"}"
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/customError.Problem"
+                        }
                     }
                 }
-            },
-            "post": {
-                "description": "Create a new group with a name",
-                "consumes": [
-                    "application/json"
+            }
+        },
+        "/admin/subject": {
+            "get": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
                 ],
+                "description": "List every group, in the trash or not, that the email owns or takes part in, with its participants and matches, and the audit events of any group that name the person or that they made. There are no wishlists or messages to export.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "group"
+                    "admin"
                 ],
-                "summary": "Create a new group",
+                "summary": "Export the personal data of a person",
                 "parameters": [
                     {
-                        "description": "Group object",
-                        "name": "body",
-                        "in": "body",
-                        "required": true,
+                        "type": "string",
+                        "description": "Email of the person",
+                        "name": "email",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.SubjectExportResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/customError.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/customError.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/models.Group"
+                            "$ref": "#/definitions/customError.Problem"
                         }
                     }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ],
+                "description": "Remove the email from every group, in the trash or not, as owner and as participant. Drawn groups keep the matches of the others: whoever gave to the person now gives to whom they gave. A group left with fewer than two participants is open again. The email and names of the person are taken out of the audit log. The erasure is recorded in a certificate that does not keep the email, only an HMAC of it under the blind index key.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Erase the personal data of a person",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Email of the person",
+                        "name": "email",
+                        "in": "query",
+                        "required": true
+                    }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Created",
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.Group"
+                            "$ref": "#/definitions/dto.ErasureCertificateResponse"
                         }
                     },
                     "400": {
-                        "description": "{\"error\": \"Bad Request.\"}"
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/customError.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/customError.Problem"
+                        }
+                    },
+                    "412": {
+                        "description": "Precondition Failed",
+                        "schema": {
+                            "$ref": "#/definitions/customError.Problem"
+                        }
                     },
                     "500": {
-                        "description": "{\"error\": \"Internal Server Error.\"}"
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/customError.Problem"
+                        }
                     }
                 }
             }
         },
-        "/group/{id}": {
+        "/group": {
             "get": {
-                "description": "Retrieve details of a specific group by its ID",
+                "description": "Retrieve a page of groups, optionally filtered and sorted. Use the returned nextCursor to fetch the following page.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "group"
                 ],
-                "summary": "Get a group by ID",
+                "summary": "Get all groups",
                 "parameters": [
+                    {
+                        "type": "integer",
+                        "default": 20,
+                        "description": "Page size (1-100)",
+                        "name": "limit",
+                        "in": "query"
+                    },
                     {
                         "type": "string",
-                        "description": "Group ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "Opaque cursor returned as nextCursor by the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "createdAt",
+                            "name"
+                        ],
+                        "type": "string",
+                        "default": "createdAt",
+                        "description": "Sort field",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "asc",
+                            "desc"
+                        ],
+                        "type": "string",
+                        "default": "desc",
+                        "description": "Sort order",
+                        "name": "order",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Group name contains (case and accent insensitive)",
+                        "name": "name",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "open",
+                            "drawn"
+                        ],
+                        "type": "string",
+                        "description": "Group status",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Owner email",
+                        "name": "owner",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Email of a participant",
+                        "name": "participantEmail",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.Group"
+                            "$ref": "#/definitions/dto.GroupPageResponse"
                         }
                     },
-                    "404": {
-                        "description": "{\"error\": \"Not Found.\"}"
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/customError.Problem"
+                        }
                     },
                     "500": {
-                        "description": "{\"error\": \"Internal Server Error.\"}"
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/customError.Problem"
+                        }
                     }
                 }
             },
-            "put": {
-                "description": "Update details of a specific group",
+            "post": {
+                "description": "Create a new group with a name",
                 "consumes": [
                     "application/json"
                 ],
@@ -125,134 +289,138 @@ const docTemplate = `{
                 "tags": [
                     "group"
                 ],
-                "summary": "Update a group",
+                "summary": "Create a new group",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Group ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "description": "Updated group object",
+                        "description": "Group object",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.Group"
+                            "$ref": "#/definitions/dto.CreateGroupRequest"
                         }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "201": {
+                        "description": "Created",
                         "schema": {
-                            "$ref": "#/definitions/models.Group"
+                            "$ref": "#/definitions/dto.GroupResponse"
                         }
                     },
                     "400": {
-                        "description": "{\"error\": \"Bad Request.\"}"
-                    },
-                    "404": {
-                        "description": "{\"error\": \"Not Found.\"}"
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/customError.Problem"
+                        }
                     },
                     "500": {
-                        "description": "{\"error\": \"Internal Server Error.\"}"
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/customError.Problem"
+                        }
                     }
                 }
-            },
-            "delete": {
-                "description": "Delete a specific group by its ID",
+            }
+        },
+        "/group/search": {
+            "get": {
+                "description": "Search groups by name or owner email, ignoring case and accents. Exact matches come first, then prefix matches, then partial matches.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "group"
                 ],
-                "summary": "Delete a group",
+                "summary": "Search groups",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Group ID",
-                        "name": "id",
-                        "in": "path",
+                        "description": "Search term",
+                        "name": "q",
+                        "in": "query",
                         "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "default": 20,
+                        "description": "Maximum number of results (1-100)",
+                        "name": "limit",
+                        "in": "query"
                     }
                 ],
                 "responses": {
-                    "204": {
-                        "description": "{}"
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/dto.GroupSummaryResponse"
+                            }
+                        }
                     },
-                    "404": {
-                        "description": "{\"error\": \"Not Found.\"}"
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/customError.Problem"
+                        }
                     },
                     "500": {
-                        "description": "{\"error\": \"Internal Server Error.\"}"
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/customError.Problem"
+                        }
                     }
                 }
             }
         },
-        "/group/{id}/add-participant": {
-            "post": {
-                "description": "Add a new participant to an existing group",
-                "consumes": [
-                    "application/json"
-                ],
+        "/group/trash": {
+            "get": {
+                "description": "List the groups in the trash, most recently deleted first, with when each one is removed for good.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "group"
                 ],
-                "summary": "Add a participant to a group",
+                "summary": "List deleted groups",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Group ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "description": "Participant to add",
-                        "name": "body",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/models.Participant"
-                        }
+                        "description": "Owner email",
+                        "name": "owner",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.Group"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/dto.TrashedGroupResponse"
+                            }
                         }
                     },
-                    "400": {
-                        "description": "{\"error\": \"Bad Request.\"}"
-                    },
-                    "404": {
-                        "description": "{\"error\": \"Not Found.\"}"
-                    },
                     "500": {
-                        "description": "{\"error\": \"Internal Server Error.\"}"
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/customError.Problem"
+                        }
                     }
                 }
             }
         },
-        "/group/{id}/match-participants": {
-            "post": {
-                "description": "Generate secret matches for participants in a group",
+        "/group/{id}": {
+            "get": {
+                "description": "Retrieve details of a specific group by its ID",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "group"
                 ],
-                "summary": "Match participants in a group",
+                "summary": "Get a group by ID",
                 "parameters": [
                     {
                         "type": "string",
@@ -260,37 +428,56 @@ const docTemplate = `{
                         "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of a previously fetched version",
+                        "name": "If-None-Match",
+                        "in": "header"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.Group"
+                            "$ref": "#/definitions/dto.GroupResponse"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Version of the group, to be sent back in If-Match"
+                            }
                         }
                     },
-                    "400": {
-                        "description": "{\"error\": \"Bad Request.\"}"
+                    "304": {
+                        "description": "Not Modified"
                     },
                     "404": {
-                        "description": "{\"error\": \"Not Found.\"}"
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/customError.Problem"
+                        }
                     },
                     "500": {
-                        "description": "{\"error\": \"Internal Server Error.\"}"
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/customError.Problem"
+                        }
                     }
                 }
-            }
-        },
-        "/group/{id}/my-match": {
-            "get": {
-                "description": "Retrieve the participant you are matched to gift in a group",
+            },
+            "put": {
+                "description": "Replace the mutable fields of a group (name, owner and participants). Every mutable field must be sent; immutable fields such as id, matches and createdAt are rejected.",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "group"
                 ],
-                "summary": "Get the match for a participant",
+                "summary": "Replace a group",
                 "parameters": [
                     {
                         "type": "string",
@@ -301,61 +488,1474 @@ const docTemplate = `{
                     },
                     {
                         "type": "string",
-                        "description": "Participant username",
-                        "name": "username",
-                        "in": "query",
+                        "description": "ETag of the group being updated, or *",
+                        "name": "If-Match",
+                        "in": "header",
                         "required": true
+                    },
+                    {
+                        "description": "Mutable fields of the group",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.ReplaceGroupRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "string"
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.GroupResponse"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "New version of the group"
+                            }
+                        }
                     },
                     "400": {
-                        "description": "{\"error\": \"Bad Request.\"}"
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/customError.Problem"
+                        }
                     },
                     "404": {
-                        "description": "{\"error\": \"Not Found.\"}"
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/customError.Problem"
+                        }
                     },
-                    "500": {
-                        "description": "{\"error\": \"Internal Server Error.\"}"
-                    }
+                    "412": {
+                        "description": "Precondition Failed",
+                        "schema": {
+                            "$ref": "#/definitions/customError.Problem"
+                        }
+                    },
+                    "428": {
+                        "description": "Precondition Required",
+                        "schema": {
+                            "$ref": "#/definitions/customError.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/customError.Problem"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Move a group to the trash. It is hidden everywhere else, can be restored with POST /group/{id}/restore and is removed for good once the trash retention runs out.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "group"
+                ],
+                "summary": "Delete a group",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Group ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the group being deleted, or *",
+                        "name": "If-Match",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "{}"
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/customError.Problem"
+                        }
+                    },
+                    "412": {
+                        "description": "Precondition Failed",
+                        "schema": {
+                            "$ref": "#/definitions/customError.Problem"
+                        }
+                    },
+                    "428": {
+                        "description": "Precondition Required",
+                        "schema": {
+                            "$ref": "#/definitions/customError.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/customError.Problem"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "description": "Change some of the mutable fields of a group (name, owner and participants) with a JSON merge patch (RFC 7396): members set to null are removed and arrays are replaced wholesale. Immutable fields such as id, matches and createdAt are rejected.",
+                "consumes": [
+                    "application/merge-patch+json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "group"
+                ],
+                "summary": "Patch a group",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Group ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the group being patched, or *",
+                        "name": "If-Match",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Merge patch with any of the mutable fields",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.PatchGroupRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.GroupResponse"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "New version of the group"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/customError.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/customError.Problem"
+                        }
+                    },
+                    "412": {
+                        "description": "Precondition Failed",
+                        "schema": {
+                            "$ref": "#/definitions/customError.Problem"
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported Media Type",
+                        "schema": {
+                            "$ref": "#/definitions/customError.Problem"
+                        }
+                    },
+                    "428": {
+                        "description": "Precondition Required",
+                        "schema": {
+                            "$ref": "#/definitions/customError.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/customError.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/group/{id}/add-participant": {
+            "post": {
+                "description": "Add a new participant to an existing group",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "group"
+                ],
+                "summary": "Add a participant to a group",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Group ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the group, or *",
+                        "name": "If-Match",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Participant to add",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.AddParticipantRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.GroupResponse"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "New version of the group"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/customError.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/customError.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/customError.Problem"
+                        }
+                    },
+                    "412": {
+                        "description": "Precondition Failed",
+                        "schema": {
+                            "$ref": "#/definitions/customError.Problem"
+                        }
+                    },
+                    "428": {
+                        "description": "Precondition Required",
+                        "schema": {
+                            "$ref": "#/definitions/customError.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/customError.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/group/{id}/audit": {
+            "get": {
+                "security": [
+                    {
+                        "GatewayToken": [],
+                        "UserEmail": []
+                    }
+                ],
+                "description": "List who changed the group, when and how, oldest first: creation, changes, participants added and removed, draws, revealed matches and deletion. Only the organizer of the group, as sent in X-User-Email by the gateway along with X-Gateway-Token, can read it, also while the group is in the trash. Matches are never recorded.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "group"
+                ],
+                "summary": "Get the audit log of a group",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Group ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "created",
+                            "updated",
+                            "participant-added",
+                            "participant-removed",
+                            "drawn",
+                            "redrawn",
+                            "revealed",
+                            "deleted",
+                            "restored",
+                            "purge-warned",
+                            "anonymized",
+                            "erased"
+                        ],
+                        "type": "string",
+                        "description": "Action",
+                        "name": "action",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Email of who made the change, or system, admin or anonymous",
+                        "name": "actor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Events at or after this time (RFC 3339)",
+                        "name": "since",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Events before this time (RFC 3339)",
+                        "name": "until",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 100,
+                        "description": "Maximum number of events (1-1000)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/dto.AuditEventResponse"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/customError.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/customError.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/customError.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/customError.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/group/{id}/match-participants": {
+            "post": {
+                "description": "Generate secret matches for participants in a group",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "group"
+                ],
+                "summary": "Match participants in a group",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Group ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the group, or *",
+                        "name": "If-Match",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.GroupResponse"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "New version of the group"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/customError.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/customError.Problem"
+                        }
+                    },
+                    "412": {
+                        "description": "Precondition Failed",
+                        "schema": {
+                            "$ref": "#/definitions/customError.Problem"
+                        }
+                    },
+                    "428": {
+                        "description": "Precondition Required",
+                        "schema": {
+                            "$ref": "#/definitions/customError.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/customError.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/group/{id}/my-match": {
+            "get": {
+                "description": "Retrieve the participant you are matched to gift in a group. A participant named exactly as the username is picked first; otherwise the username is compared ignoring case, accents and extra spaces, and when it cannot be resolved to a single participant the error lists suggestions.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "group"
+                ],
+                "summary": "Get the match for a participant",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Group ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Participant username",
+                        "name": "username",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.MyMatchResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/customError.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/customError.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/customError.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/customError.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/group/{id}/participants/search": {
+            "get": {
+                "description": "Search the participants of a group by name or email, ignoring case and accents. Exact matches come first, then prefix matches, then partial matches.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "group"
+                ],
+                "summary": "Search participants of a group",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Group ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Search term",
+                        "name": "q",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "default": 20,
+                        "description": "Maximum number of results (1-100)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/dto.ParticipantResponse"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/customError.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/customError.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/customError.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/group/{id}/restore": {
+            "post": {
+                "description": "Take a group out of the trash, with its participants and matches.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "group"
+                ],
+                "summary": "Restore a deleted group",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Group ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the deleted group, or *",
+                        "name": "If-Match",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.GroupResponse"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "New version of the group"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/customError.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/customError.Problem"
+                        }
+                    },
+                    "412": {
+                        "description": "Precondition Failed",
+                        "schema": {
+                            "$ref": "#/definitions/customError.Problem"
+                        }
+                    },
+                    "428": {
+                        "description": "Precondition Required",
+                        "schema": {
+                            "$ref": "#/definitions/customError.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/customError.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/healthz": {
+            "get": {
+                "description": "Answers while the process is able to serve requests, regardless of its dependencies.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Liveness probe",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/health.Liveness"
+                        }
+                    }
+                }
+            }
+        },
+        "/readyz": {
+            "get": {
+                "description": "Runs every registered dependency check, such as the MongoDB ping or the background jobs, and reports the status and latency of each. Background jobs add when they last ran, whether a run is in progress and the error of the last run.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Readiness probe",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/health.Report"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/health.Report"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "customError.ErrorCode": {
+            "type": "string",
+            "enum": [
+                "BAD_REQUEST",
+                "INVALID_REQUEST_BODY",
+                "INVALID_QUERY",
+                "VALIDATION_FAILED",
+                "GROUP_ID_INVALID",
+                "CURSOR_INVALID",
+                "PRECONDITION_INVALID",
+                "DRAW_INFEASIBLE",
+                "GROUP_NOT_FOUND",
+                "ERASURE_NOT_FOUND",
+                "MATCH_NOT_FOUND",
+                "NOT_FOUND",
+                "USERNAME_AMBIGUOUS",
+                "PARTICIPANT_DUPLICATE",
+                "CONFLICT",
+                "ALREADY_EXISTS",
+                "DOCUMENT_REJECTED",
+                "GROUP_VERSION_MISMATCH",
+                "UNSUPPORTED_MEDIA_TYPE",
+                "PRECONDITION_REQUIRED",
+                "UNAUTHORIZED",
+                "FORBIDDEN",
+                "INTERNAL_ERROR",
+                "SERVICE_UNAVAILABLE",
+                "DEADLINE_EXCEEDED",
+                "REQUEST_CANCELED",
+                "FIELD_REQUIRED",
+                "FIELD_INVALID",
+                "FIELD_IMMUTABLE",
+                "FIELD_UNKNOWN"
+            ],
+            "x-enum-varnames": [
+                "BadRequest",
+                "InvalidRequestBody",
+                "InvalidQuery",
+                "ValidationFailed",
+                "GroupIdInvalid",
+                "CursorInvalid",
+                "PreconditionInvalid",
+                "DrawInfeasible",
+                "GroupNotFound",
+                "ErasureNotFound",
+                "MatchNotFound",
+                "NotFound",
+                "UsernameAmbiguous",
+                "ParticipantDuplicate",
+                "Conflict",
+                "AlreadyExists",
+                "DocumentRejected",
+                "GroupVersionMismatch",
+                "UnsupportedMediaType",
+                "PreconditionRequired",
+                "Unauthorized",
+                "Forbidden",
+                "InternalError",
+                "ServiceUnavailable",
+                "DeadlineExceeded",
+                "RequestCanceled",
+                "FieldRequired",
+                "FieldInvalid",
+                "FieldImmutable",
+                "FieldUnknown"
+            ]
+        },
+        "customError.FieldError": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "$ref": "#/definitions/customError.ErrorCode"
+                },
+                "detail": {
+                    "type": "string"
+                },
+                "field": {
+                    "type": "string"
+                }
+            }
+        },
+        "customError.Problem": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "$ref": "#/definitions/customError.ErrorCode"
+                },
+                "detail": {
+                    "type": "string"
+                },
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/customError.FieldError"
+                    }
+                },
+                "instance": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "integer"
+                },
+                "suggestions": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "title": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.AddParticipantRequest": {
+            "type": "object",
+            "properties": {
+                "email": {
+                    "type": "string",
+                    "example": "Mari@gmail.com"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "Mari"
+                }
+            }
+        },
+        "dto.AuditChangeResponse": {
+            "type": "object",
+            "properties": {
+                "after": {
+                    "type": "object"
+                },
+                "before": {
+                    "type": "object"
+                },
+                "field": {
+                    "type": "string",
+                    "example": "name"
+                }
+            }
+        },
+        "dto.AuditEventResponse": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string",
+                    "example": "participant-removed"
+                },
+                "actor": {
+                    "type": "string",
+                    "example": "mari@gmail.com"
+                },
+                "at": {
+                    "type": "string"
+                },
+                "changes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.AuditChangeResponse"
+                    }
+                },
+                "detail": {
+                    "type": "string",
+                    "example": "Joao"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "6787c4a755ea623ab45e77d4"
+                },
+                "requestId": {
+                    "type": "string",
+                    "example": "4bf92f3577b34da6a3ce929d0e0e4736"
+                }
+            }
+        },
+        "dto.CreateGroupRequest": {
+            "type": "object",
+            "properties": {
+                "exchangeDate": {
+                    "type": "string",
+                    "example": "2024-12-20T18:00:00Z"
+                },
+                "locale": {
+                    "type": "string",
+                    "enum": [
+                        "en",
+                        "pt-BR",
+                        "es"
+                    ],
+                    "example": "pt-BR"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "Equipe pe no chao"
+                },
+                "owner": {
+                    "type": "string",
+                    "example": "Mari@gmail.com"
+                },
+                "participants": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.ParticipantRequest"
+                    }
+                }
+            }
+        },
+        "dto.ErasureCertificateResponse": {
+            "type": "object",
+            "properties": {
+                "drawsRepaired": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "erasedAt": {
+                    "type": "string"
+                },
+                "groups": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "id": {
+                    "type": "string",
+                    "example": "6787c4a755ea623ab45e77d4"
+                },
+                "participantsRemoved": {
+                    "type": "integer",
+                    "example": 2
+                },
+                "subjectHash": {
+                    "description": "SubjectHash is an HMAC-SHA256 of the lower-cased email under BLIND_INDEX_KEY, empty when it is not set.",
+                    "type": "string",
+                    "example": "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
                 }
             }
-        }
-    },
-    "definitions": {
-        "models.Group": {
+        },
+        "dto.GroupPageResponse": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.GroupSummaryResponse"
+                    }
+                },
+                "paging": {
+                    "$ref": "#/definitions/dto.PagingResponse"
+                }
+            }
+        },
+        "dto.GroupResponse": {
+            "type": "object",
+            "properties": {
+                "anonymizedAt": {
+                    "type": "string"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "exchangeDate": {
+                    "type": "string",
+                    "example": "2024-12-20T18:00:00Z"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "6787c4a755ea623ab45e77d4"
+                },
+                "locale": {
+                    "type": "string",
+                    "example": "pt-BR"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "Equipe pe no chao"
+                },
+                "owner": {
+                    "type": "string",
+                    "example": "Mari@gmail.com"
+                },
+                "participants": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.ParticipantResponse"
+                    }
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "open",
+                        "drawn"
+                    ],
+                    "example": "open"
+                },
+                "updatedAt": {
+                    "type": "string"
+                },
+                "version": {
+                    "type": "integer",
+                    "example": 1
+                }
+            }
+        },
+        "dto.GroupSummaryResponse": {
+            "type": "object",
+            "properties": {
+                "anonymizedAt": {
+                    "type": "string"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "exchangeDate": {
+                    "type": "string",
+                    "example": "2024-12-20T18:00:00Z"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "6787c4a755ea623ab45e77d4"
+                },
+                "locale": {
+                    "type": "string",
+                    "example": "pt-BR"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "Equipe pe no chao"
+                },
+                "owner": {
+                    "type": "string",
+                    "example": "Mari@gmail.com"
+                },
+                "participantCount": {
+                    "type": "integer",
+                    "example": 12
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "open",
+                        "drawn"
+                    ],
+                    "example": "open"
+                },
+                "updatedAt": {
+                    "type": "string"
+                },
+                "version": {
+                    "type": "integer",
+                    "example": 1
+                }
+            }
+        },
+        "dto.MyMatchResponse": {
+            "type": "object",
+            "properties": {
+                "match": {
+                    "type": "string",
+                    "example": "Mari"
+                }
+            }
+        },
+        "dto.PagingResponse": {
+            "type": "object",
+            "properties": {
+                "hasMore": {
+                    "type": "boolean"
+                },
+                "limit": {
+                    "type": "integer",
+                    "example": 20
+                },
+                "nextCursor": {
+                    "type": "string"
+                },
+                "order": {
+                    "type": "string",
+                    "example": "desc"
+                },
+                "sort": {
+                    "type": "string",
+                    "example": "createdAt"
+                }
+            }
+        },
+        "dto.ParticipantRequest": {
+            "type": "object",
+            "properties": {
+                "email": {
+                    "type": "string",
+                    "example": "Mari@gmail.com"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "Mari"
+                }
+            }
+        },
+        "dto.ParticipantResponse": {
+            "type": "object",
+            "properties": {
+                "email": {
+                    "type": "string",
+                    "example": "Mari@gmail.com"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "Mari"
+                }
+            }
+        },
+        "dto.PatchGroupRequest": {
+            "type": "object",
+            "properties": {
+                "exchangeDate": {
+                    "type": "string",
+                    "example": "2024-12-20T18:00:00Z"
+                },
+                "locale": {
+                    "type": "string",
+                    "enum": [
+                        "en",
+                        "pt-BR",
+                        "es"
+                    ],
+                    "example": "pt-BR"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "Equipe pe no chao"
+                },
+                "owner": {
+                    "type": "string",
+                    "example": "Mari@gmail.com"
+                },
+                "participants": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.ParticipantRequest"
+                    }
+                }
+            }
+        },
+        "dto.ReplaceGroupRequest": {
             "type": "object",
             "properties": {
+                "exchangeDate": {
+                    "type": "string",
+                    "example": "2024-12-20T18:00:00Z"
+                },
+                "locale": {
+                    "type": "string",
+                    "enum": [
+                        "en",
+                        "pt-BR",
+                        "es"
+                    ],
+                    "example": "pt-BR"
+                },
                 "name": {
                     "type": "string",
                     "example": "Equipe pe no chao"
                 },
+                "owner": {
+                    "type": "string",
+                    "example": "Mari@gmail.com"
+                },
                 "participants": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/models.Participant"
+                        "$ref": "#/definitions/dto.ParticipantRequest"
+                    }
+                }
+            }
+        },
+        "dto.SubjectAuditEventResponse": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string",
+                    "example": "participant-removed"
+                },
+                "actor": {
+                    "type": "string",
+                    "example": "mari@gmail.com"
+                },
+                "at": {
+                    "type": "string"
+                },
+                "changes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.AuditChangeResponse"
                     }
+                },
+                "detail": {
+                    "type": "string",
+                    "example": "Joao"
+                },
+                "groupId": {
+                    "type": "string",
+                    "example": "6787c4a755ea623ab45e77d4"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "6787c4a755ea623ab45e77d4"
+                },
+                "requestId": {
+                    "type": "string",
+                    "example": "4bf92f3577b34da6a3ce929d0e0e4736"
                 }
             }
         },
-        "models.Participant": {
+        "dto.SubjectExportResponse": {
             "type": "object",
             "properties": {
+                "audit": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.SubjectAuditEventResponse"
+                    }
+                },
                 "email": {
                     "type": "string",
                     "example": "Mari@gmail.com"
                 },
+                "exportedAt": {
+                    "type": "string"
+                },
+                "groups": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.SubjectGroupResponse"
+                    }
+                }
+            }
+        },
+        "dto.SubjectGroupResponse": {
+            "type": "object",
+            "properties": {
+                "anonymizedAt": {
+                    "type": "string"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "deletedAt": {
+                    "type": "string"
+                },
+                "exchangeDate": {
+                    "type": "string",
+                    "example": "2024-12-20T18:00:00Z"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "6787c4a755ea623ab45e77d4"
+                },
+                "isOwner": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "locale": {
+                    "type": "string",
+                    "example": "pt-BR"
+                },
+                "matches": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.SubjectMatchResponse"
+                    }
+                },
                 "name": {
+                    "type": "string",
+                    "example": "Equipe pe no chao"
+                },
+                "owner": {
+                    "type": "string",
+                    "example": "Mari@gmail.com"
+                },
+                "participantCount": {
+                    "type": "integer",
+                    "example": 12
+                },
+                "participants": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.ParticipantResponse"
+                    }
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "open",
+                        "drawn"
+                    ],
+                    "example": "open"
+                },
+                "updatedAt": {
+                    "type": "string"
+                },
+                "version": {
+                    "type": "integer",
+                    "example": 1
+                }
+            }
+        },
+        "dto.SubjectMatchResponse": {
+            "type": "object",
+            "properties": {
+                "giver": {
                     "type": "string",
                     "example": "Mari"
+                },
+                "receiver": {
+                    "type": "string",
+                    "example": "Joao"
+                }
+            }
+        },
+        "dto.TrashedGroupResponse": {
+            "type": "object",
+            "properties": {
+                "anonymizedAt": {
+                    "type": "string"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "deletedAt": {
+                    "type": "string"
+                },
+                "exchangeDate": {
+                    "type": "string",
+                    "example": "2024-12-20T18:00:00Z"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "6787c4a755ea623ab45e77d4"
+                },
+                "locale": {
+                    "type": "string",
+                    "example": "pt-BR"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "Equipe pe no chao"
+                },
+                "owner": {
+                    "type": "string",
+                    "example": "Mari@gmail.com"
+                },
+                "participantCount": {
+                    "type": "integer",
+                    "example": 12
+                },
+                "purgeAt": {
+                    "description": "PurgeAt is when the group is removed for good and can no longer be restored.",
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "open",
+                        "drawn"
+                    ],
+                    "example": "open"
+                },
+                "updatedAt": {
+                    "type": "string"
+                },
+                "version": {
+                    "type": "integer",
+                    "example": 1
+                }
+            }
+        },
+        "health.Liveness": {
+            "type": "object",
+            "properties": {
+                "status": {
+                    "type": "string",
+                    "example": "up"
+                }
+            }
+        },
+        "health.Report": {
+            "type": "object",
+            "properties": {
+                "checks": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/health.Result"
+                    }
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "ready",
+                        "not_ready"
+                    ],
+                    "example": "ready"
+                }
+            }
+        },
+        "health.Result": {
+            "type": "object",
+            "properties": {
+                "details": {
+                    "type": "object"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "latencyMs": {
+                    "type": "number",
+                    "example": 1.3
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "up",
+                        "down"
+                    ],
+                    "example": "up"
                 }
             }
         }
     },
-    "externalDocs": {
-        "description": "ReadMe"
+    "securityDefinitions": {
+        "AdminToken": {
+            "description": "Bearer token of the /admin routes (ADMIN_TOKEN).",
+            "type": "apiKey",
+            "name": "Authorization",
+            "in": "header"
+        },
+        "GatewayToken": {
+            "description": "Shared token of the gateway (GATEWAY_TOKEN), without which X-User-Email is ignored.",
+            "type": "apiKey",
+            "name": "X-Gateway-Token",
+            "in": "header"
+        },
+        "UserEmail": {
+            "description": "Email of the user, as authenticated by the gateway.",
+            "type": "apiKey",
+            "name": "X-User-Email",
+            "in": "header"
+        }
     }
 }`
 
@@ -0,0 +1,99 @@
+package dto
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"service-secret-santa/models"
+
+	"github.com/invopop/validation"
+)
+
+// AuditRequest holds the query params of GET /group/:id/audit. Since and
+// Until are RFC 3339 times.
+type AuditRequest struct {
+	Action string    `form:"action"`
+	Actor  string    `form:"actor"`
+	Since  time.Time `form:"since"`
+	Until  time.Time `form:"until"`
+	Limit  int       `form:"limit"`
+}
+
+// AuditChangeResponse is the value of a field before and after a change. A
+// missing value was not set.
+type AuditChangeResponse struct {
+	Field  string          `json:"field" example:"name"`
+	Before json.RawMessage `json:"before,omitempty" swaggertype:"object"`
+	After  json.RawMessage `json:"after,omitempty" swaggertype:"object"`
+}
+
+// AuditEventResponse is an entry of the audit log of a group.
+type AuditEventResponse struct {
+	Id        string                `json:"id" example:"6787c4a755ea623ab45e77d4"`
+	Action    string                `json:"action" example:"participant-removed"`
+	Actor     string                `json:"actor" example:"mari@gmail.com"`
+	At        time.Time             `json:"at"`
+	RequestId string                `json:"requestId,omitempty" example:"4bf92f3577b34da6a3ce929d0e0e4736"`
+	Detail    string                `json:"detail,omitempty" example:"Joao"`
+	Changes   []AuditChangeResponse `json:"changes"`
+}
+
+// WithDefaults trims the filters and fills the unset limit.
+func (r AuditRequest) WithDefaults() AuditRequest {
+	r.Action = strings.TrimSpace(r.Action)
+	r.Actor = strings.TrimSpace(r.Actor)
+	if r.Limit == 0 {
+		r.Limit = models.DefaultAuditLimit
+	}
+	return r
+}
+
+func (r AuditRequest) Validate() error {
+	actions := make([]interface{}, 0, len(models.AuditActions))
+	for _, action := range models.AuditActions {
+		actions = append(actions, action)
+	}
+
+	err := validation.ValidateStruct(&r,
+		validation.Field(&r.Action, validation.In(actions...)),
+		validation.Field(&r.Limit, validation.Min(1), validation.Max(models.MaxAuditLimit)),
+	)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r AuditRequest) ToModel() models.AuditQuery {
+	return models.AuditQuery{Action: r.Action, Actor: r.Actor, Since: r.Since, Until: r.Until, Limit: r.Limit}
+}
+
+func NewAuditEventResponses(events []models.AuditEvent) []AuditEventResponse {
+	responses := make([]AuditEventResponse, 0, len(events))
+	for _, event := range events {
+		changes := make([]AuditChangeResponse, 0, len(event.Changes))
+		for _, change := range event.Changes {
+			changes = append(changes, AuditChangeResponse{Field: change.Field, Before: rawJSON(change.Before), After: rawJSON(change.After)})
+		}
+		responses = append(responses, AuditEventResponse{
+			Id:        event.Id.Hex(),
+			Action:    event.Action,
+			Actor:     event.Actor,
+			At:        event.At,
+			RequestId: event.RequestId,
+			Detail:    event.Detail,
+			Changes:   changes,
+		})
+	}
+	return responses
+}
+
+func rawJSON(value string) json.RawMessage {
+	if value == "" {
+		return nil
+	}
+	return json.RawMessage(value)
+}
@@ -0,0 +1,294 @@
+package dto
+
+import (
+	"time"
+
+	"service-secret-santa/i18n"
+	"service-secret-santa/models"
+
+	"github.com/invopop/validation"
+)
+
+// ParticipantRequest is a participant as sent by clients.
+type ParticipantRequest struct {
+	Name  string `json:"name" example:"Mari"`
+	Email string `json:"email" example:"Mari@gmail.com"`
+}
+
+// CreateGroupRequest is the body of POST /group. Locale is the language of
+// notifications about the group; empty means the configured default.
+// ExchangeDate is when the gifts are exchanged; participant data is kept for
+// the retention period after it.
+type CreateGroupRequest struct {
+	Name         string               `json:"name" example:"Equipe pe no chao"`
+	Owner        string               `json:"owner" example:"Mari@gmail.com"`
+	Locale       string               `json:"locale,omitempty" example:"pt-BR" enums:"en,pt-BR,es"`
+	ExchangeDate *time.Time           `json:"exchangeDate,omitempty" example:"2024-12-20T18:00:00Z"`
+	Participants []ParticipantRequest `json:"participants"`
+}
+
+// ReplaceGroupRequest is the body of PUT /group/:id. Every field but the
+// locale and the exchange date is required; an omitted locale is left
+// unchanged and an omitted exchange date is removed.
+type ReplaceGroupRequest struct {
+	Name         string               `json:"name" example:"Equipe pe no chao"`
+	Owner        string               `json:"owner" example:"Mari@gmail.com"`
+	Locale       string               `json:"locale,omitempty" example:"pt-BR" enums:"en,pt-BR,es"`
+	ExchangeDate *time.Time           `json:"exchangeDate,omitempty" example:"2024-12-20T18:00:00Z"`
+	Participants []ParticipantRequest `json:"participants"`
+}
+
+// PatchGroupRequest documents the body of PATCH /group/:id, a JSON merge
+// patch where every field is optional and null removes the value.
+type PatchGroupRequest struct {
+	Name         *string              `json:"name,omitempty" example:"Equipe pe no chao"`
+	Owner        *string              `json:"owner,omitempty" example:"Mari@gmail.com"`
+	Locale       *string              `json:"locale,omitempty" example:"pt-BR" enums:"en,pt-BR,es"`
+	ExchangeDate *time.Time           `json:"exchangeDate,omitempty" example:"2024-12-20T18:00:00Z"`
+	Participants []ParticipantRequest `json:"participants,omitempty"`
+}
+
+// AddParticipantRequest is the body of POST /group/:id/add-participant.
+type AddParticipantRequest struct {
+	Name  string `json:"name" example:"Mari"`
+	Email string `json:"email" example:"Mari@gmail.com"`
+}
+
+// ParticipantResponse is a participant as returned to clients.
+type ParticipantResponse struct {
+	Name  string `json:"name" example:"Mari"`
+	Email string `json:"email" example:"Mari@gmail.com"`
+}
+
+// GroupResponse is a group as returned to clients. The matches are secret and
+// only revealed one at a time through GET /group/:id/my-match. AnonymizedAt
+// is set once the retention period ran out and the participants were
+// replaced by pseudonyms.
+type GroupResponse struct {
+	Id           string                `json:"id" example:"6787c4a755ea623ab45e77d4"`
+	Name         string                `json:"name" example:"Equipe pe no chao"`
+	Owner        string                `json:"owner" example:"Mari@gmail.com"`
+	Locale       string                `json:"locale,omitempty" example:"pt-BR"`
+	Status       string                `json:"status" example:"open" enums:"open,drawn"`
+	ExchangeDate *time.Time            `json:"exchangeDate,omitempty" example:"2024-12-20T18:00:00Z"`
+	Participants []ParticipantResponse `json:"participants"`
+	CreatedAt    time.Time             `json:"createdAt"`
+	UpdatedAt    time.Time             `json:"updatedAt"`
+	AnonymizedAt *time.Time            `json:"anonymizedAt,omitempty"`
+	Version      int64                 `json:"version" example:"1"`
+}
+
+// GroupSummaryResponse is a group as listed by GET /group and GET
+// /group/search. Participants are only counted; GET /group/:id lists them.
+type GroupSummaryResponse struct {
+	Id               string     `json:"id" example:"6787c4a755ea623ab45e77d4"`
+	Name             string     `json:"name" example:"Equipe pe no chao"`
+	Owner            string     `json:"owner" example:"Mari@gmail.com"`
+	Locale           string     `json:"locale,omitempty" example:"pt-BR"`
+	Status           string     `json:"status" example:"open" enums:"open,drawn"`
+	ExchangeDate     *time.Time `json:"exchangeDate,omitempty" example:"2024-12-20T18:00:00Z"`
+	ParticipantCount int        `json:"participantCount" example:"12"`
+	CreatedAt        time.Time  `json:"createdAt"`
+	UpdatedAt        time.Time  `json:"updatedAt"`
+	AnonymizedAt     *time.Time `json:"anonymizedAt,omitempty"`
+	Version          int64      `json:"version" example:"1"`
+}
+
+// TrashedGroupResponse is a group as listed by GET /group/trash.
+type TrashedGroupResponse struct {
+	GroupSummaryResponse
+	DeletedAt time.Time `json:"deletedAt"`
+	// PurgeAt is when the group is removed for good and can no longer be restored.
+	PurgeAt time.Time `json:"purgeAt"`
+}
+
+// MyMatchResponse is the body of GET /group/:id/my-match.
+type MyMatchResponse struct {
+	Match string `json:"match" example:"Mari"`
+}
+
+func (r ParticipantRequest) Validate() error {
+	err := validation.ValidateStruct(&r,
+		validation.Field(&r.Name, validation.Required),
+		validation.Field(&r.Email, validation.Required),
+	)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r CreateGroupRequest) Validate() error {
+	err := validation.ValidateStruct(&r,
+		validation.Field(&r.Name, validation.Required),
+		validation.Field(&r.Locale, validation.By(supportedLocale)),
+		validation.Field(&r.Participants),
+	)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r ReplaceGroupRequest) Validate() error {
+	err := validation.ValidateStruct(&r,
+		validation.Field(&r.Name, validation.Required),
+		validation.Field(&r.Locale, validation.By(supportedLocale)),
+		validation.Field(&r.Participants, validation.NotNil),
+	)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// supportedLocale accepts an empty locale or one of the bundled ones.
+func supportedLocale(value interface{}) error {
+	locale, _ := value.(string)
+	if locale == "" || i18n.Supported(locale) {
+		return nil
+	}
+	return validation.NewError("validation_locale_unsupported", "must be one of en, pt-BR or es")
+}
+
+// canonicalLocale spells a supported locale the way it is bundled.
+func canonicalLocale(locale string) string {
+	if locale == "" {
+		return ""
+	}
+	return string(i18n.Resolve("", locale))
+}
+
+// optionalTime leaves unset times out of responses.
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+func valueOf(t *time.Time) time.Time {
+	if t == nil {
+		return time.Time{}
+	}
+	return *t
+}
+
+func (r ParticipantRequest) ToModel() models.Participant {
+	return models.Participant{Name: r.Name, Email: r.Email}
+}
+
+func participantsToModel(participants []ParticipantRequest) []models.Participant {
+	res := make([]models.Participant, 0, len(participants))
+	for _, p := range participants {
+		res = append(res, p.ToModel())
+	}
+	return res
+}
+
+func (r CreateGroupRequest) ToModel() *models.Group {
+	return &models.Group{
+		Name:         r.Name,
+		Owner:        r.Owner,
+		Locale:       canonicalLocale(r.Locale),
+		ExchangeDate: valueOf(r.ExchangeDate),
+		Participants: participantsToModel(r.Participants),
+	}
+}
+
+func (r ReplaceGroupRequest) ToModel() *models.GroupUpdate {
+	return &models.GroupUpdate{
+		Name:         r.Name,
+		Owner:        r.Owner,
+		Locale:       canonicalLocale(r.Locale),
+		ExchangeDate: r.ExchangeDate,
+		Participants: participantsToModel(r.Participants),
+	}
+}
+
+// NewReplaceGroupRequest returns the request that would leave update unchanged.
+func NewReplaceGroupRequest(update models.GroupUpdate) ReplaceGroupRequest {
+	participants := make([]ParticipantRequest, 0, len(update.Participants))
+	for _, p := range update.Participants {
+		participants = append(participants, ParticipantRequest{Name: p.Name, Email: p.Email})
+	}
+	return ReplaceGroupRequest{
+		Name:         update.Name,
+		Owner:        update.Owner,
+		Locale:       update.Locale,
+		ExchangeDate: update.ExchangeDate,
+		Participants: participants,
+	}
+}
+
+func (r AddParticipantRequest) ToModel() *models.Participant {
+	return &models.Participant{Name: r.Name, Email: r.Email}
+}
+
+func NewParticipantResponses(participants []models.Participant) []ParticipantResponse {
+	res := make([]ParticipantResponse, 0, len(participants))
+	for _, p := range participants {
+		res = append(res, ParticipantResponse{Name: p.Name, Email: p.Email})
+	}
+	return res
+}
+
+func NewGroupResponse(group *models.Group) GroupResponse {
+	return GroupResponse{
+		Id:           group.Id.Hex(),
+		Name:         group.Name,
+		Owner:        group.Owner,
+		Locale:       group.Locale,
+		Status:       group.Status,
+		ExchangeDate: optionalTime(group.ExchangeDate),
+		Participants: NewParticipantResponses(group.Participants),
+		CreatedAt:    group.CreatedAt,
+		UpdatedAt:    group.UpdatedAt,
+		AnonymizedAt: optionalTime(group.AnonymizedAt),
+		Version:      group.Version,
+	}
+}
+
+func NewGroupSummaryResponse(group *models.Group) GroupSummaryResponse {
+	return GroupSummaryResponse{
+		Id:               group.Id.Hex(),
+		Name:             group.Name,
+		Owner:            group.Owner,
+		Locale:           group.Locale,
+		Status:           group.Status,
+		ExchangeDate:     optionalTime(group.ExchangeDate),
+		ParticipantCount: group.ParticipantCount,
+		CreatedAt:        group.CreatedAt,
+		UpdatedAt:        group.UpdatedAt,
+		AnonymizedAt:     optionalTime(group.AnonymizedAt),
+		Version:          group.Version,
+	}
+}
+
+func NewGroupSummaryResponses(groups []*models.Group) []GroupSummaryResponse {
+	res := make([]GroupSummaryResponse, 0, len(groups))
+	for _, g := range groups {
+		res = append(res, NewGroupSummaryResponse(g))
+	}
+	return res
+}
+
+// NewTrashedGroupResponses lists deleted groups, each purged retention after
+// it was deleted.
+func NewTrashedGroupResponses(groups []*models.Group, retention time.Duration) []TrashedGroupResponse {
+	res := make([]TrashedGroupResponse, 0, len(groups))
+	for _, g := range groups {
+		res = append(res, TrashedGroupResponse{
+			GroupSummaryResponse: NewGroupSummaryResponse(g),
+			DeletedAt:            g.DeletedAt,
+			PurgeAt:              g.DeletedAt.Add(retention),
+		})
+	}
+	return res
+}
@@ -0,0 +1,147 @@
+package dto
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"service-secret-santa/customError"
+	"service-secret-santa/models"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestNewGroupResponse(t *testing.T) {
+	group := models.CreateMockGroup()
+
+	response := NewGroupResponse(group)
+
+	assert.Equal(t, group.Id.Hex(), response.Id)
+	assert.Equal(t, group.Name, response.Name)
+	assert.Equal(t, group.UpdatedAt, response.UpdatedAt)
+	assert.Equal(t, []ParticipantResponse{{Name: "Mari", Email: "mari@gmail.com"}}, response.Participants)
+
+	raw, _ := json.Marshal(response)
+	var body map[string]interface{}
+	_ = json.Unmarshal(raw, &body)
+	assert.NotContains(t, body, "matches")
+	assert.Contains(t, body, "updatedAt")
+}
+
+func TestCreateGroupRequest_ToModel(t *testing.T) {
+	request := CreateGroupRequest{
+		Name:         "Amigos",
+		Owner:        "mari@gmail.com",
+		Participants: []ParticipantRequest{{Name: "Mari", Email: "mari@gmail.com"}},
+	}
+
+	group := request.ToModel()
+
+	assert.True(t, group.Id.IsZero())
+	assert.Empty(t, group.Matches)
+	assert.Equal(t, []models.Participant{{Name: "Mari", Email: "mari@gmail.com"}}, group.Participants)
+}
+
+func TestGroupDocumentOmitsZeroTimes(t *testing.T) {
+	raw, _ := bson.Marshal(models.Group{Name: "Amigos"})
+
+	var document bson.M
+	_ = bson.Unmarshal(raw, &document)
+
+	assert.NotContains(t, document, "createdAt")
+	assert.NotContains(t, document, "updateAt")
+}
+
+func TestGroupFieldErrors(t *testing.T) {
+	body := map[string]json.RawMessage{"name": nil, "matches": nil, "color": nil}
+
+	assert.Equal(t, []customError.FieldError{
+		{Field: "color", Code: customError.FieldUnknown, Detail: "is not a group field"},
+		{Field: "matches", Code: customError.FieldImmutable, Detail: "is immutable"},
+	}, GroupFieldErrors(body, false))
+
+	assert.Equal(t, []customError.FieldError{
+		{Field: "color", Code: customError.FieldUnknown, Detail: "is not a group field"},
+		{Field: "matches", Code: customError.FieldImmutable, Detail: "is immutable"},
+		{Field: "owner", Code: customError.FieldRequired, Detail: "is required for a full replacement", MessageKey: "required_for_replacement"},
+		{Field: "participants", Code: customError.FieldRequired, Detail: "is required for a full replacement", MessageKey: "required_for_replacement"},
+	}, GroupFieldErrors(body, true))
+}
+
+func TestApplyGroupMergePatch(t *testing.T) {
+	current := models.GroupUpdate{
+		Name:         "Amigos",
+		Owner:        "mari@gmail.com",
+		Participants: []models.Participant{{Name: "Mari", Email: "mari@gmail.com"}},
+	}
+
+	update, err := ApplyGroupMergePatch(current, []byte(`{"name":"Renamed","owner":null}`))
+	assert.Nil(t, err)
+	assert.Equal(t, "Renamed", update.Name)
+	assert.Equal(t, "", update.Owner)
+	assert.Equal(t, current.Participants, update.Participants)
+
+	update, err = ApplyGroupMergePatch(current, []byte(`{"participants":[]}`))
+	assert.Nil(t, err)
+	assert.Empty(t, update.Participants)
+
+	_, err = ApplyGroupMergePatch(current, []byte(`{"name":null}`))
+	assert.Equal(t, err.Status, 400)
+	assert.Contains(t, err.Fields, "name")
+
+	_, err = ApplyGroupMergePatch(current, []byte(`{"participants":[{"name":"Ana"}]}`))
+	assert.Equal(t, err.Status, 400)
+	assert.Contains(t, err.Fields, "participants.0.email")
+	assert.Equal(t, []customError.FieldError{
+		{Field: "participants.0.email", Code: customError.FieldRequired, Detail: "cannot be blank", MessageKey: "validation_required"},
+	}, err.FieldErrors)
+
+	_, err = ApplyGroupMergePatch(current, []byte(`{"name":5}`))
+	assert.Equal(t, err.Status, 400)
+}
+
+func TestGroupExchangeDate(t *testing.T) {
+	exchangeDate := time.Date(2024, 12, 20, 18, 0, 0, 0, time.UTC)
+	current := models.GroupUpdate{Name: "Amigos", Owner: "mari@gmail.com", ExchangeDate: &exchangeDate, Participants: []models.Participant{}}
+
+	update, err := ApplyGroupMergePatch(current, []byte(`{"name":"Renamed"}`))
+	assert.Nil(t, err)
+	assert.Equal(t, &exchangeDate, update.ExchangeDate)
+
+	update, err = ApplyGroupMergePatch(current, []byte(`{"exchangeDate":null}`))
+	assert.Nil(t, err)
+	assert.Nil(t, update.ExchangeDate)
+
+	raw, _ := json.Marshal(NewGroupResponse(models.CreateMockGroup()))
+	var body map[string]interface{}
+	_ = json.Unmarshal(raw, &body)
+	assert.NotContains(t, body, "exchangeDate")
+	assert.NotContains(t, body, "anonymizedAt")
+
+	assert.Equal(t, []customError.FieldError{
+		{Field: "anonymizedAt", Code: customError.FieldImmutable, Detail: "is immutable"},
+	}, GroupFieldErrors(map[string]json.RawMessage{"anonymizedAt": nil, "exchangeDate": nil}, false))
+}
+
+func TestGroupLocale(t *testing.T) {
+	request := CreateGroupRequest{Name: "Amigos", Locale: "PT-br"}
+	assert.Nil(t, request.Validate())
+	assert.Equal(t, "pt-BR", request.ToModel().Locale)
+
+	request.Locale = ""
+	assert.Nil(t, request.Validate())
+	assert.Equal(t, "", request.ToModel().Locale)
+
+	request.Locale = "fr"
+	assert.NotNil(t, request.Validate())
+
+	current := models.GroupUpdate{Name: "Amigos", Locale: "es", Participants: []models.Participant{}}
+	update, err := ApplyGroupMergePatch(current, []byte(`{"name":"Renamed"}`))
+	assert.Nil(t, err)
+	assert.Equal(t, "es", update.Locale)
+
+	_, err = ApplyGroupMergePatch(current, []byte(`{"locale":"fr"}`))
+	assert.Equal(t, customError.ValidationFailed, err.Kind())
+	assert.Equal(t, "validation_locale_unsupported", err.FieldErrors[0].MessageKey)
+}
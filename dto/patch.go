@@ -0,0 +1,86 @@
+package dto
+
+import (
+	"encoding/json"
+	"sort"
+
+	"service-secret-santa/customError"
+	"service-secret-santa/functions"
+	"service-secret-santa/models"
+)
+
+var mutableGroupFields = []string{"name", "owner", "locale", "exchangeDate", "participants"}
+
+// requiredGroupFields must all be sent in a full replacement.
+var requiredGroupFields = []string{"name", "owner", "participants"}
+
+var immutableGroupFields = map[string]bool{
+	"id":           true,
+	"matches":      true,
+	"createdAt":    true,
+	"updatedAt":    true,
+	"status":       true,
+	"version":      true,
+	"anonymizedAt": true,
+}
+
+// GroupFieldErrors checks the members of a PUT or PATCH body against the
+// allow-list of mutable fields. A full replacement must carry every required
+// field. The result lists what is wrong with each offending field.
+func GroupFieldErrors(body map[string]json.RawMessage, replace bool) []customError.FieldError {
+	var errs []customError.FieldError
+
+	for field := range body {
+		switch {
+		case immutableGroupFields[field]:
+			errs = append(errs, customError.FieldError{Field: field, Code: customError.FieldImmutable, Detail: "is immutable"})
+		case !isMutableGroupField(field):
+			errs = append(errs, customError.FieldError{Field: field, Code: customError.FieldUnknown, Detail: "is not a group field"})
+		}
+	}
+
+	if replace {
+		for _, field := range requiredGroupFields {
+			if _, in := body[field]; !in {
+				errs = append(errs, customError.FieldError{Field: field, Code: customError.FieldRequired, Detail: "is required for a full replacement", MessageKey: "required_for_replacement"})
+			}
+		}
+	}
+
+	sort.Slice(errs, func(i, j int) bool { return errs[i].Field < errs[j].Field })
+	return errs
+}
+
+func isMutableGroupField(field string) bool {
+	for _, mutable := range mutableGroupFields {
+		if field == mutable {
+			return true
+		}
+	}
+	return false
+}
+
+// ApplyGroupMergePatch applies an RFC 7396 merge patch to the API
+// representation of the mutable fields of a group and validates the result.
+func ApplyGroupMergePatch(current models.GroupUpdate, patch []byte) (*models.GroupUpdate, *customError.CustomError) {
+	document, _ := json.Marshal(NewReplaceGroupRequest(current))
+	merged, err := functions.MergePatch(document, patch)
+	if err != nil {
+		return nil, customError.NewCustomError(customError.WithBadRequest(err.Error(), "Invalid merge patch"), customError.WithCode(customError.InvalidRequestBody))
+	}
+
+	var request ReplaceGroupRequest
+	if err := json.Unmarshal(merged, &request); err != nil {
+		return nil, customError.NewCustomError(customError.WithBadRequest(err.Error(), "Invalid merge patch"), customError.WithCode(customError.InvalidRequestBody))
+	}
+
+	if err := request.Validate(); err != nil {
+		return nil, customError.NewCustomError(
+			customError.WithBadRequest(err.Error(), "Validation error"),
+			customError.WithCode(customError.ValidationFailed),
+			customError.WithValidationErrors(err),
+		)
+	}
+
+	return request.ToModel(), nil
+}
@@ -0,0 +1,123 @@
+package dto
+
+import (
+	"strings"
+
+	"service-secret-santa/models"
+
+	"github.com/invopop/validation"
+)
+
+// ListGroupsRequest holds the query params of GET /group.
+type ListGroupsRequest struct {
+	Limit            int    `form:"limit"`
+	Cursor           string `form:"cursor"`
+	Sort             string `form:"sort"`
+	Order            string `form:"order"`
+	Name             string `form:"name"`
+	Status           string `form:"status"`
+	Owner            string `form:"owner"`
+	ParticipantEmail string `form:"participantEmail"`
+}
+
+// SearchRequest holds the query params of the search endpoints.
+type SearchRequest struct {
+	Q     string `form:"q"`
+	Limit int    `form:"limit"`
+}
+
+// PagingResponse describes the page returned and how to request the next one.
+type PagingResponse struct {
+	Limit      int    `json:"limit" example:"20"`
+	Sort       string `json:"sort" example:"createdAt"`
+	Order      string `json:"order" example:"desc"`
+	NextCursor string `json:"nextCursor,omitempty"`
+	HasMore    bool   `json:"hasMore"`
+}
+
+// GroupPageResponse is the body of GET /group.
+type GroupPageResponse struct {
+	Items  []GroupSummaryResponse `json:"items"`
+	Paging PagingResponse         `json:"paging"`
+}
+
+// WithDefaults fills the unset paging and sorting options.
+func (r ListGroupsRequest) WithDefaults() ListGroupsRequest {
+	if r.Limit == 0 {
+		r.Limit = models.DefaultPageLimit
+	}
+	if r.Sort == "" {
+		r.Sort = models.SortByCreatedAt
+	}
+	if r.Order == "" {
+		r.Order = models.SortDesc
+	}
+	return r
+}
+
+func (r ListGroupsRequest) Validate() error {
+	err := validation.ValidateStruct(&r,
+		validation.Field(&r.Limit, validation.Min(1), validation.Max(models.MaxPageLimit)),
+		validation.Field(&r.Sort, validation.In(models.SortByCreatedAt, models.SortByName)),
+		validation.Field(&r.Order, validation.In(models.SortAsc, models.SortDesc)),
+		validation.Field(&r.Status, validation.In(models.GroupStatusOpen, models.GroupStatusDrawn)),
+	)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r ListGroupsRequest) ToModel() models.GroupQuery {
+	return models.GroupQuery{
+		Limit:            r.Limit,
+		Cursor:           r.Cursor,
+		Sort:             r.Sort,
+		Order:            r.Order,
+		Name:             r.Name,
+		Status:           r.Status,
+		Owner:            r.Owner,
+		ParticipantEmail: r.ParticipantEmail,
+	}
+}
+
+// WithDefaults trims the search term and fills the unset limit.
+func (r SearchRequest) WithDefaults() SearchRequest {
+	r.Q = strings.TrimSpace(r.Q)
+	if r.Limit == 0 {
+		r.Limit = models.DefaultPageLimit
+	}
+	return r
+}
+
+func (r SearchRequest) Validate() error {
+	err := validation.ValidateStruct(&r,
+		validation.Field(&r.Q, validation.Required),
+		validation.Field(&r.Limit, validation.Min(1), validation.Max(models.MaxPageLimit)),
+	)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r SearchRequest) ToModel() models.SearchQuery {
+	return models.SearchQuery{Q: r.Q, Limit: r.Limit}
+}
+
+func NewGroupPageResponse(page *models.GroupPage) GroupPageResponse {
+	return GroupPageResponse{
+		Items: NewGroupSummaryResponses(page.Items),
+		Paging: PagingResponse{
+			Limit:      page.Paging.Limit,
+			Sort:       page.Paging.Sort,
+			Order:      page.Paging.Order,
+			NextCursor: page.Paging.NextCursor,
+			HasMore:    page.Paging.HasMore,
+		},
+	}
+}
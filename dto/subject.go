@@ -0,0 +1,92 @@
+package dto
+
+import (
+	"time"
+
+	"service-secret-santa/models"
+)
+
+// SubjectMatchResponse is a match of the draw that involves the data subject,
+// as giver or as receiver.
+type SubjectMatchResponse struct {
+	Giver    string `json:"giver" example:"Mari"`
+	Receiver string `json:"receiver" example:"Joao"`
+}
+
+// SubjectGroupResponse is what a group holds about the data subject: whether
+// they own it, the participants with their email and the matches of those
+// participants. DeletedAt is set while the group is in the trash.
+type SubjectGroupResponse struct {
+	GroupSummaryResponse
+	DeletedAt    *time.Time             `json:"deletedAt,omitempty"`
+	IsOwner      bool                   `json:"isOwner" example:"true"`
+	Participants []ParticipantResponse  `json:"participants"`
+	Matches      []SubjectMatchResponse `json:"matches"`
+}
+
+// SubjectExportResponse is the body of GET /admin/subject.
+type SubjectExportResponse struct {
+	Email      string                      `json:"email" example:"Mari@gmail.com"`
+	ExportedAt time.Time                   `json:"exportedAt"`
+	Groups     []SubjectGroupResponse      `json:"groups"`
+	Audit      []SubjectAuditEventResponse `json:"audit"`
+}
+
+// SubjectAuditEventResponse is an audit event that names the person or that
+// they made, and the group it belongs to.
+type SubjectAuditEventResponse struct {
+	GroupId string `json:"groupId" example:"6787c4a755ea623ab45e77d4"`
+	AuditEventResponse
+}
+
+// ErasureCertificateResponse is the record of an erasure. The email is not
+// kept.
+type ErasureCertificateResponse struct {
+	Id string `json:"id" example:"6787c4a755ea623ab45e77d4"`
+	// SubjectHash is an HMAC-SHA256 of the lower-cased email under BLIND_INDEX_KEY, empty when it is not set.
+	SubjectHash         string    `json:"subjectHash" example:"9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"`
+	ErasedAt            time.Time `json:"erasedAt"`
+	Groups              []string  `json:"groups"`
+	ParticipantsRemoved int       `json:"participantsRemoved" example:"2"`
+	DrawsRepaired       int       `json:"drawsRepaired" example:"1"`
+}
+
+func NewSubjectExportResponse(export *models.SubjectExport) SubjectExportResponse {
+	groups := make([]SubjectGroupResponse, 0, len(export.Groups))
+	for _, g := range export.Groups {
+		matches := make([]SubjectMatchResponse, 0, len(g.Matches))
+		for _, m := range g.Matches {
+			matches = append(matches, SubjectMatchResponse{Giver: m.First, Receiver: m.Second})
+		}
+		groups = append(groups, SubjectGroupResponse{
+			GroupSummaryResponse: NewGroupSummaryResponse(g.Group),
+			DeletedAt:            optionalTime(g.Group.DeletedAt),
+			IsOwner:              g.Owner,
+			Participants:         NewParticipantResponses(g.Participants),
+			Matches:              matches,
+		})
+	}
+
+	audit := make([]SubjectAuditEventResponse, 0, len(export.Audit))
+	for i, event := range NewAuditEventResponses(export.Audit) {
+		audit = append(audit, SubjectAuditEventResponse{GroupId: export.Audit[i].GroupId.Hex(), AuditEventResponse: event})
+	}
+
+	return SubjectExportResponse{Email: export.Email, ExportedAt: export.ExportedAt, Groups: groups, Audit: audit}
+}
+
+func NewErasureCertificateResponse(certificate *models.ErasureCertificate) ErasureCertificateResponse {
+	groups := certificate.Groups
+	if groups == nil {
+		groups = []string{}
+	}
+
+	return ErasureCertificateResponse{
+		Id:                  certificate.Id.Hex(),
+		SubjectHash:         certificate.SubjectHash,
+		ErasedAt:            certificate.ErasedAt,
+		Groups:              groups,
+		ParticipantsRemoved: certificate.ParticipantsRemoved,
+		DrawsRepaired:       certificate.DrawsRepaired,
+	}
+}
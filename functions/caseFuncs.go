@@ -1,46 +1,180 @@
-package functions
-
-import (
-	"regexp"
-	"strings"
-
-	"go.mongodb.org/mongo-driver/bson"
-	"go.mongodb.org/mongo-driver/bson/primitive"
-)
-
-var accentsMap = func() map[rune]string {
-	accents := []string{
-		"[aàáâã]",
-		"[cç]",
-		"[eéê]",
-		"[ií]",
-		"[oóôõ]",
-		"[uú]",
-	}
-	res := make(map[rune]string)
-	for _, str := range accents {
-		for _, char := range str[1 : len(str)-1] {
-			res[char] = str
-		}
-	}
-	return res
-}()
-
-func ToCaseInsensitiveRegex(arr []string) bson.M {
-	var regex string
-	for i := range arr {
-		if i != 0 {
-			regex += "|"
-		}
-		escaped := regexp.QuoteMeta(strings.ToLower(arr[i]))
-		for _, char := range escaped {
-			accs, in := accentsMap[char]
-			if in {
-				regex += accs
-			} else {
-				regex += string(char)
-			}
-		}
-	}
-	return bson.M{"$regex": primitive.Regex{Pattern: regex, Options: "i"}}
-}
+package functions
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Letters that do not decompose into a base letter plus combining marks.
+var specialFolds = map[rune]string{
+	'ß': "ss", 'ẞ': "ss",
+	'æ': "ae", 'Æ': "ae",
+	'œ': "oe", 'Œ': "oe",
+	'ø': "o", 'Ø': "o",
+	'đ': "d", 'Đ': "d",
+	'ð': "d", 'Ð': "d",
+	'ħ': "h", 'Ħ': "h",
+	'ı': "i",
+	'ĳ': "ij", 'Ĳ': "ij",
+	'ĸ': "k",
+	'ŀ': "l", 'Ŀ': "l",
+	'ł': "l", 'Ł': "l",
+	'ŉ': "n",
+	'ŋ': "n", 'Ŋ': "n",
+	'ſ': "s",
+	'þ': "th", 'Þ': "th",
+}
+
+// baseLetter returns the unaccented lower case ASCII letter of r, if it has one.
+func baseLetter(r rune) (rune, bool) {
+	if fold, in := specialFolds[r]; in {
+		if len(fold) == 1 {
+			return rune(fold[0]), true
+		}
+		return 0, false
+	}
+	base := []rune(norm.NFD.String(string(r)))[0]
+	base = unicode.ToLower(base)
+	if base >= 'a' && base <= 'z' {
+		return base, true
+	}
+	return 0, false
+}
+
+// accentsMap maps every letter of the Latin-1 Supplement and Latin Extended-A
+// blocks, and their ASCII base letters, to a regex class matching all the
+// accented variants of that letter in either case.
+var accentsMap = func() map[rune]string {
+	variants := make(map[rune][]rune)
+	for r := 'a'; r <= 'z'; r++ {
+		variants[r] = []rune{r, unicode.ToUpper(r)}
+	}
+	for r := rune(0x00C0); r <= 0x017F; r++ {
+		if base, ok := baseLetter(r); ok && unicode.IsLetter(r) {
+			variants[base] = append(variants[base], r)
+		}
+	}
+
+	res := make(map[rune]string)
+	for _, chars := range variants {
+		if len(chars) == 2 {
+			continue
+		}
+		sort.Slice(chars, func(i, j int) bool { return chars[i] < chars[j] })
+		class := "[" + string(chars) + "]"
+		for _, char := range chars {
+			res[char] = class
+		}
+	}
+	return res
+}()
+
+// pairFolds maps the letter pairs that specialFolds folds some letters to,
+// such as "ss", to a regex class of those letters in either case.
+var pairFolds = func() map[string]string {
+	letters := make(map[string][]rune)
+	for r, fold := range specialFolds {
+		if len(fold) == 2 {
+			letters[fold] = append(letters[fold], r)
+		}
+	}
+
+	res := make(map[string]string)
+	for pair, chars := range letters {
+		sort.Slice(chars, func(i, j int) bool { return chars[i] < chars[j] })
+		res[pair] = "[" + string(chars) + "]"
+	}
+	return res
+}()
+
+func ToCaseInsensitiveRegex(arr []string) bson.M {
+	var regex string
+	for i := range arr {
+		if i != 0 {
+			regex += "|"
+		}
+		regex += foldedPattern(arr[i])
+	}
+	return bson.M{"$regex": primitive.Regex{Pattern: regex, Options: "i"}}
+}
+
+// foldedPattern matches term ignoring case and accents, like FoldAccents:
+// letters match their accented variants, and a letter such as ß matches
+// both itself and the pair it folds to.
+func foldedPattern(term string) string {
+	var b strings.Builder
+	runes := []rune(strings.ToLower(term))
+	for i := 0; i < len(runes); i++ {
+		pair := ""
+		if fold, in := specialFolds[runes[i]]; in && len(fold) == 2 {
+			pair = fold
+		} else if i+1 < len(runes) {
+			if _, in := pairFolds[string(runes[i:i+2])]; in {
+				pair = string(runes[i : i+2])
+				i++
+			}
+		}
+
+		if pair != "" {
+			b.WriteString("(?:" + letterPattern(rune(pair[0])) + letterPattern(rune(pair[1])) + "|" + pairFolds[pair] + ")")
+			continue
+		}
+		b.WriteString(letterPattern(runes[i]))
+	}
+	return b.String()
+}
+
+func letterPattern(r rune) string {
+	if accs, in := accentsMap[r]; in {
+		return accs
+	}
+	return regexp.QuoteMeta(string(r))
+}
+
+// FoldAccents lower cases s and strips the accents of its letters, so that
+// "João" and "JOAO" both become "joao".
+func FoldAccents(s string) string {
+	var b strings.Builder
+	for _, r := range norm.NFD.String(s) {
+		if unicode.Is(unicode.Mn, r) {
+			continue
+		}
+		if fold, in := specialFolds[r]; in {
+			b.WriteString(fold)
+			continue
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
+
+const (
+	RankExact = iota
+	RankPrefix
+	RankContains
+)
+
+// SearchRank reports how well term matches the best of the given fields,
+// ignoring case and accents. Lower ranks are better matches.
+func SearchRank(term string, fields ...string) (int, bool) {
+	term = FoldAccents(strings.TrimSpace(term))
+	best, found := RankContains, false
+	for _, field := range fields {
+		field = FoldAccents(strings.TrimSpace(field))
+		switch {
+		case field == term:
+			return RankExact, true
+		case strings.HasPrefix(field, term):
+			best, found = RankPrefix, true
+		case !found && strings.Contains(field, term):
+			found = true
+		}
+	}
+	return best, found
+}
@@ -0,0 +1,60 @@
+package functions
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func compileRegex(t *testing.T, terms ...string) *regexp.Regexp {
+	pattern := ToCaseInsensitiveRegex(terms)["$regex"].(primitive.Regex).Pattern
+	return regexp.MustCompile("(?i)" + pattern)
+}
+
+func TestToCaseInsensitiveRegex(t *testing.T) {
+	assert.True(t, compileRegex(t, "Joao").MatchString("João"))
+	assert.True(t, compileRegex(t, "joão").MatchString("JOAO"))
+	assert.True(t, compileRegex(t, "Muller").MatchString("Müller"))
+	assert.True(t, compileRegex(t, "Francois").MatchString("François"))
+	assert.True(t, compileRegex(t, "nunez").MatchString("Núñez"))
+	assert.True(t, compileRegex(t, "lodz").MatchString("Łódź"))
+	assert.True(t, compileRegex(t, "dvorak").MatchString("Dvořák"))
+	assert.True(t, compileRegex(t, "a.b").MatchString("a.b"))
+	assert.False(t, compileRegex(t, "a.b").MatchString("axb"))
+	assert.False(t, compileRegex(t, "Maria").MatchString("Mario"))
+	assert.True(t, compileRegex(t, "strasse").MatchString("Straße"))
+	assert.True(t, compileRegex(t, "STRAßE").MatchString("Strasse"))
+	assert.True(t, compileRegex(t, "caesar").MatchString("Cæsar"))
+	assert.True(t, compileRegex(t, "Œuvre").MatchString("oeuvre"))
+	assert.False(t, compileRegex(t, "ss").MatchString("s"))
+}
+
+func TestFoldAccents(t *testing.T) {
+	assert.Equal(t, "joao", FoldAccents("João"))
+	assert.Equal(t, "strasse", FoldAccents("Straße"))
+	assert.Equal(t, "francois", FoldAccents("FRANÇOIS"))
+	assert.Equal(t, "lodz", FoldAccents("Łódź"))
+}
+
+func TestSearchRank(t *testing.T) {
+	rank, ok := SearchRank("joao", "João")
+	assert.True(t, ok)
+	assert.Equal(t, RankExact, rank)
+
+	rank, ok = SearchRank("joao", "João Pedro")
+	assert.True(t, ok)
+	assert.Equal(t, RankPrefix, rank)
+
+	rank, ok = SearchRank("pedro", "João Pedro", "pedro@gmail.com")
+	assert.True(t, ok)
+	assert.Equal(t, RankPrefix, rank)
+
+	rank, ok = SearchRank("gmail", "João", "joao@gmail.com")
+	assert.True(t, ok)
+	assert.Equal(t, RankContains, rank)
+
+	_, ok = SearchRank("maria", "João", "joao@gmail.com")
+	assert.False(t, ok)
+}
@@ -1,7 +1,13 @@
 package functions
 
 import (
+	"context"
 	"math/rand"
+
+	"service-secret-santa/resources/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 var dp = func() []float64 {
@@ -56,3 +62,12 @@ func RandomDerangement(n int, rng *rand.Rand) []int {
 
 	return A
 }
+
+// TracedRandomDerangement is RandomDerangement inside a span of ctx, so the
+// draw shows how long the matcher took apart from the database calls.
+func TracedRandomDerangement(ctx context.Context, n int, rng *rand.Rand) []int {
+	_, span := tracing.Tracer().Start(ctx, "functions.RandomDerangement", trace.WithAttributes(attribute.Int("participants.count", n)))
+	defer span.End()
+
+	return RandomDerangement(n, rng)
+}
@@ -0,0 +1,74 @@
+package functions
+
+import (
+	"sort"
+	"strings"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// NormalizeName brings a person's name to a canonical form for comparison:
+// compatibility decomposition (NFKD), accents stripped, case folded and
+// whitespace collapsed. "  JOÃO  Pedro" and "joao pedro" normalise alike.
+func NormalizeName(s string) string {
+	return strings.Join(strings.Fields(FoldAccents(norm.NFKD.String(s))), " ")
+}
+
+// EditDistance returns the Levenshtein distance between a and b, in runes.
+func EditDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+
+	return prev[len(rb)]
+}
+
+// Suggest returns up to limit candidates close to name, closest first. Names
+// are compared normalised, and a candidate is close when it is at most a
+// third of the name's length away (and never less than two edits).
+func Suggest(name string, candidates []string, limit int) []string {
+	target := NormalizeName(name)
+	threshold := len([]rune(target)) / 3
+	if threshold < 2 {
+		threshold = 2
+	}
+
+	type suggestion struct {
+		name     string
+		distance int
+	}
+	var matches []suggestion
+	for _, candidate := range candidates {
+		if d := EditDistance(target, NormalizeName(candidate)); d <= threshold {
+			matches = append(matches, suggestion{name: candidate, distance: d})
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].distance != matches[j].distance {
+			return matches[i].distance < matches[j].distance
+		}
+		return matches[i].name < matches[j].name
+	})
+
+	suggestions := []string{}
+	for i := 0; i < len(matches) && i < limit; i++ {
+		suggestions = append(suggestions, matches[i].name)
+	}
+	return suggestions
+}
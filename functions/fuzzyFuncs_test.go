@@ -0,0 +1,31 @@
+package functions
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeName(t *testing.T) {
+	assert.Equal(t, "joao", NormalizeName("joão"))
+	assert.Equal(t, "joao", NormalizeName("Joao "))
+	assert.Equal(t, "joao", NormalizeName("JOAO"))
+	assert.Equal(t, "joao pedro", NormalizeName("  João \t Pedro "))
+	assert.Equal(t, "joao", NormalizeName("Ｊｏãｏ"))
+}
+
+func TestEditDistance(t *testing.T) {
+	assert.Equal(t, 0, EditDistance("joao", "joao"))
+	assert.Equal(t, 1, EditDistance("joao", "jao"))
+	assert.Equal(t, 3, EditDistance("kitten", "sitting"))
+	assert.Equal(t, 4, EditDistance("", "mari"))
+}
+
+func TestSuggest(t *testing.T) {
+	candidates := []string{"João", "Joana", "Mario", "Luigi"}
+
+	assert.Equal(t, []string{"Joana", "João"}, Suggest("joan", candidates, 5))
+	assert.Equal(t, []string{"Mario"}, Suggest("mareo", candidates, 5))
+	assert.Equal(t, []string{"João"}, Suggest("Jao", candidates, 1))
+	assert.Empty(t, Suggest("Bartholomew", candidates, 5))
+}
@@ -0,0 +1,120 @@
+package group
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"service-secret-santa/config"
+	"service-secret-santa/customError"
+	"service-secret-santa/dto"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequireAdmin lets through the requests that carry ADMIN_TOKEN as a bearer
+// token. Every request is refused while the token is not configured.
+func RequireAdmin(c *gin.Context) {
+	token, found := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+	expected := config.Cfg.AdminToken
+	if !found || expected == "" || subtle.ConstantTimeCompare([]byte(token), []byte(expected)) != 1 {
+		customErr := customError.NewCustomError(customError.WithUnauthorized("Missing or invalid admin token", "Unauthorized"), customError.WithCode(customError.Unauthorized))
+		respondError(c, customErr)
+		c.Abort()
+		return
+	}
+
+	c.Next()
+}
+
+// subjectEmail reads the email of the data subject from the query.
+func subjectEmail(c *gin.Context) (string, *customError.CustomError) {
+	email := strings.TrimSpace(c.Query("email"))
+	if email == "" {
+		return "", customError.NewCustomError(customError.WithBadRequest("Email is required", "Validation error"), customError.WithCode(customError.ValidationFailed))
+	}
+	return email, nil
+}
+
+// ExportSubject godoc
+//
+// @Summary 	Export the personal data of a person
+// @Description List every group, in the trash or not, that the email owns or takes part in, with its participants and matches, and the audit events of any group that name the person or that they made. There are no wishlists or messages to export.
+// @Tags 		admin
+// @Produce  	json
+// @Security 	AdminToken
+// @Param 		email 		query 		string 		true 	"Email of the person"
+// @Success 	200 		{object} 	dto.SubjectExportResponse
+// @Failure		400 		{object} 	customError.Problem
+// @Failure		401 		{object} 	customError.Problem
+// @Failure 	500 		{object} 	customError.Problem
+// @Router 		/admin/subject [get]
+func (r *resource) ExportSubject(c *gin.Context) {
+	email, customErr := subjectEmail(c)
+	if customErr != nil {
+		respondError(c, customErr)
+		return
+	}
+
+	export, err := r.svc.ExportSubject(c.Request.Context(), email)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewSubjectExportResponse(export))
+}
+
+// EraseSubject godoc
+//
+// @Summary 	Erase the personal data of a person
+// @Description Remove the email from every group, in the trash or not, as owner and as participant. Drawn groups keep the matches of the others: whoever gave to the person now gives to whom they gave. A group left with fewer than two participants is open again. The email and names of the person are taken out of the audit log. The erasure is recorded in a certificate that does not keep the email, only an HMAC of it under the blind index key.
+// @Tags 		admin
+// @Produce  	json
+// @Security 	AdminToken
+// @Param 		email 		query 		string 		true 	"Email of the person"
+// @Success 	200 		{object} 	dto.ErasureCertificateResponse
+// @Failure		400 		{object} 	customError.Problem
+// @Failure		401 		{object} 	customError.Problem
+// @Failure		412 		{object} 	customError.Problem
+// @Failure 	500 		{object} 	customError.Problem
+// @Router 		/admin/subject [delete]
+func (r *resource) EraseSubject(c *gin.Context) {
+	email, customErr := subjectEmail(c)
+	if customErr != nil {
+		respondError(c, customErr)
+		return
+	}
+
+	certificate, err := r.svc.EraseSubject(c.Request.Context(), email)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewErasureCertificateResponse(certificate))
+}
+
+// GetErasure godoc
+//
+// @Summary 	Get an erasure certificate
+// @Description Retrieve the record of an erasure by its ID.
+// @Tags 		admin
+// @Produce  	json
+// @Security 	AdminToken
+// @Param 		id 			path 		string 		true 	"Certificate ID"
+// @Success 	200 		{object} 	dto.ErasureCertificateResponse
+// @Failure		400 		{object} 	customError.Problem
+// @Failure		401 		{object} 	customError.Problem
+// @Failure		404 		{object} 	customError.Problem
+// @Failure 	500 		{object} 	customError.Problem
+// @Router 		/admin/erasures/{id} [get]
+func (r *resource) GetErasure(c *gin.Context) {
+	certificate, err := r.svc.GetErasure(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewErasureCertificateResponse(certificate))
+}
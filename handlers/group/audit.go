@@ -0,0 +1,60 @@
+package group
+
+import (
+	"net/http"
+
+	"service-secret-santa/customError"
+	"service-secret-santa/dto"
+	"service-secret-santa/resources/identity"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetAudit godoc
+//
+// @Summary 	Get the audit log of a group
+// @Description List who changed the group, when and how, oldest first: creation, changes, participants added and removed, draws, revealed matches and deletion. Only the organizer of the group, as sent in X-User-Email by the gateway along with X-Gateway-Token, can read it, also while the group is in the trash. Matches are never recorded.
+// @Tags 		group
+// @Produce  	json
+// @Security 	UserEmail || GatewayToken
+// @Param 		id 			path 		string 		true 	"Group ID"
+// @Param 		action 		query 		string 		false 	"Action" 	Enums(created, updated, participant-added, participant-removed, drawn, redrawn, revealed, deleted, restored, purge-warned, anonymized, erased)
+// @Param 		actor 		query 		string 		false 	"Email of who made the change, or system, admin or anonymous"
+// @Param 		since 		query 		string 		false 	"Events at or after this time (RFC 3339)"
+// @Param 		until 		query 		string 		false 	"Events before this time (RFC 3339)"
+// @Param 		limit 		query 		int 		false 	"Maximum number of events (1-1000)" 	default(100)
+// @Success 	200 		{array} 	dto.AuditEventResponse
+// @Failure		400 		{object} 	customError.Problem
+// @Failure		403 		{object} 	customError.Problem
+// @Failure		404 		{object} 	customError.Problem
+// @Failure		500 		{object} 	customError.Problem
+// @Router 		/group/{id}/audit [get]
+func (r *resource) GetAudit(c *gin.Context) {
+	var request dto.AuditRequest
+
+	if err := c.ShouldBindQuery(&request); err != nil {
+		customErr := customError.NewCustomError(customError.WithBadRequest(err.Error(), "Invalid query params"), customError.WithCode(customError.InvalidQuery))
+		respondError(c, customErr)
+		return
+	}
+
+	request = request.WithDefaults()
+	if err := request.Validate(); err != nil {
+		customErr := customError.NewCustomError(
+			customError.WithBadRequest(err.Error(), "Validation error"),
+			customError.WithCode(customError.ValidationFailed),
+			customError.WithValidationErrors(err),
+		)
+		respondError(c, customErr)
+		return
+	}
+
+	ctx := c.Request.Context()
+	events, err := r.svc.GetAudit(ctx, c.Param("id"), identity.User(ctx), request.ToModel())
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewAuditEventResponses(events))
+}
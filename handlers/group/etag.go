@@ -0,0 +1,63 @@
+package group
+
+import (
+	"net/http"
+	"service-secret-santa/customError"
+	"service-secret-santa/models"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// etag is the entity tag of a group, derived from its version.
+func etag(version int64) string {
+	return `"` + strconv.FormatInt(version, 10) + `"`
+}
+
+func setETag(c *gin.Context, version int64) {
+	c.Header("ETag", etag(version))
+}
+
+// ifMatchVersion reads the group version the client based its write on. The
+// If-Match header is required; "*" accepts whatever version is stored.
+func ifMatchVersion(c *gin.Context) (int64, *customError.CustomError) {
+	header := strings.TrimSpace(c.GetHeader("If-Match"))
+	if header == "" {
+		return 0, customError.NewCustomError(customError.WithCustomError(http.StatusPreconditionRequired, "If-Match header is required", "Send the ETag of the group you are changing in If-Match"), customError.WithCode(customError.PreconditionRequired))
+	}
+
+	if header == "*" {
+		return models.AnyVersion, nil
+	}
+
+	tag := strings.TrimPrefix(header, "W/")
+	if len(tag) < 2 || !strings.HasPrefix(tag, `"`) || !strings.HasSuffix(tag, `"`) {
+		return 0, customError.NewCustomError(customError.WithBadRequest("Malformed If-Match header", "Invalid request headers"), customError.WithCode(customError.PreconditionInvalid))
+	}
+
+	version, err := strconv.ParseInt(tag[1:len(tag)-1], 10, 64)
+	if err != nil || version < 0 {
+		return 0, customError.NewCustomError(customError.WithBadRequest("Malformed If-Match header", "Invalid request headers"), customError.WithCode(customError.PreconditionInvalid))
+	}
+
+	return version, nil
+}
+
+// notModified reports whether If-None-Match already names the given version.
+func notModified(c *gin.Context, version int64) bool {
+	header := c.GetHeader("If-None-Match")
+	if header == "" {
+		return false
+	}
+
+	current := etag(version)
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == "*" || tag == current {
+			return true
+		}
+	}
+
+	return false
+}
@@ -1,8 +1,11 @@
 package group
 
 import (
+	"encoding/json"
 	"net/http"
+	"service-secret-santa/config"
 	"service-secret-santa/customError"
+	"service-secret-santa/dto"
 	"service-secret-santa/models"
 	"service-secret-santa/services/group"
 
@@ -13,11 +16,20 @@ type Handler interface {
 	CreateGroup(c *gin.Context)
 	GetGroup(c *gin.Context)
 	UpdateGroup(c *gin.Context)
+	PatchGroup(c *gin.Context)
 	DeleteGroup(c *gin.Context)
+	GetTrash(c *gin.Context)
+	RestoreGroup(c *gin.Context)
 	GetMyMatch(c *gin.Context)
 	GetAllGroups(c *gin.Context)
 	MatchParticipants(c *gin.Context)
 	AddParticipant(c *gin.Context)
+	SearchGroups(c *gin.Context)
+	SearchParticipants(c *gin.Context)
+	ExportSubject(c *gin.Context)
+	EraseSubject(c *gin.Context)
+	GetErasure(c *gin.Context)
+	GetAudit(c *gin.Context)
 }
 
 type resource struct {
@@ -31,34 +43,39 @@ type resource struct {
 // @Tags 		group
 // @Accept  	json
 // @Produce  	json
-// @Param 		body 		body 		models.Group 	true 	"Group object"
-// @Success 	201 		{object} 	models.Group
-// @Failure		400 		"{"error": "Bad Request."}"
-// @Failure 	500 		"{"error": "Internal Server Error."}"
+// @Param 		body 		body 		dto.CreateGroupRequest 	true 	"Group object"
+// @Success 	201 		{object} 	dto.GroupResponse
+// @Failure		400 		{object} 	customError.Problem
+// @Failure 	500 		{object} 	customError.Problem
 // @Router 		/group [post]
 func (r *resource) CreateGroup(c *gin.Context) {
-	var group models.Group
+	var request dto.CreateGroupRequest
 
-	if err := c.ShouldBindJSON(&group); err != nil {
-		customErr := customError.NewCustomError(customError.WithBadRequest(err.Error(), "Invalid request body"))
-		c.JSON(customErr.Status, customErr)
+	if err := c.ShouldBindJSON(&request); err != nil {
+		customErr := customError.NewCustomError(customError.WithBadRequest(err.Error(), "Invalid request body"), customError.WithCode(customError.InvalidRequestBody))
+		respondError(c, customErr)
 		return
 	}
+	c.Set(groupLocaleKey, request.Locale)
 
-	err := group.Validate()
+	err := request.Validate()
 	if err != nil {
-		customErr := customError.NewCustomError(customError.WithBadRequest(err.Error(), "Validation error"))
-		c.JSON(customErr.Status, customErr)
+		customErr := customError.NewCustomError(
+			customError.WithBadRequest(err.Error(), "Validation error"),
+			customError.WithCode(customError.ValidationFailed),
+			customError.WithValidationErrors(err),
+		)
+		respondError(c, customErr)
 		return
 	}
 
-	result, createErr := r.svc.CreateGroup(&group)
+	result, createErr := r.svc.CreateGroup(c.Request.Context(), request.ToModel())
 	if createErr != nil {
-		c.JSON(createErr.Status, createErr)
+		respondError(c, createErr)
 		return
 	}
 
-	c.JSON(http.StatusCreated, result)
+	c.JSON(http.StatusCreated, dto.NewGroupResponse(result))
 }
 
 // GetGroup godoc
@@ -68,91 +85,276 @@ func (r *resource) CreateGroup(c *gin.Context) {
 // @Tags 		group
 // @Produce  	json
 // @Param 		id 			path 		string 		true 	"Group ID"
-// @Success 	200 		{object} 	models.Group
-// @Failure		404 		"{"error": "Not Found."}"
-// @Failure 	500 		"{"error": "Internal Server Error."}"
+// @Param 		If-None-Match 	header 	string 		false 	"ETag of a previously fetched version"
+// @Success 	200 		{object} 	dto.GroupResponse
+// @Success 	304 		"Not Modified"
+// @Header 		200 		{string} 	ETag 	"Version of the group, to be sent back in If-Match"
+// @Failure		404 		{object} 	customError.Problem
+// @Failure 	500 		{object} 	customError.Problem
 // @Router 		/group/{id} [get]
 func (r *resource) GetGroup(c *gin.Context) {
 	id := c.Param("id")
 	if id == "" {
-		customErr := customError.NewCustomError(customError.WithBadRequest("Group id is empty", "Invalid request params"))
-		c.JSON(customErr.Status, customErr)
+		customErr := customError.NewCustomError(customError.WithBadRequest("Group id is empty", "Invalid request params"), customError.WithCode(customError.GroupIdInvalid))
+		respondError(c, customErr)
 	}
 
-	group, err := r.svc.GetGroupByID(id)
+	group, err := r.svc.GetGroupByID(c.Request.Context(), id)
 	if err != nil {
-		c.JSON(err.Status, err)
+		respondError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, group)
+	setETag(c, group.Version)
+	if notModified(c, group.Version) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewGroupResponse(group))
 }
 
 // UpdateGroup godoc
 //
-// @Summary 	Update a group
-// @Description Update details of a specific group
+// @Summary 	Replace a group
+// @Description Replace the mutable fields of a group (name, owner and participants). Every mutable field must be sent; immutable fields such as id, matches and createdAt are rejected.
 // @Tags 		group
 // @Accept  	json
 // @Produce  	json
 // @Param 		id 			path 		string 		true 	"Group ID"
-// @Param 		body 		body 		models.Group 	true 	"Updated group object"
-// @Success 	200 		{object} 	models.Group
-// @Failure		400 		"{"error": "Bad Request."}"
-// @Failure		404 		"{"error": "Not Found."}"
-// @Failure 	500 		"{"error": "Internal Server Error."}"
+// @Param 		If-Match 	header 		string 		true 	"ETag of the group being updated, or *"
+// @Param 		body 		body 		dto.ReplaceGroupRequest 	true 	"Mutable fields of the group"
+// @Success 	200 		{object} 	dto.GroupResponse
+// @Header 		200 		{string} 	ETag 	"New version of the group"
+// @Failure		400 		{object} 	customError.Problem
+// @Failure		404 		{object} 	customError.Problem
+// @Failure		412 		{object} 	customError.Problem
+// @Failure		428 		{object} 	customError.Problem
+// @Failure 	500 		{object} 	customError.Problem
 // @Router 		/group/{id} [put]
 func (r *resource) UpdateGroup(c *gin.Context) {
 	id := c.Param("id")
 	if id == "" {
-		customErr := customError.NewCustomError(customError.WithBadRequest("Group id is empty", "Invalid request params"))
-		c.JSON(customErr.Status, customErr)
+		customErr := customError.NewCustomError(customError.WithBadRequest("Group id is empty", "Invalid request params"), customError.WithCode(customError.GroupIdInvalid))
+		respondError(c, customErr)
+	}
+
+	version, customErr := ifMatchVersion(c)
+	if customErr != nil {
+		respondError(c, customErr)
+		return
+	}
+
+	body, customErr := readGroupUpdateBody(c, true)
+	if customErr != nil {
+		respondError(c, customErr)
+		return
+	}
+
+	var request dto.ReplaceGroupRequest
+	if err := json.Unmarshal(body, &request); err != nil {
+		customErr := customError.NewCustomError(customError.WithBadRequest(err.Error(), "Invalid request body"), customError.WithCode(customError.InvalidRequestBody))
+		respondError(c, customErr)
+		return
+	}
+
+	if err := request.Validate(); err != nil {
+		customErr := customError.NewCustomError(
+			customError.WithBadRequest(err.Error(), "Validation error"),
+			customError.WithCode(customError.ValidationFailed),
+			customError.WithValidationErrors(err),
+		)
+		respondError(c, customErr)
+		return
+	}
+
+	result, err := r.svc.UpdateGroup(c.Request.Context(), id, version, request.ToModel())
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	setETag(c, result.Version)
+	c.JSON(http.StatusOK, dto.NewGroupResponse(result))
+}
+
+// PatchGroup godoc
+//
+// @Summary 	Patch a group
+// @Description Change some of the mutable fields of a group (name, owner and participants) with a JSON merge patch (RFC 7396): members set to null are removed and arrays are replaced wholesale. Immutable fields such as id, matches and createdAt are rejected.
+// @Tags 		group
+// @Accept  	application/merge-patch+json
+// @Produce  	json
+// @Param 		id 			path 		string 		true 	"Group ID"
+// @Param 		If-Match 	header 		string 		true 	"ETag of the group being patched, or *"
+// @Param 		body 		body 		dto.PatchGroupRequest 	true 	"Merge patch with any of the mutable fields"
+// @Success 	200 		{object} 	dto.GroupResponse
+// @Header 		200 		{string} 	ETag 	"New version of the group"
+// @Failure		400 		{object} 	customError.Problem
+// @Failure		404 		{object} 	customError.Problem
+// @Failure		412 		{object} 	customError.Problem
+// @Failure		415 		{object} 	customError.Problem
+// @Failure		428 		{object} 	customError.Problem
+// @Failure 	500 		{object} 	customError.Problem
+// @Router 		/group/{id} [patch]
+func (r *resource) PatchGroup(c *gin.Context) {
+	id := c.Param("id")
+	if id == "" {
+		customErr := customError.NewCustomError(customError.WithBadRequest("Group id is empty", "Invalid request params"), customError.WithCode(customError.GroupIdInvalid))
+		respondError(c, customErr)
+		return
+	}
+
+	if contentType := c.ContentType(); contentType != "application/merge-patch+json" && contentType != "application/json" {
+		customErr := customError.NewCustomError(customError.WithCustomError(http.StatusUnsupportedMediaType, "Content-Type must be application/merge-patch+json", "Invalid request headers"), customError.WithCode(customError.UnsupportedMediaType))
+		respondError(c, customErr)
+		return
 	}
 
-	var group models.Group
+	version, customErr := ifMatchVersion(c)
+	if customErr != nil {
+		respondError(c, customErr)
+		return
+	}
 
-	if err := c.ShouldBindJSON(&group); err != nil {
-		customErr := customError.NewCustomError(customError.WithBadRequest(err.Error(), "Invalid request body"))
-		c.JSON(customErr.Status, customErr)
+	patch, customErr := readGroupUpdateBody(c, false)
+	if customErr != nil {
+		respondError(c, customErr)
 		return
 	}
 
-	result, err := r.svc.UpdateGroup(id, &group)
+	result, err := r.svc.PatchGroup(c.Request.Context(), id, version, func(current models.GroupUpdate) (*models.GroupUpdate, *customError.CustomError) {
+		return dto.ApplyGroupMergePatch(current, patch)
+	})
 	if err != nil {
-		c.JSON(err.Status, err)
+		respondError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, result)
+	setETag(c, result.Version)
+	c.JSON(http.StatusOK, dto.NewGroupResponse(result))
+}
+
+// readGroupUpdateBody reads a JSON object body and checks its members against
+// the mutable group fields. With replace, every mutable field must be present.
+func readGroupUpdateBody(c *gin.Context, replace bool) ([]byte, *customError.CustomError) {
+	body, err := c.GetRawData()
+	if err != nil {
+		return nil, customError.NewCustomError(customError.WithBadRequest(err.Error(), "Invalid request body"), customError.WithCode(customError.InvalidRequestBody))
+	}
+
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(body, &members); err != nil || members == nil {
+		return nil, customError.NewCustomError(customError.WithBadRequest("Body must be a JSON object", "Invalid request body"), customError.WithCode(customError.InvalidRequestBody))
+	}
+
+	if fieldErrs := dto.GroupFieldErrors(members, replace); len(fieldErrs) > 0 {
+		return nil, customError.NewCustomError(
+			customError.WithBadRequest("Only name, owner and participants can be changed", "Validation error"),
+			customError.WithCode(customError.ValidationFailed),
+			customError.WithFieldErrors(fieldErrs),
+		)
+	}
+
+	return body, nil
 }
 
 // DeleteGroup godoc
 //
 // @Summary 	Delete a group
-// @Description Delete a specific group by its ID
+// @Description Move a group to the trash. It is hidden everywhere else, can be restored with POST /group/{id}/restore and is removed for good once the trash retention runs out.
 // @Tags 		group
 // @Produce  	json
 // @Param 		id 			path 		string 		true 	"Group ID"
+// @Param 		If-Match 	header 		string 		true 	"ETag of the group being deleted, or *"
 // @Success 	204 		"{}"
-// @Failure		404 		"{"error": "Not Found."}"
-// @Failure 	500 		"{"error": "Internal Server Error."}"
+// @Failure		404 		{object} 	customError.Problem
+// @Failure		412 		{object} 	customError.Problem
+// @Failure		428 		{object} 	customError.Problem
+// @Failure 	500 		{object} 	customError.Problem
 // @Router 		/group/{id} [delete]
 func (r *resource) DeleteGroup(c *gin.Context) {
 	id := c.Param("id")
 	if id == "" {
-		customErr := customError.NewCustomError(customError.WithBadRequest("Group id is empty", "Invalid request params"))
-		c.JSON(customErr.Status, customErr)
+		customErr := customError.NewCustomError(customError.WithBadRequest("Group id is empty", "Invalid request params"), customError.WithCode(customError.GroupIdInvalid))
+		respondError(c, customErr)
+	}
+
+	version, customErr := ifMatchVersion(c)
+	if customErr != nil {
+		respondError(c, customErr)
+		return
 	}
 
-	err := r.svc.DeleteGroup(id)
+	err := r.svc.DeleteGroup(c.Request.Context(), id, version)
 	if err != nil {
-		c.JSON(err.Status, err)
+		respondError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusNoContent, nil)
 }
 
+// GetTrash godoc
+//
+// @Summary 	List deleted groups
+// @Description List the groups in the trash, most recently deleted first, with when each one is removed for good.
+// @Tags 		group
+// @Produce  	json
+// @Param 		owner 		query 		string 		false 	"Owner email"
+// @Success 	200 		{array} 	dto.TrashedGroupResponse
+// @Failure		500 		{object} 	customError.Problem
+// @Router 		/group/trash [get]
+func (r *resource) GetTrash(c *gin.Context) {
+	groups, err := r.svc.GetTrash(c.Request.Context(), c.Query("owner"))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewTrashedGroupResponses(groups, config.Cfg.TrashRetention))
+}
+
+// RestoreGroup godoc
+//
+// @Summary 	Restore a deleted group
+// @Description Take a group out of the trash, with its participants and matches.
+// @Tags 		group
+// @Produce  	json
+// @Param 		id 			path 		string 		true 	"Group ID"
+// @Param 		If-Match 	header 		string 		true 	"ETag of the deleted group, or *"
+// @Success 	200 		{object} 	dto.GroupResponse
+// @Header 		200 		{string} 	ETag 	"New version of the group"
+// @Failure		400 		{object} 	customError.Problem
+// @Failure		404 		{object} 	customError.Problem
+// @Failure		412 		{object} 	customError.Problem
+// @Failure		428 		{object} 	customError.Problem
+// @Failure 	500 		{object} 	customError.Problem
+// @Router 		/group/{id}/restore [post]
+func (r *resource) RestoreGroup(c *gin.Context) {
+	id := c.Param("id")
+	if id == "" {
+		customErr := customError.NewCustomError(customError.WithBadRequest("Group id is empty", "Invalid request params"), customError.WithCode(customError.GroupIdInvalid))
+		respondError(c, customErr)
+		return
+	}
+
+	version, customErr := ifMatchVersion(c)
+	if customErr != nil {
+		respondError(c, customErr)
+		return
+	}
+
+	result, err := r.svc.RestoreGroup(c.Request.Context(), id, version)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	setETag(c, result.Version)
+	c.JSON(http.StatusOK, dto.NewGroupResponse(result))
+}
+
 // AddParticipant godoc
 //
 // @Summary 	Add a participant to a group
@@ -161,33 +363,45 @@ func (r *resource) DeleteGroup(c *gin.Context) {
 // @Accept  	json
 // @Produce  	json
 // @Param 		id 			path 		string 		true 	"Group ID"
-// @Param 		body 		body 		models.Participant true "Participant to add"
-// @Success 	200 		{object} 	models.Group
-// @Failure		400 		"{"error": "Bad Request."}"
-// @Failure		404 		"{"error": "Not Found."}"
-// @Failure 	500 		"{"error": "Internal Server Error."}"
+// @Param 		If-Match 	header 		string 		true 	"ETag of the group, or *"
+// @Param 		body 		body 		dto.AddParticipantRequest true "Participant to add"
+// @Success 	200 		{object} 	dto.GroupResponse
+// @Header 		200 		{string} 	ETag 	"New version of the group"
+// @Failure		400 		{object} 	customError.Problem
+// @Failure		404 		{object} 	customError.Problem
+// @Failure		409 		{object} 	customError.Problem
+// @Failure		412 		{object} 	customError.Problem
+// @Failure		428 		{object} 	customError.Problem
+// @Failure 	500 		{object} 	customError.Problem
 // @Router 		/group/{id}/add-participant [post]
 func (r *resource) AddParticipant(c *gin.Context) {
 	id := c.Param("id")
 	if id == "" {
-		customErr := customError.NewCustomError(customError.WithBadRequest("Group id is empty", "Invalid request params"))
-		c.JSON(customErr.Status, customErr)
+		customErr := customError.NewCustomError(customError.WithBadRequest("Group id is empty", "Invalid request params"), customError.WithCode(customError.GroupIdInvalid))
+		respondError(c, customErr)
+	}
+	version, customErr := ifMatchVersion(c)
+	if customErr != nil {
+		respondError(c, customErr)
+		return
 	}
-	var body models.Participant
+
+	var body dto.AddParticipantRequest
 
 	if err := c.ShouldBindJSON(&body); err != nil {
-		customErr := customError.NewCustomError(customError.WithBadRequest(err.Error(), "Invalid request body"))
-		c.JSON(customErr.Status, customErr)
+		customErr := customError.NewCustomError(customError.WithBadRequest(err.Error(), "Invalid request body"), customError.WithCode(customError.InvalidRequestBody))
+		respondError(c, customErr)
 		return
 	}
 
-	result, err := r.svc.AddParticipant(id, &body)
+	result, err := r.svc.AddParticipant(c.Request.Context(), id, version, body.ToModel())
 	if err != nil {
-		c.JSON(err.Status, err)
+		respondError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, result)
+	setETag(c, result.Version)
+	c.JSON(http.StatusOK, dto.NewGroupResponse(result))
 }
 
 // MatchParticipants godoc
@@ -197,82 +411,206 @@ func (r *resource) AddParticipant(c *gin.Context) {
 // @Tags 		group
 // @Produce  	json
 // @Param 		id 			path 		string 		true 	"Group ID"
-// @Success 	200 		{object} 	models.Group
-// @Failure		400 		"{"error": "Bad Request."}"
-// @Failure		404 		"{"error": "Not Found."}"
-// @Failure 	500 		"{"error": "Internal Server Error."}"
+// @Param 		If-Match 	header 		string 		true 	"ETag of the group, or *"
+// @Success 	200 		{object} 	dto.GroupResponse
+// @Header 		200 		{string} 	ETag 	"New version of the group"
+// @Failure		400 		{object} 	customError.Problem
+// @Failure		404 		{object} 	customError.Problem
+// @Failure		412 		{object} 	customError.Problem
+// @Failure		428 		{object} 	customError.Problem
+// @Failure 	500 		{object} 	customError.Problem
 // @Router 		/group/{id}/match-participants [post]
 func (r *resource) MatchParticipants(c *gin.Context) {
 	id := c.Param("id")
 	if id == "" {
-		customErr := customError.NewCustomError(customError.WithBadRequest("Group id is empty", "Invalid request params"))
-		c.JSON(customErr.Status, customErr)
+		customErr := customError.NewCustomError(customError.WithBadRequest("Group id is empty", "Invalid request params"), customError.WithCode(customError.GroupIdInvalid))
+		respondError(c, customErr)
 	}
 
-	result, err := r.svc.MatchParticipants(id)
+	version, customErr := ifMatchVersion(c)
+	if customErr != nil {
+		respondError(c, customErr)
+		return
+	}
+
+	result, err := r.svc.MatchParticipants(c.Request.Context(), id, version)
 	if err != nil {
-		c.JSON(err.Status, err)
+		respondError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, result)
+	setETag(c, result.Version)
+	c.JSON(http.StatusOK, dto.NewGroupResponse(result))
 }
 
 // GetMyMatch godoc
 //
 // @Summary 	Get the match for a participant
-// @Description Retrieve the participant you are matched to gift in a group
+// @Description Retrieve the participant you are matched to gift in a group. A participant named exactly as the username is picked first; otherwise the username is compared ignoring case, accents and extra spaces, and when it cannot be resolved to a single participant the error lists suggestions.
 // @Tags 		group
 // @Produce  	json
 // @Param 		id 			path 		string 		true 	"Group ID"
 // @Param 		username	query 		string 		true 	"Participant username"
-// @Success 	200 		"string"
-// @Failure		400 		"{"error": "Bad Request."}"
-// @Failure		404 		"{"error": "Not Found."}"
-// @Failure		500 		"{"error": "Internal Server Error."}"
+// @Success 	200 		{object} 	dto.MyMatchResponse
+// @Failure		400 		{object} 	customError.Problem
+// @Failure		404 		{object} 	customError.Problem
+// @Failure		409 		{object} 	customError.Problem
+// @Failure		500 		{object} 	customError.Problem
 // @Router 		/group/{id}/my-match [get]
 func (r *resource) GetMyMatch(c *gin.Context) {
 	id := c.Param("id")
 	username := c.Query("username")
 
 	if username == "" {
-		customErr := customError.NewCustomError(customError.WithBadRequest("Username is required", "Validation error"))
-		c.JSON(customErr.Status, customErr)
+		customErr := customError.NewCustomError(customError.WithBadRequest("Username is required", "Validation error"), customError.WithCode(customError.ValidationFailed))
+		respondError(c, customErr)
 		return
 	}
 
 	if id == "" {
-		customErr := customError.NewCustomError(customError.WithBadRequest("Group id is empty", "Invalid request params"))
-		c.JSON(customErr.Status, customErr)
+		customErr := customError.NewCustomError(customError.WithBadRequest("Group id is empty", "Invalid request params"), customError.WithCode(customError.GroupIdInvalid))
+		respondError(c, customErr)
 		return
 	}
 
-	match, err := r.svc.GetMyMatch(id, username)
+	match, err := r.svc.GetMyMatch(c.Request.Context(), id, username)
 	if err != nil {
-		c.JSON(err.Status, err)
+		respondError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"match": match})
+	c.JSON(http.StatusOK, dto.MyMatchResponse{Match: match})
 }
 
 // GetAllGroups godoc
 //
 // @Summary 	Get all groups
-// @Description Retrieve a list of all groups
+// @Description Retrieve a page of groups, optionally filtered and sorted. Use the returned nextCursor to fetch the following page.
 // @Tags 		group
 // @Produce  	json
-// @Success 	200 		{array} 	models.Group
-// @Failure		500 		"{"error": "Internal Server Error."}"
+// @Param 		limit 				query 		int 		false 	"Page size (1-100)" 	default(20)
+// @Param 		cursor 				query 		string 		false 	"Opaque cursor returned as nextCursor by the previous page"
+// @Param 		sort 				query 		string 		false 	"Sort field" 			Enums(createdAt, name) 	default(createdAt)
+// @Param 		order 				query 		string 		false 	"Sort order" 			Enums(asc, desc) 		default(desc)
+// @Param 		name 				query 		string 		false 	"Group name contains (case and accent insensitive)"
+// @Param 		status 				query 		string 		false 	"Group status" 			Enums(open, drawn)
+// @Param 		owner 				query 		string 		false 	"Owner email"
+// @Param 		participantEmail 	query 		string 		false 	"Email of a participant"
+// @Success 	200 		{object} 	dto.GroupPageResponse
+// @Failure		400 		{object} 	customError.Problem
+// @Failure		500 		{object} 	customError.Problem
 // @Router 		/group [get]
 func (r *resource) GetAllGroups(c *gin.Context) {
-	groups, err := r.svc.GetAllGroups()
+	var request dto.ListGroupsRequest
+
+	if err := c.ShouldBindQuery(&request); err != nil {
+		customErr := customError.NewCustomError(customError.WithBadRequest(err.Error(), "Invalid query params"), customError.WithCode(customError.InvalidQuery))
+		respondError(c, customErr)
+		return
+	}
+
+	request = request.WithDefaults()
+	if err := request.Validate(); err != nil {
+		customErr := customError.NewCustomError(
+			customError.WithBadRequest(err.Error(), "Validation error"),
+			customError.WithCode(customError.ValidationFailed),
+			customError.WithValidationErrors(err),
+		)
+		respondError(c, customErr)
+		return
+	}
+
+	page, err := r.svc.GetAllGroups(c.Request.Context(), request.ToModel())
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewGroupPageResponse(page))
+}
+
+// SearchGroups godoc
+//
+// @Summary 	Search groups
+// @Description Search groups by name or owner email, ignoring case and accents. Exact matches come first, then prefix matches, then partial matches.
+// @Tags 		group
+// @Produce  	json
+// @Param 		q 			query 		string 		true 	"Search term"
+// @Param 		limit 		query 		int 		false 	"Maximum number of results (1-100)" 	default(20)
+// @Success 	200 		{array} 	dto.GroupSummaryResponse
+// @Failure		400 		{object} 	customError.Problem
+// @Failure		500 		{object} 	customError.Problem
+// @Router 		/group/search [get]
+func (r *resource) SearchGroups(c *gin.Context) {
+	query, customErr := bindSearchQuery(c)
+	if customErr != nil {
+		respondError(c, customErr)
+		return
+	}
+
+	groups, err := r.svc.SearchGroups(c.Request.Context(), query)
 	if err != nil {
-		c.JSON(err.Status, err)
+		respondError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, groups)
+	c.JSON(http.StatusOK, dto.NewGroupSummaryResponses(groups))
+}
+
+// SearchParticipants godoc
+//
+// @Summary 	Search participants of a group
+// @Description Search the participants of a group by name or email, ignoring case and accents. Exact matches come first, then prefix matches, then partial matches.
+// @Tags 		group
+// @Produce  	json
+// @Param 		id 			path 		string 		true 	"Group ID"
+// @Param 		q 			query 		string 		true 	"Search term"
+// @Param 		limit 		query 		int 		false 	"Maximum number of results (1-100)" 	default(20)
+// @Success 	200 		{array} 	dto.ParticipantResponse
+// @Failure		400 		{object} 	customError.Problem
+// @Failure		404 		{object} 	customError.Problem
+// @Failure		500 		{object} 	customError.Problem
+// @Router 		/group/{id}/participants/search [get]
+func (r *resource) SearchParticipants(c *gin.Context) {
+	id := c.Param("id")
+	if id == "" {
+		customErr := customError.NewCustomError(customError.WithBadRequest("Group id is empty", "Invalid request params"), customError.WithCode(customError.GroupIdInvalid))
+		respondError(c, customErr)
+		return
+	}
+
+	query, customErr := bindSearchQuery(c)
+	if customErr != nil {
+		respondError(c, customErr)
+		return
+	}
+
+	participants, err := r.svc.SearchParticipants(c.Request.Context(), id, query)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewParticipantResponses(participants))
+}
+
+func bindSearchQuery(c *gin.Context) (models.SearchQuery, *customError.CustomError) {
+	var request dto.SearchRequest
+
+	if err := c.ShouldBindQuery(&request); err != nil {
+		return models.SearchQuery{}, customError.NewCustomError(customError.WithBadRequest(err.Error(), "Invalid query params"), customError.WithCode(customError.InvalidQuery))
+	}
+
+	request = request.WithDefaults()
+	if err := request.Validate(); err != nil {
+		return models.SearchQuery{}, customError.NewCustomError(
+			customError.WithBadRequest(err.Error(), "Validation error"),
+			customError.WithCode(customError.ValidationFailed),
+			customError.WithValidationErrors(err),
+		)
+	}
+
+	return request.ToModel(), nil
 }
 
 func NewGroupHandler(svc group.Service) Handler {
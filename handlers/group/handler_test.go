@@ -1,17 +1,25 @@
 package group
 
 import (
+	"context"
 	"net/http"
 	"testing"
+	"time"
 
+	"service-secret-santa/config"
 	"service-secret-santa/customError"
+	"service-secret-santa/dto"
 	"service-secret-santa/functions"
+	"service-secret-santa/i18n"
 	"service-secret-santa/models"
+	"service-secret-santa/resources/identity"
+	groupService "service-secret-santa/services/group"
 	mocks "service-secret-santa/services/group/mock"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 func internalErrorExample() *customError.CustomError {
@@ -19,52 +27,86 @@ func internalErrorExample() *customError.CustomError {
 }
 
 func setupTest(t *testing.T) (*gomock.Controller, *mocks.MockService) {
+	config.LoadConfig()
 	mockCtrl := gomock.NewController(t)
 	mockService := mocks.NewMockService(mockCtrl)
 	return mockCtrl, mockService
 }
 
+func createGroupRequest(group *models.Group) dto.CreateGroupRequest {
+	request := dto.CreateGroupRequest{Name: group.Name, Owner: group.Owner}
+	for _, p := range group.Participants {
+		request.Participants = append(request.Participants, dto.ParticipantRequest{Name: p.Name, Email: p.Email})
+	}
+	return request
+}
+
 func TestCreateGroup_Success(t *testing.T) {
 	w, ctx := functions.PrepareCtx("POST")
 	group := models.CreateMockGroup()
-	functions.SetReqBody(ctx, group)
+	request := createGroupRequest(group)
+	functions.SetReqBody(ctx, request)
 
 	mockCtrl, mockServices := setupTest(t)
 	defer mockCtrl.Finish()
 
-	mockServices.EXPECT().CreateGroup(group).Return(group, nil)
+	mockServices.EXPECT().CreateGroup(gomock.Any(), request.ToModel()).Return(group, nil)
 
 	handler := NewGroupHandler(mockServices)
 	handler.CreateGroup(ctx)
 
-	var response models.Group
+	var response dto.GroupResponse
 	functions.GetRespBody(w, &response)
 
 	assert.Equal(t, ctx.Writer.Status(), http.StatusCreated)
-	assert.Equal(t, group.Id, response.Id)
+	assert.Equal(t, group.Id.Hex(), response.Id)
 	assert.Equal(t, group.Name, response.Name)
 	assert.Equal(t, len(group.Participants), len(response.Participants))
 }
 
-func TestCreateGroup_ServiceError(t *testing.T) {
-	_, ctx := functions.PrepareCtx("POST")
+func TestCreateGroup_ResponseHidesMatches(t *testing.T) {
+	w, ctx := functions.PrepareCtx("POST")
 	group := models.CreateMockGroup()
-	functions.SetReqBody(ctx, group)
+	functions.SetReqBody(ctx, createGroupRequest(group))
 
 	mockCtrl, mockServices := setupTest(t)
 	defer mockCtrl.Finish()
 
-	mockServices.EXPECT().CreateGroup(group).Return(nil, internalErrorExample())
+	mockServices.EXPECT().CreateGroup(gomock.Any(), gomock.Any()).Return(group, nil)
+
+	handler := NewGroupHandler(mockServices)
+	handler.CreateGroup(ctx)
+
+	var response map[string]interface{}
+	functions.GetRespBody(w, &response)
+
+	assert.NotContains(t, response, "matches")
+	assert.Contains(t, response, "updatedAt")
+}
+
+func TestCreateGroup_ServiceError(t *testing.T) {
+	w, ctx := functions.PrepareCtx("POST")
+	request := createGroupRequest(models.CreateMockGroup())
+	functions.SetReqBody(ctx, request)
+
+	mockCtrl, mockServices := setupTest(t)
+	defer mockCtrl.Finish()
+
+	mockServices.EXPECT().CreateGroup(gomock.Any(), request.ToModel()).Return(nil, internalErrorExample())
 
 	handler := NewGroupHandler(mockServices)
 	handler.CreateGroup(ctx)
 
 	assert.Equal(t, ctx.Writer.Status(), http.StatusInternalServerError)
+	var response customError.Problem
+	functions.GetRespBody(w, &response)
+	assert.Equal(t, customError.InternalError, response.Code)
+	assert.Equal(t, "Something went wrong on our side, please try again later.", response.Detail)
 }
 
 func TestCreateGroup_EmptyNameError(t *testing.T) {
 	_, ctx := functions.PrepareCtx("POST")
-	invalidGroup := models.CreateMockGroup()
+	invalidGroup := createGroupRequest(models.CreateMockGroup())
 	invalidGroup.Name = ""
 	functions.SetReqBody(ctx, invalidGroup)
 
@@ -85,43 +127,184 @@ func TestGetGroup_Success(t *testing.T) {
 	mockCtrl, mockServices := setupTest(t)
 	defer mockCtrl.Finish()
 
-	mockServices.EXPECT().GetGroupByID("1").Return(expectedGroup, nil)
+	mockServices.EXPECT().GetGroupByID(gomock.Any(), "1").Return(expectedGroup, nil)
 
 	handler := NewGroupHandler(mockServices)
 	handler.GetGroup(ctx)
 
 	assert.Equal(t, ctx.Writer.Status(), http.StatusOK)
-	var response models.Group
+	assert.Equal(t, `"1"`, w.Header().Get("ETag"))
+	var response dto.GroupResponse
 	functions.GetRespBody(w, &response)
-	assert.Equal(t, expectedGroup.Id, response.Id)
+	assert.Equal(t, expectedGroup.Id.Hex(), response.Id)
 	assert.Equal(t, expectedGroup.Name, response.Name)
 	assert.Equal(t, len(expectedGroup.Participants), len(response.Participants))
 }
 
+func TestGetGroup_NotModified(t *testing.T) {
+	w, ctx := functions.PrepareCtx("GET")
+	ctx.Params = []gin.Param{{Key: "id", Value: "1"}}
+	ctx.Request.Header.Set("If-None-Match", `"0", "1"`)
+
+	expectedGroup := models.CreateMockGroup()
+	mockCtrl, mockServices := setupTest(t)
+	defer mockCtrl.Finish()
+
+	mockServices.EXPECT().GetGroupByID(gomock.Any(), "1").Return(expectedGroup, nil)
+
+	handler := NewGroupHandler(mockServices)
+	handler.GetGroup(ctx)
+
+	assert.Equal(t, ctx.Writer.Status(), http.StatusNotModified)
+	assert.Equal(t, `"1"`, w.Header().Get("ETag"))
+	assert.Empty(t, w.Body.String())
+}
+
 func TestGetGroup_NotFound(t *testing.T) {
-	_, ctx := functions.PrepareCtx("GET")
+	w, ctx := functions.PrepareCtx("GET")
+	ctx.Request.URL.Path = "/secret-santa/group/999"
 	ctx.Params = []gin.Param{{Key: "id", Value: "999"}}
 
-	err := customError.NewCustomError(customError.WithNotFound("Group not found", "Not found"))
+	err := customError.NewCustomError(customError.WithNotFound("Group not found", "Not found"), customError.WithCode(customError.GroupNotFound))
 	mockCtrl, mockServices := setupTest(t)
 	defer mockCtrl.Finish()
 
-	mockServices.EXPECT().GetGroupByID("999").Return(nil, err)
+	mockServices.EXPECT().GetGroupByID(gomock.Any(), "999").Return(nil, err)
 
 	handler := NewGroupHandler(mockServices)
 	handler.GetGroup(ctx)
 
 	assert.Equal(t, ctx.Writer.Status(), http.StatusNotFound)
+	var response customError.Problem
+	functions.GetRespBody(w, &response)
+	assert.Equal(t, customError.Problem{
+		Type:     "urn:secret-santa:problem:GROUP_NOT_FOUND",
+		Title:    "Group not found",
+		Status:   http.StatusNotFound,
+		Detail:   "No group found with the given ID.",
+		Instance: "/secret-santa/group/999",
+		Code:     customError.GroupNotFound,
+	}, response)
+}
+
+func TestGetGroup_NotFoundLocalized(t *testing.T) {
+	for acceptLanguage, title := range map[string]string{
+		"pt-BR,pt;q=0.9": "Grupo não encontrado",
+		"es-MX":          "Grupo no encontrado",
+		"fr-FR":          "Group not found",
+	} {
+		w, ctx := functions.PrepareCtx("GET")
+		ctx.Params = []gin.Param{{Key: "id", Value: "999"}}
+		ctx.Request.Header.Set("Accept-Language", acceptLanguage)
+
+		err := customError.NewCustomError(customError.WithNotFound("Group not found", "Not found"), customError.WithCode(customError.GroupNotFound))
+		mockCtrl, mockServices := setupTest(t)
+		mockServices.EXPECT().GetGroupByID(gomock.Any(), "999").Return(nil, err)
+
+		handler := NewGroupHandler(mockServices)
+		handler.GetGroup(ctx)
+		mockCtrl.Finish()
+
+		var response customError.Problem
+		functions.GetRespBody(w, &response)
+		assert.Equal(t, title, response.Title, acceptLanguage)
+		assert.Equal(t, customError.GroupNotFound, response.Code, acceptLanguage)
+	}
+}
+
+func TestGetGroup_LegacyErrorShape(t *testing.T) {
+	w, ctx := functions.PrepareCtx("GET")
+	ctx.Params = []gin.Param{{Key: "id", Value: "999"}}
+	ctx.Request.Header.Set("Accept", customError.LegacyContentType)
+
+	err := customError.NewCustomError(customError.WithNotFound("Group not found", "Not found"), customError.WithCode(customError.GroupNotFound))
+	mockCtrl, mockServices := setupTest(t)
+	defer mockCtrl.Finish()
+
+	mockServices.EXPECT().GetGroupByID(gomock.Any(), "999").Return(nil, err)
+
+	handler := NewGroupHandler(mockServices)
+	handler.GetGroup(ctx)
+
+	assert.Equal(t, ctx.Writer.Status(), http.StatusNotFound)
+	var response map[string]interface{}
+	functions.GetRespBody(w, &response)
+	assert.Equal(t, map[string]interface{}{
+		"message": "Not found",
+		"causes":  "Group not found",
+		"status":  float64(http.StatusNotFound),
+		"code":    "Not Found",
+	}, response)
+}
+
+func TestMatchParticipants_ErrorInGroupLocale(t *testing.T) {
+	for _, tc := range []struct {
+		acceptLanguage string
+		noted          string
+		title          string
+	}{
+		{noted: "pt-BR", title: "O grupo foi alterado por outra pessoa"},
+		{acceptLanguage: "es-MX", noted: "pt-BR", title: "Otra persona modificó el grupo"},
+		{title: "The group was modified by someone else"},
+	} {
+		w, ctx := functions.PrepareCtx("POST")
+		ctx.Params = []gin.Param{{Key: "id", Value: "1"}}
+		ctx.Request = ctx.Request.WithContext(i18n.WithLocaleSlot(ctx.Request.Context()))
+		ctx.Request.Header.Set("If-Match", `"2"`)
+		ctx.Request.Header.Set("Accept-Language", tc.acceptLanguage)
+
+		err := customError.NewCustomError(customError.WithPreconditionFailed("Group version mismatch", "Group was changed"), customError.WithCode(customError.GroupVersionMismatch))
+		mockCtrl, mockServices := setupTest(t)
+		mockServices.EXPECT().MatchParticipants(gomock.Any(), "1", int64(2)).DoAndReturn(
+			func(ctx context.Context, id string, version int64) (*models.Group, *customError.CustomError) {
+				if tc.noted != "" {
+					i18n.NoteLocale(ctx, tc.noted)
+				}
+				return nil, err
+			})
+
+		handler := NewGroupHandler(mockServices)
+		handler.MatchParticipants(ctx)
+		mockCtrl.Finish()
+
+		var response customError.Problem
+		functions.GetRespBody(w, &response)
+		assert.Equal(t, tc.title, response.Title, tc.acceptLanguage)
+	}
+}
+
+func TestGetGroup_LegacyErrorShapeLocalized(t *testing.T) {
+	w, ctx := functions.PrepareCtx("GET")
+	ctx.Params = []gin.Param{{Key: "id", Value: "999"}}
+	ctx.Request.Header.Set("Accept", customError.LegacyContentType)
+	ctx.Request.Header.Set("Accept-Language", "pt-BR")
+
+	err := customError.NewCustomError(customError.WithNotFound("Group not found", "Not found"), customError.WithCode(customError.GroupNotFound))
+	mockCtrl, mockServices := setupTest(t)
+	defer mockCtrl.Finish()
+
+	mockServices.EXPECT().GetGroupByID(gomock.Any(), "999").Return(nil, err)
+
+	handler := NewGroupHandler(mockServices)
+	handler.GetGroup(ctx)
+
+	assert.Equal(t, "pt-BR", w.Header().Get("Content-Language"))
+	var response customError.CustomError
+	functions.GetRespBody(w, &response)
+	detail, _ := customError.GroupNotFound.Detail(i18n.Portuguese)
+	assert.Equal(t, detail, response.Message)
+	assert.Equal(t, "Group not found", response.Causes)
 }
 
 func TestDeleteGroup_Success(t *testing.T) {
 	_, ctx := functions.PrepareCtx("DELETE")
 	ctx.Params = []gin.Param{{Key: "id", Value: "1"}}
+	ctx.Request.Header.Set("If-Match", `"3"`)
 
 	mockCtrl, mockServices := setupTest(t)
 	defer mockCtrl.Finish()
 
-	mockServices.EXPECT().DeleteGroup("1").Return(nil)
+	mockServices.EXPECT().DeleteGroup(gomock.Any(), "1", int64(3)).Return(nil)
 
 	handler := NewGroupHandler(mockServices)
 	handler.DeleteGroup(ctx)
@@ -132,19 +315,160 @@ func TestDeleteGroup_Success(t *testing.T) {
 func TestDeleteGroup_NotFound(t *testing.T) {
 	_, ctx := functions.PrepareCtx("DELETE")
 	ctx.Params = []gin.Param{{Key: "id", Value: "999"}}
+	ctx.Request.Header.Set("If-Match", "*")
+
+	err := customError.NewCustomError(customError.WithNotFound("Group not found", "Not Found"), customError.WithCode(customError.GroupNotFound))
+	mockCtrl, mockServices := setupTest(t)
+	defer mockCtrl.Finish()
+
+	mockServices.EXPECT().DeleteGroup(gomock.Any(), "999", models.AnyVersion).Return(err)
+
+	handler := NewGroupHandler(mockServices)
+	handler.DeleteGroup(ctx)
+
+	assert.Equal(t, ctx.Writer.Status(), http.StatusNotFound)
+}
+
+func TestDeleteGroup_MissingIfMatch(t *testing.T) {
+	_, ctx := functions.PrepareCtx("DELETE")
+	ctx.Params = []gin.Param{{Key: "id", Value: "1"}}
+
+	mockCtrl, mockServices := setupTest(t)
+	defer mockCtrl.Finish()
+
+	handler := NewGroupHandler(mockServices)
+	handler.DeleteGroup(ctx)
 
-	err := customError.NewCustomError(customError.WithNotFound("Group not found", "Not Found"))
+	assert.Equal(t, ctx.Writer.Status(), http.StatusPreconditionRequired)
+}
+
+func TestDeleteGroup_VersionMismatch(t *testing.T) {
+	_, ctx := functions.PrepareCtx("DELETE")
+	ctx.Params = []gin.Param{{Key: "id", Value: "1"}}
+	ctx.Request.Header.Set("If-Match", `W/"2"`)
+
+	err := customError.NewCustomError(customError.WithPreconditionFailed("Group version mismatch", "Modified"))
 	mockCtrl, mockServices := setupTest(t)
 	defer mockCtrl.Finish()
 
-	mockServices.EXPECT().DeleteGroup("999").Return(err)
+	mockServices.EXPECT().DeleteGroup(gomock.Any(), "1", int64(2)).Return(err)
 
 	handler := NewGroupHandler(mockServices)
 	handler.DeleteGroup(ctx)
 
+	assert.Equal(t, ctx.Writer.Status(), http.StatusPreconditionFailed)
+}
+
+func TestGetTrash_Success(t *testing.T) {
+	w, ctx := functions.PrepareCtx("GET")
+	ctx.Request.URL.RawQuery = "owner=ana@example.com"
+
+	mockCtrl, mockServices := setupTest(t)
+	defer mockCtrl.Finish()
+
+	deleted := models.CreateMockGroup()
+	deleted.DeletedAt = time.Date(2024, 12, 1, 10, 0, 0, 0, time.UTC)
+	mockServices.EXPECT().GetTrash(gomock.Any(), "ana@example.com").Return([]*models.Group{deleted}, nil)
+
+	handler := NewGroupHandler(mockServices)
+	handler.GetTrash(ctx)
+
+	assert.Equal(t, ctx.Writer.Status(), http.StatusOK)
+	var response []dto.TrashedGroupResponse
+	functions.GetRespBody(w, &response)
+	if assert.Len(t, response, 1) {
+		assert.Equal(t, deleted.Id.Hex(), response[0].Id)
+		assert.True(t, deleted.DeletedAt.Equal(response[0].DeletedAt))
+		assert.True(t, deleted.DeletedAt.Add(config.Cfg.TrashRetention).Equal(response[0].PurgeAt))
+	}
+}
+
+func TestRestoreGroup_Success(t *testing.T) {
+	w, ctx := functions.PrepareCtx("POST")
+	ctx.Params = []gin.Param{{Key: "id", Value: "1"}}
+	ctx.Request.Header.Set("If-Match", `"4"`)
+
+	mockCtrl, mockServices := setupTest(t)
+	defer mockCtrl.Finish()
+
+	restored := models.CreateMockGroup()
+	restored.Version = 5
+	mockServices.EXPECT().RestoreGroup(gomock.Any(), "1", int64(4)).Return(restored, nil)
+
+	handler := NewGroupHandler(mockServices)
+	handler.RestoreGroup(ctx)
+
+	assert.Equal(t, ctx.Writer.Status(), http.StatusOK)
+	assert.Equal(t, `"5"`, w.Header().Get("ETag"))
+	var response dto.GroupResponse
+	functions.GetRespBody(w, &response)
+	assert.Equal(t, restored.Name, response.Name)
+}
+
+func TestRestoreGroup_MissingIfMatch(t *testing.T) {
+	_, ctx := functions.PrepareCtx("POST")
+	ctx.Params = []gin.Param{{Key: "id", Value: "1"}}
+
+	mockCtrl, mockServices := setupTest(t)
+	defer mockCtrl.Finish()
+
+	handler := NewGroupHandler(mockServices)
+	handler.RestoreGroup(ctx)
+
+	assert.Equal(t, ctx.Writer.Status(), http.StatusPreconditionRequired)
+}
+
+func TestRestoreGroup_NotInTrash(t *testing.T) {
+	_, ctx := functions.PrepareCtx("POST")
+	ctx.Params = []gin.Param{{Key: "id", Value: "1"}}
+	ctx.Request.Header.Set("If-Match", "*")
+
+	err := customError.NewCustomError(customError.WithNotFound("Group not found", "Not Found"), customError.WithCode(customError.GroupNotFound))
+	mockCtrl, mockServices := setupTest(t)
+	defer mockCtrl.Finish()
+
+	mockServices.EXPECT().RestoreGroup(gomock.Any(), "1", models.AnyVersion).Return(nil, err)
+
+	handler := NewGroupHandler(mockServices)
+	handler.RestoreGroup(ctx)
+
 	assert.Equal(t, ctx.Writer.Status(), http.StatusNotFound)
 }
 
+func TestUpdateGroup_MalformedIfMatch(t *testing.T) {
+	_, ctx := functions.PrepareCtx("PUT")
+	ctx.Params = []gin.Param{{Key: "id", Value: "1"}}
+	ctx.Request.Header.Set("If-Match", "2")
+	functions.SetReqBody(ctx, dto.ReplaceGroupRequest{Name: "New name"})
+
+	mockCtrl, mockServices := setupTest(t)
+	defer mockCtrl.Finish()
+
+	handler := NewGroupHandler(mockServices)
+	handler.UpdateGroup(ctx)
+
+	assert.Equal(t, ctx.Writer.Status(), http.StatusBadRequest)
+}
+
+func TestMatchParticipants_SetsETag(t *testing.T) {
+	w, ctx := functions.PrepareCtx("POST")
+	ctx.Params = []gin.Param{{Key: "id", Value: "1"}}
+	ctx.Request.Header.Set("If-Match", `"1"`)
+
+	group := models.CreateMockGroup()
+	group.Version = 2
+	mockCtrl, mockServices := setupTest(t)
+	defer mockCtrl.Finish()
+
+	mockServices.EXPECT().MatchParticipants(gomock.Any(), "1", int64(1)).Return(group, nil)
+
+	handler := NewGroupHandler(mockServices)
+	handler.MatchParticipants(ctx)
+
+	assert.Equal(t, ctx.Writer.Status(), http.StatusOK)
+	assert.Equal(t, `"2"`, w.Header().Get("ETag"))
+}
+
 func TestGetMyMatch_EmptyUsername(t *testing.T) {
 	_, ctx := functions.PrepareCtx("GET")
 	ctx.Params = []gin.Param{{Key: "id", Value: "1"}}
@@ -171,3 +495,370 @@ func TestGetMyMatch_EmptyGroup(t *testing.T) {
 
 	assert.Equal(t, ctx.Writer.Status(), http.StatusBadRequest)
 }
+
+func TestGetAllGroups_Success(t *testing.T) {
+	w, ctx := functions.PrepareCtx("GET")
+	ctx.Request.URL.RawQuery = "limit=5&sort=name&order=asc&status=open"
+
+	mockCtrl, mockServices := setupTest(t)
+	defer mockCtrl.Finish()
+
+	expectedQuery := models.GroupQuery{Limit: 5, Sort: models.SortByName, Order: models.SortAsc, Status: models.GroupStatusOpen}
+	expectedPage := &models.GroupPage{
+		Items:  []*models.Group{models.CreateMockGroup()},
+		Paging: models.Paging{Limit: 5, Sort: models.SortByName, Order: models.SortAsc},
+	}
+	mockServices.EXPECT().GetAllGroups(gomock.Any(), expectedQuery).Return(expectedPage, nil)
+
+	handler := NewGroupHandler(mockServices)
+	handler.GetAllGroups(ctx)
+
+	assert.Equal(t, ctx.Writer.Status(), http.StatusOK)
+	assert.NotContains(t, w.Body.String(), `"participants"`)
+	var response models.GroupPage
+	functions.GetRespBody(w, &response)
+	assert.Len(t, response.Items, 1)
+	assert.Equal(t, 1, response.Items[0].ParticipantCount)
+	assert.Equal(t, expectedPage.Paging, response.Paging)
+}
+
+func TestGetAllGroups_InvalidQuery(t *testing.T) {
+	for _, rawQuery := range []string{"limit=-1", "limit=101", "limit=abc", "sort=email", "order=up", "status=closed"} {
+		_, ctx := functions.PrepareCtx("GET")
+		ctx.Request.URL.RawQuery = rawQuery
+
+		mockCtrl, mockServices := setupTest(t)
+
+		handler := NewGroupHandler(mockServices)
+		handler.GetAllGroups(ctx)
+
+		assert.Equal(t, ctx.Writer.Status(), http.StatusBadRequest, rawQuery)
+		mockCtrl.Finish()
+	}
+}
+
+func TestSearchGroups_Success(t *testing.T) {
+	w, ctx := functions.PrepareCtx("GET")
+	ctx.Request.URL.RawQuery = "q=+Joao+"
+
+	mockCtrl, mockServices := setupTest(t)
+	defer mockCtrl.Finish()
+
+	expected := []*models.Group{models.CreateMockGroup()}
+	mockServices.EXPECT().SearchGroups(gomock.Any(), models.SearchQuery{Q: "Joao", Limit: models.DefaultPageLimit}).Return(expected, nil)
+
+	handler := NewGroupHandler(mockServices)
+	handler.SearchGroups(ctx)
+
+	assert.Equal(t, ctx.Writer.Status(), http.StatusOK)
+	var response []dto.GroupSummaryResponse
+	functions.GetRespBody(w, &response)
+	assert.Len(t, response, 1)
+	assert.Equal(t, 1, response[0].ParticipantCount)
+}
+
+func TestSearchGroups_EmptyTerm(t *testing.T) {
+	_, ctx := functions.PrepareCtx("GET")
+	ctx.Request.URL.RawQuery = "q=+"
+
+	mockCtrl, mockServices := setupTest(t)
+	defer mockCtrl.Finish()
+
+	handler := NewGroupHandler(mockServices)
+	handler.SearchGroups(ctx)
+
+	assert.Equal(t, ctx.Writer.Status(), http.StatusBadRequest)
+}
+
+func TestSearchParticipants_NotFound(t *testing.T) {
+	_, ctx := functions.PrepareCtx("GET")
+	ctx.Params = []gin.Param{{Key: "id", Value: "999"}}
+	ctx.Request.URL.RawQuery = "q=joao"
+
+	mockCtrl, mockServices := setupTest(t)
+	defer mockCtrl.Finish()
+
+	err := customError.NewCustomError(customError.WithNotFound("Group not found", "Not found"), customError.WithCode(customError.GroupNotFound))
+	mockServices.EXPECT().SearchParticipants(gomock.Any(), "999", models.SearchQuery{Q: "joao", Limit: models.DefaultPageLimit}).Return(nil, err)
+
+	handler := NewGroupHandler(mockServices)
+	handler.SearchParticipants(ctx)
+
+	assert.Equal(t, ctx.Writer.Status(), http.StatusNotFound)
+}
+
+func TestUpdateGroup_Success(t *testing.T) {
+	w, ctx := functions.PrepareCtx("PUT")
+	ctx.Params = []gin.Param{{Key: "id", Value: "1"}}
+	ctx.Request.Header.Set("If-Match", `"1"`)
+	request := dto.ReplaceGroupRequest{Name: "New name", Owner: "mari@gmail.com", Participants: []dto.ParticipantRequest{{Name: "Mari", Email: "mari@gmail.com"}}}
+	functions.SetReqBody(ctx, request)
+
+	group := models.CreateMockGroup()
+	group.Version = 2
+	mockCtrl, mockServices := setupTest(t)
+	defer mockCtrl.Finish()
+
+	mockServices.EXPECT().UpdateGroup(gomock.Any(), "1", int64(1), request.ToModel()).Return(group, nil)
+
+	handler := NewGroupHandler(mockServices)
+	handler.UpdateGroup(ctx)
+
+	assert.Equal(t, ctx.Writer.Status(), http.StatusOK)
+	assert.Equal(t, `"2"`, w.Header().Get("ETag"))
+}
+
+func TestUpdateGroup_RejectsImmutableAndMissingFields(t *testing.T) {
+	w, ctx := functions.PrepareCtx("PUT")
+	ctx.Params = []gin.Param{{Key: "id", Value: "1"}}
+	ctx.Request.Header.Set("If-Match", "*")
+	functions.SetReqBody(ctx, map[string]interface{}{
+		"name":    "New name",
+		"matches": []models.Match{{First: "a", Second: "b"}},
+	})
+
+	mockCtrl, mockServices := setupTest(t)
+	defer mockCtrl.Finish()
+
+	handler := NewGroupHandler(mockServices)
+	handler.UpdateGroup(ctx)
+
+	assert.Equal(t, ctx.Writer.Status(), http.StatusBadRequest)
+	assert.Equal(t, customError.ProblemContentType, w.Header().Get("Content-Type"))
+	var response customError.Problem
+	functions.GetRespBody(w, &response)
+	assert.Equal(t, customError.ValidationFailed, response.Code)
+	assert.Equal(t, "urn:secret-santa:problem:VALIDATION_FAILED", response.Type)
+	assert.Equal(t, []customError.FieldError{
+		{Field: "matches", Code: customError.FieldImmutable, Detail: "is immutable"},
+		{Field: "owner", Code: customError.FieldRequired, Detail: "is required for a full replacement"},
+		{Field: "participants", Code: customError.FieldRequired, Detail: "is required for a full replacement"},
+	}, response.Errors)
+}
+
+func TestPatchGroup_Success(t *testing.T) {
+	_, ctx := functions.PrepareCtx("PATCH")
+	ctx.Params = []gin.Param{{Key: "id", Value: "1"}}
+	ctx.Request.Header.Set("If-Match", `"1"`)
+	functions.SetReqBody(ctx, map[string]interface{}{"name": "New name", "owner": nil})
+	ctx.Request.Header.Set("Content-Type", "application/merge-patch+json")
+
+	mockCtrl, mockServices := setupTest(t)
+	defer mockCtrl.Finish()
+
+	group := models.CreateMockGroup()
+	mockServices.EXPECT().PatchGroup(gomock.Any(), "1", int64(1), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, id string, version int64, patch groupService.GroupPatch) (*models.Group, *customError.CustomError) {
+			update, err := patch(models.NewGroupUpdate(group))
+			assert.Nil(t, err)
+			assert.Equal(t, "New name", update.Name)
+			assert.Equal(t, "", update.Owner)
+			assert.Equal(t, group.Participants, update.Participants)
+			return group, nil
+		})
+
+	handler := NewGroupHandler(mockServices)
+	handler.PatchGroup(ctx)
+
+	assert.Equal(t, ctx.Writer.Status(), http.StatusOK)
+}
+
+func TestPatchGroup_RejectsImmutableFields(t *testing.T) {
+	w, ctx := functions.PrepareCtx("PATCH")
+	ctx.Params = []gin.Param{{Key: "id", Value: "1"}}
+	ctx.Request.Header.Set("If-Match", "*")
+	functions.SetReqBody(ctx, map[string]interface{}{"id": "abc", "createdAt": nil, "color": "red"})
+	ctx.Request.Header.Set("Accept", customError.LegacyContentType)
+
+	mockCtrl, mockServices := setupTest(t)
+	defer mockCtrl.Finish()
+
+	handler := NewGroupHandler(mockServices)
+	handler.PatchGroup(ctx)
+
+	assert.Equal(t, ctx.Writer.Status(), http.StatusBadRequest)
+	assert.Equal(t, customError.LegacyContentType, w.Header().Get("Content-Type"))
+	var response customError.CustomError
+	functions.GetRespBody(w, &response)
+	assert.Equal(t, map[string]string{
+		"id":        "is immutable",
+		"createdAt": "is immutable",
+		"color":     "is not a group field",
+	}, response.Fields)
+}
+
+func TestPatchGroup_UnsupportedMediaType(t *testing.T) {
+	_, ctx := functions.PrepareCtx("PATCH")
+	ctx.Params = []gin.Param{{Key: "id", Value: "1"}}
+	ctx.Request.Header.Set("If-Match", "*")
+	functions.SetReqBody(ctx, map[string]interface{}{"name": "New name"})
+	ctx.Request.Header.Set("Content-Type", "application/json-patch+json")
+
+	mockCtrl, mockServices := setupTest(t)
+	defer mockCtrl.Finish()
+
+	handler := NewGroupHandler(mockServices)
+	handler.PatchGroup(ctx)
+
+	assert.Equal(t, ctx.Writer.Status(), http.StatusUnsupportedMediaType)
+}
+
+func TestRequireAdmin(t *testing.T) {
+	config.LoadConfig()
+	defer func(token string) { config.Cfg.AdminToken = token }(config.Cfg.AdminToken)
+
+	for name, tc := range map[string]struct {
+		configured string
+		header     string
+		status     int
+	}{
+		"valid token":         {configured: "s3cret", header: "Bearer s3cret", status: http.StatusOK},
+		"wrong token":         {configured: "s3cret", header: "Bearer guess", status: http.StatusUnauthorized},
+		"missing header":      {configured: "s3cret", header: "", status: http.StatusUnauthorized},
+		"not configured":      {configured: "", header: "Bearer ", status: http.StatusUnauthorized},
+		"not a bearer scheme": {configured: "s3cret", header: "s3cret", status: http.StatusUnauthorized},
+	} {
+		t.Run(name, func(t *testing.T) {
+			config.Cfg.AdminToken = tc.configured
+			_, ctx := functions.PrepareCtx("GET")
+			ctx.Request.Header.Set("Authorization", tc.header)
+
+			RequireAdmin(ctx)
+
+			assert.Equal(t, tc.status, ctx.Writer.Status())
+			assert.Equal(t, tc.status != http.StatusOK, ctx.IsAborted())
+		})
+	}
+}
+
+func TestExportSubject_Success(t *testing.T) {
+	w, ctx := functions.PrepareCtx("GET")
+	ctx.Request.URL.RawQuery = "email=ana@example.com"
+
+	mockCtrl, mockServices := setupTest(t)
+	defer mockCtrl.Finish()
+
+	owned := models.CreateMockGroup()
+	export := &models.SubjectExport{Email: "ana@example.com", ExportedAt: time.Now(), Groups: []models.SubjectGroup{{
+		Group:        owned,
+		Owner:        true,
+		Participants: []models.Participant{{Name: "Ana", Email: "ana@example.com"}},
+		Matches:      []models.Match{{First: "Ana", Second: "Bia"}},
+	}}, Audit: []models.AuditEvent{{Id: primitive.NewObjectID(), GroupId: owned.Id, Action: models.AuditCreated, Actor: "ana@example.com"}}}
+	mockServices.EXPECT().ExportSubject(gomock.Any(), "ana@example.com").Return(export, nil)
+
+	handler := NewGroupHandler(mockServices)
+	handler.ExportSubject(ctx)
+
+	assert.Equal(t, http.StatusOK, ctx.Writer.Status())
+	var response dto.SubjectExportResponse
+	functions.GetRespBody(w, &response)
+	assert.Equal(t, "ana@example.com", response.Email)
+	if assert.Len(t, response.Groups, 1) {
+		assert.Equal(t, owned.Id.Hex(), response.Groups[0].Id)
+		assert.True(t, response.Groups[0].IsOwner)
+		assert.Equal(t, []dto.SubjectMatchResponse{{Giver: "Ana", Receiver: "Bia"}}, response.Groups[0].Matches)
+	}
+	if assert.Len(t, response.Audit, 1) {
+		assert.Equal(t, owned.Id.Hex(), response.Audit[0].GroupId)
+		assert.Equal(t, export.Audit[0].Id.Hex(), response.Audit[0].Id)
+		assert.Equal(t, "ana@example.com", response.Audit[0].Actor)
+	}
+}
+
+func TestExportSubject_MissingEmail(t *testing.T) {
+	_, ctx := functions.PrepareCtx("GET")
+
+	mockCtrl, mockServices := setupTest(t)
+	defer mockCtrl.Finish()
+
+	handler := NewGroupHandler(mockServices)
+	handler.ExportSubject(ctx)
+
+	assert.Equal(t, http.StatusBadRequest, ctx.Writer.Status())
+}
+
+func TestEraseSubject_Success(t *testing.T) {
+	w, ctx := functions.PrepareCtx("DELETE")
+	ctx.Request.URL.RawQuery = "email=ana@example.com"
+
+	mockCtrl, mockServices := setupTest(t)
+	defer mockCtrl.Finish()
+
+	certificate := &models.ErasureCertificate{Id: primitive.NewObjectID(), SubjectHash: groupService.SubjectHash([]byte("index-key"), "ana@example.com"), Groups: []string{"g1"}, ParticipantsRemoved: 1, DrawsRepaired: 1}
+	mockServices.EXPECT().EraseSubject(gomock.Any(), "ana@example.com").Return(certificate, nil)
+
+	handler := NewGroupHandler(mockServices)
+	handler.EraseSubject(ctx)
+
+	assert.Equal(t, http.StatusOK, ctx.Writer.Status())
+	assert.NotContains(t, w.Body.String(), "ana@example.com")
+	var response dto.ErasureCertificateResponse
+	functions.GetRespBody(w, &response)
+	assert.Equal(t, certificate.Id.Hex(), response.Id)
+	assert.Equal(t, certificate.SubjectHash, response.SubjectHash)
+}
+
+func TestGetAudit_Success(t *testing.T) {
+	w, ctx := functions.PrepareCtx("GET")
+	id := primitive.NewObjectID()
+	ctx.Params = []gin.Param{{Key: "id", Value: id.Hex()}}
+	ctx.Request.URL.RawQuery = "action=participant-removed&since=2024-12-01T00:00:00Z"
+	ctx.Request = ctx.Request.WithContext(identity.WithUser(context.Background(), "ana@example.com"))
+
+	mockCtrl, mockServices := setupTest(t)
+	defer mockCtrl.Finish()
+
+	since := time.Date(2024, 12, 1, 0, 0, 0, 0, time.UTC)
+	event := models.AuditEvent{
+		Id: primitive.NewObjectID(), GroupId: id, Action: models.AuditParticipantRemoved, Actor: "ana@example.com", At: since.Add(time.Hour),
+		Detail: "Bia", Changes: []models.AuditChange{{Field: "participants", Before: `"Bia"`}},
+	}
+	query := models.AuditQuery{Action: models.AuditParticipantRemoved, Since: since, Limit: models.DefaultAuditLimit}
+	mockServices.EXPECT().GetAudit(gomock.Any(), id.Hex(), "ana@example.com", query).Return([]models.AuditEvent{event}, nil)
+
+	handler := NewGroupHandler(mockServices)
+	handler.GetAudit(ctx)
+
+	assert.Equal(t, http.StatusOK, ctx.Writer.Status())
+	assert.Contains(t, w.Body.String(), `"changes":[{"field":"participants","before":"Bia"}]`)
+	var response []dto.AuditEventResponse
+	functions.GetRespBody(w, &response)
+	if assert.Len(t, response, 1) {
+		assert.Equal(t, event.Id.Hex(), response[0].Id)
+		assert.Equal(t, "Bia", response[0].Detail)
+	}
+}
+
+func TestGetAudit_InvalidFilters(t *testing.T) {
+	for _, rawQuery := range []string{"action=viewed", "limit=5000", "since=yesterday"} {
+		_, ctx := functions.PrepareCtx("GET")
+		ctx.Params = []gin.Param{{Key: "id", Value: primitive.NewObjectID().Hex()}}
+		ctx.Request.URL.RawQuery = rawQuery
+
+		mockCtrl, mockServices := setupTest(t)
+
+		handler := NewGroupHandler(mockServices)
+		handler.GetAudit(ctx)
+
+		assert.Equal(t, http.StatusBadRequest, ctx.Writer.Status(), rawQuery)
+		mockCtrl.Finish()
+	}
+}
+
+func TestGetAudit_NotTheOrganizer(t *testing.T) {
+	_, ctx := functions.PrepareCtx("GET")
+	id := primitive.NewObjectID().Hex()
+	ctx.Params = []gin.Param{{Key: "id", Value: id}}
+
+	mockCtrl, mockServices := setupTest(t)
+	defer mockCtrl.Finish()
+
+	forbidden := customError.NewCustomError(customError.WithForbidden("Not the organizer of the group", "Only the organizer of the group can read its audit log"), customError.WithCode(customError.Forbidden))
+	mockServices.EXPECT().GetAudit(gomock.Any(), id, "", gomock.Any()).Return(nil, forbidden)
+
+	handler := NewGroupHandler(mockServices)
+	handler.GetAudit(ctx)
+
+	assert.Equal(t, http.StatusForbidden, ctx.Writer.Status())
+}
@@ -0,0 +1,51 @@
+package group
+
+import (
+	"strings"
+
+	"service-secret-santa/config"
+	"service-secret-santa/customError"
+	"service-secret-santa/i18n"
+
+	"github.com/gin-gonic/gin"
+)
+
+// groupLocaleKey holds, in the gin context, the locale of the group a request
+// is about once it is known.
+const groupLocaleKey = "groupLocale"
+
+// respondError writes err as application/problem+json in the locale of the
+// request, or in the legacy message/causes/status shape when the client asks
+// for it in Accept. The error is also attached to the context for the access
+// log.
+func respondError(c *gin.Context, err *customError.CustomError) {
+	_ = c.Error(err)
+
+	locale := requestLocale(c)
+	c.Header("Content-Language", string(locale))
+
+	if strings.Contains(c.GetHeader("Accept"), customError.LegacyContentType) {
+		c.Header("Content-Type", customError.LegacyContentType)
+		c.JSON(err.Status, err.Localized(locale))
+		return
+	}
+
+	c.Header("Content-Type", customError.ProblemContentType)
+	c.JSON(err.Status, customError.NewProblem(err, c.Request.URL.Path, locale))
+}
+
+// GroupLocale gives the request room for the locale of the group it is
+// about, which the service notes when it reads the group.
+func GroupLocale() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Request = c.Request.WithContext(i18n.WithLocaleSlot(c.Request.Context()))
+		c.Next()
+	}
+}
+
+// requestLocale resolves the locale from Accept-Language, falling back to the
+// locale of the group when the request already read it and then to the
+// configured default. The group is never read just to pick the locale.
+func requestLocale(c *gin.Context) i18n.Locale {
+	return i18n.Resolve(c.GetHeader("Accept-Language"), c.GetString(groupLocaleKey), i18n.NotedLocale(c.Request.Context()), config.Cfg.DefaultLocale)
+}
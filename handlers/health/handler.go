@@ -0,0 +1,59 @@
+package health
+
+import (
+	"net/http"
+	"time"
+
+	"service-secret-santa/resources/health"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Handler interface {
+	Liveness(c *gin.Context)
+	Readiness(c *gin.Context)
+}
+
+// checkTimeout bounds each readiness check, well below the probe timeouts
+// orchestrators use by default.
+const checkTimeout = 2 * time.Second
+
+type resource struct {
+	checks []health.Check
+}
+
+// Liveness godoc
+//
+// @Summary 	Liveness probe
+// @Description Answers while the process is able to serve requests, regardless of its dependencies.
+// @Tags 		health
+// @Produce  	json
+// @Success 	200 		{object} 	health.Liveness
+// @Router 		/healthz [get]
+func (r *resource) Liveness(c *gin.Context) {
+	c.JSON(http.StatusOK, health.Liveness{Status: health.StatusUp})
+}
+
+// Readiness godoc
+//
+// @Summary 	Readiness probe
+// @Description Runs every registered dependency check, such as the MongoDB ping or the background jobs, and reports the status and latency of each. Background jobs add when they last ran, whether a run is in progress and the error of the last run.
+// @Tags 		health
+// @Produce  	json
+// @Success 	200 		{object} 	health.Report
+// @Failure		503 		{object} 	health.Report
+// @Router 		/readyz [get]
+func (r *resource) Readiness(c *gin.Context) {
+	report := health.Evaluate(c.Request.Context(), r.checks, checkTimeout)
+
+	status := http.StatusOK
+	if report.Status != health.StatusReady {
+		status = http.StatusServiceUnavailable
+	}
+
+	c.JSON(status, report)
+}
+
+func NewHealthHandler(checks health.Checks) Handler {
+	return &resource{checks: checks.Readiness}
+}
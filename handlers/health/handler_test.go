@@ -0,0 +1,62 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"service-secret-santa/functions"
+	"service-secret-santa/resources/health"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func checks(errs map[string]error) health.Checks {
+	var res health.Checks
+	for name, err := range errs {
+		err := err
+		res.Readiness = append(res.Readiness, health.Check{Name: name, Run: func(ctx context.Context) error { return err }})
+	}
+	return res
+}
+
+func TestLiveness(t *testing.T) {
+	w, ctx := functions.PrepareCtx("GET")
+
+	handler := NewHealthHandler(checks(map[string]error{"mongo": errors.New("down")}))
+	handler.Liveness(ctx)
+
+	assert.Equal(t, http.StatusOK, ctx.Writer.Status())
+	var response health.Liveness
+	functions.GetRespBody(w, &response)
+	assert.Equal(t, health.StatusUp, response.Status)
+}
+
+func TestReadiness_Ready(t *testing.T) {
+	w, ctx := functions.PrepareCtx("GET")
+
+	handler := NewHealthHandler(checks(map[string]error{"mongo": nil, "lifecycle": nil}))
+	handler.Readiness(ctx)
+
+	assert.Equal(t, http.StatusOK, ctx.Writer.Status())
+	var response health.Report
+	functions.GetRespBody(w, &response)
+	assert.Equal(t, health.StatusReady, response.Status)
+	assert.Len(t, response.Checks, 2)
+}
+
+func TestReadiness_MongoDown(t *testing.T) {
+	w, ctx := functions.PrepareCtx("GET")
+
+	handler := NewHealthHandler(checks(map[string]error{"mongo": errors.New("server selection timeout"), "lifecycle": nil}))
+	handler.Readiness(ctx)
+
+	assert.Equal(t, http.StatusServiceUnavailable, ctx.Writer.Status())
+	var response health.Report
+	functions.GetRespBody(w, &response)
+	assert.Equal(t, health.StatusNotReady, response.Status)
+	assert.Equal(t, health.StatusDown, response.Checks["mongo"].Status)
+	assert.Equal(t, "server selection timeout", response.Checks["mongo"].Error)
+	assert.Equal(t, health.StatusUp, response.Checks["lifecycle"].Status)
+}
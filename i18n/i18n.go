@@ -0,0 +1,155 @@
+package i18n
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"encoding/json"
+	"log"
+	"path"
+	"strings"
+	"text/template"
+
+	"golang.org/x/text/language"
+)
+
+// Locale is a BCP 47 tag of a bundled translation.
+type Locale string
+
+const (
+	English    Locale = "en"
+	Portuguese Locale = "pt-BR"
+	Spanish    Locale = "es"
+
+	// Fallback is used for keys missing from the requested locale.
+	Fallback = English
+)
+
+// Locales lists the bundled locales, the fallback first.
+var Locales = []Locale{English, Portuguese, Spanish}
+
+//go:embed locales/*.json
+var files embed.FS
+
+var (
+	bundles = loadBundles()
+	matcher = language.NewMatcher(tags())
+)
+
+func loadBundles() map[Locale]map[string]string {
+	res := map[Locale]map[string]string{}
+	for _, locale := range Locales {
+		data, err := files.ReadFile(path.Join("locales", string(locale)+".json"))
+		if err != nil {
+			log.Fatalf("missing message bundle for %s: %v", locale, err)
+		}
+		messages := map[string]string{}
+		if err := json.Unmarshal(data, &messages); err != nil {
+			log.Fatalf("invalid message bundle for %s: %v", locale, err)
+		}
+		res[locale] = messages
+	}
+	return res
+}
+
+func tags() []language.Tag {
+	res := make([]language.Tag, 0, len(Locales))
+	for _, locale := range Locales {
+		res = append(res, language.MustParse(string(locale)))
+	}
+	return res
+}
+
+// Supported reports whether locale is one of the bundled locales.
+func Supported(locale string) bool {
+	for _, l := range Locales {
+		if strings.EqualFold(string(l), locale) {
+			return true
+		}
+	}
+	return false
+}
+
+// Resolve picks the bundled locale closest to an Accept-Language header.
+// When the header names nothing we translate, the defaults are tried in
+// order, e.g. the locale of a group and then the configured one.
+func Resolve(acceptLanguage string, defaults ...string) Locale {
+	if acceptLanguage != "" {
+		if preferred, _, err := language.ParseAcceptLanguage(acceptLanguage); err == nil && len(preferred) > 0 {
+			_, index, confidence := matcher.Match(preferred...)
+			if confidence != language.No {
+				return Locales[index]
+			}
+		}
+	}
+
+	for _, locale := range defaults {
+		for _, l := range Locales {
+			if strings.EqualFold(string(l), locale) {
+				return l
+			}
+		}
+	}
+
+	return Fallback
+}
+
+// Translate returns the message for key in locale, falling back to English.
+// Messages are text/template strings filled with params. The second result
+// is false when no bundle has the key.
+func Translate(locale Locale, key string, params map[string]interface{}) (string, bool) {
+	message, in := bundles[locale][key]
+	if !in {
+		message, in = bundles[Fallback][key]
+	}
+	if !in {
+		return "", false
+	}
+
+	if len(params) == 0 || !strings.Contains(message, "{{") {
+		return message, true
+	}
+
+	tmpl, err := template.New(key).Parse(message)
+	if err != nil {
+		return message, true
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, params); err != nil {
+		return message, true
+	}
+	return buf.String(), true
+}
+
+// Keys returns the message keys bundled for locale.
+func Keys(locale Locale) []string {
+	res := make([]string, 0, len(bundles[locale]))
+	for key := range bundles[locale] {
+		res = append(res, key)
+	}
+	return res
+}
+
+type localeKey struct{}
+
+// WithLocaleSlot returns a copy of ctx with room for the locale of the group a
+// request is about, so the code that reads the group can note it and an error
+// later in the request can be told in that language without reading it again.
+func WithLocaleSlot(ctx context.Context) context.Context {
+	return context.WithValue(ctx, localeKey{}, new(string))
+}
+
+// NoteLocale notes locale in ctx when ctx has room for it.
+func NoteLocale(ctx context.Context, locale string) {
+	if slot, ok := ctx.Value(localeKey{}).(*string); ok {
+		*slot = locale
+	}
+}
+
+// NotedLocale returns the locale noted in ctx, or "".
+func NotedLocale(ctx context.Context) string {
+	if slot, ok := ctx.Value(localeKey{}).(*string); ok {
+		return *slot
+	}
+	return ""
+}
@@ -0,0 +1,66 @@
+package i18n
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEveryKeyInEveryLocale(t *testing.T) {
+	keys := map[string]bool{}
+	for _, locale := range Locales {
+		for _, key := range Keys(locale) {
+			keys[key] = true
+		}
+	}
+
+	for _, locale := range Locales {
+		for key := range keys {
+			message, in := bundles[locale][key]
+			assert.True(t, in, "%s is missing %s", locale, key)
+			assert.NotEmpty(t, message, "%s has an empty %s", locale, key)
+		}
+	}
+}
+
+func TestResolve(t *testing.T) {
+	assert.Equal(t, Portuguese, Resolve("pt-BR,pt;q=0.9,en;q=0.8"))
+	assert.Equal(t, Portuguese, Resolve("pt"))
+	assert.Equal(t, Spanish, Resolve("es-AR"))
+	assert.Equal(t, English, Resolve("en-GB"))
+	assert.Equal(t, Spanish, Resolve("fr-FR, es;q=0.5"))
+
+	assert.Equal(t, Portuguese, Resolve("", "pt-br"))
+	assert.Equal(t, Spanish, Resolve("de", "xx", "es"))
+	assert.Equal(t, English, Resolve("not a header"))
+	assert.Equal(t, English, Resolve(""))
+}
+
+func TestTranslate(t *testing.T) {
+	message, in := Translate(Portuguese, "problem.GROUP_NOT_FOUND.title", nil)
+	assert.True(t, in)
+	assert.Equal(t, "Grupo não encontrado", message)
+
+	_, in = Translate(Spanish, "no.such.key", nil)
+	assert.False(t, in)
+}
+
+func TestTranslateFallsBackToEnglish(t *testing.T) {
+	bundles[English]["test.only_english"] = "Hello {{.name}}"
+	defer delete(bundles[English], "test.only_english")
+
+	message, in := Translate(Spanish, "test.only_english", map[string]interface{}{"name": "Mari"})
+	assert.True(t, in)
+	assert.Equal(t, "Hello Mari", message)
+}
+
+func TestNoteLocale(t *testing.T) {
+	NoteLocale(context.Background(), "pt-BR")
+	assert.Equal(t, "", NotedLocale(context.Background()))
+
+	ctx := WithLocaleSlot(context.Background())
+	assert.Equal(t, "", NotedLocale(ctx))
+	NoteLocale(ctx, "pt-BR")
+	assert.Equal(t, "pt-BR", NotedLocale(ctx))
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"log"
 	"net/http"
 	"net/http/httptest"
@@ -11,6 +12,7 @@ import (
 	"testing"
 
 	"service-secret-santa/config"
+	"service-secret-santa/dto"
 	handlers "service-secret-santa/handlers/group"
 	"service-secret-santa/models"
 	repos "service-secret-santa/repositories/group"
@@ -20,6 +22,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/ory/dockertest/v3"
 	"github.com/ory/dockertest/v3/docker"
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
@@ -28,6 +31,8 @@ var (
 	dbClient *mongo.Client
 	handler  handlers.Handler
 	router   *gin.Engine
+
+	anyVersion = map[string]string{"If-Match": "*"}
 )
 
 func setupRouter() *gin.Engine {
@@ -38,9 +43,16 @@ func setupRouter() *gin.Engine {
 }
 
 func executeRequest(method, url string, body interface{}) *httptest.ResponseRecorder {
+	return executeRequestWithHeaders(method, url, body, nil)
+}
+
+func executeRequestWithHeaders(method, url string, body interface{}, headers map[string]string) *httptest.ResponseRecorder {
 	jsonBody, _ := json.Marshal(body)
 	req, _ := http.NewRequest(method, url, bytes.NewBuffer(jsonBody))
 	req.Header.Set("Content-Type", "application/json")
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
 
 	w := httptest.NewRecorder()
 	router.ServeHTTP(w, req)
@@ -63,6 +75,8 @@ func TestMain(m *testing.M) {
 	resource, err := pool.RunWithOptions(&dockertest.RunOptions{
 		Repository: "mongo",
 		Tag:        "4.4.10",
+		// A single-node replica set, so that writes run in transactions.
+		Cmd: []string{"--replSet", "rs0"},
 		PortBindings: map[docker.Port][]docker.PortBinding{
 			"27017/tcp": {{HostPort: "27017"}},
 		},
@@ -78,19 +92,26 @@ func TestMain(m *testing.M) {
 
 	if err := pool.Retry(func() error {
 		var err error
-		dbClient, err = mongo.Connect(context.Background(), options.Client().ApplyURI("mongodb://localhost:27017"))
-		return err
+		dbClient, err = mongo.Connect(context.Background(), options.Client().ApplyURI("mongodb://localhost:27017/?directConnection=true"))
+		if err != nil {
+			return err
+		}
+		return initiateReplicaSet(dbClient)
 	}); err != nil {
 		log.Fatalf("Could not connect to MongoDB: %s", err)
 	}
 
-	groupRepo := repos.NewGroupRepository(dbClient)
-	groupSvc := services.NewGroupService(groupRepo)
+	groupRepo := repos.NewGroupRepository(dbClient, nil)
+	groupSvc := services.NewGroupService(groupRepo, nil)
 	handler = handlers.NewGroupHandler(groupSvc)
 	router = setupRouter()
 
-	collection := dbClient.Database(config.Cfg.MongoDB).Collection("groups")
-	collection.InsertOne(context.TODO(), models.CreateMockGroup())
+	if _, err := repos.MigrateMongo(context.Background(), dbClient); err != nil {
+		log.Fatalf("Could not migrate the database: %s", err)
+	}
+	if _, err := groupRepo.CreateGroup(context.TODO(), models.CreateMockGroup()); err != nil {
+		log.Fatalf("Could not create the mock group: %s", err)
+	}
 	code := m.Run()
 
 	if err := pool.Purge(resource); err != nil {
@@ -103,6 +124,35 @@ func TestMain(m *testing.M) {
 
 	os.Exit(code)
 }
+
+// initiateReplicaSet makes the server the primary of replica set rs0 and
+// returns nil once it accepts writes.
+func initiateReplicaSet(client *mongo.Client) error {
+	admin := client.Database("admin")
+	initiate := bson.D{{Key: "replSetInitiate", Value: bson.M{"_id": "rs0", "members": bson.A{bson.M{"_id": 0, "host": "localhost:27017"}}}}}
+	if err := admin.RunCommand(context.Background(), initiate).Err(); err != nil {
+		var serverErr mongo.ServerError
+		if !errors.As(err, &serverErr) || !serverErr.HasErrorCode(alreadyInitialized) {
+			return err
+		}
+	}
+
+	var hello struct {
+		IsWritablePrimary bool `bson:"isWritablePrimary"`
+	}
+	if err := admin.RunCommand(context.Background(), bson.D{{Key: "hello", Value: 1}}).Decode(&hello); err != nil {
+		return err
+	}
+	if !hello.IsWritablePrimary {
+		return errors.New("the replica set has no primary yet")
+	}
+	return nil
+}
+
+// alreadyInitialized is the server error code of replSetInitiate on a
+// replica set that already has a configuration.
+const alreadyInitialized = 23
+
 func TestCreateGroupSuccess(t *testing.T) {
 	newGroup := map[string]interface{}{
 		"Name": "Amigos do Trabalho",
@@ -113,7 +163,7 @@ func TestCreateGroupSuccess(t *testing.T) {
 		t.Errorf("Expected status code %d, got %d", http.StatusCreated, w.Code)
 	}
 
-	var createdGroup models.Group
+	var createdGroup dto.GroupResponse
 	if err := json.Unmarshal(w.Body.Bytes(), &createdGroup); err != nil {
 		t.Fatalf("Could not parse response body: %s", err)
 	}
@@ -122,7 +172,7 @@ func TestCreateGroupSuccess(t *testing.T) {
 		t.Errorf("Expected group name %s, got %s", newGroup["Name"].(string), createdGroup.Name)
 	}
 
-	if createdGroup.Id.IsZero() {
+	if createdGroup.Id == "" {
 		t.Error("Expected a valid group ID, got zero value")
 	}
 }
@@ -135,7 +185,7 @@ func TestGetGroupByIDSuccess(t *testing.T) {
 		t.Errorf("Expected status code %d, got %d", http.StatusOK, w.Code)
 	}
 
-	var fetchedGroup models.Group
+	var fetchedGroup dto.GroupResponse
 	if err := json.Unmarshal(w.Body.Bytes(), &fetchedGroup); err != nil {
 		t.Fatalf("Could not parse response body: %s", err)
 	}
@@ -147,13 +197,13 @@ func TestGetGroupByIDSuccess(t *testing.T) {
 
 func TestUpdateGroupSuccess(t *testing.T) {
 	updatedGroup := models.CreateMockGroup()
-	w := executeRequest("PUT", "/secret-santa/group/"+updatedGroup.Id.Hex(), updatedGroup)
+	w := executeRequestWithHeaders("PUT", "/secret-santa/group/"+updatedGroup.Id.Hex(), dto.NewReplaceGroupRequest(models.NewGroupUpdate(updatedGroup)), anyVersion)
 
 	if w.Code != http.StatusOK {
 		t.Errorf("Expected status code %d, got %d", http.StatusOK, w.Code)
 	}
 
-	var updatedGroupResponse models.Group
+	var updatedGroupResponse dto.GroupResponse
 	if err := json.Unmarshal(w.Body.Bytes(), &updatedGroupResponse); err != nil {
 		t.Fatalf("Could not parse response body: %s", err)
 	}
@@ -168,13 +218,13 @@ func TestAddParticipantSuccess(t *testing.T) {
 	newParticipant := map[string]interface{}{
 		"Name": "Carlos",
 	}
-	w := executeRequest("POST", "/secret-santa/group/"+createdGroup.Id.Hex()+"/add-participant", newParticipant)
+	w := executeRequestWithHeaders("POST", "/secret-santa/group/"+createdGroup.Id.Hex()+"/add-participant", newParticipant, anyVersion)
 
 	if w.Code != http.StatusOK {
 		t.Errorf("Expected status code %d, got %d", http.StatusOK, w.Code)
 	}
 
-	var updatedGroup models.Group
+	var updatedGroup dto.GroupResponse
 	if err := json.Unmarshal(w.Body.Bytes(), &updatedGroup); err != nil {
 		t.Fatalf("Could not parse response body: %s", err)
 	}
@@ -188,9 +238,59 @@ func TestAddParticipantSuccess(t *testing.T) {
 	}
 }
 
+func TestUpdateGroupStaleVersion(t *testing.T) {
+	group := models.CreateMockGroup()
+	w := executeRequest("GET", "/secret-santa/group/"+group.Id.Hex(), nil)
+	etag := w.Header().Get("ETag")
+
+	w = executeRequestWithHeaders("GET", "/secret-santa/group/"+group.Id.Hex(), nil, map[string]string{"If-None-Match": etag})
+	if w.Code != http.StatusNotModified {
+		t.Errorf("Expected status code %d, got %d", http.StatusNotModified, w.Code)
+	}
+
+	w = executeRequestWithHeaders("PUT", "/secret-santa/group/"+group.Id.Hex(), dto.NewReplaceGroupRequest(models.NewGroupUpdate(group)), map[string]string{"If-Match": etag})
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status code %d, got %d", http.StatusOK, w.Code)
+	}
+
+	w = executeRequestWithHeaders("PUT", "/secret-santa/group/"+group.Id.Hex(), dto.NewReplaceGroupRequest(models.NewGroupUpdate(group)), map[string]string{"If-Match": etag})
+	if w.Code != http.StatusPreconditionFailed {
+		t.Errorf("Expected status code %d, got %d", http.StatusPreconditionFailed, w.Code)
+	}
+}
+
+func TestPatchGroupKeepsOtherFields(t *testing.T) {
+	group := models.CreateMockGroup()
+	patch := map[string]interface{}{"name": "Amigos do Escritório"}
+	headers := map[string]string{"If-Match": "*", "Content-Type": "application/merge-patch+json"}
+	w := executeRequestWithHeaders("PATCH", "/secret-santa/group/"+group.Id.Hex(), patch, headers)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d", http.StatusOK, w.Code)
+	}
+
+	var patchedGroup dto.GroupResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &patchedGroup); err != nil {
+		t.Fatalf("Could not parse response body: %s", err)
+	}
+
+	if patchedGroup.Name != patch["name"] {
+		t.Errorf("Expected group name %s, got %s", patch["name"], patchedGroup.Name)
+	}
+
+	if len(patchedGroup.Participants) == 0 || !patchedGroup.CreatedAt.Equal(group.CreatedAt) {
+		t.Errorf("Expected participants and createdAt to be kept, got %+v", patchedGroup)
+	}
+
+	w = executeRequestWithHeaders("PATCH", "/secret-santa/group/"+group.Id.Hex(), map[string]interface{}{"matches": nil}, headers)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status code %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
 func TestDeleteGroupSuccess(t *testing.T) {
 	createdGroup := models.CreateMockGroup()
-	w := executeRequest("DELETE", "/secret-santa/group/"+createdGroup.Id.Hex(), nil)
+	w := executeRequestWithHeaders("DELETE", "/secret-santa/group/"+createdGroup.Id.Hex(), nil, anyVersion)
 
 	if w.Code != http.StatusNoContent {
 		t.Errorf("Expected status code %d, got %d", http.StatusNoContent, w.Code)
@@ -202,3 +302,35 @@ func TestDeleteGroupSuccess(t *testing.T) {
 		t.Errorf("Expected status code %d, got %d", http.StatusNotFound, w.Code)
 	}
 }
+
+func TestRestoreDeletedGroup(t *testing.T) {
+	w := executeRequest("POST", "/secret-santa/group", map[string]interface{}{"name": "Lixeira", "owner": "lixeira@example.com"})
+	var created dto.GroupResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &created); err != nil {
+		t.Fatalf("Could not parse response body: %s", err)
+	}
+
+	w = executeRequestWithHeaders("DELETE", "/secret-santa/group/"+created.Id, nil, anyVersion)
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("Expected status code %d, got %d", http.StatusNoContent, w.Code)
+	}
+
+	w = executeRequest("GET", "/secret-santa/group/trash?owner=lixeira@example.com", nil)
+	var trash []dto.TrashedGroupResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &trash); err != nil {
+		t.Fatalf("Could not parse response body: %s", err)
+	}
+	if len(trash) != 1 || trash[0].Id != created.Id || trash[0].DeletedAt.IsZero() {
+		t.Fatalf("Expected the deleted group in the trash, got %+v", trash)
+	}
+
+	w = executeRequestWithHeaders("POST", "/secret-santa/group/"+created.Id+"/restore", nil, anyVersion)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d", http.StatusOK, w.Code)
+	}
+
+	w = executeRequest("GET", "/secret-santa/group/"+created.Id, nil)
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status code %d, got %d", http.StatusOK, w.Code)
+	}
+}
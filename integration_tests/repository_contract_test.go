@@ -0,0 +1,176 @@
+package integration_tests
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"service-secret-santa/config"
+	"service-secret-santa/customError"
+	"service-secret-santa/models"
+	repos "service-secret-santa/repositories/group"
+	"service-secret-santa/repositories/group/grouptest"
+	services "service-secret-santa/services/group"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// TestMongoRepositoryContract runs the repository contract against a real
+// MongoDB, each case in a database of its own.
+func TestMongoRepositoryContract(t *testing.T) {
+	grouptest.RunContract(t, func(t *testing.T) repos.Repository {
+		previous := config.Cfg.MongoDB
+		config.Cfg.MongoDB = "contract-" + primitive.NewObjectID().Hex()
+		database := config.Cfg.MongoDB
+
+		t.Cleanup(func() {
+			_ = dbClient.Database(database).Drop(context.Background())
+			config.Cfg.MongoDB = previous
+		})
+
+		if err := repos.CreateIndexes(dbClient); err != nil {
+			t.Fatalf("Could not create indexes: %s", err)
+		}
+
+		return repos.NewGroupRepository(dbClient, nil)
+	})
+}
+
+// TestMigrateMongo moves a group written with embedded participants and
+// matches and reads it back through the repository.
+func TestMigrateMongo(t *testing.T) {
+	previous := config.Cfg.MongoDB
+	config.Cfg.MongoDB = "migration-" + primitive.NewObjectID().Hex()
+	database := config.Cfg.MongoDB
+	defer func() {
+		_ = dbClient.Database(database).Drop(context.Background())
+		config.Cfg.MongoDB = previous
+	}()
+
+	groupID := primitive.NewObjectID()
+	_, err := dbClient.Database(database).Collection("groups").InsertOne(context.Background(), bson.M{
+		"_id":          groupID,
+		"name":         "Amigos",
+		"status":       models.GroupStatusDrawn,
+		"version":      4,
+		"participants": bson.A{bson.M{"name": "Ana", "email": "ana@example.com"}, bson.M{"name": "Bia", "email": "bia@example.com"}},
+		"matches":      bson.A{bson.M{"first": "Ana", "second": "Bia"}, bson.M{"first": "Bia", "second": "Ana"}},
+	})
+	if err != nil {
+		t.Fatalf("Could not insert the embedded group: %s", err)
+	}
+
+	// Instances starting together take turns; only one applies each migration.
+	var wg sync.WaitGroup
+	errs := make(chan error, 3)
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := repos.MigrateMongo(context.Background(), dbClient)
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("Migration failed: %s", err)
+		}
+	}
+
+	statuses, err := repos.MongoMigrationStatus(context.Background(), dbClient)
+	if err != nil {
+		t.Fatalf("Could not list migrations: %s", err)
+	}
+	records, _ := dbClient.Database(database).Collection("migrations").CountDocuments(context.Background(), bson.M{"_id": bson.M{"$type": "number"}})
+	if int(records) != len(statuses) {
+		t.Errorf("Expected %d migration records, got %d", len(statuses), records)
+	}
+	for _, status := range statuses {
+		if !status.Applied() {
+			t.Errorf("Expected migration %d to be applied", status.Version)
+		}
+	}
+
+	repo := repos.NewGroupRepository(dbClient, nil)
+	group, customErr := repo.GetGroupByID(context.Background(), groupID.Hex())
+	if customErr != nil {
+		t.Fatalf("Could not read the migrated group: %s", customErr.Message)
+	}
+	if len(group.Participants) != 2 || group.Participants[0].Name != "Ana" || len(group.Matches) != 2 || group.Version != 4 || group.SchemaVersion != repos.SchemaVersion {
+		t.Errorf("Expected the embedded participants and matches, got %+v", group)
+	}
+
+	match, customErr := repo.GetMyMatch(context.Background(), groupID.Hex(), "bia")
+	if customErr != nil || match != "Ana" {
+		t.Errorf("Expected Bia to draw Ana, got %q, %v", match, customErr)
+	}
+
+	count, err := dbClient.Database(database).Collection("groups").CountDocuments(context.Background(), bson.M{"participants": bson.M{"$exists": true}})
+	if err != nil || count != 0 {
+		t.Errorf("Expected no embedded participants left, got %d, %v", count, err)
+	}
+}
+
+// TestDrawDuringAdds draws a group while participants are being added. Every
+// draw that gets through must cover exactly the participants it was computed
+// from: since participants are only appended, the matches of the last draw
+// name the first participants of the group, each once on either side.
+func TestDrawDuringAdds(t *testing.T) {
+	repo := repos.NewGroupRepository(dbClient, nil)
+	svc := services.NewGroupService(repo, nil)
+
+	created, customErr := svc.CreateGroup(context.Background(), &models.Group{
+		Name:         "Sorteio concorrente",
+		Participants: []models.Participant{{Name: "Ana"}, {Name: "Bia"}, {Name: "Caio"}},
+	})
+	if customErr != nil {
+		t.Fatalf("Could not create the group: %s", customErr.Message)
+	}
+	id := created.Id.Hex()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			participant := &models.Participant{Name: fmt.Sprintf("Convidado %d", i)}
+			if _, err := svc.AddParticipant(context.Background(), id, models.AnyVersion, participant); err != nil {
+				t.Errorf("Could not add %s: %s", participant.Name, err.Message)
+			}
+		}(i)
+		go func() {
+			defer wg.Done()
+			if _, err := svc.MatchParticipants(context.Background(), id, models.AnyVersion); err != nil && err.Kind() != customError.GroupVersionMismatch {
+				t.Errorf("Draw failed: %s", err.Message)
+			}
+		}()
+	}
+	wg.Wait()
+
+	group, customErr := repo.GetGroupByID(context.Background(), id)
+	if customErr != nil {
+		t.Fatalf("Could not read the group: %s", customErr.Message)
+	}
+	if group.ParticipantCount != 13 || len(group.Participants) != 13 {
+		t.Fatalf("Expected 13 participants, got %d (count %d)", len(group.Participants), group.ParticipantCount)
+	}
+	if group.Status != models.GroupStatusDrawn {
+		return
+	}
+
+	drawn := group.Participants[:len(group.Matches)]
+	givers, receivers := map[string]int{}, map[string]int{}
+	for _, match := range group.Matches {
+		givers[match.First]++
+		receivers[match.Second]++
+	}
+	for _, participant := range drawn {
+		if givers[participant.Name] != 1 || receivers[participant.Name] != 1 {
+			t.Errorf("Expected %s to give and receive once, got %d and %d", participant.Name, givers[participant.Name], receivers[participant.Name])
+		}
+	}
+}
@@ -2,16 +2,28 @@ package main
 
 import (
 	"context"
+	"database/sql"
 	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
 
 	. "service-secret-santa/config"
 	"service-secret-santa/docs"
 	"service-secret-santa/resources/di"
+	"service-secret-santa/resources/identity"
+	"service-secret-santa/resources/lifecycle"
+	"service-secret-santa/resources/logging"
+	"service-secret-santa/resources/metrics"
+	"service-secret-santa/resources/tracing"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
 //	@title			Service Secret Santa
@@ -21,33 +33,82 @@ import (
 //	@host
 //	@BasePath /secret-santa
 //
+//	@securityDefinitions.apikey	AdminToken
+//	@in							header
+//	@name						Authorization
+//	@description					Bearer token of the /admin routes (ADMIN_TOKEN).
+//
+//	@securityDefinitions.apikey	UserEmail
+//	@in							header
+//	@name						X-User-Email
+//	@description					Email of the user, as authenticated by the gateway.
+
+//	@securityDefinitions.apikey	GatewayToken
+//	@in							header
+//	@name						X-Gateway-Token
+//	@description					Shared token of the gateway (GATEWAY_TOKEN), without which X-User-Email is ignored.
+//
 // @externalDocs.description	ReadMe
 func main() {
 	LoadConfig()
-	mongoClient := di.InitializeMongoClient()
-	defer func() {
-		if err := mongoClient.Disconnect(context.TODO()); err != nil {
-			panic(err)
-		}
-	}()
+	if err := logging.Setup(os.Stdout, Cfg.LogLevel, Cfg.LogFormat); err != nil {
+		log.Fatalf("Failed to configure logging: %v", err)
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		os.Exit(runMigrate(os.Args[2:], os.Stdout))
+	}
+
+	exporter, err := tracing.NewExporter(context.Background(), Cfg.TracingExporter)
+	if err != nil {
+		log.Fatalf("Failed to configure tracing: %v", err)
+	}
+	tracerProvider := tracing.NewProvider(exporter, Cfg.TracingSampleRatio, Cfg.Environment)
+	tracing.Setup(tracerProvider)
+
+	manager := lifecycle.NewManager(Cfg.ShutdownDelay, Cfg.DrainTimeout)
+
+	// Os componentes iniciam nesta ordem e param na ordem inversa: tracing,
+	// banco de dados (MongoDB ou SQL), rotinas em segundo plano e, por último, o servidor HTTP
+	manager.Append(lifecycle.Component{Name: "tracing", Stop: tracerProvider.Shutdown})
+	var mongoClient *mongo.Client
+	var db *sql.DB
+	switch Cfg.Storage {
+	case StorageMongo:
+		mongoClient = di.InitializeMongoClient()
+		manager.Append(lifecycle.Component{Name: "mongo", Stop: mongoClient.Disconnect})
+	case StorageSQLite, StoragePostgres:
+		db = di.InitializeSQL()
+		manager.Append(lifecycle.Component{Name: "database", Stop: func(context.Context) error { return db.Close() }})
+	}
 
 	docs.SwaggerInfo.Host = Cfg.SwaggerHost
 
-	router := gin.Default()
+	router := gin.New()
+	router.Use(gin.Recovery(), tracing.Middleware(), logging.RequestIDMiddleware(), identity.Middleware(Cfg.GatewayToken), logging.AccessLog())
 
 	corsConfig := cors.DefaultConfig()
-	corsConfig.AddAllowHeaders("Authorization")
+	corsConfig.AddAllowHeaders("Authorization", "If-Match", "If-None-Match", logging.RequestIDHeader, identity.UserHeader, "traceparent", "tracestate")
+	corsConfig.AddExposeHeaders("ETag", logging.RequestIDHeader)
 	corsConfig.AllowAllOrigins = true
 
 	router.Use(cors.New(corsConfig))
+	router.Use(metrics.Middleware())
+	router.GET("/metrics", gin.WrapH(promhttp.Handler()))
 
 	secretSantaGroup := router.Group("/secret-santa")
 
-	di.InitializeDI(mongoClient)
+	di.InitializeDI(mongoClient, db, manager)
 	di.Invoke(secretSantaGroup)
 	secretSantaGroup.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
-	if err := router.Run(":" + Cfg.Port); err != nil {
+	server := &http.Server{Addr: ":" + Cfg.Port, Handler: router}
+	manager.Append(manager.HTTPServer(server))
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := manager.Run(ctx); err != nil {
 		log.Fatalf("Failed to run server: %v", err)
 	}
 }
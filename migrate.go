@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	. "service-secret-santa/config"
+	groupRepository "service-secret-santa/repositories/group"
+	"service-secret-santa/resources/di"
+)
+
+const migrateUsage = `usage: main migrate [up|list|dry-run]
+
+  up       apply the pending migrations (default)
+  list     show every migration and when it was applied
+  dry-run  show the migrations that up would apply, without applying them`
+
+// runMigrate runs the migrate subcommand and returns its exit code. The
+// service applies the migrations on startup as well; the subcommand lets them
+// run, or be inspected, before a deploy.
+func runMigrate(args []string, out io.Writer) int {
+	command := "up"
+	if len(args) > 0 {
+		command = args[0]
+	}
+	if len(args) > 1 || (command != "up" && command != "list" && command != "dry-run") {
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		return 2
+	}
+
+	if Cfg.Storage != StorageMongo {
+		fmt.Fprintf(os.Stderr, "migrate only works with STORAGE=mongo, not %s; SQL databases are migrated on startup\n", Cfg.Storage)
+		return 2
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	client := di.InitializeMongoClient()
+	defer client.Disconnect(context.Background())
+
+	var statuses []groupRepository.MigrationStatus
+	var err error
+	switch command {
+	case "up":
+		statuses, err = groupRepository.MigrateMongo(ctx, client)
+		if len(statuses) == 0 && err == nil {
+			fmt.Fprintln(out, "The database is up to date.")
+			return 0
+		}
+	case "list":
+		statuses, err = groupRepository.MongoMigrationStatus(ctx, client)
+	case "dry-run":
+		statuses, err = groupRepository.MongoMigrationStatus(ctx, client)
+		statuses = pending(statuses)
+		if len(statuses) == 0 && err == nil {
+			fmt.Fprintln(out, "The database is up to date.")
+			return 0
+		}
+	}
+
+	printMigrations(out, statuses)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "migrate %s: %v\n", command, err)
+		return 1
+	}
+	return 0
+}
+
+func pending(statuses []groupRepository.MigrationStatus) []groupRepository.MigrationStatus {
+	var result []groupRepository.MigrationStatus
+	for _, status := range statuses {
+		if !status.Applied() {
+			result = append(result, status)
+		}
+	}
+	return result
+}
+
+func printMigrations(out io.Writer, statuses []groupRepository.MigrationStatus) {
+	if len(statuses) == 0 {
+		return
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tSTATUS\tAPPLIED AT\tDURATION\tDESCRIPTION")
+	for _, status := range statuses {
+		state, appliedAt, duration := "pending", "-", "-"
+		if status.Applied() {
+			state = "applied"
+			appliedAt = status.AppliedAt.Format(time.RFC3339)
+			duration = status.Duration.String()
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\n", status.Version, state, appliedAt, duration, status.Description)
+	}
+	w.Flush()
+}
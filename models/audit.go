@@ -0,0 +1,83 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Actions recorded in the audit log.
+const (
+	AuditCreated            = "created"
+	AuditUpdated            = "updated"
+	AuditParticipantAdded   = "participant-added"
+	AuditParticipantRemoved = "participant-removed"
+	AuditDrawn              = "drawn"
+	AuditRedrawn            = "redrawn"
+	AuditRevealed           = "revealed"
+	AuditDeleted            = "deleted"
+	AuditRestored           = "restored"
+	AuditPurgeWarned        = "purge-warned"
+	AuditAnonymized         = "anonymized"
+	AuditErased             = "erased"
+)
+
+// AuditActions lists the actions the audit log can be filtered by.
+var AuditActions = []string{
+	AuditCreated, AuditUpdated, AuditParticipantAdded, AuditParticipantRemoved,
+	AuditDrawn, AuditRedrawn, AuditRevealed, AuditDeleted, AuditRestored,
+	AuditPurgeWarned, AuditAnonymized, AuditErased,
+}
+
+// AuditActorSystem is the actor of changes made by the service on its own,
+// such as the retention job.
+const AuditActorSystem = "system"
+
+// AuditActorAdmin is the actor of changes made through the /admin routes.
+const AuditActorAdmin = "admin"
+
+// AuditActorAnonymous is the actor of requests that did not say who made
+// them.
+const AuditActorAnonymous = "anonymous"
+
+// AuditRedacted stands in for the actors, names and emails taken out of the
+// audit log when the people behind them are anonymized or erased.
+const AuditRedacted = "redacted"
+
+// AuditEvent is an entry of the append-only audit log of a group. Detail
+// describes the change and Changes the fields it changed. Neither carries
+// the matches of a draw.
+type AuditEvent struct {
+	Id        primitive.ObjectID `bson:"_id,omitempty"`
+	GroupId   primitive.ObjectID `bson:"groupId"`
+	Action    string             `bson:"action"`
+	Actor     string             `bson:"actor"`
+	At        time.Time          `bson:"at"`
+	RequestId string             `bson:"requestId,omitempty"`
+	Detail    string             `bson:"detail,omitempty"`
+	Changes   []AuditChange      `bson:"changes,omitempty"`
+}
+
+// AuditChange is the value of a field before and after a change, as JSON. An
+// empty Before or After means the field was not set.
+type AuditChange struct {
+	Field  string `bson:"field"`
+	Before string `bson:"before,omitempty"`
+	After  string `bson:"after,omitempty"`
+}
+
+const (
+	DefaultAuditLimit = 100
+	MaxAuditLimit     = 1000
+)
+
+// AuditQuery selects the events of a group, oldest first. Empty fields do not
+// filter, and a zero Limit reads them all; Since is inclusive and Until
+// exclusive.
+type AuditQuery struct {
+	Action string
+	Actor  string
+	Since  time.Time
+	Until  time.Time
+	Limit  int
+}
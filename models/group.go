@@ -3,17 +3,53 @@ package models
 import (
 	"time"
 
-	"github.com/invopop/validation"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+const (
+	GroupStatusOpen  = "open"
+	GroupStatusDrawn = "drawn"
+)
+
+// AnyVersion is the expected version of a write that must not be checked
+// against the stored one, as requested with "If-Match: *".
+const AnyVersion int64 = -1
+
+// Group is the document stored in the groups collection. It is never bound
+// from or written to HTTP bodies; see the dto package for the API shapes.
+//
+// Participants and matches live in collections of their own and are only
+// loaded when a single group is read. Listings leave them nil and report
+// ParticipantCount instead.
+//
+// A deleted group keeps its documents, with DeletedAt set, until the trash
+// retention runs out. Only the trash reads it; every other read and write
+// treats it as missing.
+//
+// ExchangeDate is when the gifts are exchanged, if the organizer set it.
+// Participant data is kept for a retention period after it (or after
+// CreatedAt without one): the owner is warned at PurgeWarnedAt and the names
+// and emails are replaced by pseudonyms at AnonymizedAt. PurgeWarnFailures
+// counts the warnings that could not be sent. Trashed groups are anonymized
+// too.
 type Group struct {
-	Id           primitive.ObjectID `json:"id" bson:"_id,omitempty" swaggerignore:"true"`
-	Name         string             `json:"name" bson:"name" example:"Equipe pe no chao"`
-	Participants []Participant      `json:"participants" bson:"participants" `
-	Matches      []Match            `json:"matches" bson:"matches"  swaggerignore:"true"`
-	CreatedAt    time.Time          `json:"createdAt" bson:"createdAt, omitempty" swaggerignore:"true"`
-	UpdatedAt    time.Time          `json:"updateAt" bson:"updateAt, omitempty" swaggerignore:"true"`
+	Id                primitive.ObjectID `bson:"_id,omitempty"`
+	Name              string             `bson:"name"`
+	Owner             string             `bson:"owner"`
+	Locale            string             `bson:"locale,omitempty"`
+	Status            string             `bson:"status"`
+	Participants      []Participant      `bson:"-"`
+	Matches           []Match            `bson:"-"`
+	ParticipantCount  int                `bson:"participantCount"`
+	CreatedAt         time.Time          `bson:"createdAt,omitempty"`
+	UpdatedAt         time.Time          `bson:"updateAt,omitempty"`
+	Version           int64              `bson:"version"`
+	SchemaVersion     int                `bson:"schemaVersion,omitempty"`
+	DeletedAt         time.Time          `bson:"deletedAt,omitempty"`
+	ExchangeDate      time.Time          `bson:"exchangeDate,omitempty"`
+	PurgeWarnedAt     time.Time          `bson:"purgeWarnedAt,omitempty"`
+	PurgeWarnFailures int                `bson:"purgeWarnFailures,omitempty"`
+	AnonymizedAt      time.Time          `bson:"anonymizedAt,omitempty"`
 }
 
 var mockGroupID = func() primitive.ObjectID {
@@ -23,46 +59,25 @@ var mockGroupID = func() primitive.ObjectID {
 
 func CreateMockGroup() *Group {
 	return &Group{
-		Id:           mockGroupID,
-		Name:         "Test Group",
-		Participants: []Participant{{Name: "Mari", Email: "mari@gmail.com"}},
-		Matches:      []Match{{First: "joao", Second: "mari"}},
-		CreatedAt:    time.Date(2023, 12, 11, 0, 0, 0, 0, time.UTC),
-		UpdatedAt:    time.Date(2023, 12, 11, 0, 0, 0, 0, time.UTC),
+		Id:               mockGroupID,
+		Name:             "Test Group",
+		Owner:            "mari@gmail.com",
+		Status:           GroupStatusDrawn,
+		Participants:     []Participant{{Name: "Mari", Email: "mari@gmail.com"}},
+		Matches:          []Match{{First: "joao", Second: "mari"}},
+		ParticipantCount: 1,
+		CreatedAt:        time.Date(2023, 12, 11, 0, 0, 0, 0, time.UTC),
+		UpdatedAt:        time.Date(2023, 12, 11, 0, 0, 0, 0, time.UTC),
+		Version:          1,
 	}
 }
 
 type Participant struct {
-	Name  string `json:"name" bson:"name" example:"Mari"`
-	Email string `json:"email" bson:"email" example:"Mari@gmail.com"`
+	Name  string `bson:"name"`
+	Email string `bson:"email"`
 }
 
 type Match struct {
-	First  string `json:"first" bson:"first"  example:"joao"`
-	Second string `json:"second" bson:"second" example:"mari"`
-}
-
-func (l Group) Validate() error {
-	err := validation.ValidateStruct(&l,
-		validation.Field(&l.Name, validation.Required),
-	)
-
-	if err != nil {
-		return err
-	}
-
-	return nil
-}
-
-func (l Participant) Validate() error {
-	err := validation.ValidateStruct(&l,
-		validation.Field(&l.Name, validation.Required),
-		validation.Field(&l.Email, validation.Required),
-	)
-
-	if err != nil {
-		return err
-	}
-
-	return nil
+	First  string `bson:"first"`
+	Second string `bson:"second"`
 }
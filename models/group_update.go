@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+)
+
+// GroupUpdate is the $set document for the fields of a group that clients
+// are allowed to change. Participants are not part of it: the repository
+// replaces them in their own collection. A nil ExchangeDate removes it.
+type GroupUpdate struct {
+	Name         string        `bson:"name"`
+	Owner        string        `bson:"owner"`
+	Locale       string        `bson:"locale,omitempty"`
+	ExchangeDate *time.Time    `bson:"exchangeDate"`
+	Participants []Participant `bson:"-"`
+	UpdatedAt    time.Time     `bson:"updateAt"`
+}
+
+// NewGroupUpdate returns the current values of the mutable fields of group.
+func NewGroupUpdate(group *Group) GroupUpdate {
+	update := GroupUpdate{
+		Name:         group.Name,
+		Owner:        group.Owner,
+		Locale:       group.Locale,
+		Participants: group.Participants,
+	}
+	if !group.ExchangeDate.IsZero() {
+		exchangeDate := group.ExchangeDate
+		update.ExchangeDate = &exchangeDate
+	}
+	return update
+}
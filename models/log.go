@@ -0,0 +1,25 @@
+package models
+
+import "log/slog"
+
+// LogValue keeps participants and matches out of the logs: only their counts
+// are logged.
+func (g Group) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("id", g.Id.Hex()),
+		slog.String("status", g.Status),
+		slog.Int64("version", g.Version),
+		slog.Int("participants_count", g.ParticipantCount),
+		slog.Int("matches_count", len(g.Matches)),
+	)
+}
+
+// LogValue hides who the participant is.
+func (p Participant) LogValue() slog.Value {
+	return slog.StringValue("[REDACTED]")
+}
+
+// LogValue hides who draws whom.
+func (m Match) LogValue() slog.Value {
+	return slog.StringValue("[REDACTED]")
+}
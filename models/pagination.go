@@ -0,0 +1,38 @@
+package models
+
+const (
+	DefaultPageLimit = 20
+	MaxPageLimit     = 100
+
+	SortByCreatedAt = "createdAt"
+	SortByName      = "name"
+
+	SortAsc  = "asc"
+	SortDesc = "desc"
+)
+
+// GroupQuery holds the paging, sorting and filtering options of a group listing.
+type GroupQuery struct {
+	Limit            int
+	Cursor           string
+	Sort             string
+	Order            string
+	Name             string
+	Status           string
+	Owner            string
+	ParticipantEmail string
+}
+
+// Paging describes the page returned and how to request the next one.
+type Paging struct {
+	Limit      int
+	Sort       string
+	Order      string
+	NextCursor string
+	HasMore    bool
+}
+
+type GroupPage struct {
+	Items  []*Group
+	Paging Paging
+}
@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// RetentionQuery selects the groups, in the trash or not, whose participant data has not been
+// anonymized yet and whose exchange, or creation when they have no exchange
+// date, is before ExchangedBefore. A zero WarnedBefore selects the groups
+// whose owner was not warned yet; otherwise those warned before it.
+type RetentionQuery struct {
+	ExchangedBefore time.Time
+	WarnedBefore    time.Time
+	Limit           int
+}
+
+// RetainedSince is when the retention period of group started.
+func RetainedSince(group *Group) time.Time {
+	if !group.ExchangeDate.IsZero() {
+		return group.ExchangeDate
+	}
+	return group.CreatedAt
+}
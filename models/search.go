@@ -0,0 +1,7 @@
+package models
+
+// SearchQuery holds a search term and how many results to return.
+type SearchQuery struct {
+	Q     string
+	Limit int
+}
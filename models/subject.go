@@ -0,0 +1,48 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// SubjectGroup is what a group holds about a data subject: whether they own
+// it, their participant entries and the matches that involve them, as giver
+// or receiver.
+type SubjectGroup struct {
+	Group        *Group
+	Owner        bool
+	Participants []Participant
+	Matches      []Match
+}
+
+// SubjectExport is everything stored about the person behind Email. Audit
+// holds the events of any group that name them or that they made.
+type SubjectExport struct {
+	Email      string
+	ExportedAt time.Time
+	Groups     []SubjectGroup
+	Audit      []AuditEvent
+}
+
+// GroupRewrite replaces the personal data of a group, in the trash or not,
+// when a data subject is erased. Matches replace the draw as a whole.
+type GroupRewrite struct {
+	Owner        string
+	Status       string
+	Participants []Participant
+	Matches      []Match
+}
+
+// ErasureCertificate records that the personal data of a data subject was
+// erased. SubjectHash is an HMAC-SHA256 of the lower-cased email under the
+// blind index key, so a certificate can be checked against an email without
+// storing it; it is empty when no key is configured.
+type ErasureCertificate struct {
+	Id                  primitive.ObjectID `bson:"_id,omitempty"`
+	SubjectHash         string             `bson:"subjectHash"`
+	ErasedAt            time.Time          `bson:"erasedAt"`
+	Groups              []string           `bson:"groups"`
+	ParticipantsRemoved int                `bson:"participantsRemoved"`
+	DrawsRepaired       int                `bson:"drawsRepaired"`
+}
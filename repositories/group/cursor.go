@@ -0,0 +1,109 @@
+package group
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"service-secret-santa/customError"
+	"service-secret-santa/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var errInvalidCursor = errors.New("invalid cursor")
+
+// pageCursor is the position of the last item of a page. It is handed to
+// clients as an opaque base64 token and is only valid for the sort it was
+// issued with.
+type pageCursor struct {
+	Sort      string    `json:"s"`
+	Order     string    `json:"o"`
+	Name      string    `json:"n,omitempty"`
+	CreatedAt time.Time `json:"c,omitempty"`
+	Id        string    `json:"i"`
+}
+
+func newPageCursor(query models.GroupQuery, last *models.Group) pageCursor {
+	return pageCursor{
+		Sort:      query.Sort,
+		Order:     query.Order,
+		Name:      last.Name,
+		CreatedAt: last.CreatedAt,
+		Id:        last.Id.Hex(),
+	}
+}
+
+func (c pageCursor) encode() string {
+	raw, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func decodePageCursor(token string, query models.GroupQuery) (*pageCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, errInvalidCursor
+	}
+
+	var c pageCursor
+	if err := json.Unmarshal(raw, &c); err != nil {
+		return nil, errInvalidCursor
+	}
+
+	if c.Sort != query.Sort || c.Order != query.Order {
+		return nil, errors.New("cursor does not match the requested sort")
+	}
+
+	if _, err := primitive.ObjectIDFromHex(c.Id); err != nil {
+		return nil, errInvalidCursor
+	}
+
+	return &c, nil
+}
+
+// filter returns the keyset condition selecting the documents after the cursor.
+func (c pageCursor) filter() bson.M {
+	id, _ := primitive.ObjectIDFromHex(c.Id)
+
+	op := "$gt"
+	if c.Order == models.SortDesc {
+		op = "$lt"
+	}
+
+	var value interface{} = c.CreatedAt
+	if c.Sort == models.SortByName {
+		value = c.Name
+	}
+
+	return bson.M{"$or": bson.A{
+		bson.M{c.Sort: bson.M{op: value}},
+		bson.M{c.Sort: value, "_id": bson.M{op: id}},
+	}}
+}
+
+// newGroupPage builds the page from up to query.Limit+1 sorted groups; the
+// extra one only tells that there is a next page.
+func newGroupPage(query models.GroupQuery, groups []*models.Group) *models.GroupPage {
+	page := &models.GroupPage{
+		Items: groups,
+		Paging: models.Paging{
+			Limit: query.Limit,
+			Sort:  query.Sort,
+			Order: query.Order,
+		},
+	}
+
+	if len(groups) > query.Limit {
+		page.Items = groups[:query.Limit]
+		page.Paging.HasMore = true
+		page.Paging.NextCursor = newPageCursor(query, page.Items[query.Limit-1]).encode()
+	}
+
+	return page
+}
+
+func invalidCursor(err error) *customError.CustomError {
+	return customError.NewCustomError(customError.WithBadRequest(err.Error(), "Invalid cursor"), customError.WithCode(customError.CursorInvalid))
+}
@@ -0,0 +1,96 @@
+package group
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"service-secret-santa/customError"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// documentValidationFailure is the server error code of a write rejected by
+// the collection's JSON schema validator.
+const documentValidationFailure = 121
+
+// translateError maps a driver error to the status it calls for. message says
+// what the repository was doing, e.g. "Failed to update group". Deadlines of
+// our own operations become 504; timeouts inside Mongo become 503. Failures
+// that are not the client's fault are logged with the driver error.
+func translateError(ctx context.Context, err error, message string) *customError.CustomError {
+	translated := mapError(err, message)
+	if translated.Status >= http.StatusInternalServerError {
+		slog.ErrorContext(ctx, message, "error", err, "code", translated.Kind())
+	}
+	return translated
+}
+
+func mapError(err error, message string) *customError.CustomError {
+	switch {
+	case errors.Is(err, mongo.ErrNoDocuments), errors.Is(err, sql.ErrNoRows):
+		return groupNotFound()
+	case errors.Is(err, context.DeadlineExceeded):
+		return customError.NewCustomError(
+			customError.WithCustomError(http.StatusGatewayTimeout, err.Error(), message),
+			customError.WithCode(customError.DeadlineExceeded),
+		)
+	case errors.Is(err, context.Canceled):
+		return customError.NewCustomError(
+			customError.WithCustomError(customError.StatusClientClosedRequest, err.Error(), message),
+			customError.WithCode(customError.RequestCanceled),
+		)
+	case mongo.IsDuplicateKeyError(err):
+		return customError.NewCustomError(
+			customError.WithCustomError(http.StatusConflict, err.Error(), message),
+			customError.WithCode(customError.AlreadyExists),
+		)
+	case mongo.IsTimeout(err), mongo.IsNetworkError(err), errors.Is(err, driver.ErrBadConn), errors.Is(err, sql.ErrConnDone):
+		return customError.NewCustomError(
+			customError.WithCustomError(http.StatusServiceUnavailable, err.Error(), message),
+			customError.WithCode(customError.ServiceUnavailable),
+		)
+	case hasErrorCode(err, documentValidationFailure):
+		return customError.NewCustomError(
+			customError.WithCustomError(http.StatusUnprocessableEntity, err.Error(), message),
+			customError.WithCode(customError.DocumentRejected),
+		)
+	default:
+		return customError.NewCustomError(customError.WithInternalServerError(err.Error(), message))
+	}
+}
+
+func hasErrorCode(err error, code int) bool {
+	var serverErr mongo.ServerError
+	return errors.As(err, &serverErr) && serverErr.HasErrorCode(code)
+}
+
+func groupNotFound() *customError.CustomError {
+	return customError.NewCustomError(customError.WithNotFound("Group not found", "No group found with the given ID"), customError.WithCode(customError.GroupNotFound))
+}
+
+func erasureNotFound() *customError.CustomError {
+	return customError.NewCustomError(customError.WithNotFound("Erasure not found", "No erasure certificate found with the given ID"), customError.WithCode(customError.ErasureNotFound))
+}
+
+func invalidErasureID() *customError.CustomError {
+	return customError.NewCustomError(customError.WithBadRequest("Invalid erasure ID", "Invalid ID format"), customError.WithCode(customError.BadRequest))
+}
+
+func invalidGroupID() *customError.CustomError {
+	return customError.NewCustomError(customError.WithBadRequest("Invalid group ID", "Invalid ID format"), customError.WithCode(customError.GroupIdInvalid))
+}
+
+func groupVersionMismatch() *customError.CustomError {
+	return customError.NewCustomError(customError.WithPreconditionFailed("Group version mismatch", "The group was modified by someone else, reload it and try again"), customError.WithCode(customError.GroupVersionMismatch))
+}
+
+func participantDuplicate() *customError.CustomError {
+	return customError.NewCustomError(
+		customError.WithCustomError(http.StatusConflict, "Participant already in group", "A participant with this email is already in the group"),
+		customError.WithCode(customError.ParticipantDuplicate),
+	)
+}
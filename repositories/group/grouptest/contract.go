@@ -0,0 +1,718 @@
+// Package grouptest holds the behaviour every group.Repository must share, so
+// the Mongo, SQL and in-memory implementations can be checked against each
+// other.
+package grouptest
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"service-secret-santa/customError"
+	"service-secret-santa/models"
+	"service-secret-santa/repositories/group"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// RunContract runs the contract against repositories built by newRepository,
+// which must return an empty repository on every call.
+func RunContract(t *testing.T, newRepository func(t *testing.T) group.Repository) {
+	tests := []struct {
+		name string
+		run  func(t *testing.T, repo group.Repository)
+	}{
+		{"CreateAndGet", testCreateAndGet},
+		{"GetErrors", testGetErrors},
+		{"UpdateChecksVersion", testUpdateChecksVersion},
+		{"DeleteChecksVersion", testDeleteChecksVersion},
+		{"DeletedGroupsAreHidden", testDeletedGroupsAreHidden},
+		{"TrashAndRestore", testTrashAndRestore},
+		{"PurgeTrash", testPurgeTrash},
+		{"RetainedGroups", testRetainedGroups},
+		{"AnonymizeGroup", testAnonymizeGroup},
+		{"AppendAudit", testAppendAudit},
+		{"AuditEvents", testAuditEvents},
+		{"RewriteAuditEvents", testRewriteAuditEvents},
+		{"GroupsByEmail", testGroupsByEmail},
+		{"RewriteGroup", testRewriteGroup},
+		{"Erasures", testErasures},
+		{"AddParticipant", testAddParticipant},
+		{"UpdateMatchesAndGetMyMatch", testUpdateMatchesAndGetMyMatch},
+		{"GetAllGroupsPages", testGetAllGroupsPages},
+		{"GetAllGroupsFilters", testGetAllGroupsFilters},
+		{"ListingsAreSummaries", testListingsAreSummaries},
+		{"SearchGroups", testSearchGroups},
+		{"SearchGroupsRanksCandidates", testSearchGroupsRanksCandidates},
+		{"CanceledContext", testCanceledContext},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.run(t, newRepository(t))
+		})
+	}
+}
+
+func newGroup(name, owner string, createdAt time.Time, participants ...models.Participant) *models.Group {
+	return &models.Group{
+		Name:         name,
+		Owner:        owner,
+		Status:       models.GroupStatusOpen,
+		Participants: participants,
+		CreatedAt:    createdAt,
+		UpdatedAt:    createdAt,
+	}
+}
+
+func create(t *testing.T, repo group.Repository, g *models.Group) *models.Group {
+	created, err := repo.CreateGroup(context.Background(), g)
+	require.Nil(t, err)
+	return created
+}
+
+func assertCode(t *testing.T, err *customError.CustomError, status int, code customError.ErrorCode) {
+	t.Helper()
+	if assert.NotNil(t, err) {
+		assert.Equal(t, status, err.Status)
+		assert.Equal(t, code, err.Kind())
+	}
+}
+
+func testCreateAndGet(t *testing.T, repo group.Repository) {
+	createdAt := time.Date(2024, 12, 1, 10, 0, 0, 123456789, time.UTC)
+	created := create(t, repo, newGroup("Família", "ana@example.com", createdAt))
+
+	assert.False(t, created.Id.IsZero())
+	assert.Equal(t, int64(1), created.Version)
+	assert.NotNil(t, created.Participants)
+
+	stored, err := repo.GetGroupByID(context.Background(), created.Id.Hex())
+	require.Nil(t, err)
+	assert.Equal(t, "Família", stored.Name)
+	assert.Equal(t, "ana@example.com", stored.Owner)
+	assert.Equal(t, models.GroupStatusOpen, stored.Status)
+	assert.Equal(t, int64(1), stored.Version)
+	assert.Empty(t, stored.Participants)
+	assert.Equal(t, createdAt.Truncate(time.Millisecond), stored.CreatedAt)
+
+	_, err = repo.CreateGroup(context.Background(), &models.Group{Id: created.Id, Name: "Again"})
+	assertCode(t, err, 409, customError.AlreadyExists)
+}
+
+func testGetErrors(t *testing.T, repo group.Repository) {
+	_, err := repo.GetGroupByID(context.Background(), "not-an-id")
+	assertCode(t, err, 400, customError.GroupIdInvalid)
+
+	_, err = repo.GetGroupByID(context.Background(), primitive.NewObjectID().Hex())
+	assertCode(t, err, 404, customError.GroupNotFound)
+}
+
+func testUpdateChecksVersion(t *testing.T, repo group.Repository) {
+	created := create(t, repo, newGroup("Trabalho", "ana@example.com", time.Now()))
+	id := created.Id.Hex()
+
+	update := &models.GroupUpdate{Name: "Escritório", Owner: "bia@example.com", Participants: []models.Participant{{Name: "Bia", Email: "bia@example.com"}}}
+	updated, err := repo.UpdateGroup(context.Background(), id, 1, update)
+	require.Nil(t, err)
+	assert.Equal(t, "Escritório", updated.Name)
+	assert.Equal(t, "bia@example.com", updated.Owner)
+	assert.Len(t, updated.Participants, 1)
+	assert.Equal(t, int64(2), updated.Version)
+
+	_, err = repo.UpdateGroup(context.Background(), id, 1, update)
+	assertCode(t, err, 412, customError.GroupVersionMismatch)
+
+	updated, err = repo.UpdateGroup(context.Background(), id, models.AnyVersion, update)
+	require.Nil(t, err)
+	assert.Equal(t, int64(3), updated.Version)
+
+	_, err = repo.UpdateGroup(context.Background(), primitive.NewObjectID().Hex(), 1, update)
+	assertCode(t, err, 404, customError.GroupNotFound)
+}
+
+func testDeleteChecksVersion(t *testing.T, repo group.Repository) {
+	created := create(t, repo, newGroup("Amigos", "ana@example.com", time.Now()))
+	id := created.Id.Hex()
+
+	assertCode(t, repo.DeleteGroup(context.Background(), id, 7), 412, customError.GroupVersionMismatch)
+	assert.Nil(t, repo.DeleteGroup(context.Background(), id, 1))
+
+	_, err := repo.GetGroupByID(context.Background(), id)
+	assertCode(t, err, 404, customError.GroupNotFound)
+	assertCode(t, repo.DeleteGroup(context.Background(), id, models.AnyVersion), 404, customError.GroupNotFound)
+}
+
+func testDeletedGroupsAreHidden(t *testing.T, repo group.Repository) {
+	created := create(t, repo, newGroup("Amigos", "ana@example.com", time.Now(),
+		models.Participant{Name: "João", Email: "joao@example.com"},
+		models.Participant{Name: "Maria", Email: "maria@example.com"},
+	))
+	id := created.Id.Hex()
+	require.Nil(t, repo.UpdateMatches(context.Background(), id, 1, []models.Match{{First: "João", Second: "Maria"}, {First: "Maria", Second: "João"}}))
+	require.Nil(t, repo.DeleteGroup(context.Background(), id, 2))
+
+	_, err := repo.GetMyMatch(context.Background(), id, "João")
+	assertCode(t, err, 404, customError.GroupNotFound)
+	_, err = repo.UpdateGroup(context.Background(), id, models.AnyVersion, &models.GroupUpdate{Name: "Outro", Participants: []models.Participant{}})
+	assertCode(t, err, 404, customError.GroupNotFound)
+	_, err = repo.AddParticipant(context.Background(), id, models.AnyVersion, &models.Participant{Name: "João", Email: "joao@example.com"})
+	assertCode(t, err, 404, customError.GroupNotFound)
+	assertCode(t, repo.UpdateMatches(context.Background(), id, models.AnyVersion, []models.Match{}), 404, customError.GroupNotFound)
+
+	page, err := repo.GetAllGroups(context.Background(), models.GroupQuery{Limit: 10, Sort: models.SortByName, Order: models.SortAsc, ParticipantEmail: "joao@example.com"})
+	require.Nil(t, err)
+	assert.Empty(t, page.Items)
+	searched, err := repo.SearchGroups(context.Background(), "amigos")
+	require.Nil(t, err)
+	assert.Empty(t, searched)
+}
+
+func testTrashAndRestore(t *testing.T, repo group.Repository) {
+	first := create(t, repo, newGroup("Amigos", "ana@example.com", time.Now(), models.Participant{Name: "Bia", Email: "bia@example.com"}))
+	second := create(t, repo, newGroup("Trabalho", "caio@example.com", time.Now()))
+	create(t, repo, newGroup("Família", "ana@example.com", time.Now()))
+
+	trash, err := repo.GetTrash(context.Background(), "")
+	require.Nil(t, err)
+	assert.Empty(t, trash)
+
+	require.Nil(t, repo.DeleteGroup(context.Background(), first.Id.Hex(), 1))
+	time.Sleep(2 * time.Millisecond)
+	require.Nil(t, repo.DeleteGroup(context.Background(), second.Id.Hex(), 1))
+
+	trash, err = repo.GetTrash(context.Background(), "")
+	require.Nil(t, err)
+	if assert.Len(t, trash, 2) {
+		assert.Equal(t, "Trabalho", trash[0].Name)
+		assert.Equal(t, "Amigos", trash[1].Name)
+		assert.Equal(t, 1, trash[1].ParticipantCount)
+		assert.Nil(t, trash[1].Participants)
+		assert.Equal(t, int64(2), trash[1].Version)
+		assert.False(t, trash[1].DeletedAt.IsZero())
+	}
+
+	trash, err = repo.GetTrash(context.Background(), "ANA@example.com")
+	require.Nil(t, err)
+	if assert.Len(t, trash, 1) {
+		assert.Equal(t, "Amigos", trash[0].Name)
+	}
+
+	_, err = repo.RestoreGroup(context.Background(), first.Id.Hex(), 1)
+	assertCode(t, err, 412, customError.GroupVersionMismatch)
+	restored, err := repo.RestoreGroup(context.Background(), first.Id.Hex(), 2)
+	require.Nil(t, err)
+	assert.Equal(t, int64(3), restored.Version)
+	assert.True(t, restored.DeletedAt.IsZero())
+	assert.Equal(t, []models.Participant{{Name: "Bia", Email: "bia@example.com"}}, restored.Participants)
+
+	stored, err := repo.GetGroupByID(context.Background(), first.Id.Hex())
+	require.Nil(t, err)
+	assert.Equal(t, "Amigos", stored.Name)
+
+	_, err = repo.RestoreGroup(context.Background(), first.Id.Hex(), models.AnyVersion)
+	assertCode(t, err, 404, customError.GroupNotFound)
+	_, err = repo.RestoreGroup(context.Background(), primitive.NewObjectID().Hex(), models.AnyVersion)
+	assertCode(t, err, 404, customError.GroupNotFound)
+	_, err = repo.RestoreGroup(context.Background(), "not-an-id", models.AnyVersion)
+	assertCode(t, err, 400, customError.GroupIdInvalid)
+
+	trash, err = repo.GetTrash(context.Background(), "")
+	require.Nil(t, err)
+	assert.Len(t, trash, 1)
+}
+
+func testPurgeTrash(t *testing.T, repo group.Repository) {
+	old := create(t, repo, newGroup("Antigo", "ana@example.com", time.Now(), models.Participant{Name: "Bia", Email: "bia@example.com"}))
+	live := create(t, repo, newGroup("Vivo", "ana@example.com", time.Now()))
+	require.Nil(t, repo.DeleteGroup(context.Background(), old.Id.Hex(), 1))
+
+	purged, err := repo.PurgeTrash(context.Background(), time.Now().Add(-time.Hour))
+	require.Nil(t, err)
+	assert.Equal(t, 0, purged)
+
+	purged, err = repo.PurgeTrash(context.Background(), time.Now().Add(time.Second))
+	require.Nil(t, err)
+	assert.Equal(t, 1, purged)
+
+	trash, err := repo.GetTrash(context.Background(), "")
+	require.Nil(t, err)
+	assert.Empty(t, trash)
+	_, err = repo.RestoreGroup(context.Background(), old.Id.Hex(), models.AnyVersion)
+	assertCode(t, err, 404, customError.GroupNotFound)
+
+	_, err = repo.GetGroupByID(context.Background(), live.Id.Hex())
+	assert.Nil(t, err)
+}
+
+func testRetainedGroups(t *testing.T, repo group.Repository) {
+	now := time.Now()
+	exchanged := newGroup("Trocado", "ana@example.com", now)
+	exchanged.ExchangeDate = now.Add(-48 * time.Hour)
+	exchanged = create(t, repo, exchanged)
+	upcoming := newGroup("Futuro", "ana@example.com", now.Add(-72*time.Hour))
+	upcoming.ExchangeDate = now.Add(48 * time.Hour)
+	create(t, repo, upcoming)
+	undated := create(t, repo, newGroup("Sem data", "ana@example.com", now.Add(-72*time.Hour)))
+	create(t, repo, newGroup("Recente", "ana@example.com", now))
+	trashed := create(t, repo, newGroup("Na lixeira", "ana@example.com", now.Add(-72*time.Hour)))
+	require.Nil(t, repo.DeleteGroup(context.Background(), trashed.Id.Hex(), models.AnyVersion))
+
+	query := models.RetentionQuery{ExchangedBefore: now.Add(-24 * time.Hour), Limit: 10}
+	groups, err := repo.GetRetainedGroups(context.Background(), query)
+	require.Nil(t, err)
+	assert.ElementsMatch(t, []string{exchanged.Id.Hex(), undated.Id.Hex(), trashed.Id.Hex()}, ids(groups))
+	assert.Equal(t, exchanged.ExchangeDate.Truncate(time.Millisecond).UTC(), groupWithID(groups, exchanged.Id.Hex()).ExchangeDate)
+
+	marked, err := repo.MarkPurgeWarned(context.Background(), exchanged.Id.Hex())
+	require.Nil(t, err)
+	assert.True(t, marked)
+	marked, err = repo.MarkPurgeWarned(context.Background(), exchanged.Id.Hex())
+	require.Nil(t, err)
+	assert.False(t, marked, "the warning is recorded once")
+
+	groups, err = repo.GetRetainedGroups(context.Background(), query)
+	require.Nil(t, err)
+	assert.ElementsMatch(t, []string{undated.Id.Hex(), trashed.Id.Hex()}, ids(groups))
+
+	query.WarnedBefore = time.Now().Add(time.Second)
+	groups, err = repo.GetRetainedGroups(context.Background(), query)
+	require.Nil(t, err)
+	assert.Equal(t, []string{exchanged.Id.Hex()}, ids(groups))
+	assert.False(t, groups[0].PurgeWarnedAt.IsZero())
+
+	query.WarnedBefore = now.Add(-time.Hour)
+	groups, err = repo.GetRetainedGroups(context.Background(), query)
+	require.Nil(t, err)
+	assert.Empty(t, groups)
+
+	require.Nil(t, repo.UnmarkPurgeWarned(context.Background(), exchanged.Id.Hex()))
+	query.WarnedBefore = time.Time{}
+	groups, err = repo.GetRetainedGroups(context.Background(), query)
+	require.Nil(t, err)
+	assert.ElementsMatch(t, []string{exchanged.Id.Hex(), undated.Id.Hex(), trashed.Id.Hex()}, ids(groups))
+	assert.True(t, groupWithID(groups, exchanged.Id.Hex()).PurgeWarnedAt.IsZero())
+	assert.Equal(t, 1, groupWithID(groups, exchanged.Id.Hex()).PurgeWarnFailures)
+
+	marked, err = repo.MarkPurgeWarned(context.Background(), trashed.Id.Hex())
+	require.Nil(t, err)
+	assert.True(t, marked, "trashed groups are warned too")
+
+	_, err = repo.MarkPurgeWarned(context.Background(), "6787c4a755ea623ab45e77d4")
+	assertCode(t, err, 404, customError.GroupNotFound)
+	assertCode(t, repo.UnmarkPurgeWarned(context.Background(), "6787c4a755ea623ab45e77d4"), 404, customError.GroupNotFound)
+}
+
+func testAnonymizeGroup(t *testing.T, repo group.Repository) {
+	created := create(t, repo, newGroup("Amigos", "ana@example.com", time.Now(),
+		models.Participant{Name: "Ana", Email: "ana@example.com"},
+		models.Participant{Name: "Bia", Email: "bia@example.com"},
+	))
+	id := created.Id.Hex()
+	require.Nil(t, repo.UpdateMatches(context.Background(), id, 1, []models.Match{{First: "Ana", Second: "Bia"}, {First: "bia", Second: "Ana"}}))
+
+	anonymized, err := repo.AnonymizeGroup(context.Background(), id)
+	require.Nil(t, err)
+	assert.Equal(t, []models.Participant{{Name: "Participant 1"}, {Name: "Participant 2"}}, anonymized.Participants)
+	assert.Equal(t, []models.Match{{First: "Participant 1", Second: "Participant 2"}, {First: "Participant 2", Second: "Participant 1"}}, anonymized.Matches)
+	assert.Empty(t, anonymized.Owner)
+	assert.False(t, anonymized.AnonymizedAt.IsZero())
+	assert.Equal(t, int64(3), anonymized.Version)
+
+	loaded, err := repo.GetGroupByID(context.Background(), id)
+	require.Nil(t, err)
+	assert.Equal(t, anonymized.Participants, loaded.Participants)
+	assert.Equal(t, anonymized.Matches, loaded.Matches)
+	assert.Equal(t, "Amigos", loaded.Name)
+	assert.Equal(t, models.GroupStatusDrawn, loaded.Status)
+	assert.Empty(t, loaded.Owner)
+	assert.Equal(t, anonymized.AnonymizedAt, loaded.AnonymizedAt)
+
+	groups, err := repo.GetRetainedGroups(context.Background(), models.RetentionQuery{ExchangedBefore: time.Now().Add(time.Hour), Limit: 10})
+	require.Nil(t, err)
+	assert.Empty(t, groups)
+
+	_, err = repo.AnonymizeGroup(context.Background(), id)
+	assertCode(t, err, 404, customError.GroupNotFound)
+	_, err = repo.MarkPurgeWarned(context.Background(), id)
+	assertCode(t, err, 404, customError.GroupNotFound)
+	assertCode(t, repo.UnmarkPurgeWarned(context.Background(), id), 404, customError.GroupNotFound)
+
+	trashed := create(t, repo, newGroup("Na lixeira", "ana@example.com", time.Now(), models.Participant{Name: "Caio", Email: "caio@example.com"}))
+	require.Nil(t, repo.DeleteGroup(context.Background(), trashed.Id.Hex(), models.AnyVersion))
+	_, err = repo.AnonymizeGroup(context.Background(), trashed.Id.Hex())
+	require.Nil(t, err)
+
+	restored, err := repo.RestoreGroup(context.Background(), trashed.Id.Hex(), models.AnyVersion)
+	require.Nil(t, err)
+	assert.Equal(t, []models.Participant{{Name: "Participant 1"}}, restored.Participants)
+	assert.Empty(t, restored.Owner)
+}
+
+func testAppendAudit(t *testing.T, repo group.Repository) {
+	created := create(t, repo, newGroup("Amigos", "ana@example.com", time.Now()))
+
+	event := &models.AuditEvent{GroupId: created.Id, Action: models.AuditPurgeWarned, Actor: models.AuditActorSystem, Detail: "owner notified"}
+	require.Nil(t, repo.AppendAudit(context.Background(), event))
+	assert.False(t, event.Id.IsZero())
+	assert.False(t, event.At.IsZero())
+}
+
+func testAuditEvents(t *testing.T, repo group.Repository) {
+	created := create(t, repo, newGroup("Amigos", "ana@example.com", time.Now()))
+	other := create(t, repo, newGroup("Outro", "ana@example.com", time.Now()))
+	start := time.Now().Add(-time.Hour).Truncate(time.Millisecond).UTC()
+
+	events := []*models.AuditEvent{
+		{GroupId: created.Id, Action: models.AuditCreated, Actor: "ana@example.com", At: start, RequestId: "req-1"},
+		{GroupId: created.Id, Action: models.AuditUpdated, Actor: "ana@example.com", At: start.Add(time.Minute), RequestId: "req-2",
+			Changes: []models.AuditChange{{Field: "name", Before: `"Amigos"`, After: `"Família"`}}},
+		{GroupId: created.Id, Action: models.AuditParticipantRemoved, Actor: "bia@example.com", At: start.Add(2 * time.Minute), Detail: "Caio"},
+		{GroupId: other.Id, Action: models.AuditCreated, Actor: "ana@example.com", At: start},
+	}
+	for _, event := range events {
+		require.Nil(t, repo.AppendAudit(context.Background(), event))
+	}
+
+	all, err := repo.GetAuditEvents(context.Background(), created.Id.Hex(), models.AuditQuery{Limit: 10})
+	require.Nil(t, err)
+	require.Len(t, all, 3)
+	assert.Equal(t, *events[0], all[0])
+	assert.Equal(t, *events[1], all[1])
+	assert.Equal(t, *events[2], all[2])
+
+	filtered, err := repo.GetAuditEvents(context.Background(), created.Id.Hex(), models.AuditQuery{Actor: "ana@example.com", Since: start.Add(time.Minute), Limit: 10})
+	require.Nil(t, err)
+	assert.Equal(t, []models.AuditEvent{*events[1]}, filtered)
+
+	filtered, err = repo.GetAuditEvents(context.Background(), created.Id.Hex(), models.AuditQuery{Action: models.AuditParticipantRemoved, Until: start.Add(2 * time.Minute), Limit: 10})
+	require.Nil(t, err)
+	assert.Empty(t, filtered)
+
+	limited, err := repo.GetAuditEvents(context.Background(), created.Id.Hex(), models.AuditQuery{Limit: 2})
+	require.Nil(t, err)
+	assert.Equal(t, []models.AuditEvent{*events[0], *events[1]}, limited)
+
+	require.Nil(t, repo.DeleteGroup(context.Background(), created.Id.Hex(), 1))
+	kept, err := repo.GetAuditEvents(context.Background(), created.Id.Hex(), models.AuditQuery{Limit: 10})
+	require.Nil(t, err)
+	assert.Len(t, kept, 3, "the audit log outlives the group")
+
+	_, err = repo.GetAuditEvents(context.Background(), "invalid", models.AuditQuery{Limit: 10})
+	assertCode(t, err, 400, customError.GroupIdInvalid)
+}
+
+func testRewriteAuditEvents(t *testing.T, repo group.Repository) {
+	created := create(t, repo, newGroup("Amigos", "ana@example.com", time.Now()))
+	other := create(t, repo, newGroup("Outro", "bia@example.com", time.Now()))
+	start := time.Now().Add(-time.Hour).Truncate(time.Millisecond).UTC()
+
+	events := []*models.AuditEvent{
+		{GroupId: created.Id, Action: models.AuditCreated, Actor: "ana@example.com", At: start,
+			Changes: []models.AuditChange{{Field: "owner", After: `"ana@example.com"`}}},
+		{GroupId: created.Id, Action: models.AuditParticipantAdded, Actor: "bia@example.com", At: start.Add(time.Minute), Detail: "Ana"},
+		{GroupId: other.Id, Action: models.AuditUpdated, Actor: "ana@example.com", At: start.Add(2 * time.Minute)},
+	}
+	for _, event := range events {
+		require.Nil(t, repo.AppendAudit(context.Background(), event))
+	}
+
+	acted, err := repo.GetAuditEventsByActor(context.Background(), "ana@example.com")
+	require.Nil(t, err)
+	assert.Equal(t, []models.AuditEvent{*events[0], *events[2]}, acted)
+
+	redacted := *events[0]
+	redacted.Actor = models.AuditRedacted
+	redacted.Changes = []models.AuditChange{{Field: "owner", After: `"redacted"`}}
+	require.Nil(t, repo.RewriteAuditEvents(context.Background(), []models.AuditEvent{redacted}))
+
+	all, err := repo.GetAuditEvents(context.Background(), created.Id.Hex(), models.AuditQuery{})
+	require.Nil(t, err)
+	assert.Equal(t, []models.AuditEvent{redacted, *events[1]}, all, "a zero limit reads them all")
+
+	acted, err = repo.GetAuditEventsByActor(context.Background(), "ana@example.com")
+	require.Nil(t, err)
+	assert.Equal(t, []models.AuditEvent{*events[2]}, acted)
+}
+
+func testGroupsByEmail(t *testing.T, repo group.Repository) {
+	owned := create(t, repo, newGroup("Dono", "Ana@Example.com", time.Now()))
+	joined := create(t, repo, newGroup("Membro", "bia@example.com", time.Now(), models.Participant{Name: "Ana", Email: "ana@example.com"}))
+	trashed := create(t, repo, newGroup("Apagado", "bia@example.com", time.Now(), models.Participant{Name: "Ana", Email: "ANA@example.com"}))
+	create(t, repo, newGroup("Outro", "bia@example.com", time.Now(), models.Participant{Name: "Bia", Email: "bia@example.com"}))
+	require.Nil(t, repo.DeleteGroup(context.Background(), trashed.Id.Hex(), 1))
+
+	groups, err := repo.GetGroupsByEmail(context.Background(), "ana@example.com")
+	require.Nil(t, err)
+	assert.ElementsMatch(t, []string{owned.Id.Hex(), joined.Id.Hex(), trashed.Id.Hex()}, ids(groups))
+	assert.Equal(t, []models.Participant{{Name: "Ana", Email: "ana@example.com"}}, groupWithID(groups, joined.Id.Hex()).Participants)
+	assert.False(t, groupWithID(groups, trashed.Id.Hex()).DeletedAt.IsZero())
+
+	groups, err = repo.GetGroupsByEmail(context.Background(), "nobody@example.com")
+	require.Nil(t, err)
+	assert.Empty(t, groups)
+}
+
+func testRewriteGroup(t *testing.T, repo group.Repository) {
+	created := create(t, repo, newGroup("Amigos", "ana@example.com", time.Now(),
+		models.Participant{Name: "Ana", Email: "ana@example.com"},
+		models.Participant{Name: "Bia", Email: "bia@example.com"},
+		models.Participant{Name: "Caio", Email: "caio@example.com"},
+	))
+	id := created.Id.Hex()
+	require.Nil(t, repo.UpdateMatches(context.Background(), id, 1, []models.Match{{First: "Ana", Second: "Bia"}, {First: "Bia", Second: "Caio"}, {First: "Caio", Second: "Ana"}}))
+
+	rewrite := &models.GroupRewrite{
+		Status:       models.GroupStatusDrawn,
+		Participants: []models.Participant{{Name: "Bia", Email: "bia@example.com"}, {Name: "Caio", Email: "caio@example.com"}},
+		Matches:      []models.Match{{First: "Bia", Second: "Caio"}, {First: "Caio", Second: "Bia"}},
+	}
+	_, err := repo.RewriteGroup(context.Background(), id, 1, rewrite)
+	assertCode(t, err, 412, customError.GroupVersionMismatch)
+
+	rewritten, err := repo.RewriteGroup(context.Background(), id, 2, rewrite)
+	require.Nil(t, err)
+	assert.Empty(t, rewritten.Owner)
+	assert.Equal(t, rewrite.Participants, rewritten.Participants)
+	assert.Equal(t, rewrite.Matches, rewritten.Matches)
+	assert.Equal(t, int64(3), rewritten.Version)
+
+	loaded, err := repo.GetGroupByID(context.Background(), id)
+	require.Nil(t, err)
+	assert.Equal(t, rewrite.Participants, loaded.Participants)
+	assert.Equal(t, rewrite.Matches, loaded.Matches)
+	assert.Equal(t, 2, loaded.ParticipantCount)
+
+	require.Nil(t, repo.DeleteGroup(context.Background(), id, 3))
+	rewritten, err = repo.RewriteGroup(context.Background(), id, 4, &models.GroupRewrite{Status: models.GroupStatusOpen})
+	require.Nil(t, err)
+	assert.Empty(t, rewritten.Participants)
+	assert.False(t, rewritten.DeletedAt.IsZero(), "a trashed group stays in the trash")
+
+	_, err = repo.RewriteGroup(context.Background(), "6787c4a755ea623ab45e77d4", models.AnyVersion, rewrite)
+	assertCode(t, err, 404, customError.GroupNotFound)
+}
+
+func testErasures(t *testing.T, repo group.Repository) {
+	certificate := &models.ErasureCertificate{SubjectHash: "abc", ErasedAt: time.Now(), Groups: []string{"6787c4a755ea623ab45e77d4"}, ParticipantsRemoved: 2, DrawsRepaired: 1}
+	require.Nil(t, repo.RecordErasure(context.Background(), certificate))
+	assert.False(t, certificate.Id.IsZero())
+
+	loaded, err := repo.GetErasure(context.Background(), certificate.Id.Hex())
+	require.Nil(t, err)
+	assert.Equal(t, certificate, loaded)
+
+	_, err = repo.GetErasure(context.Background(), "6787c4a755ea623ab45e77d4")
+	assertCode(t, err, 404, customError.ErasureNotFound)
+	_, err = repo.GetErasure(context.Background(), "not-an-id")
+	assertCode(t, err, 400, customError.BadRequest)
+}
+
+func ids(groups []*models.Group) []string {
+	res := make([]string, 0, len(groups))
+	for _, g := range groups {
+		res = append(res, g.Id.Hex())
+	}
+	return res
+}
+
+func groupWithID(groups []*models.Group, id string) *models.Group {
+	for _, g := range groups {
+		if g.Id.Hex() == id {
+			return g
+		}
+	}
+	return &models.Group{}
+}
+
+func testAddParticipant(t *testing.T, repo group.Repository) {
+	created := create(t, repo, newGroup("Amigos", "ana@example.com", time.Now(), models.Participant{Name: "Ana", Email: "ana@example.com"}))
+	id := created.Id.Hex()
+
+	updated, err := repo.AddParticipant(context.Background(), id, 1, &models.Participant{Name: "Bia", Email: "bia@example.com"})
+	require.Nil(t, err)
+	assert.Equal(t, []models.Participant{{Name: "Ana", Email: "ana@example.com"}, {Name: "Bia", Email: "bia@example.com"}}, updated.Participants)
+	assert.Equal(t, 2, updated.ParticipantCount)
+	assert.Equal(t, int64(2), updated.Version)
+
+	_, err = repo.AddParticipant(context.Background(), id, models.AnyVersion, &models.Participant{Name: "Ana Clara", Email: "ANA@example.com"})
+	assertCode(t, err, 409, customError.ParticipantDuplicate)
+
+	_, err = repo.AddParticipant(context.Background(), id, 1, &models.Participant{Name: "Caio", Email: "caio@example.com"})
+	assertCode(t, err, 412, customError.GroupVersionMismatch)
+
+	_, err = repo.AddParticipant(context.Background(), primitive.NewObjectID().Hex(), models.AnyVersion, &models.Participant{Name: "Caio", Email: "caio@example.com"})
+	assertCode(t, err, 404, customError.GroupNotFound)
+}
+
+func testUpdateMatchesAndGetMyMatch(t *testing.T, repo group.Repository) {
+	created := create(t, repo, newGroup("Amigos", "ana@example.com", time.Now(),
+		models.Participant{Name: "João", Email: "joao@example.com"},
+		models.Participant{Name: "Maria", Email: "maria@example.com"},
+	))
+	id := created.Id.Hex()
+	matches := []models.Match{{First: "João", Second: "Maria"}, {First: "Maria", Second: "João"}}
+
+	assertCode(t, repo.UpdateMatches(context.Background(), id, 5, matches), 412, customError.GroupVersionMismatch)
+	require.Nil(t, repo.UpdateMatches(context.Background(), id, 1, matches))
+
+	stored, err := repo.GetGroupByID(context.Background(), id)
+	require.Nil(t, err)
+	assert.Equal(t, models.GroupStatusDrawn, stored.Status)
+	assert.Equal(t, matches, stored.Matches)
+	assert.Equal(t, int64(2), stored.Version)
+
+	match, err := repo.GetMyMatch(context.Background(), id, " joao ")
+	require.Nil(t, err)
+	assert.Equal(t, "Maria", match)
+
+	_, err = repo.GetMyMatch(context.Background(), id, "Pedro")
+	assertCode(t, err, 404, customError.MatchNotFound)
+}
+
+func testGetAllGroupsPages(t *testing.T, repo group.Repository) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i, name := range []string{"Delta", "Alfa", "Eco", "Charlie", "Bravo"} {
+		create(t, repo, newGroup(name, "ana@example.com", start.Add(time.Duration(i)*time.Hour)))
+	}
+
+	collect := func(sort, order string) []string {
+		var names []string
+		query := models.GroupQuery{Limit: 2, Sort: sort, Order: order}
+		for {
+			page, err := repo.GetAllGroups(context.Background(), query)
+			require.Nil(t, err)
+			for _, g := range page.Items {
+				names = append(names, g.Name)
+			}
+			if !page.Paging.HasMore {
+				assert.Empty(t, page.Paging.NextCursor)
+				return names
+			}
+			query.Cursor = page.Paging.NextCursor
+		}
+	}
+
+	assert.Equal(t, []string{"Alfa", "Bravo", "Charlie", "Delta", "Eco"}, collect(models.SortByName, models.SortAsc))
+	assert.Equal(t, []string{"Bravo", "Charlie", "Eco", "Alfa", "Delta"}, collect(models.SortByCreatedAt, models.SortDesc))
+
+	page, err := repo.GetAllGroups(context.Background(), models.GroupQuery{Limit: 2, Sort: models.SortByName, Order: models.SortAsc})
+	require.Nil(t, err)
+	_, err = repo.GetAllGroups(context.Background(), models.GroupQuery{Limit: 2, Sort: models.SortByName, Order: models.SortDesc, Cursor: page.Paging.NextCursor})
+	assertCode(t, err, 400, customError.CursorInvalid)
+	_, err = repo.GetAllGroups(context.Background(), models.GroupQuery{Limit: 2, Sort: models.SortByName, Order: models.SortAsc, Cursor: "%%%"})
+	assertCode(t, err, 400, customError.CursorInvalid)
+}
+
+func testGetAllGroupsFilters(t *testing.T, repo group.Repository) {
+	now := time.Now()
+	create(t, repo, newGroup("Família São João", "ana@example.com", now, models.Participant{Name: "Bia", Email: "bia@example.com"}))
+	create(t, repo, newGroup("Trabalho", "caio@example.com", now))
+	drawn := create(t, repo, newGroup("Faculdade", "ana@example.com", now))
+	require.Nil(t, repo.UpdateMatches(context.Background(), drawn.Id.Hex(), models.AnyVersion, []models.Match{}))
+
+	names := func(query models.GroupQuery) []string {
+		query.Limit, query.Sort, query.Order = 10, models.SortByName, models.SortAsc
+		page, err := repo.GetAllGroups(context.Background(), query)
+		require.Nil(t, err)
+		result := []string{}
+		for _, g := range page.Items {
+			result = append(result, g.Name)
+		}
+		return result
+	}
+
+	assert.Equal(t, []string{"Família São João"}, names(models.GroupQuery{Name: "sao joao"}))
+	assert.Equal(t, []string{"Faculdade", "Família São João"}, names(models.GroupQuery{Owner: "ANA@example.com"}))
+	assert.Equal(t, []string{"Família São João"}, names(models.GroupQuery{ParticipantEmail: "Bia@Example.com"}))
+	assert.Equal(t, []string{"Faculdade"}, names(models.GroupQuery{Status: models.GroupStatusDrawn}))
+	assert.Equal(t, []string{}, names(models.GroupQuery{Owner: "ana@example"}))
+}
+
+func testListingsAreSummaries(t *testing.T, repo group.Repository) {
+	created := create(t, repo, newGroup("Amigos", "ana@example.com", time.Now(),
+		models.Participant{Name: "João", Email: "joao@example.com"},
+		models.Participant{Name: "Maria", Email: "maria@example.com"},
+	))
+	require.Nil(t, repo.UpdateMatches(context.Background(), created.Id.Hex(), models.AnyVersion, []models.Match{{First: "João", Second: "Maria"}, {First: "Maria", Second: "João"}}))
+
+	page, err := repo.GetAllGroups(context.Background(), models.GroupQuery{Limit: 10, Sort: models.SortByName, Order: models.SortAsc})
+	require.Nil(t, err)
+	searched, err := repo.SearchGroups(context.Background(), "amigos")
+	require.Nil(t, err)
+
+	for _, listed := range [][]*models.Group{page.Items, searched} {
+		require.Len(t, listed, 1)
+		assert.Equal(t, "Amigos", listed[0].Name)
+		assert.Equal(t, 2, listed[0].ParticipantCount)
+		assert.Nil(t, listed[0].Participants)
+		assert.Nil(t, listed[0].Matches)
+	}
+
+	stored, err := repo.GetGroupByID(context.Background(), created.Id.Hex())
+	require.Nil(t, err)
+	assert.Equal(t, 2, stored.ParticipantCount)
+	assert.Len(t, stored.Participants, 2)
+	assert.Len(t, stored.Matches, 2)
+}
+
+func testSearchGroups(t *testing.T, repo group.Repository) {
+	now := time.Now()
+	create(t, repo, newGroup("Amigos do João", "ana@example.com", now))
+	create(t, repo, newGroup("Trabalho", "joana@example.com", now))
+	create(t, repo, newGroup("Faculdade", "caio@example.com", now))
+
+	groups, err := repo.SearchGroups(context.Background(), "JOA")
+	require.Nil(t, err)
+
+	var names []string
+	for _, g := range groups {
+		names = append(names, g.Name)
+	}
+	assert.ElementsMatch(t, []string{"Amigos do João", "Trabalho"}, names)
+
+	groups, err = repo.SearchGroups(context.Background(), "strasse")
+	require.Nil(t, err)
+	assert.Empty(t, groups)
+	create(t, repo, newGroup("Straße", "caio@example.com", now))
+	groups, err = repo.SearchGroups(context.Background(), "STRASSE")
+	require.Nil(t, err)
+	assert.Equal(t, []string{"Straße"}, groupNames(groups))
+}
+
+func testSearchGroupsRanksCandidates(t *testing.T, repo group.Repository) {
+	now := time.Now()
+	// More partial matches than the 200 candidates handed over for ranking,
+	// all created before the better matches.
+	for i := 0; i < 201; i++ {
+		create(t, repo, newGroup(fmt.Sprintf("Turma %d do Joao", i), "ana@example.com", now))
+	}
+	create(t, repo, newGroup("Joãozinho", "ana@example.com", now))
+	create(t, repo, newGroup("Time", "JOAO", now))
+
+	groups, err := repo.SearchGroups(context.Background(), "joão")
+	require.Nil(t, err)
+	require.Len(t, groups, 200)
+	assert.Equal(t, []string{"Time", "Joãozinho", "Turma 0 do Joao"}, groupNames(groups)[:3])
+}
+
+func groupNames(groups []*models.Group) []string {
+	names := []string{}
+	for _, g := range groups {
+		names = append(names, g.Name)
+	}
+	return names
+}
+
+func testCanceledContext(t *testing.T, repo group.Repository) {
+	created := create(t, repo, newGroup("Amigos", "ana@example.com", time.Now()))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := repo.GetGroupByID(ctx, created.Id.Hex())
+	assertCode(t, err, customError.StatusClientClosedRequest, customError.RequestCanceled)
+}
@@ -0,0 +1,76 @@
+package group
+
+import (
+	"context"
+	"service-secret-santa/config"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// groupIndexes backs the sorts and filters offered by GetAllGroups and the
+// exchange date lookup of GetRetainedGroups.
+var groupIndexes = []mongo.IndexModel{
+	{Keys: bson.D{{Key: "createdAt", Value: 1}, {Key: "_id", Value: 1}}},
+	{Keys: bson.D{{Key: "name", Value: 1}, {Key: "_id", Value: 1}}},
+	{Keys: bson.D{{Key: "status", Value: 1}}},
+	{Keys: bson.D{{Key: "owner", Value: 1}}},
+	{Keys: bson.D{{Key: "exchangeDate", Value: 1}}},
+}
+
+// participantIndexes back the details lookup, the duplicate check of
+// AddParticipant, the participant email filter of GetAllGroups and the
+// search for stale master keys of the re-encryption job. An email is unique
+// within a group, which keeps concurrent AddParticipant calls from adding it
+// twice where there are no transactions; participants without email have no
+// emailKey and are left out.
+var participantIndexes = []mongo.IndexModel{
+	{Keys: bson.D{{Key: "groupId", Value: 1}, {Key: "position", Value: 1}}},
+	{
+		Keys: bson.D{{Key: "groupId", Value: 1}, {Key: "emailKey", Value: 1}},
+		Options: options.Index().SetUnique(true).
+			SetPartialFilterExpression(bson.M{"emailKey": bson.M{"$exists": true, "$type": "string"}}),
+	},
+	{Keys: bson.D{{Key: "emailKey", Value: 1}}},
+	{Keys: bson.D{{Key: "envelope.keyId", Value: 1}}},
+}
+
+// matchIndexes back the details lookup, GetMyMatch and the re-encryption
+// job.
+var matchIndexes = []mongo.IndexModel{
+	{Keys: bson.D{{Key: "groupId", Value: 1}, {Key: "position", Value: 1}}},
+	{Keys: bson.D{{Key: "envelope.keyId", Value: 1}}},
+}
+
+// auditIndexes back the audit log of a group, in the order it was written,
+// and the events of an actor that data subject requests look up.
+var auditIndexes = []mongo.IndexModel{
+	{Keys: bson.D{{Key: "groupId", Value: 1}, {Key: "at", Value: 1}}},
+	{Keys: bson.D{{Key: "actor", Value: 1}}},
+}
+
+// erasureIndexes let a certificate be found from the email of its subject.
+var erasureIndexes = []mongo.IndexModel{
+	{Keys: bson.D{{Key: "subjectHash", Value: 1}}},
+}
+
+// CreateIndexes creates the indexes of the groups, participants, matches,
+// audit and erasures collections. It is idempotent.
+func CreateIndexes(db *mongo.Client) error {
+	database := db.Database(config.Cfg.MongoDB)
+
+	for name, indexes := range map[string][]mongo.IndexModel{
+		"groups":       groupIndexes,
+		"participants": participantIndexes,
+		"matches":      matchIndexes,
+		"audit":        auditIndexes,
+		"erasures":     erasureIndexes,
+	} {
+		if _, err := database.Collection(name).Indexes().CreateMany(context.Background(), indexes); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,196 @@
+package group
+
+import (
+	"context"
+	"time"
+
+	"service-secret-santa/customError"
+	"service-secret-santa/models"
+	"service-secret-santa/resources/metrics"
+)
+
+type instrumented struct {
+	next Repository
+}
+
+// NewInstrumentedRepository records the latency and errors of every call to
+// repo in the Prometheus metrics.
+func NewInstrumentedRepository(repo Repository) Repository {
+	return &instrumented{next: repo}
+}
+
+func observe(method string, start time.Time, err *customError.CustomError) {
+	code := ""
+	if err != nil {
+		code = string(err.Kind())
+	}
+	metrics.ObserveMongo(method, start, code)
+}
+
+func (r *instrumented) CreateGroup(ctx context.Context, group *models.Group) (*models.Group, *customError.CustomError) {
+	start := time.Now()
+	res, err := r.next.CreateGroup(ctx, group)
+	observe("CreateGroup", start, err)
+	return res, err
+}
+
+func (r *instrumented) GetGroupByID(ctx context.Context, id string) (*models.Group, *customError.CustomError) {
+	start := time.Now()
+	res, err := r.next.GetGroupByID(ctx, id)
+	observe("GetGroupByID", start, err)
+	return res, err
+}
+
+func (r *instrumented) UpdateGroup(ctx context.Context, id string, version int64, update *models.GroupUpdate) (*models.Group, *customError.CustomError) {
+	start := time.Now()
+	res, err := r.next.UpdateGroup(ctx, id, version, update)
+	observe("UpdateGroup", start, err)
+	return res, err
+}
+
+func (r *instrumented) DeleteGroup(ctx context.Context, id string, version int64) *customError.CustomError {
+	start := time.Now()
+	err := r.next.DeleteGroup(ctx, id, version)
+	observe("DeleteGroup", start, err)
+	return err
+}
+
+func (r *instrumented) AddParticipant(ctx context.Context, id string, version int64, participant *models.Participant) (*models.Group, *customError.CustomError) {
+	start := time.Now()
+	res, err := r.next.AddParticipant(ctx, id, version, participant)
+	observe("AddParticipant", start, err)
+	return res, err
+}
+
+func (r *instrumented) UpdateMatches(ctx context.Context, id string, version int64, matches []models.Match) *customError.CustomError {
+	start := time.Now()
+	err := r.next.UpdateMatches(ctx, id, version, matches)
+	observe("UpdateMatches", start, err)
+	return err
+}
+
+func (r *instrumented) GetAllGroups(ctx context.Context, query models.GroupQuery) (*models.GroupPage, *customError.CustomError) {
+	start := time.Now()
+	res, err := r.next.GetAllGroups(ctx, query)
+	observe("GetAllGroups", start, err)
+	return res, err
+}
+
+func (r *instrumented) GetMyMatch(ctx context.Context, id string, username string) (string, *customError.CustomError) {
+	start := time.Now()
+	res, err := r.next.GetMyMatch(ctx, id, username)
+	observe("GetMyMatch", start, err)
+	return res, err
+}
+
+func (r *instrumented) SearchGroups(ctx context.Context, term string) ([]*models.Group, *customError.CustomError) {
+	start := time.Now()
+	res, err := r.next.SearchGroups(ctx, term)
+	observe("SearchGroups", start, err)
+	return res, err
+}
+
+func (r *instrumented) GetTrash(ctx context.Context, owner string) ([]*models.Group, *customError.CustomError) {
+	start := time.Now()
+	res, err := r.next.GetTrash(ctx, owner)
+	observe("GetTrash", start, err)
+	return res, err
+}
+
+func (r *instrumented) RestoreGroup(ctx context.Context, id string, version int64) (*models.Group, *customError.CustomError) {
+	start := time.Now()
+	res, err := r.next.RestoreGroup(ctx, id, version)
+	observe("RestoreGroup", start, err)
+	return res, err
+}
+
+func (r *instrumented) PurgeTrash(ctx context.Context, deletedBefore time.Time) (int, *customError.CustomError) {
+	start := time.Now()
+	res, err := r.next.PurgeTrash(ctx, deletedBefore)
+	observe("PurgeTrash", start, err)
+	return res, err
+}
+
+func (r *instrumented) GetRetainedGroups(ctx context.Context, query models.RetentionQuery) ([]*models.Group, *customError.CustomError) {
+	start := time.Now()
+	res, err := r.next.GetRetainedGroups(ctx, query)
+	observe("GetRetainedGroups", start, err)
+	return res, err
+}
+
+func (r *instrumented) MarkPurgeWarned(ctx context.Context, id string) (bool, *customError.CustomError) {
+	start := time.Now()
+	res, err := r.next.MarkPurgeWarned(ctx, id)
+	observe("MarkPurgeWarned", start, err)
+	return res, err
+}
+
+func (r *instrumented) UnmarkPurgeWarned(ctx context.Context, id string) *customError.CustomError {
+	start := time.Now()
+	err := r.next.UnmarkPurgeWarned(ctx, id)
+	observe("UnmarkPurgeWarned", start, err)
+	return err
+}
+
+func (r *instrumented) AnonymizeGroup(ctx context.Context, id string) (*models.Group, *customError.CustomError) {
+	start := time.Now()
+	res, err := r.next.AnonymizeGroup(ctx, id)
+	observe("AnonymizeGroup", start, err)
+	return res, err
+}
+
+func (r *instrumented) AppendAudit(ctx context.Context, event *models.AuditEvent) *customError.CustomError {
+	start := time.Now()
+	err := r.next.AppendAudit(ctx, event)
+	observe("AppendAudit", start, err)
+	return err
+}
+
+func (r *instrumented) GetAuditEvents(ctx context.Context, id string, query models.AuditQuery) ([]models.AuditEvent, *customError.CustomError) {
+	start := time.Now()
+	res, err := r.next.GetAuditEvents(ctx, id, query)
+	observe("GetAuditEvents", start, err)
+	return res, err
+}
+
+func (r *instrumented) GetAuditEventsByActor(ctx context.Context, actor string) ([]models.AuditEvent, *customError.CustomError) {
+	start := time.Now()
+	res, err := r.next.GetAuditEventsByActor(ctx, actor)
+	observe("GetAuditEventsByActor", start, err)
+	return res, err
+}
+
+func (r *instrumented) RewriteAuditEvents(ctx context.Context, events []models.AuditEvent) *customError.CustomError {
+	start := time.Now()
+	err := r.next.RewriteAuditEvents(ctx, events)
+	observe("RewriteAuditEvents", start, err)
+	return err
+}
+
+func (r *instrumented) GetGroupsByEmail(ctx context.Context, email string) ([]*models.Group, *customError.CustomError) {
+	start := time.Now()
+	res, err := r.next.GetGroupsByEmail(ctx, email)
+	observe("GetGroupsByEmail", start, err)
+	return res, err
+}
+
+func (r *instrumented) RewriteGroup(ctx context.Context, id string, version int64, rewrite *models.GroupRewrite) (*models.Group, *customError.CustomError) {
+	start := time.Now()
+	res, err := r.next.RewriteGroup(ctx, id, version, rewrite)
+	observe("RewriteGroup", start, err)
+	return res, err
+}
+
+func (r *instrumented) RecordErasure(ctx context.Context, certificate *models.ErasureCertificate) *customError.CustomError {
+	start := time.Now()
+	err := r.next.RecordErasure(ctx, certificate)
+	observe("RecordErasure", start, err)
+	return err
+}
+
+func (r *instrumented) GetErasure(ctx context.Context, id string) (*models.ErasureCertificate, *customError.CustomError) {
+	start := time.Now()
+	res, err := r.next.GetErasure(ctx, id)
+	observe("GetErasure", start, err)
+	return res, err
+}
@@ -0,0 +1,482 @@
+package group
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"service-secret-santa/customError"
+	"service-secret-santa/functions"
+	"service-secret-santa/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// memory keeps the groups in a map and answers like the Mongo repository,
+// down to the error codes, version checks and millisecond timestamps. It is
+// meant for local development and tests, and loses everything on restart.
+type memory struct {
+	mu     sync.RWMutex
+	groups map[primitive.ObjectID]*models.Group
+	audit  []models.AuditEvent
+
+	erasures map[primitive.ObjectID]models.ErasureCertificate
+}
+
+func NewMemoryRepository() Repository {
+	return &memory{groups: map[primitive.ObjectID]*models.Group{}, erasures: map[primitive.ObjectID]models.ErasureCertificate{}}
+}
+
+func (r *memory) CreateGroup(ctx context.Context, group *models.Group) (*models.Group, *customError.CustomError) {
+	if err := ctx.Err(); err != nil {
+		return nil, translateError(ctx, err, "Failed to create group")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if group.Id.IsZero() {
+		group.Id = primitive.NewObjectID()
+	} else if _, found := r.groups[group.Id]; found {
+		return nil, customError.NewCustomError(
+			customError.WithCustomError(http.StatusConflict, "duplicate key error: "+group.Id.Hex(), "Failed to create group"),
+			customError.WithCode(customError.AlreadyExists),
+		)
+	}
+
+	if group.Participants == nil {
+		group.Participants = []models.Participant{}
+	}
+	group.ParticipantCount = len(group.Participants)
+	group.Version = 1
+
+	r.groups[group.Id] = storedCopy(group)
+	return group, nil
+}
+
+func (r *memory) GetGroupByID(ctx context.Context, id string) (*models.Group, *customError.CustomError) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, invalidGroupID()
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, translateError(ctx, err, "Error finding group")
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	group, found := r.groups[objectID]
+	if !found || !group.DeletedAt.IsZero() {
+		return nil, groupNotFound()
+	}
+
+	return copyGroup(group), nil
+}
+
+func (r *memory) UpdateGroup(ctx context.Context, id string, version int64, update *models.GroupUpdate) (*models.Group, *customError.CustomError) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, invalidGroupID()
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, translateError(ctx, err, "Failed to update group")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	group, errVersion := r.atVersion(objectID, version)
+	if errVersion != nil {
+		return nil, errVersion
+	}
+
+	group.Name = update.Name
+	group.Owner = update.Owner
+	if update.Locale != "" {
+		group.Locale = update.Locale
+	}
+	group.ExchangeDate = time.Time{}
+	if update.ExchangeDate != nil {
+		group.ExchangeDate = storedTime(*update.ExchangeDate)
+	}
+	group.Participants = copyParticipants(update.Participants)
+	group.UpdatedAt = storedTime(update.UpdatedAt)
+	group.Version++
+
+	return copyGroup(group), nil
+}
+
+func (r *memory) DeleteGroup(ctx context.Context, id string, version int64) *customError.CustomError {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return invalidGroupID()
+	}
+
+	if err := ctx.Err(); err != nil {
+		return translateError(ctx, err, "Failed to delete group")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	group, errVersion := r.atVersion(objectID, version)
+	if errVersion != nil {
+		return errVersion
+	}
+
+	group.DeletedAt = storedTime(time.Now())
+	group.Version++
+	return nil
+}
+
+func (r *memory) AddParticipant(ctx context.Context, id string, version int64, participant *models.Participant) (*models.Group, *customError.CustomError) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, invalidGroupID()
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, translateError(ctx, err, "Failed to add participant")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if current, found := r.groups[objectID]; found && current.DeletedAt.IsZero() && participant.Email != "" {
+		for _, existing := range current.Participants {
+			if matchesRegex(existing.Email, exactCaseInsensitive(participant.Email)) {
+				return nil, participantDuplicate()
+			}
+		}
+	}
+
+	group, errVersion := r.atVersion(objectID, version)
+	if errVersion != nil {
+		return nil, errVersion
+	}
+
+	// Like $addToSet, an identical participant is not added twice.
+	present := false
+	for _, existing := range group.Participants {
+		if existing == *participant {
+			present = true
+			break
+		}
+	}
+	if !present {
+		group.Participants = append(group.Participants, *participant)
+	}
+	group.Version++
+
+	return copyGroup(group), nil
+}
+
+func (r *memory) UpdateMatches(ctx context.Context, id string, version int64, matches []models.Match) *customError.CustomError {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return invalidGroupID()
+	}
+
+	if err := ctx.Err(); err != nil {
+		return translateError(ctx, err, "Failed to update matches")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	group, errVersion := r.atVersion(objectID, version)
+	if errVersion != nil {
+		return errVersion
+	}
+
+	group.Matches = copyMatches(matches)
+	group.Status = models.GroupStatusDrawn
+	group.Version++
+
+	return nil
+}
+
+// atVersion returns the stored group when it is out of the trash and at the
+// given version, with the same rules as versionFilter and the same errors as
+// missingOrStale. Callers hold the lock.
+func (r *memory) atVersion(objectID primitive.ObjectID, version int64) (*models.Group, *customError.CustomError) {
+	group, found := r.groups[objectID]
+	if !found || !group.DeletedAt.IsZero() {
+		return nil, groupNotFound()
+	}
+
+	if version != models.AnyVersion && group.Version != version {
+		return nil, groupVersionMismatch()
+	}
+
+	return group, nil
+}
+
+func (r *memory) GetMyMatch(ctx context.Context, id string, username string) (string, *customError.CustomError) {
+	group, err := r.GetGroupByID(ctx, id)
+	if err != nil {
+		return "", err
+	}
+
+	return resolveMatch(group.Matches, username)
+}
+
+func (r *memory) GetAllGroups(ctx context.Context, query models.GroupQuery) (*models.GroupPage, *customError.CustomError) {
+	var after *pageCursor
+	if query.Cursor != "" {
+		cursor, err := decodePageCursor(query.Cursor, query)
+		if err != nil {
+			return nil, invalidCursor(err)
+		}
+		after = cursor
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, translateError(ctx, err, "Error retrieving groups")
+	}
+
+	groups := r.summaries(func(group *models.Group) bool {
+		return group.DeletedAt.IsZero() && matchesQuery(group, query) && (after == nil || after.admits(group))
+	})
+
+	sort.Slice(groups, func(i, j int) bool {
+		if query.Order == models.SortDesc {
+			return lessBySort(groups[j], groups[i], query.Sort)
+		}
+		return lessBySort(groups[i], groups[j], query.Sort)
+	})
+
+	if len(groups) > query.Limit+1 {
+		groups = groups[:query.Limit+1]
+	}
+
+	return newGroupPage(query, groups), nil
+}
+
+func (r *memory) SearchGroups(ctx context.Context, term string) ([]*models.Group, *customError.CustomError) {
+	if err := ctx.Err(); err != nil {
+		return nil, translateError(ctx, err, "Error searching groups")
+	}
+
+	tiers := searchPatterns(term)
+	rank := func(group *models.Group) int {
+		for i, pattern := range tiers {
+			condition := bson.M{"$regex": pattern}
+			if matchesRegex(group.Name, condition) || matchesRegex(group.Owner, condition) {
+				return i
+			}
+		}
+		return len(tiers)
+	}
+	groups := r.summaries(func(group *models.Group) bool {
+		return group.DeletedAt.IsZero() && rank(group) < len(tiers)
+	})
+
+	sort.Slice(groups, func(i, j int) bool {
+		if ri, rj := rank(groups[i]), rank(groups[j]); ri != rj {
+			return ri < rj
+		}
+		return bytes.Compare(groups[i].Id[:], groups[j].Id[:]) < 0
+	})
+
+	if len(groups) > searchCandidates {
+		groups = groups[:searchCandidates]
+	}
+
+	return groups, nil
+}
+
+func (r *memory) GetTrash(ctx context.Context, owner string) ([]*models.Group, *customError.CustomError) {
+	if err := ctx.Err(); err != nil {
+		return nil, translateError(ctx, err, "Error retrieving trash")
+	}
+
+	groups := r.summaries(func(group *models.Group) bool {
+		return !group.DeletedAt.IsZero() && (owner == "" || matchesRegex(group.Owner, exactCaseInsensitive(owner)))
+	})
+
+	sort.Slice(groups, func(i, j int) bool {
+		if !groups[i].DeletedAt.Equal(groups[j].DeletedAt) {
+			return groups[i].DeletedAt.After(groups[j].DeletedAt)
+		}
+		return bytes.Compare(groups[i].Id[:], groups[j].Id[:]) > 0
+	})
+
+	if len(groups) > trashLimit {
+		groups = groups[:trashLimit]
+	}
+
+	return groups, nil
+}
+
+func (r *memory) RestoreGroup(ctx context.Context, id string, version int64) (*models.Group, *customError.CustomError) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, invalidGroupID()
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, translateError(ctx, err, "Failed to restore group")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	group, found := r.groups[objectID]
+	if !found || group.DeletedAt.IsZero() {
+		return nil, groupNotFound()
+	}
+	if version != models.AnyVersion && group.Version != version {
+		return nil, groupVersionMismatch()
+	}
+
+	group.DeletedAt = time.Time{}
+	group.Version++
+
+	return copyGroup(group), nil
+}
+
+func (r *memory) PurgeTrash(ctx context.Context, deletedBefore time.Time) (int, *customError.CustomError) {
+	if err := ctx.Err(); err != nil {
+		return 0, translateError(ctx, err, "Failed to purge trash")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	purged := 0
+	for id, group := range r.groups {
+		if !group.DeletedAt.IsZero() && group.DeletedAt.Before(deletedBefore) {
+			delete(r.groups, id)
+			purged++
+		}
+	}
+
+	return purged, nil
+}
+
+// summaries returns the groups for which keep is true as listings return
+// them: without participants and matches.
+func (r *memory) summaries(keep func(*models.Group) bool) []*models.Group {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	groups := []*models.Group{}
+	for _, group := range r.groups {
+		if keep(group) {
+			summary := *group
+			summary.Participants, summary.Matches = nil, nil
+			summary.ParticipantCount = len(group.Participants)
+			groups = append(groups, &summary)
+		}
+	}
+	return groups
+}
+
+// matchesQuery applies the filters of groupFilter.
+func matchesQuery(group *models.Group, query models.GroupQuery) bool {
+	if query.Name != "" && !matchesRegex(group.Name, functions.ToCaseInsensitiveRegex([]string{query.Name})) {
+		return false
+	}
+	if query.Status != "" && group.Status != query.Status {
+		return false
+	}
+	if query.Owner != "" && !matchesRegex(group.Owner, exactCaseInsensitive(query.Owner)) {
+		return false
+	}
+	if query.ParticipantEmail != "" {
+		for _, participant := range group.Participants {
+			if matchesRegex(participant.Email, exactCaseInsensitive(query.ParticipantEmail)) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// matchesRegex evaluates a {"$regex": ...} condition built for Mongo, so both
+// repositories match exactly the same values.
+func matchesRegex(value string, condition bson.M) bool {
+	regex := condition["$regex"].(primitive.Regex)
+
+	pattern := regex.Pattern
+	if strings.Contains(regex.Options, "i") {
+		pattern = "(?i)" + pattern
+	}
+
+	return regexp.MustCompile(pattern).MatchString(value)
+}
+
+// lessBySort orders groups by the sort field and then by ID, like the
+// compound indexes used by the Mongo listing.
+func lessBySort(a, b *models.Group, field string) bool {
+	if field == models.SortByName {
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+	} else if !a.CreatedAt.Equal(b.CreatedAt) {
+		return a.CreatedAt.Before(b.CreatedAt)
+	}
+	return bytes.Compare(a.Id[:], b.Id[:]) < 0
+}
+
+// admits reports whether group comes after the cursor, like its filter.
+func (c pageCursor) admits(group *models.Group) bool {
+	id, _ := primitive.ObjectIDFromHex(c.Id)
+	last := &models.Group{Id: id, Name: c.Name, CreatedAt: c.CreatedAt}
+
+	if c.Order == models.SortDesc {
+		return lessBySort(group, last, c.Sort)
+	}
+	return lessBySort(last, group, c.Sort)
+}
+
+// storedCopy copies group as Mongo would store it: times lose their
+// sub-millisecond part and come back in UTC.
+func storedCopy(group *models.Group) *models.Group {
+	stored := copyGroup(group)
+	stored.CreatedAt = storedTime(group.CreatedAt)
+	stored.UpdatedAt = storedTime(group.UpdatedAt)
+	stored.ExchangeDate = storedTime(group.ExchangeDate)
+	return stored
+}
+
+func storedTime(t time.Time) time.Time {
+	if t.IsZero() {
+		return time.Time{}
+	}
+	return t.Truncate(time.Millisecond).UTC()
+}
+
+// copyGroup keeps callers from changing stored groups through shared slices.
+func copyGroup(group *models.Group) *models.Group {
+	copied := *group
+	copied.Participants = copyParticipants(group.Participants)
+	copied.ParticipantCount = len(group.Participants)
+	copied.Matches = copyMatches(group.Matches)
+	return &copied
+}
+
+func copyParticipants(participants []models.Participant) []models.Participant {
+	if participants == nil {
+		return nil
+	}
+	return append([]models.Participant{}, participants...)
+}
+
+func copyMatches(matches []models.Match) []models.Match {
+	if matches == nil {
+		return nil
+	}
+	return append([]models.Match{}, matches...)
+}
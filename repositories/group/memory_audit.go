@@ -0,0 +1,123 @@
+package group
+
+import (
+	"bytes"
+	"context"
+	"sort"
+	"time"
+
+	"service-secret-santa/customError"
+	"service-secret-santa/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func (r *memory) AppendAudit(ctx context.Context, event *models.AuditEvent) *customError.CustomError {
+	if err := ctx.Err(); err != nil {
+		return translateError(ctx, err, "Failed to record audit event")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if event.Id.IsZero() {
+		event.Id = primitive.NewObjectID()
+	}
+	if event.At.IsZero() {
+		event.At = time.Now()
+	}
+	event.At = storedTime(event.At)
+
+	stored := *event
+	stored.Changes = append([]models.AuditChange(nil), event.Changes...)
+	r.audit = append(r.audit, stored)
+	return nil
+}
+
+func (r *memory) GetAuditEvents(ctx context.Context, id string, query models.AuditQuery) ([]models.AuditEvent, *customError.CustomError) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, invalidGroupID()
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, translateError(ctx, err, "Error retrieving audit events")
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	events := []models.AuditEvent{}
+	for _, event := range r.audit {
+		switch {
+		case event.GroupId != objectID,
+			query.Action != "" && event.Action != query.Action,
+			query.Actor != "" && event.Actor != query.Actor,
+			!query.Since.IsZero() && event.At.Before(query.Since),
+			!query.Until.IsZero() && !event.At.Before(query.Until):
+			continue
+		}
+		event.Changes = append([]models.AuditChange(nil), event.Changes...)
+		events = append(events, event)
+	}
+
+	sortAuditEvents(events)
+	if query.Limit > 0 && len(events) > query.Limit {
+		events = events[:query.Limit]
+	}
+
+	return events, nil
+}
+
+func (r *memory) GetAuditEventsByActor(ctx context.Context, actor string) ([]models.AuditEvent, *customError.CustomError) {
+	if err := ctx.Err(); err != nil {
+		return nil, translateError(ctx, err, "Error retrieving audit events")
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	events := []models.AuditEvent{}
+	for _, event := range r.audit {
+		if event.Actor != actor {
+			continue
+		}
+		event.Changes = append([]models.AuditChange(nil), event.Changes...)
+		events = append(events, event)
+	}
+
+	sortAuditEvents(events)
+	return events, nil
+}
+
+func (r *memory) RewriteAuditEvents(ctx context.Context, events []models.AuditEvent) *customError.CustomError {
+	if err := ctx.Err(); err != nil {
+		return translateError(ctx, err, "Failed to rewrite audit events")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	rewrites := make(map[primitive.ObjectID]models.AuditEvent, len(events))
+	for _, event := range events {
+		rewrites[event.Id] = event
+	}
+	for i, stored := range r.audit {
+		if rewrite, found := rewrites[stored.Id]; found {
+			r.audit[i].Actor = rewrite.Actor
+			r.audit[i].Detail = rewrite.Detail
+			r.audit[i].Changes = append([]models.AuditChange(nil), rewrite.Changes...)
+		}
+	}
+	return nil
+}
+
+// sortAuditEvents puts events in the order they were recorded.
+func sortAuditEvents(events []models.AuditEvent) {
+	sort.SliceStable(events, func(i, j int) bool {
+		if !events[i].At.Equal(events[j].At) {
+			return events[i].At.Before(events[j].At)
+		}
+		return bytes.Compare(events[i].Id[:], events[j].Id[:]) < 0
+	})
+}
@@ -0,0 +1,119 @@
+package group
+
+import (
+	"bytes"
+	"context"
+	"sort"
+	"time"
+
+	"service-secret-santa/customError"
+	"service-secret-santa/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func (r *memory) GetRetainedGroups(ctx context.Context, query models.RetentionQuery) ([]*models.Group, *customError.CustomError) {
+	if err := ctx.Err(); err != nil {
+		return nil, translateError(ctx, err, "Error retrieving retained groups")
+	}
+
+	groups := r.summaries(func(group *models.Group) bool {
+		return group.AnonymizedAt.IsZero() && retains(group, query)
+	})
+
+	sort.Slice(groups, func(i, j int) bool {
+		return bytes.Compare(groups[i].Id[:], groups[j].Id[:]) < 0
+	})
+
+	if len(groups) > query.Limit {
+		groups = groups[:query.Limit]
+	}
+
+	return groups, nil
+}
+
+// retains applies the conditions of retentionFilter on the retention dates.
+func retains(group *models.Group, query models.RetentionQuery) bool {
+	since := models.RetainedSince(group)
+	if since.IsZero() || !since.Before(query.ExchangedBefore) {
+		return false
+	}
+	if query.WarnedBefore.IsZero() {
+		return group.PurgeWarnedAt.IsZero()
+	}
+	return !group.PurgeWarnedAt.IsZero() && group.PurgeWarnedAt.Before(query.WarnedBefore)
+}
+
+func (r *memory) MarkPurgeWarned(ctx context.Context, id string) (bool, *customError.CustomError) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return false, invalidGroupID()
+	}
+
+	if err := ctx.Err(); err != nil {
+		return false, translateError(ctx, err, "Failed to mark group as warned")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	group, found := r.groups[objectID]
+	if !found || !group.AnonymizedAt.IsZero() {
+		return false, groupNotFound()
+	}
+	if !group.PurgeWarnedAt.IsZero() {
+		return false, nil
+	}
+
+	group.PurgeWarnedAt = storedTime(time.Now())
+	return true, nil
+}
+
+func (r *memory) UnmarkPurgeWarned(ctx context.Context, id string) *customError.CustomError {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return invalidGroupID()
+	}
+
+	if err := ctx.Err(); err != nil {
+		return translateError(ctx, err, "Failed to unmark group as warned")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	group, found := r.groups[objectID]
+	if !found || !group.AnonymizedAt.IsZero() {
+		return groupNotFound()
+	}
+
+	group.PurgeWarnedAt = time.Time{}
+	group.PurgeWarnFailures++
+	return nil
+}
+
+func (r *memory) AnonymizeGroup(ctx context.Context, id string) (*models.Group, *customError.CustomError) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, invalidGroupID()
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, translateError(ctx, err, "Failed to anonymize group")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	group, found := r.groups[objectID]
+	if !found || !group.AnonymizedAt.IsZero() {
+		return nil, groupNotFound()
+	}
+
+	group.Participants, group.Matches = pseudonymize(group.Participants, group.Matches)
+	group.Owner = ""
+	group.AnonymizedAt = storedTime(time.Now())
+	group.Version++
+
+	return copyGroup(group), nil
+}
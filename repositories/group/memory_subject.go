@@ -0,0 +1,125 @@
+package group
+
+import (
+	"bytes"
+	"context"
+	"sort"
+	"strings"
+
+	"service-secret-santa/customError"
+	"service-secret-santa/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func (r *memory) GetGroupsByEmail(ctx context.Context, email string) ([]*models.Group, *customError.CustomError) {
+	if err := ctx.Err(); err != nil {
+		return nil, translateError(ctx, err, "Error retrieving groups")
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	groups := []*models.Group{}
+	for _, group := range r.groups {
+		if involves(group, email) {
+			groups = append(groups, copyGroup(group))
+		}
+	}
+
+	sort.Slice(groups, func(i, j int) bool {
+		return bytes.Compare(groups[i].Id[:], groups[j].Id[:]) < 0
+	})
+
+	if len(groups) > subjectGroupsLimit {
+		groups = groups[:subjectGroupsLimit]
+	}
+
+	return groups, nil
+}
+
+// involves reports whether group is owned by or joined with email.
+func involves(group *models.Group, email string) bool {
+	if strings.EqualFold(group.Owner, email) {
+		return true
+	}
+	for _, participant := range group.Participants {
+		if strings.EqualFold(participant.Email, email) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *memory) RewriteGroup(ctx context.Context, id string, version int64, rewrite *models.GroupRewrite) (*models.Group, *customError.CustomError) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, invalidGroupID()
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, translateError(ctx, err, "Failed to rewrite group")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	group, found := r.groups[objectID]
+	if !found {
+		return nil, groupNotFound()
+	}
+	if version != models.AnyVersion && group.Version != version {
+		return nil, groupVersionMismatch()
+	}
+
+	group.Owner = rewrite.Owner
+	group.Status = rewrite.Status
+	group.Participants = copyParticipants(rewrite.Participants)
+	if group.Participants == nil {
+		group.Participants = []models.Participant{}
+	}
+	group.Matches = copyMatches(rewrite.Matches)
+	group.Version++
+
+	return copyGroup(group), nil
+}
+
+func (r *memory) RecordErasure(ctx context.Context, certificate *models.ErasureCertificate) *customError.CustomError {
+	if err := ctx.Err(); err != nil {
+		return translateError(ctx, err, "Failed to record erasure")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if certificate.Id.IsZero() {
+		certificate.Id = primitive.NewObjectID()
+	}
+	certificate.ErasedAt = storedTime(certificate.ErasedAt)
+
+	stored := *certificate
+	stored.Groups = append([]string{}, certificate.Groups...)
+	r.erasures[certificate.Id] = stored
+	return nil
+}
+
+func (r *memory) GetErasure(ctx context.Context, id string) (*models.ErasureCertificate, *customError.CustomError) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, invalidErasureID()
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, translateError(ctx, err, "Error finding erasure")
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	certificate, found := r.erasures[objectID]
+	if !found {
+		return nil, erasureNotFound()
+	}
+	certificate.Groups = append([]string{}, certificate.Groups...)
+	return &certificate, nil
+}
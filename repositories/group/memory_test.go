@@ -0,0 +1,57 @@
+package group_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"service-secret-santa/models"
+	"service-secret-santa/repositories/group"
+	"service-secret-santa/repositories/group/grouptest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryRepositoryContract(t *testing.T) {
+	grouptest.RunContract(t, func(t *testing.T) group.Repository {
+		return group.NewMemoryRepository()
+	})
+}
+
+func TestMemoryRepository_ConcurrentWrites(t *testing.T) {
+	repo := group.NewMemoryRepository()
+	created, err := repo.CreateGroup(context.Background(), &models.Group{Name: "Amigos"})
+	require.Nil(t, err)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, err := repo.AddParticipant(context.Background(), created.Id.Hex(), models.AnyVersion, &models.Participant{Name: string(rune('A' + i))})
+			assert.Nil(t, err)
+		}(i)
+	}
+	wg.Wait()
+
+	stored, err := repo.GetGroupByID(context.Background(), created.Id.Hex())
+	require.Nil(t, err)
+	assert.Len(t, stored.Participants, 50)
+	assert.Equal(t, int64(51), stored.Version)
+}
+
+func TestMemoryRepository_ReturnsCopies(t *testing.T) {
+	repo := group.NewMemoryRepository()
+	created, err := repo.CreateGroup(context.Background(), &models.Group{Name: "Amigos", Participants: []models.Participant{{Name: "Ana"}}})
+	require.Nil(t, err)
+
+	loaded, err := repo.GetGroupByID(context.Background(), created.Id.Hex())
+	require.Nil(t, err)
+	loaded.Participants[0].Name = "Changed"
+	created.Participants[0].Name = "Changed too"
+
+	stored, err := repo.GetGroupByID(context.Background(), created.Id.Hex())
+	require.Nil(t, err)
+	assert.Equal(t, "Ana", stored.Participants[0].Name)
+}
@@ -0,0 +1,35 @@
+-- Timestamps are Unix milliseconds, the precision MongoDB keeps. Names use
+-- the "C" collation so listings sort by code point, as MongoDB does.
+CREATE TABLE groups (
+    id         TEXT PRIMARY KEY,
+    name       TEXT COLLATE "C" NOT NULL,
+    owner      TEXT NOT NULL,
+    locale     TEXT NOT NULL DEFAULT '',
+    status     TEXT NOT NULL,
+    created_at BIGINT,
+    updated_at BIGINT,
+    version    BIGINT NOT NULL DEFAULT 0
+);
+
+CREATE INDEX groups_created_at_id ON groups (created_at, id);
+CREATE INDEX groups_name_id ON groups (name, id);
+CREATE INDEX groups_status ON groups (status);
+CREATE INDEX groups_owner ON groups (lower(owner));
+
+CREATE TABLE participants (
+    group_id TEXT NOT NULL REFERENCES groups (id) ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    name     TEXT NOT NULL,
+    email    TEXT NOT NULL,
+    PRIMARY KEY (group_id, position)
+);
+
+CREATE INDEX participants_email ON participants (lower(email));
+
+CREATE TABLE matches (
+    group_id TEXT NOT NULL REFERENCES groups (id) ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    first    TEXT NOT NULL,
+    second   TEXT NOT NULL,
+    PRIMARY KEY (group_id, position)
+);
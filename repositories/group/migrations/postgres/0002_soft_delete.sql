@@ -0,0 +1,4 @@
+-- Deleted groups stay, with deleted_at set, until the trash is purged.
+ALTER TABLE groups ADD COLUMN deleted_at BIGINT;
+
+CREATE INDEX groups_deleted_at ON groups (deleted_at);
@@ -0,0 +1,4 @@
+-- Audit events name the request they were recorded in and carry the fields
+-- they changed, as a JSON array; '' when there are none.
+ALTER TABLE audit_events ADD COLUMN request_id TEXT NOT NULL DEFAULT '';
+ALTER TABLE audit_events ADD COLUMN changes TEXT NOT NULL DEFAULT '';
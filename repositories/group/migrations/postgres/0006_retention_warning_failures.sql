@@ -0,0 +1,3 @@
+-- Warnings of the retention that could not be sent are sent again, a few
+-- times at most; the failures are counted here.
+ALTER TABLE groups ADD COLUMN purge_warn_failures INTEGER NOT NULL DEFAULT 0;
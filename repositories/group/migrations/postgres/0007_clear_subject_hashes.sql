@@ -0,0 +1,3 @@
+-- Erasure certificates kept a plain SHA-256 of the email, which hashing
+-- candidate emails would reverse. Those written since carry an HMAC.
+UPDATE erasures SET subject_hash = '';
@@ -0,0 +1,19 @@
+-- Participant data is anonymized a retention period after the exchange, or
+-- after the creation of groups without an exchange date.
+ALTER TABLE groups ADD COLUMN exchange_date INTEGER;
+ALTER TABLE groups ADD COLUMN purge_warned_at INTEGER;
+ALTER TABLE groups ADD COLUMN anonymized_at INTEGER;
+
+CREATE INDEX groups_exchange_date ON groups (exchange_date);
+
+-- The audit log is append-only and outlives the groups it is about.
+CREATE TABLE audit_events (
+    id       TEXT PRIMARY KEY,
+    group_id TEXT NOT NULL,
+    action   TEXT NOT NULL,
+    actor    TEXT NOT NULL,
+    at       INTEGER NOT NULL,
+    detail   TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX audit_events_group_id_at ON audit_events (group_id, at);
@@ -0,0 +1,12 @@
+-- Certificates of the erasures of data subjects. The subject is kept as a
+-- hash of the email; group_ids is a JSON array of the group IDs changed.
+CREATE TABLE erasures (
+    id                   TEXT PRIMARY KEY,
+    subject_hash         TEXT NOT NULL,
+    erased_at            INTEGER NOT NULL,
+    group_ids            TEXT NOT NULL,
+    participants_removed INTEGER NOT NULL,
+    draws_repaired       INTEGER NOT NULL
+);
+
+CREATE INDEX erasures_subject_hash ON erasures (subject_hash);
@@ -0,0 +1,2 @@
+-- Data subject requests look up the audit events a person made.
+CREATE INDEX audit_events_actor ON audit_events (actor);
@@ -0,0 +1,5 @@
+-- An email is unique within a group, as in MongoDB since its migration 6, so
+-- concurrent AddParticipant calls cannot both add it. Participants without
+-- email are left out. MigrateSQL refuses to run this while a group already has
+-- an email twice.
+CREATE UNIQUE INDEX participants_group_email ON participants (group_id, lower(email)) WHERE email <> '';
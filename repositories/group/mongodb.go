@@ -2,177 +2,622 @@ package group
 
 import (
 	"context"
-	"fmt"
+	"log/slog"
+	"net/http"
+	"regexp"
 	"service-secret-santa/config"
 	"service-secret-santa/customError"
+	"service-secret-santa/functions"
 	"service-secret-santa/models"
+	"service-secret-santa/resources/encryption"
+	"sync/atomic"
+	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+// Repository stores groups. GetAllGroups, SearchGroups and GetTrash return
+// summaries: participants and matches are left out and ParticipantCount says
+// how many participants a group has. The other reads return the whole group.
+//
+// DeleteGroup moves a group to the trash. Trashed groups are missing for
+// every method but GetTrash, RestoreGroup, PurgeTrash and the retention ones.
+//
+// GetRetainedGroups, MarkPurgeWarned, UnmarkPurgeWarned and AnonymizeGroup
+// back the retention of participant data, in the trash or not;
+// GetRetainedGroups returns summaries too. An anonymized group is missing
+// for MarkPurgeWarned, UnmarkPurgeWarned and AnonymizeGroup.
+// AppendAudit adds an event to the append-only audit log and
+// GetAuditEvents reads the events of a group, whether or not the group is
+// still there. GetAuditEventsByActor reads the events of every group made by
+// actor, and RewriteAuditEvents replaces the actor, detail and changes of
+// events, found by their ID, to take personal data out of the log; these are
+// the only changes the log ever sees.
+//
+// GetGroupsByEmail and RewriteGroup serve data subject requests and see
+// trashed groups too. GetGroupsByEmail returns whole groups owned by or
+// joined with email, ignoring case.
 type Repository interface {
-	CreateGroup(group *models.Group) (*models.Group, *customError.CustomError)
-	GetGroupByID(id string) (*models.Group, *customError.CustomError)
-	UpdateGroup(id string, group *models.Group) (*models.Group, *customError.CustomError)
-	DeleteGroup(id string) *customError.CustomError
-	AddParticipant(id string, participant *models.Participant) (*models.Group, *customError.CustomError)
-	UpdateMatches(id string, matches []models.Match) *customError.CustomError
-	GetAllGroups() ([]*models.Group, *customError.CustomError)
-	GetMyMatch(id string, username string) (string, *customError.CustomError)
+	CreateGroup(ctx context.Context, group *models.Group) (*models.Group, *customError.CustomError)
+	GetGroupByID(ctx context.Context, id string) (*models.Group, *customError.CustomError)
+	UpdateGroup(ctx context.Context, id string, version int64, update *models.GroupUpdate) (*models.Group, *customError.CustomError)
+	DeleteGroup(ctx context.Context, id string, version int64) *customError.CustomError
+	AddParticipant(ctx context.Context, id string, version int64, participant *models.Participant) (*models.Group, *customError.CustomError)
+	UpdateMatches(ctx context.Context, id string, version int64, matches []models.Match) *customError.CustomError
+	GetAllGroups(ctx context.Context, query models.GroupQuery) (*models.GroupPage, *customError.CustomError)
+	GetMyMatch(ctx context.Context, id string, username string) (string, *customError.CustomError)
+	SearchGroups(ctx context.Context, term string) ([]*models.Group, *customError.CustomError)
+	GetTrash(ctx context.Context, owner string) ([]*models.Group, *customError.CustomError)
+	RestoreGroup(ctx context.Context, id string, version int64) (*models.Group, *customError.CustomError)
+	PurgeTrash(ctx context.Context, deletedBefore time.Time) (int, *customError.CustomError)
+	GetRetainedGroups(ctx context.Context, query models.RetentionQuery) ([]*models.Group, *customError.CustomError)
+	MarkPurgeWarned(ctx context.Context, id string) (bool, *customError.CustomError)
+	UnmarkPurgeWarned(ctx context.Context, id string) *customError.CustomError
+	AnonymizeGroup(ctx context.Context, id string) (*models.Group, *customError.CustomError)
+	AppendAudit(ctx context.Context, event *models.AuditEvent) *customError.CustomError
+	GetAuditEvents(ctx context.Context, id string, query models.AuditQuery) ([]models.AuditEvent, *customError.CustomError)
+	GetAuditEventsByActor(ctx context.Context, actor string) ([]models.AuditEvent, *customError.CustomError)
+	RewriteAuditEvents(ctx context.Context, events []models.AuditEvent) *customError.CustomError
+	GetGroupsByEmail(ctx context.Context, email string) ([]*models.Group, *customError.CustomError)
+	RewriteGroup(ctx context.Context, id string, version int64, rewrite *models.GroupRewrite) (*models.Group, *customError.CustomError)
+	RecordErasure(ctx context.Context, certificate *models.ErasureCertificate) *customError.CustomError
+	GetErasure(ctx context.Context, id string) (*models.ErasureCertificate, *customError.CustomError)
 }
 
+// searchCandidates caps how many groups SearchGroups hands over for ranking.
+// The exact matches come first, then the prefix and the partial ones, so the
+// cap only drops the worst candidates.
+const searchCandidates = 200
+
+// subjectGroupsLimit caps how many groups GetGroupsByEmail returns.
+const subjectGroupsLimit = 1000
+
+// trashLimit caps how many groups GetTrash returns, most recently deleted
+// first.
+const trashLimit = 200
+
 type resource struct {
 	db *mongo.Client
+
+	// keyring encrypts participant emails and matches; nil stores them as
+	// they are.
+	keyring *encryption.Keyring
+
+	// transactions caches whether the deployment supports transactions, see
+	// supportsTransactions.
+	transactions atomic.Int32
+}
+
+// NewGroupRepository stores groups in db, encrypting participant emails and
+// matches with keyring when it is not nil.
+func NewGroupRepository(db *mongo.Client, keyring *encryption.Keyring) Repository {
+	return &resource{db: db, keyring: keyring}
 }
 
-func NewGroupRepository(db *mongo.Client) Repository {
-	return &resource{db: db}
+func (r *resource) collection(name string) *mongo.Collection {
+	return r.db.Database(config.Cfg.MongoDB).Collection(name)
 }
 
-func (r *resource) CreateGroup(group *models.Group) (*models.Group, *customError.CustomError) {
-	collection := r.db.Database(config.Cfg.MongoDB).Collection("groups")
+func (r *resource) CreateGroup(ctx context.Context, group *models.Group) (*models.Group, *customError.CustomError) {
+	ctx, cancel := writeContext(ctx)
+	defer cancel()
 
 	if group.Participants == nil {
 		group.Participants = []models.Participant{}
 	}
+	group.ParticipantCount = len(group.Participants)
+	group.Version = 1
+	group.SchemaVersion = SchemaVersion
+
+	var objectID primitive.ObjectID
+	err := r.transaction(ctx, func(ctx context.Context, atomically bool) error {
+		collection := r.collection("groups")
 
-	result, err := collection.InsertOne(context.Background(), group)
+		result, err := collection.InsertOne(ctx, group)
+		if err != nil {
+			return failed("Failed to create group", err)
+		}
+		objectID = result.InsertedID.(primitive.ObjectID)
+
+		err = r.insertParticipants(ctx, objectID, 0, group.Participants)
+		if err == nil {
+			err = r.insertMatches(ctx, objectID, group.Matches)
+		}
+		if err != nil {
+			// Without the participants the group would look empty, so undo it.
+			if !atomically {
+				r.deleteChildren(ctx, objectID)
+				collection.DeleteOne(ctx, bson.M{"_id": objectID})
+			}
+			return participantsFailed("Failed to create group", err)
+		}
+
+		return nil
+	})
 	if err != nil {
-		return nil, customError.NewCustomError(customError.WithInternalServerError(err.Error(), "Failed to create group"))
+		return nil, err
 	}
 
-	group.Id = result.InsertedID.(primitive.ObjectID)
+	group.Id = objectID
 	return group, nil
 }
 
-func (r *resource) GetGroupByID(id string) (*models.Group, *customError.CustomError) {
-	collection := r.db.Database(config.Cfg.MongoDB).Collection("groups")
+func (r *resource) GetGroupByID(ctx context.Context, id string) (*models.Group, *customError.CustomError) {
+	ctx, cancel := readContext(ctx)
+	defer cancel()
 
 	objectID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
-		return nil, customError.NewCustomError(customError.WithBadRequest("Invalid group ID", "Invalid ID format"))
+		return nil, invalidGroupID()
 	}
 
-	var group models.Group
-	err = collection.FindOne(context.Background(), bson.M{"_id": objectID}).Decode(&group)
+	group, err := r.details(ctx, objectID)
 	if err != nil {
-		if err == mongo.ErrNoDocuments {
-			return nil, customError.NewCustomError(customError.WithNotFound("Group not found", "No group found with the given ID"))
-		}
-		return nil, customError.NewCustomError(customError.WithInternalServerError(err.Error(), "Error finding group"))
+		return nil, translateError(ctx, err, "Error finding group")
 	}
-	fmt.Println(group)
+	slog.DebugContext(ctx, "Group loaded", "group", group)
 
-	return &group, nil
+	return group, nil
 }
 
-func (r *resource) UpdateGroup(id string, group *models.Group) (*models.Group, *customError.CustomError) {
-	collection := r.db.Database(config.Cfg.MongoDB).Collection("groups")
+func (r *resource) UpdateGroup(ctx context.Context, id string, version int64, update *models.GroupUpdate) (*models.Group, *customError.CustomError) {
+	ctx, cancel := writeContext(ctx)
+	defer cancel()
 
 	objectID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
-		return nil, customError.NewCustomError(customError.WithBadRequest("Invalid group ID", "Invalid ID format"))
+		return nil, invalidGroupID()
 	}
 
-	_, err = collection.UpdateOne(context.Background(), bson.M{"_id": objectID}, bson.M{"$set": group})
+	changes, err := groupChanges(update)
 	if err != nil {
-		return nil, customError.NewCustomError(customError.WithInternalServerError(err.Error(), "Failed to update group"))
+		return nil, translateError(ctx, err, "Failed to update group")
 	}
 
-	return group, nil
-}
+	var updated *models.Group
+	txErr := r.transaction(ctx, func(ctx context.Context, _ bool) error {
+		collection := r.collection("groups")
 
-func (r *resource) DeleteGroup(id string) *customError.CustomError {
-	collection := r.db.Database(config.Cfg.MongoDB).Collection("groups")
+		result, err := collection.UpdateOne(ctx, versionFilter(objectID, version), changes)
+		if err != nil {
+			return failed("Failed to update group", err)
+		}
+		if result.MatchedCount == 0 {
+			return r.missingOrStale(ctx, collection, liveFilter(objectID))
+		}
 
-	objectID, err := primitive.ObjectIDFromHex(id)
-	if err != nil {
-		return customError.NewCustomError(customError.WithBadRequest("Invalid group ID", "Invalid ID format"))
+		if err := r.replaceParticipants(ctx, objectID, update.Participants); err != nil {
+			return participantsFailed("Failed to update group", err)
+		}
+
+		updated, err = r.details(ctx, objectID)
+		if err != nil {
+			return failed("Error finding group", err)
+		}
+
+		return nil
+	})
+	if txErr != nil {
+		return nil, txErr
 	}
 
-	_, err = collection.DeleteOne(context.Background(), bson.M{"_id": objectID})
+	return updated, nil
+}
+
+// groupChanges is the update applied to a group by UpdateGroup: the fields of
+// update, the new participant count and the version bump.
+func groupChanges(update *models.GroupUpdate) (bson.M, error) {
+	raw, err := bson.Marshal(update)
 	if err != nil {
-		return customError.NewCustomError(customError.WithInternalServerError(err.Error(), "Failed to delete group"))
+		return nil, err
+	}
+
+	var set bson.M
+	if err := bson.Unmarshal(raw, &set); err != nil {
+		return nil, err
 	}
+	set["participantCount"] = len(update.Participants)
 
-	return nil
+	return bson.M{"$set": set, "$inc": bson.M{"version": 1}}, nil
 }
 
-func (r *resource) AddParticipant(id string, participant *models.Participant) (*models.Group, *customError.CustomError) {
-	collection := r.db.Database(config.Cfg.MongoDB).Collection("groups")
+func (r *resource) DeleteGroup(ctx context.Context, id string, version int64) *customError.CustomError {
+	ctx, cancel := writeContext(ctx)
+	defer cancel()
 
 	objectID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
-		return nil, customError.NewCustomError(customError.WithBadRequest("Invalid group ID", "Invalid ID format"))
+		return invalidGroupID()
 	}
 
-	update := bson.M{"$addToSet": bson.M{"participants": participant}}
-	_, err = collection.UpdateOne(context.Background(), bson.M{"_id": objectID}, update)
+	// The children are stamped too, so the TTL indexes purge them along
+	// with the group.
+	deletedAt := storedTime(time.Now())
+	return r.transaction(ctx, func(ctx context.Context, _ bool) error {
+		collection := r.collection("groups")
+
+		update := bson.M{"$set": bson.M{"deletedAt": deletedAt}, "$inc": bson.M{"version": 1}}
+		result, err := collection.UpdateOne(ctx, versionFilter(objectID, version), update)
+		if err != nil {
+			return failed("Failed to delete group", err)
+		}
+
+		if result.MatchedCount == 0 {
+			return r.missingOrStale(ctx, collection, liveFilter(objectID))
+		}
+
+		if err := r.stampChildren(ctx, objectID, bson.M{"$set": bson.M{"deletedAt": deletedAt}}); err != nil {
+			return failed("Failed to delete group", err)
+		}
+
+		return nil
+	})
+}
+
+func (r *resource) AddParticipant(ctx context.Context, id string, version int64, participant *models.Participant) (*models.Group, *customError.CustomError) {
+	ctx, cancel := writeContext(ctx)
+	defer cancel()
+
+	objectID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
-		return nil, customError.NewCustomError(customError.WithInternalServerError(err.Error(), "Failed to add participant"))
+		return nil, invalidGroupID()
 	}
 
-	return r.GetGroupByID(id)
+	var updated *models.Group
+	txErr := r.transaction(ctx, func(ctx context.Context, atomically bool) error {
+		collection := r.collection("groups")
+		participants := r.collection("participants")
+
+		// In a transaction, two requests adding the same email at once both
+		// bump the group and one of them is retried, finding the other's
+		// participant. Without one both can get through, and the unique index
+		// on the email turns the second insert away.
+		if participant.Email != "" {
+			count, err := participants.CountDocuments(ctx, bson.M{"groupId": objectID, "emailKey": r.emailKeys(participant.Email), "deletedAt": nil})
+			if err != nil {
+				return failed("Error finding group", err)
+			}
+			if count > 0 {
+				return participantDuplicate()
+			}
+		}
+
+		// Like $addToSet, an identical participant is not added twice.
+		// Emails are compared through their blind index, which participants
+		// without email do not have.
+		var key interface{}
+		if participant.Email != "" {
+			key = r.emailKeys(participant.Email)
+		}
+		identical, err := participants.CountDocuments(ctx, bson.M{"groupId": objectID, "name": participant.Name, "emailKey": key})
+		if err != nil {
+			return failed("Error finding group", err)
+		}
+
+		increments := bson.M{"version": 1}
+		if identical == 0 {
+			increments["participantCount"] = 1
+		}
+
+		// Bumping the count hands out the position of the new participant.
+		var bumped models.Group
+		opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+		err = collection.FindOneAndUpdate(ctx, versionFilter(objectID, version), bson.M{"$inc": increments}, opts).Decode(&bumped)
+		if err != nil {
+			if err == mongo.ErrNoDocuments {
+				return r.missingOrStale(ctx, collection, liveFilter(objectID))
+			}
+			return failed("Failed to add participant", err)
+		}
+
+		if identical == 0 {
+			if err := r.insertParticipants(ctx, objectID, bumped.ParticipantCount-1, []models.Participant{*participant}); err != nil {
+				// Without a transaction the bump is undone by hand, version
+				// included, so clients holding the previous ETag are not
+				// turned away for a participant that was never added. A
+				// group changed since is left as the change made it.
+				if !atomically {
+					collection.UpdateOne(ctx, bson.M{"_id": objectID, "version": bumped.Version}, bson.M{"$inc": bson.M{"participantCount": -1, "version": -1}})
+				}
+				return participantsFailed("Failed to add participant", err)
+			}
+		}
+
+		updated, err = r.details(ctx, objectID)
+		if err != nil {
+			return failed("Error finding group", err)
+		}
+
+		return nil
+	})
+	if txErr != nil {
+		return nil, txErr
+	}
+
+	return updated, nil
 }
 
-func (r *resource) UpdateMatches(id string, matches []models.Match) *customError.CustomError {
-	collection := r.db.Database(config.Cfg.MongoDB).Collection("groups")
+// UpdateMatches saves the draw and marks the group drawn, as long as the group
+// is still at version. In a transaction the status, the version and the
+// matches change together, so a draw never lands next to participants it was
+// not computed from.
+func (r *resource) UpdateMatches(ctx context.Context, id string, version int64, matches []models.Match) *customError.CustomError {
+	ctx, cancel := writeContext(ctx)
+	defer cancel()
 
 	objectID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
-		return customError.NewCustomError(customError.WithBadRequest("Invalid group ID", "Invalid ID format"))
+		return invalidGroupID()
 	}
 
-	update := bson.M{"$set": bson.M{"matches": matches}}
-	_, err = collection.UpdateOne(context.Background(), bson.M{"_id": objectID}, update)
+	return r.transaction(ctx, func(ctx context.Context, _ bool) error {
+		collection := r.collection("groups")
+
+		update := bson.M{"$set": bson.M{"status": models.GroupStatusDrawn}, "$inc": bson.M{"version": 1}}
+		result, err := collection.UpdateOne(ctx, versionFilter(objectID, version), update)
+		if err != nil {
+			return failed("Failed to update matches", err)
+		}
+
+		if result.MatchedCount == 0 {
+			return r.missingOrStale(ctx, collection, liveFilter(objectID))
+		}
+
+		if err := r.replaceMatches(ctx, objectID, matches); err != nil {
+			return failed("Failed to update matches", err)
+		}
+
+		return nil
+	})
+}
+
+// liveFilter selects the group unless it is in the trash.
+func liveFilter(objectID primitive.ObjectID) bson.M {
+	return bson.M{"_id": objectID, "deletedAt": nil}
+}
+
+// trashedFilter selects the group only while it is in the trash.
+func trashedFilter(objectID primitive.ObjectID) bson.M {
+	return bson.M{"_id": objectID, "deletedAt": bson.M{"$ne": nil}}
+}
+
+// versionFilter selects the group only while it is out of the trash and
+// still at the given version. Groups written before versioning have no
+// version field and count as version 0; models.AnyVersion skips the check.
+func versionFilter(objectID primitive.ObjectID, version int64) bson.M {
+	return withVersion(liveFilter(objectID), version)
+}
+
+func withVersion(filter bson.M, version int64) bson.M {
+	switch {
+	case version == models.AnyVersion:
+	case version == 0:
+		filter["version"] = bson.M{"$in": bson.A{0, nil}}
+	default:
+		filter["version"] = version
+	}
+	return filter
+}
+
+// missingOrStale explains why a versioned write matched nothing: either no
+// group matches filter, the write's filter without the version, or somebody
+// else changed it first.
+func (r *resource) missingOrStale(ctx context.Context, collection *mongo.Collection, filter bson.M) *customError.CustomError {
+	count, err := collection.CountDocuments(ctx, filter)
 	if err != nil {
-		return customError.NewCustomError(customError.WithInternalServerError(err.Error(), "Failed to update matches"))
+		return translateError(ctx, err, "Error finding group")
+	}
+
+	if count == 0 {
+		return groupNotFound()
 	}
 
-	return nil
+	return groupVersionMismatch()
 }
 
-func (r *resource) GetMyMatch(id string, username string) (string, *customError.CustomError) {
-	collection := r.db.Database(config.Cfg.MongoDB).Collection("groups")
+func (r *resource) GetMyMatch(ctx context.Context, id string, username string) (string, *customError.CustomError) {
+	ctx, cancel := readContext(ctx)
+	defer cancel()
 
 	objectID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
-		return "", customError.NewCustomError(customError.WithBadRequest("Invalid group ID", "Invalid ID format"))
+		return "", invalidGroupID()
 	}
 
-	var group models.Group
-	err = collection.FindOne(context.Background(), bson.M{"_id": objectID}).Decode(&group)
+	matches, err := r.loadMatches(ctx, objectID)
 	if err != nil {
-		if err == mongo.ErrNoDocuments {
-			return "", customError.NewCustomError(customError.WithNotFound("Group not found", "No group found with the given ID"))
+		return "", translateError(ctx, err, "Error finding group")
+	}
+
+	// A group that was not drawn has no matches; tell it from a missing one.
+	if len(matches) == 0 {
+		count, err := r.collection("groups").CountDocuments(ctx, liveFilter(objectID))
+		if err != nil {
+			return "", translateError(ctx, err, "Error finding group")
+		}
+		if count == 0 {
+			return "", groupNotFound()
 		}
-		return "", customError.NewCustomError(customError.WithInternalServerError(err.Error(), "Error finding group"))
 	}
 
-	for _, match := range group.Matches {
+	return resolveMatch(matches, username)
+}
+
+// maxSuggestions caps the "did you mean" names returned when a username is not resolved.
+const maxSuggestions = 5
+
+// resolveMatch finds the match of username. A name stored exactly as
+// username wins; otherwise names are compared normalised, so that case,
+// accents and extra spaces do not matter.
+func resolveMatch(matches []models.Match, username string) (string, *customError.CustomError) {
+	for _, match := range matches {
 		if match.First == username {
 			return match.Second, nil
 		}
 	}
 
-	return "", customError.NewCustomError(customError.WithNotFound("Match not found", "No match found for the given username"))
+	target := functions.NormalizeName(username)
+
+	var found []models.Match
+	names := make([]string, 0, len(matches))
+	for _, match := range matches {
+		names = append(names, match.First)
+		if functions.NormalizeName(match.First) == target {
+			found = append(found, match)
+		}
+	}
+
+	switch len(found) {
+	case 1:
+		return found[0].Second, nil
+	case 0:
+		return "", customError.NewCustomError(
+			customError.WithNotFound("Match not found", "No match found for the given username"),
+			customError.WithCode(customError.MatchNotFound),
+			customError.WithSuggestions(functions.Suggest(username, names, maxSuggestions)),
+		)
+	default:
+		ambiguous := make([]string, 0, len(found))
+		for _, match := range found {
+			ambiguous = append(ambiguous, match.First)
+		}
+		return "", customError.NewCustomError(
+			customError.WithCustomError(http.StatusConflict, "Ambiguous username", "More than one participant matches the given username"),
+			customError.WithCode(customError.UsernameAmbiguous),
+			customError.WithSuggestions(ambiguous),
+		)
+	}
+}
+
+func (r *resource) GetAllGroups(ctx context.Context, query models.GroupQuery) (*models.GroupPage, *customError.CustomError) {
+	ctx, cancel := readContext(ctx)
+	defer cancel()
+
+	collection := r.collection("groups")
+
+	filter, err := groupFilter(query)
+	if err != nil {
+		return nil, invalidCursor(err)
+	}
+
+	if query.ParticipantEmail != "" {
+		groupIDs, err := r.collection("participants").Distinct(ctx, "groupId", bson.M{"emailKey": r.emailKeys(query.ParticipantEmail)})
+		if err != nil {
+			return nil, translateError(ctx, err, "Error retrieving groups")
+		}
+		filter = bson.M{"$and": bson.A{filter, bson.M{"_id": bson.M{"$in": groupIDs}}}}
+	}
+
+	direction := 1
+	if query.Order == models.SortDesc {
+		direction = -1
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: query.Sort, Value: direction}, {Key: "_id", Value: direction}}).
+		SetLimit(int64(query.Limit + 1))
+
+	cursor, err := collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, translateError(ctx, err, "Error retrieving groups")
+	}
+	defer cursor.Close(ctx)
+
+	groups := []*models.Group{}
+	if err = cursor.All(ctx, &groups); err != nil {
+		return nil, translateError(ctx, err, "Error decoding groups")
+	}
+
+	return newGroupPage(query, groups), nil
 }
 
-func (r *resource) GetAllGroups() ([]*models.Group, *customError.CustomError) {
-	collection := r.db.Database(config.Cfg.MongoDB).Collection("groups")
+func (r *resource) SearchGroups(ctx context.Context, term string) ([]*models.Group, *customError.CustomError) {
+	ctx, cancel := readContext(ctx)
+	defer cancel()
+
+	collection := r.collection("groups")
+
+	tiers := searchPatterns(term)
+	matches := func(pattern primitive.Regex) bson.M {
+		return bson.M{"$or": bson.A{
+			bson.M{"$regexMatch": bson.M{"input": "$name", "regex": pattern}},
+			bson.M{"$regexMatch": bson.M{"input": "$owner", "regex": pattern}},
+		}}
+	}
+
+	// Candidates are ranked by the database, so the limit keeps the best.
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"deletedAt": nil, "$or": bson.A{
+			bson.M{"name": bson.M{"$regex": tiers[2]}},
+			bson.M{"owner": bson.M{"$regex": tiers[2]}},
+		}}}},
+		{{Key: "$addFields", Value: bson.M{"searchRank": bson.M{"$switch": bson.M{
+			"branches": bson.A{
+				bson.M{"case": matches(tiers[0]), "then": functions.RankExact},
+				bson.M{"case": matches(tiers[1]), "then": functions.RankPrefix},
+			},
+			"default": functions.RankContains,
+		}}}}},
+		{{Key: "$sort", Value: bson.D{{Key: "searchRank", Value: 1}, {Key: "_id", Value: 1}}}},
+		{{Key: "$limit", Value: searchCandidates}},
+		{{Key: "$project", Value: bson.M{"searchRank": 0}}},
+	}
 
-	cursor, err := collection.Find(context.Background(), bson.M{})
+	cursor, err := collection.Aggregate(ctx, pipeline)
 	if err != nil {
-		return nil, customError.NewCustomError(customError.WithInternalServerError(err.Error(), "Error retrieving groups"))
+		return nil, translateError(ctx, err, "Error searching groups")
 	}
-	defer cursor.Close(context.Background())
+	defer cursor.Close(ctx)
 
-	var groups []*models.Group
-	if err = cursor.All(context.Background(), &groups); err != nil {
-		return nil, customError.NewCustomError(customError.WithInternalServerError(err.Error(), "Error decoding groups"))
+	groups := []*models.Group{}
+	if err = cursor.All(ctx, &groups); err != nil {
+		return nil, translateError(ctx, err, "Error decoding groups")
 	}
 
 	return groups, nil
 }
+
+// searchPatterns are the case and accent insensitive regexes of the exact,
+// prefix and partial matches of term, from the best rank to the worst.
+func searchPatterns(term string) [3]primitive.Regex {
+	pattern := functions.ToCaseInsensitiveRegex([]string{term})["$regex"].(primitive.Regex).Pattern
+	return [3]primitive.Regex{
+		{Pattern: "^(?:" + pattern + ")$", Options: "i"},
+		{Pattern: "^(?:" + pattern + ")", Options: "i"},
+		{Pattern: pattern, Options: "i"},
+	}
+}
+
+// groupFilter translates the query filters on the group itself and the cursor
+// into a Mongo filter that leaves out the trash. The participant email is
+// looked up by GetAllGroups.
+func groupFilter(query models.GroupQuery) (bson.M, error) {
+	conditions := bson.A{bson.M{"deletedAt": nil}}
+
+	if query.Name != "" {
+		conditions = append(conditions, bson.M{"name": functions.ToCaseInsensitiveRegex([]string{query.Name})})
+	}
+	if query.Status != "" {
+		conditions = append(conditions, bson.M{"status": query.Status})
+	}
+	if query.Owner != "" {
+		conditions = append(conditions, bson.M{"owner": exactCaseInsensitive(query.Owner)})
+	}
+
+	if query.Cursor != "" {
+		after, err := decodePageCursor(query.Cursor, query)
+		if err != nil {
+			return nil, err
+		}
+		conditions = append(conditions, after.filter())
+	}
+
+	return bson.M{"$and": conditions}, nil
+}
+
+func exactCaseInsensitive(value string) bson.M {
+	return bson.M{"$regex": primitive.Regex{Pattern: "^" + regexp.QuoteMeta(value) + "$", Options: "i"}}
+}
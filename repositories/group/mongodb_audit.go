@@ -0,0 +1,109 @@
+package group
+
+import (
+	"context"
+	"time"
+
+	"service-secret-santa/customError"
+	"service-secret-santa/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func (r *resource) AppendAudit(ctx context.Context, event *models.AuditEvent) *customError.CustomError {
+	ctx, cancel := writeContext(ctx)
+	defer cancel()
+
+	if event.At.IsZero() {
+		event.At = time.Now()
+	}
+	event.At = storedTime(event.At)
+
+	result, err := r.collection("audit").InsertOne(ctx, event)
+	if err != nil {
+		return translateError(ctx, err, "Failed to record audit event")
+	}
+	if objectID, ok := result.InsertedID.(primitive.ObjectID); ok {
+		event.Id = objectID
+	}
+
+	return nil
+}
+
+func (r *resource) GetAuditEvents(ctx context.Context, id string, query models.AuditQuery) ([]models.AuditEvent, *customError.CustomError) {
+	ctx, cancel := readContext(ctx)
+	defer cancel()
+
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, invalidGroupID()
+	}
+
+	filter := bson.M{"groupId": objectID}
+	if query.Action != "" {
+		filter["action"] = query.Action
+	}
+	if query.Actor != "" {
+		filter["actor"] = query.Actor
+	}
+	at := bson.M{}
+	if !query.Since.IsZero() {
+		at["$gte"] = query.Since
+	}
+	if !query.Until.IsZero() {
+		at["$lt"] = query.Until
+	}
+	if len(at) > 0 {
+		filter["at"] = at
+	}
+
+	return r.auditWhere(ctx, filter, int64(query.Limit))
+}
+
+func (r *resource) GetAuditEventsByActor(ctx context.Context, actor string) ([]models.AuditEvent, *customError.CustomError) {
+	ctx, cancel := readContext(ctx)
+	defer cancel()
+
+	return r.auditWhere(ctx, bson.M{"actor": actor}, 0)
+}
+
+// auditWhere reads the events that match filter in the order they were
+// recorded, up to limit; 0 reads them all.
+func (r *resource) auditWhere(ctx context.Context, filter bson.M, limit int64) ([]models.AuditEvent, *customError.CustomError) {
+	opts := options.Find().SetSort(bson.D{{Key: "at", Value: 1}, {Key: "_id", Value: 1}}).SetLimit(limit)
+	cursor, err := r.collection("audit").Find(ctx, filter, opts)
+	if err != nil {
+		return nil, translateError(ctx, err, "Error retrieving audit events")
+	}
+	defer cursor.Close(ctx)
+
+	events := []models.AuditEvent{}
+	if err := cursor.All(ctx, &events); err != nil {
+		return nil, translateError(ctx, err, "Error retrieving audit events")
+	}
+
+	return events, nil
+}
+
+func (r *resource) RewriteAuditEvents(ctx context.Context, events []models.AuditEvent) *customError.CustomError {
+	if len(events) == 0 {
+		return nil
+	}
+
+	ctx, cancel := writeContext(ctx)
+	defer cancel()
+
+	writes := make([]mongo.WriteModel, 0, len(events))
+	for _, event := range events {
+		update := bson.M{"$set": bson.M{"actor": event.Actor, "detail": event.Detail, "changes": event.Changes}}
+		writes = append(writes, mongo.NewUpdateOneModel().SetFilter(bson.M{"_id": event.Id}).SetUpdate(update))
+	}
+	if _, err := r.collection("audit").BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false)); err != nil {
+		return translateError(ctx, err, "Failed to rewrite audit events")
+	}
+
+	return nil
+}
@@ -0,0 +1,80 @@
+package group
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"service-secret-santa/config"
+	"service-secret-santa/models"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func TestGetAuditEvents(t *testing.T) {
+	config.LoadConfig()
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("filters and sorts oldest first", func(mt *mtest.T) {
+		repo := standaloneRepository(mt.Client)
+		groupID := primitive.NewObjectID()
+		since := time.Date(2024, 12, 1, 0, 0, 0, 0, time.UTC)
+
+		event := models.AuditEvent{
+			Id: primitive.NewObjectID(), GroupId: groupID, Action: models.AuditParticipantRemoved, Actor: "ana@example.com", At: since.Add(time.Hour),
+			RequestId: "req-1", Changes: []models.AuditChange{{Field: "participants", Before: `"Bia"`}},
+		}
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "secret-santa.audit", mtest.FirstBatch, toBSON(t, event)))
+
+		events, err := repo.GetAuditEvents(context.Background(), groupID.Hex(), models.AuditQuery{Action: models.AuditParticipantRemoved, Since: since, Limit: 10})
+		assert.Nil(t, err)
+		assert.Equal(t, []models.AuditEvent{event}, events)
+
+		find := mt.GetStartedEvent().Command
+		assert.Equal(t, groupID, find.Lookup("filter", "groupId").ObjectID())
+		assert.Equal(t, models.AuditParticipantRemoved, find.Lookup("filter", "action").StringValue())
+		assert.Equal(t, since.UnixMilli(), int64(find.Lookup("filter", "at", "$gte").DateTime()))
+		_, missing := find.LookupErr("filter", "actor")
+		assert.Error(t, missing)
+		assert.Equal(t, bson.D{{Key: "at", Value: int32(1)}, {Key: "_id", Value: int32(1)}}, toBSON(t, find.Lookup("sort").Document()))
+	})
+
+	mt.Run("invalid id", func(mt *mtest.T) {
+		repo := standaloneRepository(mt.Client)
+
+		_, err := repo.GetAuditEvents(context.Background(), "not-an-id", models.AuditQuery{Limit: 10})
+		assert.Equal(t, 400, err.Status)
+	})
+}
+
+func TestRewriteAuditEvents(t *testing.T) {
+	config.LoadConfig()
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("replaces actor, detail and changes", func(mt *mtest.T) {
+		repo := standaloneRepository(mt.Client)
+		event := models.AuditEvent{Id: primitive.NewObjectID(), Actor: models.AuditRedacted, Detail: models.AuditRedacted}
+		mt.AddMockResponses(mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}, bson.E{Key: "nModified", Value: 1}))
+
+		assert.Nil(t, repo.RewriteAuditEvents(context.Background(), []models.AuditEvent{event}))
+
+		update := mt.GetStartedEvent().Command
+		assert.Equal(t, "audit", update.Lookup("update").StringValue())
+		updates, _ := update.Lookup("updates").Array().Values()
+		if assert.Len(t, updates, 1) {
+			assert.Equal(t, event.Id, updates[0].Document().Lookup("q", "_id").ObjectID())
+			assert.Equal(t, models.AuditRedacted, updates[0].Document().Lookup("u", "$set", "actor").StringValue())
+			assert.Equal(t, models.AuditRedacted, updates[0].Document().Lookup("u", "$set", "detail").StringValue())
+		}
+	})
+
+	mt.Run("nothing to rewrite", func(mt *mtest.T) {
+		repo := standaloneRepository(mt.Client)
+
+		assert.Nil(t, repo.RewriteAuditEvents(context.Background(), nil))
+		assert.Nil(t, mt.GetStartedEvent())
+	})
+}
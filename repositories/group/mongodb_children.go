@@ -0,0 +1,243 @@
+package group
+
+import (
+	"bytes"
+	"context"
+	"sort"
+
+	"service-secret-santa/models"
+	"service-secret-santa/resources/encryption"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// participantDocument is a participant in the participants collection.
+// Position keeps the order in which participants joined; emailKey is the
+// blind index of the email that duplicate checks and the email filter look
+// up. With encryption the email is only stored in emailCipher, under the
+// data key in envelope.
+type participantDocument struct {
+	Id            primitive.ObjectID   `bson:"_id,omitempty"`
+	GroupId       primitive.ObjectID   `bson:"groupId"`
+	Position      int                  `bson:"position"`
+	Name          string               `bson:"name"`
+	Email         string               `bson:"email,omitempty"`
+	EmailCipher   []byte               `bson:"emailCipher,omitempty"`
+	EmailKey      string               `bson:"emailKey,omitempty"`
+	Envelope      *encryption.Envelope `bson:"envelope,omitempty"`
+	SchemaVersion int                  `bson:"schemaVersion"`
+}
+
+// matchDocument is a match in the matches collection. With encryption both
+// names are only stored in firstCipher and secondCipher.
+type matchDocument struct {
+	Id            primitive.ObjectID   `bson:"_id,omitempty"`
+	GroupId       primitive.ObjectID   `bson:"groupId"`
+	Position      int                  `bson:"position"`
+	First         string               `bson:"first,omitempty"`
+	Second        string               `bson:"second,omitempty"`
+	FirstCipher   []byte               `bson:"firstCipher,omitempty"`
+	SecondCipher  []byte               `bson:"secondCipher,omitempty"`
+	Envelope      *encryption.Envelope `bson:"envelope,omitempty"`
+	SchemaVersion int                  `bson:"schemaVersion"`
+}
+
+// groupDetails is a group joined with its participants and matches by
+// detailsPipeline.
+type groupDetails struct {
+	models.Group `bson:",inline"`
+	Participants []participantDocument `bson:"participants"`
+	Matches      []matchDocument       `bson:"matches"`
+}
+
+// detailsPipeline reads the groups selected by filter, at most limit of them
+// in _id order, with their participants and matches. The lookups go through
+// the groupId indexes; $lookup does not keep an order, so toModel sorts the
+// results by position.
+func detailsPipeline(filter bson.M, limit int64) mongo.Pipeline {
+	return mongo.Pipeline{
+		{{Key: "$match", Value: filter}},
+		{{Key: "$sort", Value: bson.D{{Key: "_id", Value: 1}}}},
+		{{Key: "$limit", Value: limit}},
+		{{Key: "$lookup", Value: bson.M{"from": "participants", "localField": "_id", "foreignField": "groupId", "as": "participants"}}},
+		{{Key: "$lookup", Value: bson.M{"from": "matches", "localField": "_id", "foreignField": "groupId", "as": "matches"}}},
+	}
+}
+
+// details reads a group with its participants and matches. A missing group
+// is mongo.ErrNoDocuments, which translateError turns into 404.
+func (r *resource) details(ctx context.Context, objectID primitive.ObjectID) (*models.Group, error) {
+	groups, err := r.detailsWhere(ctx, liveFilter(objectID), 1)
+	if err != nil {
+		return nil, err
+	}
+	if len(groups) == 0 {
+		return nil, mongo.ErrNoDocuments
+	}
+	return groups[0], nil
+}
+
+// detailsWhere reads the groups selected by filter like details.
+func (r *resource) detailsWhere(ctx context.Context, filter bson.M, limit int64) ([]*models.Group, error) {
+	cursor, err := r.collection("groups").Aggregate(ctx, detailsPipeline(filter, limit))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var documents []groupDetails
+	if err := cursor.All(ctx, &documents); err != nil {
+		return nil, err
+	}
+
+	groups := make([]*models.Group, 0, len(documents))
+	for i := range documents {
+		group, err := r.detailsToModel(&documents[i])
+		if err != nil {
+			return nil, err
+		}
+		groups = append(groups, group)
+	}
+	return groups, nil
+}
+
+func (r *resource) detailsToModel(d *groupDetails) (*models.Group, error) {
+	group := d.Group
+
+	sort.Slice(d.Participants, func(i, j int) bool {
+		return lessByPosition(d.Participants[i].Position, d.Participants[j].Position, d.Participants[i].Id, d.Participants[j].Id)
+	})
+	group.Participants = make([]models.Participant, 0, len(d.Participants))
+	for _, document := range d.Participants {
+		participant, err := r.participantToModel(document)
+		if err != nil {
+			return nil, err
+		}
+		group.Participants = append(group.Participants, participant)
+	}
+	group.ParticipantCount = len(group.Participants)
+
+	matches, err := r.matchesToModel(d.Matches)
+	if err != nil {
+		return nil, err
+	}
+	group.Matches = matches
+	return &group, nil
+}
+
+// lessByPosition orders children by position, and by insertion among those
+// that share one.
+func lessByPosition(a, b int, aID, bID primitive.ObjectID) bool {
+	if a != b {
+		return a < b
+	}
+	return bytes.Compare(aID[:], bID[:]) < 0
+}
+
+func (r *resource) matchesToModel(documents []matchDocument) ([]models.Match, error) {
+	if len(documents) == 0 {
+		return nil, nil
+	}
+
+	sort.Slice(documents, func(i, j int) bool {
+		return lessByPosition(documents[i].Position, documents[j].Position, documents[i].Id, documents[j].Id)
+	})
+	matches := make([]models.Match, 0, len(documents))
+	for _, document := range documents {
+		match, err := r.matchToModel(document)
+		if err != nil {
+			return nil, err
+		}
+		matches = append(matches, match)
+	}
+	return matches, nil
+}
+
+func (r *resource) loadMatches(ctx context.Context, objectID primitive.ObjectID) ([]models.Match, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "position", Value: 1}})
+	cursor, err := r.collection("matches").Find(ctx, bson.M{"groupId": objectID, "deletedAt": nil}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var documents []matchDocument
+	if err := cursor.All(ctx, &documents); err != nil {
+		return nil, err
+	}
+
+	return r.matchesToModel(documents)
+}
+
+// insertParticipants stores participants of the group from position first on.
+func (r *resource) insertParticipants(ctx context.Context, objectID primitive.ObjectID, first int, participants []models.Participant) error {
+	if len(participants) == 0 {
+		return nil
+	}
+
+	documents := make([]interface{}, 0, len(participants))
+	for i, participant := range participants {
+		document, err := r.participantDocument(objectID, first+i, participant)
+		if err != nil {
+			return err
+		}
+		documents = append(documents, document)
+	}
+
+	_, err := r.collection("participants").InsertMany(ctx, documents)
+	return err
+}
+
+func (r *resource) insertMatches(ctx context.Context, objectID primitive.ObjectID, matches []models.Match) error {
+	if len(matches) == 0 {
+		return nil
+	}
+
+	documents := make([]interface{}, 0, len(matches))
+	for i, match := range matches {
+		document, err := r.matchDocument(objectID, i, match)
+		if err != nil {
+			return err
+		}
+		documents = append(documents, document)
+	}
+
+	_, err := r.collection("matches").InsertMany(ctx, documents)
+	return err
+}
+
+func (r *resource) replaceParticipants(ctx context.Context, objectID primitive.ObjectID, participants []models.Participant) error {
+	if _, err := r.collection("participants").DeleteMany(ctx, bson.M{"groupId": objectID}); err != nil {
+		return err
+	}
+	return r.insertParticipants(ctx, objectID, 0, participants)
+}
+
+func (r *resource) replaceMatches(ctx context.Context, objectID primitive.ObjectID, matches []models.Match) error {
+	if _, err := r.collection("matches").DeleteMany(ctx, bson.M{"groupId": objectID}); err != nil {
+		return err
+	}
+	return r.insertMatches(ctx, objectID, matches)
+}
+
+func (r *resource) deleteChildren(ctx context.Context, objectID primitive.ObjectID) error {
+	if _, err := r.collection("participants").DeleteMany(ctx, bson.M{"groupId": objectID}); err != nil {
+		return err
+	}
+	_, err := r.collection("matches").DeleteMany(ctx, bson.M{"groupId": objectID})
+	return err
+}
+
+// stampChildren applies update to the participants and matches of the group,
+// e.g. to move them in and out of the trash with it.
+func (r *resource) stampChildren(ctx context.Context, objectID primitive.ObjectID, update bson.M) error {
+	for _, name := range []string{"participants", "matches"} {
+		if _, err := r.collection(name).UpdateMany(ctx, bson.M{"groupId": objectID}, update); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,241 @@
+package group
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"service-secret-santa/models"
+	"service-secret-santa/resources/encryption"
+	"service-secret-santa/resources/health"
+	"service-secret-santa/resources/lifecycle"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// emailKey is the blind index of email that participants are looked up by.
+func (r *resource) emailKey(email string) string {
+	return r.keyring.BlindIndex(email)
+}
+
+// emailKeys is the filter on emailKey that finds the participants of email.
+// Participants stored in plaintext before encryption was configured keep the
+// lower-cased email as their key until the ReencryptJob encrypts them, so
+// with a keyring both keys are looked up.
+func (r *resource) emailKeys(email string) interface{} {
+	if !r.keyring.Enabled() {
+		return r.emailKey(email)
+	}
+	return bson.M{"$in": bson.A{r.emailKey(email), strings.ToLower(email)}}
+}
+
+func (r *resource) participantDocument(objectID primitive.ObjectID, position int, participant models.Participant) (participantDocument, error) {
+	document := participantDocument{
+		GroupId:       objectID,
+		Position:      position,
+		Name:          participant.Name,
+		EmailKey:      r.emailKey(participant.Email),
+		SchemaVersion: SchemaVersion,
+	}
+	if !r.keyring.Enabled() {
+		document.Email = participant.Email
+		return document, nil
+	}
+
+	envelope, ciphertexts, err := r.keyring.Seal(participant.Email)
+	if err != nil {
+		return participantDocument{}, err
+	}
+	document.Envelope = envelope
+	document.EmailCipher = ciphertexts[0]
+	return document, nil
+}
+
+func (r *resource) matchDocument(objectID primitive.ObjectID, position int, match models.Match) (matchDocument, error) {
+	document := matchDocument{GroupId: objectID, Position: position, SchemaVersion: SchemaVersion}
+	if !r.keyring.Enabled() {
+		document.First, document.Second = match.First, match.Second
+		return document, nil
+	}
+
+	envelope, ciphertexts, err := r.keyring.Seal(match.First, match.Second)
+	if err != nil {
+		return matchDocument{}, err
+	}
+	document.Envelope = envelope
+	document.FirstCipher, document.SecondCipher = ciphertexts[0], ciphertexts[1]
+	return document, nil
+}
+
+// participantToModel decrypts document, or reads it as it is when it was
+// stored in plaintext.
+func (r *resource) participantToModel(document participantDocument) (models.Participant, error) {
+	participant := models.Participant{Name: document.Name, Email: document.Email}
+	if document.Envelope == nil {
+		return participant, nil
+	}
+
+	fields, err := r.keyring.Open(document.Envelope, document.EmailCipher)
+	if err != nil {
+		return models.Participant{}, err
+	}
+	participant.Email = fields[0]
+	return participant, nil
+}
+
+func (r *resource) matchToModel(document matchDocument) (models.Match, error) {
+	if document.Envelope == nil {
+		return models.Match{First: document.First, Second: document.Second}, nil
+	}
+
+	fields, err := r.keyring.Open(document.Envelope, document.FirstCipher, document.SecondCipher)
+	if err != nil {
+		return models.Match{}, err
+	}
+	return models.Match{First: fields[0], Second: fields[1]}, nil
+}
+
+// ReencryptJob brings the participants and matches up to the current master
+// key. Documents under an older master key get their data key wrapped again;
+// documents stored in plaintext, before encryption was configured, are
+// encrypted and get the blind index of their email. Until then, lookups by
+// email also try the plaintext key, see emailKeys.
+type ReencryptJob struct {
+	repo   *resource
+	status *health.JobStatus
+}
+
+func NewReencryptJob(db *mongo.Client, keyring *encryption.Keyring, interval time.Duration) *ReencryptJob {
+	return &ReencryptJob{repo: &resource{db: db, keyring: keyring}, status: health.NewJobStatus("reencrypt", interval)}
+}
+
+// Run rewrites every stale document it finds. A document that fails is
+// logged and left for the next run, which makes the run fail.
+func (j *ReencryptJob) Run(ctx context.Context) error {
+	var errs []error
+	for _, name := range []string{"participants", "matches"} {
+		rewritten, failed, err := j.reencrypt(ctx, name)
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to re-encrypt documents", "collection", name, "error", err)
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+		}
+		if failed > 0 {
+			slog.ErrorContext(ctx, "Documents could not be re-encrypted", "collection", name, "documents", failed)
+			errs = append(errs, fmt.Errorf("%s that could not be re-encrypted: %d", name, failed))
+		}
+		if rewritten > 0 {
+			slog.InfoContext(ctx, "Documents re-encrypted", "collection", name, "documents", rewritten, "key", j.repo.keyring.CurrentKey())
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Component runs once on start and then every interval until stopped.
+func (j *ReencryptJob) Component() lifecycle.Component {
+	return j.status.Component(j.Run)
+}
+
+// Check reports the runs to the readiness probe.
+func (j *ReencryptJob) Check() health.Check {
+	return j.status.Check()
+}
+
+func (j *ReencryptJob) reencrypt(ctx context.Context, name string) (rewritten, failed int, err error) {
+	collection := j.repo.collection(name)
+
+	filter := bson.M{"envelope.keyId": bson.M{"$ne": j.repo.keyring.CurrentKey()}}
+	cursor, err := collection.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}))
+	if err != nil {
+		return 0, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		current, update, sealErr := j.repo.reencryptDocument(name, cursor.Current)
+		if sealErr != nil {
+			slog.ErrorContext(ctx, "Failed to re-encrypt document", "collection", name, "id", cursor.Current.Lookup("_id").String(), "error", sealErr)
+			failed++
+			continue
+		}
+
+		// The document is left alone if it changed since it was read.
+		writeCtx, cancel := writeContext(ctx)
+		result, updateErr := collection.UpdateOne(writeCtx, current, update)
+		cancel()
+		if updateErr != nil {
+			return rewritten, failed, updateErr
+		}
+		rewritten += int(result.ModifiedCount)
+	}
+
+	return rewritten, failed, cursor.Err()
+}
+
+// reencryptDocument returns the filter that matches raw, a document of the
+// named collection, as it was read and the update that brings it to the
+// current master key.
+func (r *resource) reencryptDocument(name string, raw bson.Raw) (bson.M, bson.M, error) {
+	if name == "participants" {
+		var document participantDocument
+		if err := bson.Unmarshal(raw, &document); err != nil {
+			return nil, nil, err
+		}
+		return r.reencryptParticipant(document)
+	}
+
+	var document matchDocument
+	if err := bson.Unmarshal(raw, &document); err != nil {
+		return nil, nil, err
+	}
+	return r.reencryptMatch(document)
+}
+
+func (r *resource) reencryptParticipant(document participantDocument) (bson.M, bson.M, error) {
+	if document.Envelope != nil {
+		envelope, err := r.keyring.Rewrap(document.Envelope)
+		if err != nil {
+			return nil, nil, err
+		}
+		return bson.M{"_id": document.Id, "envelope.keyId": document.Envelope.KeyId}, bson.M{"$set": bson.M{"envelope": envelope}}, nil
+	}
+
+	sealed, err := r.participantDocument(document.GroupId, document.Position, models.Participant{Name: document.Name, Email: document.Email})
+	if err != nil {
+		return nil, nil, err
+	}
+	set := bson.M{"envelope": sealed.Envelope, "emailCipher": sealed.EmailCipher}
+	unset := bson.M{"email": ""}
+	if sealed.EmailKey != "" {
+		set["emailKey"] = sealed.EmailKey
+	} else {
+		unset["emailKey"] = ""
+	}
+	update := bson.M{"$set": set, "$unset": unset}
+	return bson.M{"_id": document.Id, "envelope": nil}, update, nil
+}
+
+func (r *resource) reencryptMatch(document matchDocument) (bson.M, bson.M, error) {
+	if document.Envelope != nil {
+		envelope, err := r.keyring.Rewrap(document.Envelope)
+		if err != nil {
+			return nil, nil, err
+		}
+		return bson.M{"_id": document.Id, "envelope.keyId": document.Envelope.KeyId}, bson.M{"$set": bson.M{"envelope": envelope}}, nil
+	}
+
+	sealed, err := r.matchDocument(document.GroupId, document.Position, models.Match{First: document.First, Second: document.Second})
+	if err != nil {
+		return nil, nil, err
+	}
+	update := bson.M{
+		"$set":   bson.M{"envelope": sealed.Envelope, "firstCipher": sealed.FirstCipher, "secondCipher": sealed.SecondCipher},
+		"$unset": bson.M{"first": "", "second": ""},
+	}
+	return bson.M{"_id": document.Id, "envelope": nil}, update, nil
+}
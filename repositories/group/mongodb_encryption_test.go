@@ -0,0 +1,176 @@
+package group
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"service-secret-santa/config"
+	"service-secret-santa/customError"
+	"service-secret-santa/models"
+	"service-secret-santa/resources/encryption"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func testKeyring(t *testing.T, masterKeys string) *encryption.Keyring {
+	key := func(fill byte) string { return base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{fill}, 32)) }
+	keyring, err := encryption.NewKeyring(map[string]string{
+		"new":         "new:" + key(2),
+		"old":         "old:" + key(1),
+		"new and old": "new:" + key(2) + ",old:" + key(1),
+	}[masterKeys], key(9))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return keyring
+}
+
+func toBSON(t *testing.T, document interface{}) bson.D {
+	raw, err := bson.Marshal(document)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var d bson.D
+	_ = bson.Unmarshal(raw, &d)
+	return d
+}
+
+func TestEncryptedDocuments(t *testing.T) {
+	repo := &resource{keyring: testKeyring(t, "new")}
+	groupID := primitive.NewObjectID()
+
+	participant, err := repo.participantDocument(groupID, 0, models.Participant{Name: "Ana", Email: "Ana@Example.com"})
+	assert.NoError(t, err)
+	assert.Empty(t, participant.Email)
+	assert.Equal(t, repo.keyring.BlindIndex("ana@example.com"), participant.EmailKey)
+	assert.Equal(t, "new", participant.Envelope.KeyId)
+	assert.NotContains(t, string(participant.EmailCipher), "Ana@Example.com")
+
+	match, err := repo.matchDocument(groupID, 0, models.Match{First: "Ana", Second: "Bia"})
+	assert.NoError(t, err)
+	assert.Empty(t, match.First)
+	assert.Empty(t, match.Second)
+
+	plaintext := &resource{}
+	_, err = plaintext.participantToModel(participant)
+	assert.Error(t, err, "encrypted documents need the keyring")
+
+	decoded, err := repo.participantToModel(participant)
+	assert.NoError(t, err)
+	assert.Equal(t, models.Participant{Name: "Ana", Email: "Ana@Example.com"}, decoded)
+}
+
+func TestGetGroupByID_Encrypted(t *testing.T) {
+	config.LoadConfig()
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("decrypts encrypted and plaintext children", func(mt *mtest.T) {
+		keyring := testKeyring(t, "new and old")
+		repo := NewGroupRepository(mt.Client, keyring)
+		writer := &resource{keyring: testKeyring(t, "old")}
+
+		groupID := primitive.NewObjectID()
+		ana, _ := writer.participantDocument(groupID, 0, models.Participant{Name: "Ana", Email: "ana@example.com"})
+		first, _ := writer.matchDocument(groupID, 0, models.Match{First: "Ana", Second: "Bia"})
+		second, _ := writer.matchDocument(groupID, 1, models.Match{First: "Bia", Second: "Ana"})
+		details := append(groupToBSON(&models.Group{Id: groupID, Name: "Amigos", Version: 3}),
+			bson.E{Key: "participants", Value: bson.A{
+				toBSON(t, ana),
+				bson.D{{Key: "groupId", Value: groupID}, {Key: "position", Value: 1}, {Key: "name", Value: "Bia"}, {Key: "email", Value: "bia@example.com"}},
+			}},
+			bson.E{Key: "matches", Value: bson.A{toBSON(t, first), toBSON(t, second)}},
+		)
+
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "secret-santa.groups", mtest.FirstBatch, details))
+
+		group, err := repo.GetGroupByID(context.Background(), groupID.Hex())
+		assert.Nil(t, err)
+		assert.Equal(t, []models.Participant{{Name: "Ana", Email: "ana@example.com"}, {Name: "Bia", Email: "bia@example.com"}}, group.Participants)
+		assert.Equal(t, []models.Match{{First: "Ana", Second: "Bia"}, {First: "Bia", Second: "Ana"}}, group.Matches)
+	})
+
+	mt.Run("looks participants up by blind index and plaintext key", func(mt *mtest.T) {
+		keyring := testKeyring(t, "new")
+		repo := NewGroupRepository(mt.Client, keyring)
+
+		mt.AddMockResponses(
+			bson.D{{Key: "ok", Value: 1}, {Key: "values", Value: bson.A{}}},
+			mtest.CreateCursorResponse(0, "secret-santa.groups", mtest.FirstBatch),
+		)
+
+		_, err := repo.GetGroupsByEmail(context.Background(), "Ana@Example.com")
+		assert.Nil(t, err)
+
+		distinct := mt.GetStartedEvent()
+		keys, _ := distinct.Command.Lookup("query", "emailKey", "$in").Array().Values()
+		if assert.Len(t, keys, 2) {
+			assert.Equal(t, keyring.BlindIndex("ana@example.com"), keys[0].StringValue())
+			assert.Equal(t, "ana@example.com", keys[1].StringValue())
+		}
+	})
+
+	mt.Run("finds duplicates still stored in plaintext", func(mt *mtest.T) {
+		repo := &resource{db: mt.Client, keyring: testKeyring(t, "new")}
+		repo.transactions.Store(transactionsUnsupported)
+
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "secret-santa.participants", mtest.FirstBatch, bson.D{{Key: "n", Value: 1}}))
+
+		_, err := repo.AddParticipant(context.Background(), primitive.NewObjectID().Hex(), 1, &models.Participant{Name: "Ana", Email: "Ana@Example.com"})
+		if assert.NotNil(t, err) {
+			assert.Equal(t, customError.ParticipantDuplicate, err.Kind())
+		}
+
+		count := mt.GetStartedEvent()
+		keys, _ := count.Command.Lookup("pipeline").Array().Index(0).Value().Document().Lookup("$match", "emailKey", "$in").Array().Values()
+		assert.Len(t, keys, 2)
+	})
+}
+
+func TestReencryptJob(t *testing.T) {
+	config.LoadConfig()
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("encrypts plaintext and rewraps old keys", func(mt *mtest.T) {
+		keyring := testKeyring(t, "new and old")
+		job := NewReencryptJob(mt.Client, keyring, time.Hour)
+
+		groupID := primitive.NewObjectID()
+		plaintext := bson.D{{Key: "_id", Value: primitive.NewObjectID()}, {Key: "groupId", Value: groupID}, {Key: "name", Value: "Ana"}, {Key: "email", Value: "Ana@Example.com"}, {Key: "emailKey", Value: "ana@example.com"}}
+		old, _ := (&resource{keyring: testKeyring(t, "old")}).matchDocument(groupID, 0, models.Match{First: "Ana", Second: "Bia"})
+		old.Id = primitive.NewObjectID()
+
+		updated := bson.D{{Key: "ok", Value: 1}, {Key: "n", Value: 1}, {Key: "nModified", Value: 1}}
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, "secret-santa.participants", mtest.FirstBatch, plaintext),
+			updated,
+			mtest.CreateCursorResponse(0, "secret-santa.matches", mtest.FirstBatch, toBSON(t, old)),
+			updated,
+		)
+
+		assert.NoError(t, job.Run(context.Background()))
+
+		events := mt.GetAllStartedEvents()
+		if !assert.Len(t, events, 4) {
+			return
+		}
+		assert.Equal(t, "new", events[0].Command.Lookup("filter", "envelope.keyId", "$ne").StringValue())
+
+		participant := events[1].Command.Lookup("updates").Array().Index(0).Value().Document()
+		assert.Equal(t, bson.TypeNull, participant.Lookup("q", "envelope").Type)
+		assert.Equal(t, keyring.BlindIndex("ana@example.com"), participant.Lookup("u", "$set", "emailKey").StringValue())
+		assert.Equal(t, "new", participant.Lookup("u", "$set", "envelope", "keyId").StringValue())
+		assert.Equal(t, bson.TypeString, participant.Lookup("u", "$unset", "email").Type)
+
+		match := events[3].Command.Lookup("updates").Array().Index(0).Value().Document()
+		assert.Equal(t, "old", match.Lookup("q", "envelope.keyId").StringValue())
+		assert.Equal(t, "new", match.Lookup("u", "$set", "envelope", "keyId").StringValue())
+		_, err := match.LookupErr("u", "$set", "firstCipher")
+		assert.Error(t, err, "the fields keep their data key")
+	})
+}
@@ -0,0 +1,372 @@
+package group
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"service-secret-santa/config"
+	"service-secret-santa/models"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// SchemaVersion is the shape of the group, participant and match documents
+// written by this build, stored in their schemaVersion field. Groups without
+// one were written with participants and matches embedded.
+const SchemaVersion = 2
+
+// MongoMigration brings the Mongo database from the previous version to
+// Version. Up must be safe to run again after failing halfway.
+type MongoMigration struct {
+	Version     int
+	Description string
+	Up          func(ctx context.Context, db *mongo.Client) error
+}
+
+// mongoMigrations is the history of the Mongo database, oldest first. New
+// migrations go at the end with the next version; applied ones must not
+// change, since databases will not run them again.
+var mongoMigrations = []MongoMigration{
+	{Version: 1, Description: "Create the indexes of groups, participants and matches", Up: createIndexes},
+	{Version: 2, Description: "Move embedded participants and matches to their collections and set schemaVersion", Up: moveEmbeddedChildren},
+	{Version: 3, Description: "Create the indexes of the retention job and the audit log", Up: createIndexes},
+	{Version: 4, Description: "Create the index of the erasure certificates", Up: createIndexes},
+	{Version: 5, Description: "Create the master key indexes of the re-encryption job", Up: createIndexes},
+	{Version: 6, Description: "Make participant emails unique within a group", Up: uniqueParticipantEmails},
+	{Version: 7, Description: "Clear the unkeyed subject hashes of the erasure certificates", Up: clearSubjectHashes},
+	{Version: 8, Description: "Create the actor index of the audit log", Up: createIndexes},
+}
+
+// MigrationStatus is a migration and when it was applied. AppliedAt is zero
+// while it is pending.
+type MigrationStatus struct {
+	Version     int
+	Description string
+	AppliedAt   time.Time
+	Duration    time.Duration
+}
+
+func (s MigrationStatus) Applied() bool {
+	return !s.AppliedAt.IsZero()
+}
+
+// migrationRecord is an applied migration in the migrations collection.
+type migrationRecord struct {
+	Version     int       `bson:"_id"`
+	Description string    `bson:"description"`
+	AppliedAt   time.Time `bson:"appliedAt"`
+	DurationMs  int64     `bson:"durationMs"`
+	AppliedBy   string    `bson:"appliedBy"`
+}
+
+// The lock is a lease on a document of the migrations collection. Its holder
+// renews it while migrating, so a crashed instance blocks the others for at
+// most migrationLockTTL.
+const migrationLockID = "lock"
+
+var (
+	migrationLockTTL  = time.Minute
+	migrationLockPoll = time.Second
+)
+
+// MongoMigrationStatus lists the migrations this build knows and whether the
+// database has applied them, without changing anything.
+func MongoMigrationStatus(ctx context.Context, db *mongo.Client) ([]MigrationStatus, error) {
+	return migrationStatus(ctx, db, mongoMigrations)
+}
+
+// MigrateMongo applies the pending migrations in order and returns them.
+// Each is recorded in the migrations collection once it succeeds. Instances
+// take turns through a lock, so the ones that wait find nothing left to do.
+// It fails when the database was migrated by a newer build.
+func MigrateMongo(ctx context.Context, db *mongo.Client) ([]MigrationStatus, error) {
+	return migrate(ctx, db, mongoMigrations)
+}
+
+func migrationsCollection(db *mongo.Client) *mongo.Collection {
+	return db.Database(config.Cfg.MongoDB).Collection("migrations")
+}
+
+func migrationStatus(ctx context.Context, db *mongo.Client, migrations []MongoMigration) ([]MigrationStatus, error) {
+	for i := 1; i < len(migrations); i++ {
+		if migrations[i].Version <= migrations[i-1].Version {
+			return nil, fmt.Errorf("migration %d is listed after migration %d", migrations[i].Version, migrations[i-1].Version)
+		}
+	}
+
+	cursor, err := migrationsCollection(db).Find(ctx, bson.M{"_id": bson.M{"$type": "number"}})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var records []migrationRecord
+	if err := cursor.All(ctx, &records); err != nil {
+		return nil, err
+	}
+
+	applied := make(map[int]migrationRecord, len(records))
+	for _, record := range records {
+		applied[record.Version] = record
+	}
+
+	statuses := make([]MigrationStatus, 0, len(migrations))
+	for _, migration := range migrations {
+		status := MigrationStatus{Version: migration.Version, Description: migration.Description}
+		if record, found := applied[migration.Version]; found {
+			status.AppliedAt = record.AppliedAt
+			status.Duration = time.Duration(record.DurationMs) * time.Millisecond
+			delete(applied, migration.Version)
+		}
+		statuses = append(statuses, status)
+	}
+
+	for version := range applied {
+		return nil, fmt.Errorf("the database has migration %d, which this build does not know; it was migrated by a newer version", version)
+	}
+
+	return statuses, nil
+}
+
+func migrate(ctx context.Context, db *mongo.Client, migrations []MongoMigration) ([]MigrationStatus, error) {
+	collection := migrationsCollection(db)
+	holder := lockHolder()
+
+	release, err := acquireMigrationLock(ctx, collection, holder)
+	if err != nil {
+		return nil, fmt.Errorf("acquiring the migration lock: %w", err)
+	}
+	defer release()
+
+	statuses, err := migrationStatus(ctx, db, migrations)
+	if err != nil {
+		return nil, err
+	}
+
+	applied := []MigrationStatus{}
+	for i, migration := range migrations {
+		if statuses[i].Applied() {
+			continue
+		}
+
+		start := time.Now()
+		if err := migration.Up(ctx, db); err != nil {
+			return applied, fmt.Errorf("migration %d (%s): %w", migration.Version, migration.Description, err)
+		}
+
+		record := migrationRecord{
+			Version:     migration.Version,
+			Description: migration.Description,
+			AppliedAt:   time.Now().UTC().Truncate(time.Millisecond),
+			DurationMs:  time.Since(start).Milliseconds(),
+			AppliedBy:   holder,
+		}
+		if _, err := collection.InsertOne(ctx, record); err != nil {
+			return applied, fmt.Errorf("recording migration %d: %w", migration.Version, err)
+		}
+
+		slog.InfoContext(ctx, "Migration applied", "version", record.Version, "description", record.Description, "duration_ms", record.DurationMs)
+		applied = append(applied, MigrationStatus{
+			Version:     record.Version,
+			Description: record.Description,
+			AppliedAt:   record.AppliedAt,
+			Duration:    time.Duration(record.DurationMs) * time.Millisecond,
+		})
+	}
+
+	return applied, nil
+}
+
+func lockHolder() string {
+	host, _ := os.Hostname()
+	return fmt.Sprintf("%s/%d", host, os.Getpid())
+}
+
+// acquireMigrationLock waits until it holds the lock and returns the function
+// that releases it. Taking the lock is an upsert that only matches an expired
+// lease; while someone else holds it, the upsert collides on _id.
+func acquireMigrationLock(ctx context.Context, collection *mongo.Collection, holder string) (func(), error) {
+	for waiting := false; ; waiting = true {
+		now := time.Now()
+		_, err := collection.UpdateOne(ctx,
+			bson.M{"_id": migrationLockID, "expiresAt": bson.M{"$lt": now}},
+			bson.M{"$set": bson.M{"holder": holder, "expiresAt": now.Add(migrationLockTTL)}},
+			options.Update().SetUpsert(true),
+		)
+		if err == nil {
+			break
+		}
+		if !mongo.IsDuplicateKeyError(err) {
+			return nil, err
+		}
+
+		if !waiting {
+			slog.InfoContext(ctx, "Waiting for another instance to finish migrating")
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(migrationLockPoll):
+		}
+	}
+
+	renewCtx, stop := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(migrationLockTTL / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-renewCtx.Done():
+				return
+			case <-ticker.C:
+				renew := bson.M{"$set": bson.M{"expiresAt": time.Now().Add(migrationLockTTL)}}
+				if _, err := collection.UpdateOne(renewCtx, bson.M{"_id": migrationLockID, "holder": holder}, renew); err != nil && renewCtx.Err() == nil {
+					slog.Warn("Failed to renew the migration lock", "error", err)
+				}
+			}
+		}
+	}()
+
+	return func() {
+		stop()
+		<-done
+		if _, err := collection.DeleteOne(context.Background(), bson.M{"_id": migrationLockID, "holder": holder}); err != nil {
+			slog.Warn("Failed to release the migration lock", "error", err)
+		}
+	}, nil
+}
+
+func createIndexes(_ context.Context, db *mongo.Client) error {
+	return CreateIndexes(db)
+}
+
+// Server error codes of dropping an index that, or whose collection, does not
+// exist.
+const (
+	namespaceNotFound = 26
+	indexNotFound     = 27
+)
+
+// participantEmailIndex is the index on the blind index of participant emails
+// within a group, unique since migration 6.
+const participantEmailIndex = "groupId_1_emailKey_1"
+
+// uniqueParticipantEmails replaces the index on the emails of a group by a
+// unique one. It refuses to when a group already has an email twice, since
+// only its organizer can tell which participant should go.
+func uniqueParticipantEmails(ctx context.Context, db *mongo.Client) error {
+	participants := (&resource{db: db}).collection("participants")
+
+	cursor, err := participants.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"emailKey": bson.M{"$type": "string"}}}},
+		{{Key: "$group", Value: bson.M{"_id": bson.M{"groupId": "$groupId", "emailKey": "$emailKey"}, "count": bson.M{"$sum": 1}}}},
+		{{Key: "$match", Value: bson.M{"count": bson.M{"$gt": 1}}}},
+		{{Key: "$group", Value: bson.M{"_id": "$_id.groupId"}}},
+	})
+	if err != nil {
+		return err
+	}
+	var duplicated []struct {
+		GroupId primitive.ObjectID `bson:"_id"`
+	}
+	if err := cursor.All(ctx, &duplicated); err != nil {
+		return err
+	}
+	if len(duplicated) > 0 {
+		ids := make([]string, 0, len(duplicated))
+		for _, group := range duplicated {
+			ids = append(ids, group.GroupId.Hex())
+		}
+		return fmt.Errorf("groups %s have participants with the same email; remove the duplicates and start again", strings.Join(ids, ", "))
+	}
+
+	if _, err := participants.Indexes().DropOne(ctx, participantEmailIndex); err != nil && !hasErrorCode(err, indexNotFound) && !hasErrorCode(err, namespaceNotFound) {
+		return err
+	}
+
+	return CreateIndexes(db)
+}
+
+// clearSubjectHashes empties the subject hash of the certificates written
+// while it was a plain SHA-256 of the email, which hashing candidate emails
+// would reverse. Certificates written since carry an HMAC and come later.
+func clearSubjectHashes(ctx context.Context, db *mongo.Client) error {
+	erasures := (&resource{db: db}).collection("erasures")
+	_, err := erasures.UpdateMany(ctx, bson.M{"subjectHash": bson.M{"$ne": ""}}, bson.M{"$set": bson.M{"subjectHash": ""}})
+	return err
+}
+
+// embeddedGroup is the part of a group document written before participants
+// and matches moved to collections of their own.
+type embeddedGroup struct {
+	Id           primitive.ObjectID   `bson:"_id"`
+	Participants []models.Participant `bson:"participants"`
+	Matches      []models.Match       `bson:"matches"`
+}
+
+// moveEmbeddedChildren moves the participants and matches embedded in groups
+// written before schema version 2 to their collections, stamps the groups
+// with schemaVersion 2 and drops the index on the old participants.email
+// field. Groups are moved one at a time and their children replaced, so a
+// run that stops halfway is finished by the next one.
+func moveEmbeddedChildren(ctx context.Context, db *mongo.Client) error {
+	r := &resource{db: db}
+	groups := r.collection("groups")
+
+	filter := bson.M{"schemaVersion": bson.M{"$not": bson.M{"$gte": 2}}}
+	projection := options.Find().SetProjection(bson.M{"participants": 1, "matches": 1})
+
+	cursor, err := groups.Find(ctx, filter, projection)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	moved := 0
+	for cursor.Next(ctx) {
+		var group embeddedGroup
+		if err := cursor.Decode(&group); err != nil {
+			return err
+		}
+
+		set := bson.M{"schemaVersion": 2}
+		if _, err := cursor.Current.LookupErr("participants"); err == nil {
+			if err := r.replaceParticipants(ctx, group.Id, group.Participants); err != nil {
+				return err
+			}
+			set["participantCount"] = len(group.Participants)
+		}
+		if _, err := cursor.Current.LookupErr("matches"); err == nil {
+			if err := r.replaceMatches(ctx, group.Id, group.Matches); err != nil {
+				return err
+			}
+		}
+
+		update := bson.M{"$set": set, "$unset": bson.M{"participants": "", "matches": ""}}
+		if _, err := groups.UpdateOne(ctx, bson.M{"_id": group.Id}, update); err != nil {
+			return err
+		}
+		moved++
+	}
+	if err := cursor.Err(); err != nil {
+		return err
+	}
+
+	if moved > 0 {
+		slog.InfoContext(ctx, "Moved embedded participants and matches", "groups", moved)
+	}
+
+	if _, err := groups.Indexes().DropOne(ctx, "participants.email_1"); err != nil && !hasErrorCode(err, indexNotFound) && !hasErrorCode(err, namespaceNotFound) {
+		return err
+	}
+
+	return nil
+}
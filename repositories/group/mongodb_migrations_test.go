@@ -0,0 +1,272 @@
+package group
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"service-secret-santa/config"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+var lockTaken = bson.D{{Key: "ok", Value: 1}, {Key: "n", Value: 1}, {Key: "nModified", Value: 0}, {Key: "upserted", Value: bson.A{bson.D{{Key: "index", Value: 0}, {Key: "_id", Value: migrationLockID}}}}}
+
+func recordedMigrations(versions ...int) bson.D {
+	records := make([]bson.D, 0, len(versions))
+	for _, version := range versions {
+		records = append(records, bson.D{{Key: "_id", Value: version}, {Key: "description", Value: "applied"}, {Key: "appliedAt", Value: time.Now()}, {Key: "durationMs", Value: 12}})
+	}
+	return mtest.CreateCursorResponse(0, "secret-santa.migrations", mtest.FirstBatch, records...)
+}
+
+func fakeMigrations(ran *[]int, failing int) []MongoMigration {
+	migrations := []MongoMigration{}
+	for _, version := range []int{1, 2, 3} {
+		version := version
+		migrations = append(migrations, MongoMigration{Version: version, Description: "fake", Up: func(context.Context, *mongo.Client) error {
+			if version == failing {
+				return errors.New("boom")
+			}
+			*ran = append(*ran, version)
+			return nil
+		}})
+	}
+	return migrations
+}
+
+func commandNames(mt *mtest.T) []string {
+	var names []string
+	for _, event := range mt.GetAllStartedEvents() {
+		names = append(names, event.CommandName)
+	}
+	return names
+}
+
+func TestMigrate(t *testing.T) {
+	config.LoadConfig()
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("applies pending migrations in order", func(mt *mtest.T) {
+		var ran []int
+		mt.AddMockResponses(
+			lockTaken,
+			recordedMigrations(1),
+			mtest.CreateSuccessResponse(),
+			mtest.CreateSuccessResponse(),
+			bson.D{{Key: "ok", Value: 1}, {Key: "n", Value: 1}},
+		)
+
+		applied, err := migrate(context.Background(), mt.Client, fakeMigrations(&ran, 0))
+		assert.Nil(t, err)
+		assert.Equal(t, []int{2, 3}, ran)
+		if assert.Len(t, applied, 2) {
+			assert.Equal(t, 2, applied[0].Version)
+			assert.True(t, applied[0].Applied())
+		}
+		assert.Equal(t, []string{"update", "find", "insert", "insert", "delete"}, commandNames(mt))
+
+		record := mt.GetAllStartedEvents()[2].Command.Lookup("documents").Array().Index(0).Value().Document()
+		assert.Equal(t, int32(2), record.Lookup("_id").Int32())
+	})
+
+	mt.Run("waits for the lock", func(mt *mtest.T) {
+		defer func(poll time.Duration) { migrationLockPoll = poll }(migrationLockPoll)
+		migrationLockPoll = time.Millisecond
+
+		var ran []int
+		mt.AddMockResponses(
+			mtest.CreateWriteErrorsResponse(mtest.WriteError{Code: 11000, Message: "E11000 duplicate key"}),
+			lockTaken,
+			recordedMigrations(1, 2, 3),
+			bson.D{{Key: "ok", Value: 1}, {Key: "n", Value: 1}},
+		)
+
+		applied, err := migrate(context.Background(), mt.Client, fakeMigrations(&ran, 0))
+		assert.Nil(t, err)
+		assert.Empty(t, applied)
+		assert.Empty(t, ran)
+		assert.Equal(t, []string{"update", "update", "find", "delete"}, commandNames(mt))
+	})
+
+	mt.Run("gives up waiting when the context ends", func(mt *mtest.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		mt.AddMockResponses(mtest.CreateWriteErrorsResponse(mtest.WriteError{Code: 11000, Message: "E11000 duplicate key"}))
+
+		_, err := migrate(ctx, mt.Client, fakeMigrations(new([]int), 0))
+		assert.NotNil(t, err)
+	})
+
+	mt.Run("stops at a failing migration", func(mt *mtest.T) {
+		var ran []int
+		mt.AddMockResponses(
+			lockTaken,
+			recordedMigrations(),
+			mtest.CreateSuccessResponse(),
+			bson.D{{Key: "ok", Value: 1}, {Key: "n", Value: 1}},
+		)
+
+		applied, err := migrate(context.Background(), mt.Client, fakeMigrations(&ran, 2))
+		assert.ErrorContains(t, err, "migration 2 (fake): boom")
+		assert.Equal(t, []int{1}, ran)
+		assert.Len(t, applied, 1)
+		assert.Equal(t, []string{"update", "find", "insert", "delete"}, commandNames(mt))
+	})
+
+	mt.Run("refuses a database migrated by a newer build", func(mt *mtest.T) {
+		mt.AddMockResponses(recordedMigrations(1, 2, 3, 4))
+
+		_, err := migrationStatus(context.Background(), mt.Client, fakeMigrations(new([]int), 0))
+		assert.ErrorContains(t, err, "migration 4")
+	})
+
+	mt.Run("lists applied and pending migrations", func(mt *mtest.T) {
+		mt.AddMockResponses(recordedMigrations(1))
+
+		statuses, err := migrationStatus(context.Background(), mt.Client, fakeMigrations(new([]int), 0))
+		assert.Nil(t, err)
+		if assert.Len(t, statuses, 3) {
+			assert.True(t, statuses[0].Applied())
+			assert.Equal(t, 12*time.Millisecond, statuses[0].Duration)
+			assert.False(t, statuses[1].Applied())
+		}
+	})
+}
+
+func TestMigrationOrder(t *testing.T) {
+	config.LoadConfig()
+
+	migrations := fakeMigrations(new([]int), 0)
+	migrations[1], migrations[2] = migrations[2], migrations[1]
+
+	_, err := migrationStatus(context.Background(), nil, migrations)
+	assert.ErrorContains(t, err, "listed after")
+
+	for i, migration := range mongoMigrations {
+		assert.Equal(t, i+1, migration.Version)
+	}
+}
+
+func TestMoveEmbeddedChildren(t *testing.T) {
+	config.LoadConfig()
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("moves participants and matches", func(mt *mtest.T) {
+		groupID := primitive.NewObjectID()
+		embedded := bson.D{
+			{Key: "_id", Value: groupID},
+			{Key: "participants", Value: bson.A{
+				bson.D{{Key: "name", Value: "Ana"}, {Key: "email", Value: "Ana@example.com"}},
+				bson.D{{Key: "name", Value: "Bia"}, {Key: "email", Value: "bia@example.com"}},
+			}},
+			{Key: "matches", Value: bson.A{bson.D{{Key: "first", Value: "Ana"}, {Key: "second", Value: "Bia"}}}},
+		}
+
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, "secret-santa.groups", mtest.FirstBatch, embedded),
+			bson.D{{Key: "ok", Value: 1}, {Key: "n", Value: 0}},
+			bson.D{{Key: "ok", Value: 1}, {Key: "n", Value: 2}},
+			bson.D{{Key: "ok", Value: 1}, {Key: "n", Value: 0}},
+			bson.D{{Key: "ok", Value: 1}, {Key: "n", Value: 1}},
+			bson.D{{Key: "ok", Value: 1}, {Key: "n", Value: 1}, {Key: "nModified", Value: 1}},
+			mtest.CreateCommandErrorResponse(mtest.CommandError{Code: 27, Message: "index not found with name [participants.email_1]"}),
+		)
+
+		assert.Nil(t, moveEmbeddedChildren(context.Background(), mt.Client))
+		assert.Equal(t, []string{"find", "delete", "insert", "delete", "insert", "update", "dropIndexes"}, commandNames(mt))
+
+		participants, _ := mt.GetAllStartedEvents()[2].Command.Lookup("documents").Array().Values()
+		if assert.Len(t, participants, 2) {
+			assert.Equal(t, "ana@example.com", participants[0].Document().Lookup("emailKey").StringValue())
+			assert.Equal(t, int32(SchemaVersion), participants[0].Document().Lookup("schemaVersion").Int32())
+		}
+
+		update := mt.GetAllStartedEvents()[5].Command.Lookup("updates").Array().Index(0).Value().Document().Lookup("u").Document()
+		assert.Equal(t, int32(2), update.Lookup("$set", "participantCount").Int32())
+		assert.Equal(t, int32(2), update.Lookup("$set", "schemaVersion").Int32())
+		assert.NotNil(t, update.Lookup("$unset", "participants"))
+	})
+
+	mt.Run("keeps the children of groups already moved", func(mt *mtest.T) {
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, "secret-santa.groups", mtest.FirstBatch, bson.D{{Key: "_id", Value: primitive.NewObjectID()}}),
+			bson.D{{Key: "ok", Value: 1}, {Key: "n", Value: 1}, {Key: "nModified", Value: 1}},
+			mtest.CreateSuccessResponse(),
+		)
+
+		assert.Nil(t, moveEmbeddedChildren(context.Background(), mt.Client))
+		assert.Equal(t, []string{"find", "update", "dropIndexes"}, commandNames(mt))
+	})
+
+	mt.Run("stops on errors", func(mt *mtest.T) {
+		mt.AddMockResponses(mtest.CreateCommandErrorResponse(mtest.CommandError{Code: 13, Message: "unauthorized"}))
+
+		assert.NotNil(t, moveEmbeddedChildren(context.Background(), mt.Client))
+	})
+}
+
+func TestUniqueParticipantEmails(t *testing.T) {
+	config.LoadConfig()
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("replaces the index", func(mt *mtest.T) {
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, "secret-santa.participants", mtest.FirstBatch),
+			mtest.CreateSuccessResponse(),
+			mtest.CreateSuccessResponse(),
+			mtest.CreateSuccessResponse(),
+			mtest.CreateSuccessResponse(),
+			mtest.CreateSuccessResponse(),
+			mtest.CreateSuccessResponse(),
+		)
+
+		assert.Nil(t, uniqueParticipantEmails(context.Background(), mt.Client))
+		assert.Equal(t, []string{"aggregate", "dropIndexes"}, commandNames(mt)[:2])
+		assert.Equal(t, participantEmailIndex, mt.GetAllStartedEvents()[1].Command.Lookup("index").StringValue())
+
+		created := false
+		for _, event := range mt.GetAllStartedEvents()[2:] {
+			if event.Command.Lookup("createIndexes").StringValue() != "participants" {
+				continue
+			}
+			created = true
+			indexes, _ := event.Command.Lookup("indexes").Array().Values()
+			unique := indexes[1].Document()
+			assert.Equal(t, participantEmailIndex, unique.Lookup("name").StringValue())
+			assert.True(t, unique.Lookup("unique").Boolean())
+			assert.Equal(t, "string", unique.Lookup("partialFilterExpression", "emailKey", "$type").StringValue())
+		}
+		assert.True(t, created)
+	})
+
+	mt.Run("refuses groups with an email twice", func(mt *mtest.T) {
+		groupID := primitive.NewObjectID()
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "secret-santa.participants", mtest.FirstBatch, bson.D{{Key: "_id", Value: groupID}}))
+
+		err := uniqueParticipantEmails(context.Background(), mt.Client)
+		assert.ErrorContains(t, err, groupID.Hex())
+		assert.Equal(t, []string{"aggregate"}, commandNames(mt))
+	})
+}
+
+func TestClearSubjectHashes(t *testing.T) {
+	config.LoadConfig()
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("empties the stored hashes", func(mt *mtest.T) {
+		mt.AddMockResponses(mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 2}, bson.E{Key: "nModified", Value: 2}))
+
+		assert.Nil(t, clearSubjectHashes(context.Background(), mt.Client))
+		event := mt.GetStartedEvent()
+		assert.Equal(t, "erasures", event.Command.Lookup("update").StringValue())
+		update, _ := event.Command.Lookup("updates").Array().Values()
+		assert.Equal(t, "", update[0].Document().Lookup("u", "$set", "subjectHash").StringValue())
+	})
+}
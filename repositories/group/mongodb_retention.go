@@ -0,0 +1,181 @@
+package group
+
+import (
+	"context"
+	"time"
+
+	"service-secret-santa/customError"
+	"service-secret-santa/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func (r *resource) GetRetainedGroups(ctx context.Context, query models.RetentionQuery) ([]*models.Group, *customError.CustomError) {
+	ctx, cancel := readContext(ctx)
+	defer cancel()
+
+	cursor, err := r.collection("groups").Find(ctx, retentionFilter(query), options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}).SetLimit(int64(query.Limit)))
+	if err != nil {
+		return nil, translateError(ctx, err, "Error retrieving retained groups")
+	}
+	defer cursor.Close(ctx)
+
+	groups := []*models.Group{}
+	if err = cursor.All(ctx, &groups); err != nil {
+		return nil, translateError(ctx, err, "Error decoding groups")
+	}
+
+	return groups, nil
+}
+
+// retentionFilter translates query into a Mongo filter. Groups without an
+// exchange date count from their creation, and trashed groups are included:
+// the trash may be kept longer than their participant data.
+func retentionFilter(query models.RetentionQuery) bson.M {
+	warned := bson.M{"purgeWarnedAt": nil}
+	if !query.WarnedBefore.IsZero() {
+		warned = bson.M{"purgeWarnedAt": bson.M{"$lt": query.WarnedBefore}}
+	}
+
+	return bson.M{"$and": bson.A{
+		bson.M{"anonymizedAt": nil},
+		bson.M{"$or": bson.A{
+			bson.M{"exchangeDate": bson.M{"$lt": query.ExchangedBefore}},
+			bson.M{"exchangeDate": nil, "createdAt": bson.M{"$lt": query.ExchangedBefore}},
+		}},
+		warned,
+	}}
+}
+
+// unanonymizedFilter selects the group, in the trash or not, while its
+// participants were not anonymized yet.
+func unanonymizedFilter(objectID primitive.ObjectID) bson.M {
+	return bson.M{"_id": objectID, "anonymizedAt": nil}
+}
+
+// MarkPurgeWarned records that the owner of the group was warned of the
+// anonymization. It reports false when somebody else recorded it first, so
+// that only one instance sends the warning. The version is left alone: the
+// group did not change for its clients.
+func (r *resource) MarkPurgeWarned(ctx context.Context, id string) (bool, *customError.CustomError) {
+	ctx, cancel := writeContext(ctx)
+	defer cancel()
+
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return false, invalidGroupID()
+	}
+
+	collection := r.collection("groups")
+
+	filter := unanonymizedFilter(objectID)
+	filter["purgeWarnedAt"] = nil
+	result, err := collection.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"purgeWarnedAt": storedTime(time.Now())}})
+	if err != nil {
+		return false, translateError(ctx, err, "Failed to mark group as warned")
+	}
+	if result.MatchedCount > 0 {
+		return true, nil
+	}
+
+	count, err := collection.CountDocuments(ctx, unanonymizedFilter(objectID))
+	if err != nil {
+		return false, translateError(ctx, err, "Error finding group")
+	}
+	if count == 0 {
+		return false, groupNotFound()
+	}
+	return false, nil
+}
+
+// UnmarkPurgeWarned takes back the warning recorded by MarkPurgeWarned when
+// it could not be sent, so that the next run sends it again, and counts the
+// failure in purgeWarnFailures.
+func (r *resource) UnmarkPurgeWarned(ctx context.Context, id string) *customError.CustomError {
+	ctx, cancel := writeContext(ctx)
+	defer cancel()
+
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return invalidGroupID()
+	}
+
+	update := bson.M{"$unset": bson.M{"purgeWarnedAt": ""}, "$inc": bson.M{"purgeWarnFailures": 1}}
+	result, err := r.collection("groups").UpdateOne(ctx, unanonymizedFilter(objectID), update)
+	if err != nil {
+		return translateError(ctx, err, "Failed to unmark group as warned")
+	}
+	if result.MatchedCount == 0 {
+		return groupNotFound()
+	}
+	return nil
+}
+
+// AnonymizeGroup replaces the participants and matches of the group by
+// pseudonyms, clears the owner and bumps the version. The group is stamped
+// last, so that without a transaction a failure leaves it to be anonymized
+// again by the next run. The children of a trashed group are stamped again,
+// so the TTL indexes still purge them with it.
+func (r *resource) AnonymizeGroup(ctx context.Context, id string) (*models.Group, *customError.CustomError) {
+	ctx, cancel := writeContext(ctx)
+	defer cancel()
+
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, invalidGroupID()
+	}
+
+	var anonymized *models.Group
+	txErr := r.transaction(ctx, func(ctx context.Context, _ bool) error {
+		groups, err := r.detailsWhere(ctx, unanonymizedFilter(objectID), 1)
+		if err != nil {
+			return failed("Failed to anonymize group", err)
+		}
+		if len(groups) == 0 {
+			return groupNotFound()
+		}
+		group := groups[0]
+
+		participants, matches := pseudonymize(group.Participants, group.Matches)
+		if err := r.replaceParticipants(ctx, objectID, participants); err != nil {
+			return failed("Failed to anonymize group", err)
+		}
+		if err := r.replaceMatches(ctx, objectID, matches); err != nil {
+			return failed("Failed to anonymize group", err)
+		}
+		if !group.DeletedAt.IsZero() {
+			if err := r.stampChildren(ctx, objectID, bson.M{"$set": bson.M{"deletedAt": group.DeletedAt}}); err != nil {
+				return failed("Failed to anonymize group", err)
+			}
+		}
+
+		anonymizedAt := storedTime(time.Now())
+		update := bson.M{
+			"$set": bson.M{"owner": "", "anonymizedAt": anonymizedAt, "participantCount": len(participants)},
+			"$inc": bson.M{"version": 1},
+		}
+		result, err := r.collection("groups").UpdateOne(ctx, withVersion(unanonymizedFilter(objectID), group.Version), update)
+		if err != nil {
+			return failed("Failed to anonymize group", err)
+		}
+		if result.MatchedCount == 0 {
+			return r.missingOrStale(ctx, r.collection("groups"), unanonymizedFilter(objectID))
+		}
+
+		group.Owner = ""
+		group.AnonymizedAt = anonymizedAt
+		group.Participants = participants
+		group.Matches = matches
+		group.ParticipantCount = len(participants)
+		group.Version++
+		anonymized = group
+		return nil
+	})
+	if txErr != nil {
+		return nil, txErr
+	}
+
+	return anonymized, nil
+}
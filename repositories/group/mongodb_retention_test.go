@@ -0,0 +1,142 @@
+package group
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"service-secret-santa/config"
+	"service-secret-santa/models"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func TestMarkPurgeWarned(t *testing.T) {
+	config.LoadConfig()
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("claims the warning", func(mt *mtest.T) {
+		repo := standaloneRepository(mt.Client)
+
+		mt.AddMockResponses(bson.D{{Key: "ok", Value: 1}, {Key: "n", Value: 1}, {Key: "nModified", Value: 1}})
+
+		marked, err := repo.MarkPurgeWarned(context.Background(), primitive.NewObjectID().Hex())
+		assert.Nil(t, err)
+		assert.True(t, marked)
+
+		update := mt.GetStartedEvent().Command.Lookup("updates").Array().Index(0).Value().Document()
+		assert.Equal(t, bson.TypeNull, update.Lookup("q", "purgeWarnedAt").Type)
+		assert.Equal(t, bson.TypeNull, update.Lookup("q", "anonymizedAt").Type)
+		assert.Equal(t, bson.TypeDateTime, update.Lookup("u", "$set", "purgeWarnedAt").Type)
+	})
+
+	mt.Run("already warned", func(mt *mtest.T) {
+		repo := standaloneRepository(mt.Client)
+
+		mt.AddMockResponses(
+			bson.D{{Key: "ok", Value: 1}, {Key: "n", Value: 0}},
+			mtest.CreateCursorResponse(0, "secret-santa.groups", mtest.FirstBatch, bson.D{{Key: "n", Value: 1}}),
+		)
+
+		marked, err := repo.MarkPurgeWarned(context.Background(), primitive.NewObjectID().Hex())
+		assert.Nil(t, err)
+		assert.False(t, marked)
+	})
+}
+
+func TestAnonymizeGroup(t *testing.T) {
+	config.LoadConfig()
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("stamps the group last", func(mt *mtest.T) {
+		repo := standaloneRepository(mt.Client)
+
+		objectID := primitive.NewObjectID()
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, "secret-santa.groups", mtest.FirstBatch, bson.D{
+				{Key: "_id", Value: objectID},
+				{Key: "owner", Value: "ana@example.com"},
+				{Key: "version", Value: int64(2)},
+				{Key: "participants", Value: bson.A{bson.D{{Key: "name", Value: "Ana"}, {Key: "email", Value: "ana@example.com"}}}},
+			}),
+			bson.D{{Key: "ok", Value: 1}, {Key: "n", Value: 1}},
+			bson.D{{Key: "ok", Value: 1}, {Key: "n", Value: 1}},
+			bson.D{{Key: "ok", Value: 1}, {Key: "n", Value: 0}},
+			bson.D{{Key: "ok", Value: 1}, {Key: "n", Value: 1}, {Key: "nModified", Value: 1}},
+		)
+
+		anonymized, err := repo.AnonymizeGroup(context.Background(), objectID.Hex())
+		assert.Nil(t, err)
+		assert.Equal(t, "Participant 1", anonymized.Participants[0].Name)
+		assert.Empty(t, anonymized.Owner)
+		assert.Equal(t, int64(3), anonymized.Version)
+		assert.Equal(t, []string{"aggregate", "delete", "insert", "delete", "update"}, commandNames(mt))
+
+		events := mt.GetAllStartedEvents()
+		update := events[len(events)-1].Command.Lookup("updates").Array().Index(0).Value().Document()
+		assert.Equal(t, int64(2), update.Lookup("q", "version").AsInt64())
+		assert.Equal(t, "", update.Lookup("u", "$set", "owner").StringValue())
+	})
+
+	mt.Run("restamps the children of a trashed group", func(mt *mtest.T) {
+		repo := standaloneRepository(mt.Client)
+
+		objectID := primitive.NewObjectID()
+		deletedAt := time.Now().Add(-time.Hour).Truncate(time.Millisecond).UTC()
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, "secret-santa.groups", mtest.FirstBatch, bson.D{
+				{Key: "_id", Value: objectID},
+				{Key: "version", Value: int64(2)},
+				{Key: "deletedAt", Value: deletedAt},
+				{Key: "participants", Value: bson.A{bson.D{{Key: "name", Value: "Ana"}, {Key: "email", Value: "ana@example.com"}}}},
+			}),
+			bson.D{{Key: "ok", Value: 1}, {Key: "n", Value: 1}},
+			bson.D{{Key: "ok", Value: 1}, {Key: "n", Value: 1}},
+			bson.D{{Key: "ok", Value: 1}, {Key: "n", Value: 0}},
+			bson.D{{Key: "ok", Value: 1}, {Key: "n", Value: 1}, {Key: "nModified", Value: 1}},
+			bson.D{{Key: "ok", Value: 1}, {Key: "n", Value: 0}, {Key: "nModified", Value: 0}},
+			bson.D{{Key: "ok", Value: 1}, {Key: "n", Value: 1}, {Key: "nModified", Value: 1}},
+		)
+
+		_, err := repo.AnonymizeGroup(context.Background(), objectID.Hex())
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"aggregate", "delete", "insert", "delete", "update", "update", "update"}, commandNames(mt))
+
+		stamp := mt.GetAllStartedEvents()[4].Command.Lookup("updates").Array().Index(0).Value().Document()
+		assert.Equal(t, deletedAt, stamp.Lookup("u", "$set", "deletedAt").Time().UTC())
+	})
+
+	mt.Run("already anonymized", func(mt *mtest.T) {
+		repo := standaloneRepository(mt.Client)
+
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "secret-santa.groups", mtest.FirstBatch))
+
+		_, err := repo.AnonymizeGroup(context.Background(), primitive.NewObjectID().Hex())
+		assert.Equal(t, 404, err.Status)
+		assert.Equal(t, []string{"aggregate"}, commandNames(mt))
+
+		match := mt.GetStartedEvent().Command.Lookup("pipeline").Array().Index(0).Value().Document().Lookup("$match").Document()
+		assert.Equal(t, bson.TypeNull, match.Lookup("anonymizedAt").Type)
+		_, missing := match.LookupErr("deletedAt")
+		assert.Error(t, missing, "trashed groups are anonymized too")
+	})
+}
+
+func TestRetentionFilter(t *testing.T) {
+	cutoff := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	unwarned := retentionFilter(models.RetentionQuery{ExchangedBefore: cutoff})
+	conditions := unwarned["$and"].(bson.A)
+	assert.Equal(t, bson.M{"purgeWarnedAt": nil}, conditions[2])
+
+	warned := retentionFilter(models.RetentionQuery{ExchangedBefore: cutoff, WarnedBefore: cutoff})
+	conditions = warned["$and"].(bson.A)
+	assert.Equal(t, bson.M{"purgeWarnedAt": bson.M{"$lt": cutoff}}, conditions[2])
+	assert.Equal(t, bson.M{"$or": bson.A{
+		bson.M{"exchangeDate": bson.M{"$lt": cutoff}},
+		bson.M{"exchangeDate": nil, "createdAt": bson.M{"$lt": cutoff}},
+	}}, conditions[1])
+}
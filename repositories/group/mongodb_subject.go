@@ -0,0 +1,129 @@
+package group
+
+import (
+	"context"
+
+	"service-secret-santa/customError"
+	"service-secret-santa/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func (r *resource) GetGroupsByEmail(ctx context.Context, email string) ([]*models.Group, *customError.CustomError) {
+	ctx, cancel := readContext(ctx)
+	defer cancel()
+
+	joined, err := r.collection("participants").Distinct(ctx, "groupId", bson.M{"emailKey": r.emailKeys(email)})
+	if err != nil {
+		return nil, translateError(ctx, err, "Error retrieving groups")
+	}
+
+	filter := bson.M{"$or": bson.A{
+		bson.M{"_id": bson.M{"$in": joined}},
+		bson.M{"owner": exactCaseInsensitive(email)},
+	}}
+	groups, err := r.detailsWhere(ctx, filter, subjectGroupsLimit)
+	if err != nil {
+		return nil, translateError(ctx, err, "Error retrieving groups")
+	}
+
+	return groups, nil
+}
+
+// RewriteGroup replaces the owner, status, participants and matches of the
+// group as long as it is at version. The children of a trashed group are
+// stamped again, so the TTL indexes still purge them with it.
+func (r *resource) RewriteGroup(ctx context.Context, id string, version int64, rewrite *models.GroupRewrite) (*models.Group, *customError.CustomError) {
+	ctx, cancel := writeContext(ctx)
+	defer cancel()
+
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, invalidGroupID()
+	}
+
+	var rewritten *models.Group
+	txErr := r.transaction(ctx, func(ctx context.Context, _ bool) error {
+		collection := r.collection("groups")
+
+		update := bson.M{
+			"$set": bson.M{"owner": rewrite.Owner, "status": rewrite.Status, "participantCount": len(rewrite.Participants)},
+			"$inc": bson.M{"version": 1},
+		}
+		var stored models.Group
+		opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+		err := collection.FindOneAndUpdate(ctx, withVersion(bson.M{"_id": objectID}, version), update, opts).Decode(&stored)
+		if err != nil {
+			if err == mongo.ErrNoDocuments {
+				return r.missingOrStale(ctx, collection, bson.M{"_id": objectID})
+			}
+			return failed("Failed to rewrite group", err)
+		}
+
+		if err := r.replaceParticipants(ctx, objectID, rewrite.Participants); err != nil {
+			return failed("Failed to rewrite group", err)
+		}
+		if err := r.replaceMatches(ctx, objectID, rewrite.Matches); err != nil {
+			return failed("Failed to rewrite group", err)
+		}
+		if !stored.DeletedAt.IsZero() {
+			if err := r.stampChildren(ctx, objectID, bson.M{"$set": bson.M{"deletedAt": stored.DeletedAt}}); err != nil {
+				return failed("Failed to rewrite group", err)
+			}
+		}
+
+		groups, err := r.detailsWhere(ctx, bson.M{"_id": objectID}, 1)
+		if err != nil {
+			return failed("Error finding group", err)
+		}
+		if len(groups) == 0 {
+			return groupNotFound()
+		}
+		rewritten = groups[0]
+		return nil
+	})
+	if txErr != nil {
+		return nil, txErr
+	}
+
+	return rewritten, nil
+}
+
+func (r *resource) RecordErasure(ctx context.Context, certificate *models.ErasureCertificate) *customError.CustomError {
+	ctx, cancel := writeContext(ctx)
+	defer cancel()
+
+	if certificate.Id.IsZero() {
+		certificate.Id = primitive.NewObjectID()
+	}
+	certificate.ErasedAt = storedTime(certificate.ErasedAt)
+
+	if _, err := r.collection("erasures").InsertOne(ctx, certificate); err != nil {
+		return translateError(ctx, err, "Failed to record erasure")
+	}
+
+	return nil
+}
+
+func (r *resource) GetErasure(ctx context.Context, id string) (*models.ErasureCertificate, *customError.CustomError) {
+	ctx, cancel := readContext(ctx)
+	defer cancel()
+
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, invalidErasureID()
+	}
+
+	var certificate models.ErasureCertificate
+	if err := r.collection("erasures").FindOne(ctx, bson.M{"_id": objectID}).Decode(&certificate); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, erasureNotFound()
+		}
+		return nil, translateError(ctx, err, "Error finding erasure")
+	}
+
+	return &certificate, nil
+}
@@ -0,0 +1,42 @@
+package group
+
+import (
+	"context"
+	"testing"
+
+	"service-secret-santa/config"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func TestGetGroupsByEmail(t *testing.T) {
+	config.LoadConfig()
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("joins memberships and owned groups, trash included", func(mt *mtest.T) {
+		repo := standaloneRepository(mt.Client)
+
+		joined := primitive.NewObjectID()
+		mt.AddMockResponses(
+			bson.D{{Key: "ok", Value: 1}, {Key: "values", Value: bson.A{joined}}},
+			mtest.CreateCursorResponse(0, "secret-santa.groups", mtest.FirstBatch, bson.D{{Key: "_id", Value: joined}, {Key: "name", Value: "Amigos"}}),
+		)
+
+		groups, err := repo.GetGroupsByEmail(context.Background(), "Ana@Example.com")
+		assert.Nil(t, err)
+		if assert.Len(t, groups, 1) {
+			assert.Equal(t, joined, groups[0].Id)
+		}
+
+		events := mt.GetAllStartedEvents()
+		assert.Equal(t, "ana@example.com", events[0].Command.Lookup("query", "emailKey").StringValue())
+
+		match := events[1].Command.Lookup("pipeline").Array().Index(0).Value().Document().Lookup("$match").Document()
+		assert.Equal(t, bson.TypeArray, match.Lookup("$or").Type)
+		_, missing := match.LookupErr("deletedAt")
+		assert.Error(t, missing, "trashed groups are not filtered out")
+	})
+}
@@ -1,14 +1,20 @@
 package group
 
 import (
+	"context"
+	"errors"
+	"strings"
 	"testing"
+	"time"
 
 	"service-secret-santa/config"
+	"service-secret-santa/customError"
 	"service-secret-santa/models"
 
 	"github.com/stretchr/testify/assert"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
 )
 
@@ -19,69 +25,474 @@ func groupToBSON(g *models.Group) bson.D {
 	return groupBSON
 }
 
+func matchesToBSON(groupID primitive.ObjectID, matches []models.Match) []bson.D {
+	documents := make([]bson.D, 0, len(matches))
+	for i, match := range matches {
+		temp, _ := bson.Marshal(matchDocument{Id: primitive.NewObjectID(), GroupId: groupID, Position: i, First: match.First, Second: match.Second})
+		var document bson.D
+		_ = bson.Unmarshal(temp, &document)
+		documents = append(documents, document)
+	}
+	return documents
+}
+
 func TestGetMyMatch(t *testing.T) {
 	config.LoadConfig()
 	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
 
 	mt.Run("success", func(mt *mtest.T) {
-		repo := NewGroupRepository(mt.Client)
-
-		group := models.Group{
-			Id: primitive.NewObjectID(),
-			Matches: []models.Match{
-				{First: "João", Second: "Mario"},
-				{First: "Mario", Second: "Luigi"},
-				{First: "Luigi", Second: "João"},
-			},
-		}
-		groupBSON := groupToBSON(&group)
+		repo := NewGroupRepository(mt.Client, nil)
+
+		groupID := primitive.NewObjectID()
+		matches := matchesToBSON(groupID, []models.Match{
+			{First: "João", Second: "Mario"},
+			{First: "Mario", Second: "Luigi"},
+			{First: "Luigi", Second: "João"},
+		})
 
 		mt.AddMockResponses(
-			mtest.CreateCursorResponse(0, "secret-santa.groups", mtest.FirstBatch, groupBSON),
-			mtest.CreateCursorResponse(0, "secret-santa.groups", mtest.FirstBatch, groupBSON),
-			mtest.CreateCursorResponse(0, "secret-santa.groups", mtest.FirstBatch, groupBSON),
+			mtest.CreateCursorResponse(0, "secret-santa.matches", mtest.FirstBatch, matches...),
+			mtest.CreateCursorResponse(0, "secret-santa.matches", mtest.FirstBatch, matches...),
+			mtest.CreateCursorResponse(0, "secret-santa.matches", mtest.FirstBatch, matches...),
 		)
 
-		match, err := repo.GetMyMatch(group.Id.Hex(), "João")
+		match, err := repo.GetMyMatch(context.Background(), groupID.Hex(), "João")
 		assert.Nil(t, err)
 		assert.Equal(t, match, "Mario")
 
-		match, err = repo.GetMyMatch(group.Id.Hex(), "Mario")
+		match, err = repo.GetMyMatch(context.Background(), groupID.Hex(), "Mario")
 		assert.Nil(t, err)
 		assert.Equal(t, match, "Luigi")
 
-		match, err = repo.GetMyMatch(group.Id.Hex(), "Luigi")
+		match, err = repo.GetMyMatch(context.Background(), groupID.Hex(), "Luigi")
 		assert.Nil(t, err)
 		assert.Equal(t, match, "João")
 	})
 
 	mt.Run("no match", func(mt *mtest.T) {
-		repo := NewGroupRepository(mt.Client)
+		repo := NewGroupRepository(mt.Client, nil)
 
-		group := models.Group{
-			Id: primitive.NewObjectID(),
-			Matches: []models.Match{
-				{First: "João", Second: "Mario"},
-			},
-		}
-		groupBSON := groupToBSON(&group)
+		groupID := primitive.NewObjectID()
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, "secret-santa.matches", mtest.FirstBatch, matchesToBSON(groupID, []models.Match{{First: "João", Second: "Mario"}})...),
+		)
+
+		_, err := repo.GetMyMatch(context.Background(), groupID.Hex(), "Mario")
+		assert.Equal(t, err.Status, 404)
+		assert.Equal(t, customError.MatchNotFound, err.Kind())
+	})
+
+	mt.Run("group not found", func(mt *mtest.T) {
+		repo := NewGroupRepository(mt.Client, nil)
 
 		mt.AddMockResponses(
-			mtest.CreateCursorResponse(0, "secret-santa.groups", mtest.FirstBatch, groupBSON),
+			mtest.CreateCursorResponse(0, "secret-santa.matches", mtest.FirstBatch),
+			mtest.CreateCursorResponse(0, "secret-santa.groups", mtest.FirstBatch),
 		)
 
-		_, err := repo.GetMyMatch(group.Id.Hex(), "Mario")
+		_, err := repo.GetMyMatch(context.Background(), primitive.NewObjectID().Hex(), "Mario")
 		assert.Equal(t, err.Status, 404)
+		assert.Equal(t, customError.GroupNotFound, err.Kind())
 	})
 
 	mt.Run("db error", func(mt *mtest.T) {
-		repo := NewGroupRepository(mt.Client)
+		repo := NewGroupRepository(mt.Client, nil)
 
 		mt.AddMockResponses(
 			mtest.CreateWriteErrorsResponse(mtest.WriteError{Code: 11000}),
 		)
 
-		_, err := repo.GetMyMatch(primitive.NewObjectID().Hex(), "João")
+		_, err := repo.GetMyMatch(context.Background(), primitive.NewObjectID().Hex(), "João")
 		assert.Equal(t, err.Status, 500)
 	})
 }
+
+func TestGetGroupByID(t *testing.T) {
+	config.LoadConfig()
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("joins participants and matches in order", func(mt *mtest.T) {
+		repo := NewGroupRepository(mt.Client, nil)
+
+		groupID := primitive.NewObjectID()
+		details := append(groupToBSON(&models.Group{Id: groupID, Name: "Amigos", Version: 3}),
+			bson.E{Key: "participants", Value: bson.A{
+				bson.D{{Key: "groupId", Value: groupID}, {Key: "position", Value: 1}, {Key: "name", Value: "Bia"}, {Key: "email", Value: "bia@example.com"}},
+				bson.D{{Key: "groupId", Value: groupID}, {Key: "position", Value: 0}, {Key: "name", Value: "Ana"}, {Key: "email", Value: "ana@example.com"}},
+			}},
+			bson.E{Key: "matches", Value: bson.A{}},
+		)
+
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "secret-santa.groups", mtest.FirstBatch, details))
+
+		group, err := repo.GetGroupByID(context.Background(), groupID.Hex())
+		assert.Nil(t, err)
+		assert.Equal(t, "Amigos", group.Name)
+		assert.Equal(t, []models.Participant{{Name: "Ana", Email: "ana@example.com"}, {Name: "Bia", Email: "bia@example.com"}}, group.Participants)
+		assert.Equal(t, 2, group.ParticipantCount)
+		assert.Nil(t, group.Matches)
+	})
+
+	mt.Run("not found", func(mt *mtest.T) {
+		repo := NewGroupRepository(mt.Client, nil)
+
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "secret-santa.groups", mtest.FirstBatch))
+
+		_, err := repo.GetGroupByID(context.Background(), primitive.NewObjectID().Hex())
+		assert.Equal(t, 404, err.Status)
+		assert.Equal(t, customError.GroupNotFound, err.Kind())
+	})
+}
+
+func TestGroupChanges(t *testing.T) {
+	update := &models.GroupUpdate{Name: "Amigos", Owner: "ana@example.com", Participants: []models.Participant{{Name: "Ana"}, {Name: "Bia"}}}
+
+	changes, err := groupChanges(update)
+	assert.Nil(t, err)
+
+	set := changes["$set"].(bson.M)
+	assert.Equal(t, "Amigos", set["name"])
+	assert.Equal(t, 2, set["participantCount"])
+	assert.NotContains(t, set, "participants")
+	assert.NotContains(t, set, "locale")
+	assert.Equal(t, bson.M{"version": 1}, changes["$inc"])
+}
+
+func TestGetAllGroups(t *testing.T) {
+	config.LoadConfig()
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	query := models.GroupQuery{Limit: 2, Sort: models.SortByCreatedAt, Order: models.SortDesc}
+
+	mt.Run("has more", func(mt *mtest.T) {
+		repo := NewGroupRepository(mt.Client, nil)
+
+		groups := []*models.Group{
+			{Id: primitive.NewObjectID(), Name: "C", CreatedAt: time.Date(2023, 12, 3, 0, 0, 0, 0, time.UTC)},
+			{Id: primitive.NewObjectID(), Name: "B", CreatedAt: time.Date(2023, 12, 2, 0, 0, 0, 0, time.UTC)},
+			{Id: primitive.NewObjectID(), Name: "A", CreatedAt: time.Date(2023, 12, 1, 0, 0, 0, 0, time.UTC)},
+		}
+
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, "secret-santa.groups", mtest.FirstBatch,
+				groupToBSON(groups[0]), groupToBSON(groups[1]), groupToBSON(groups[2])),
+		)
+
+		page, err := repo.GetAllGroups(context.Background(), query)
+		assert.Nil(t, err)
+		assert.Len(t, page.Items, 2)
+		assert.True(t, page.Paging.HasMore)
+		assert.NotEmpty(t, page.Paging.NextCursor)
+
+		cursor, decodeErr := decodePageCursor(page.Paging.NextCursor, query)
+		assert.Nil(t, decodeErr)
+		assert.Equal(t, groups[1].Id.Hex(), cursor.Id)
+		assert.True(t, groups[1].CreatedAt.Equal(cursor.CreatedAt))
+	})
+
+	mt.Run("last page", func(mt *mtest.T) {
+		repo := NewGroupRepository(mt.Client, nil)
+
+		group := &models.Group{Id: primitive.NewObjectID(), Name: "A"}
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, "secret-santa.groups", mtest.FirstBatch, groupToBSON(group)),
+		)
+
+		page, err := repo.GetAllGroups(context.Background(), query)
+		assert.Nil(t, err)
+		assert.Len(t, page.Items, 1)
+		assert.False(t, page.Paging.HasMore)
+		assert.Empty(t, page.Paging.NextCursor)
+	})
+
+	mt.Run("invalid cursor", func(mt *mtest.T) {
+		repo := NewGroupRepository(mt.Client, nil)
+
+		invalid := query
+		invalid.Cursor = "not-a-cursor"
+
+		_, err := repo.GetAllGroups(context.Background(), invalid)
+		assert.Equal(t, err.Status, 400)
+	})
+
+	mt.Run("cursor from another sort", func(mt *mtest.T) {
+		repo := NewGroupRepository(mt.Client, nil)
+
+		byName := query
+		byName.Sort = models.SortByName
+		byName.Cursor = newPageCursor(query, &models.Group{Id: primitive.NewObjectID()}).encode()
+
+		_, err := repo.GetAllGroups(context.Background(), byName)
+		assert.Equal(t, err.Status, 400)
+	})
+}
+
+func TestSearchGroups(t *testing.T) {
+	config.LoadConfig()
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("ranks candidates before the limit", func(mt *mtest.T) {
+		repo := NewGroupRepository(mt.Client, nil)
+
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "secret-santa.groups", mtest.FirstBatch))
+
+		_, err := repo.SearchGroups(context.Background(), "joão")
+		assert.Nil(t, err)
+
+		pipeline := mt.GetStartedEvent().Command.Lookup("pipeline").Array()
+		sort := pipeline.Index(2).Value().Document().Lookup("$sort").Document()
+		assert.Equal(t, "searchRank", sort.Index(0).Key())
+		assert.Equal(t, int32(searchCandidates), pipeline.Index(3).Value().Document().Lookup("$limit").Int32())
+		exact := pipeline.Index(1).Value().Document().Lookup("$addFields", "searchRank", "$switch", "branches").Array().Index(0).Value().Document()
+		pattern, _ := exact.Lookup("case", "$or").Array().Index(0).Value().Document().Lookup("$regexMatch", "regex").Regex()
+		assert.True(t, strings.HasPrefix(pattern, "^(?:"))
+	})
+}
+
+func TestResolveMatch(t *testing.T) {
+	matches := []models.Match{
+		{First: "João Pedro", Second: "Mario"},
+		{First: "Mario", Second: "Luigi"},
+		{First: "Luigi", Second: "João Pedro"},
+	}
+
+	for _, username := range []string{"João Pedro", "joão pedro", "JOAO PEDRO", "  Joao   Pedro "} {
+		match, err := resolveMatch(matches, username)
+		assert.Nil(t, err, username)
+		assert.Equal(t, "Mario", match, username)
+	}
+
+	_, err := resolveMatch(matches, "Joao Pdro")
+	assert.Equal(t, 404, err.Status)
+	assert.Equal(t, []string{"João Pedro"}, err.Suggestions)
+
+	_, err = resolveMatch(matches, "Bartholomew")
+	assert.Equal(t, 404, err.Status)
+	assert.Empty(t, err.Suggestions)
+
+	ambiguous := append(matches, models.Match{First: "Joao Pedro", Second: "Luigi"})
+	_, err = resolveMatch(ambiguous, "joao pedro")
+	assert.Equal(t, 409, err.Status)
+	assert.ElementsMatch(t, []string{"João Pedro", "Joao Pedro"}, err.Suggestions)
+
+	// A name typed exactly as stored is not ambiguous.
+	match, err := resolveMatch(ambiguous, "João Pedro")
+	assert.Nil(t, err)
+	assert.Equal(t, "Mario", match)
+	match, err = resolveMatch(ambiguous, "Joao Pedro")
+	assert.Nil(t, err)
+	assert.Equal(t, "Luigi", match)
+}
+
+func TestDeleteGroup(t *testing.T) {
+	config.LoadConfig()
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("success", func(mt *mtest.T) {
+		repo := standaloneRepository(mt.Client)
+
+		mt.AddMockResponses(
+			bson.D{{Key: "ok", Value: 1}, {Key: "n", Value: 1}},
+			bson.D{{Key: "ok", Value: 1}, {Key: "n", Value: 3}},
+			bson.D{{Key: "ok", Value: 1}, {Key: "n", Value: 3}},
+		)
+
+		err := repo.DeleteGroup(context.Background(), primitive.NewObjectID().Hex(), 1)
+		assert.Nil(t, err)
+
+		// The documents stay, marked as deleted.
+		var collections []string
+		for _, event := range mt.GetAllStartedEvents() {
+			assert.Equal(t, "update", event.CommandName)
+			update := event.Command.Lookup("updates").Array().Index(0).Value().Document()
+			assert.Equal(t, bson.TypeDateTime, update.Lookup("u", "$set", "deletedAt").Type)
+			collections = append(collections, event.Command.Lookup("update").StringValue())
+		}
+		assert.Equal(t, []string{"groups", "participants", "matches"}, collections)
+	})
+
+	mt.Run("stale version", func(mt *mtest.T) {
+		repo := standaloneRepository(mt.Client)
+
+		mt.AddMockResponses(
+			bson.D{{Key: "ok", Value: 1}, {Key: "n", Value: 0}},
+			mtest.CreateCursorResponse(0, "secret-santa.groups", mtest.FirstBatch, bson.D{{Key: "n", Value: 1}}),
+		)
+
+		err := repo.DeleteGroup(context.Background(), primitive.NewObjectID().Hex(), 1)
+		assert.Equal(t, err.Status, 412)
+	})
+
+	mt.Run("not found", func(mt *mtest.T) {
+		repo := standaloneRepository(mt.Client)
+
+		mt.AddMockResponses(
+			bson.D{{Key: "ok", Value: 1}, {Key: "n", Value: 0}},
+			mtest.CreateCursorResponse(0, "secret-santa.groups", mtest.FirstBatch),
+		)
+
+		err := repo.DeleteGroup(context.Background(), primitive.NewObjectID().Hex(), 1)
+		assert.Equal(t, err.Status, 404)
+	})
+}
+
+func TestVersionFilter(t *testing.T) {
+	id := primitive.NewObjectID()
+
+	assert.Equal(t, bson.M{"_id": id, "deletedAt": nil}, versionFilter(id, models.AnyVersion))
+	assert.Equal(t, bson.M{"_id": id, "deletedAt": nil, "version": int64(3)}, versionFilter(id, 3))
+	assert.Equal(t, bson.M{"_id": id, "deletedAt": nil, "version": bson.M{"$in": bson.A{0, nil}}}, versionFilter(id, 0))
+	assert.Equal(t, bson.M{"_id": id, "deletedAt": bson.M{"$ne": nil}, "version": int64(3)}, withVersion(trashedFilter(id), 3))
+}
+
+func TestAddParticipant(t *testing.T) {
+	config.LoadConfig()
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	participant := &models.Participant{Name: "Mari", Email: "MARI@gmail.com"}
+
+	mt.Run("duplicate email", func(mt *mtest.T) {
+		repo := standaloneRepository(mt.Client)
+
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, "secret-santa.participants", mtest.FirstBatch, bson.D{{Key: "n", Value: 1}}),
+		)
+
+		_, err := repo.AddParticipant(context.Background(), primitive.NewObjectID().Hex(), 1, participant)
+		assert.Equal(t, 409, err.Status)
+		assert.Equal(t, customError.ParticipantDuplicate, err.Kind())
+	})
+
+	mt.Run("email added concurrently", func(mt *mtest.T) {
+		repo := standaloneRepository(mt.Client)
+
+		groupID := primitive.NewObjectID()
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, "secret-santa.participants", mtest.FirstBatch),
+			mtest.CreateCursorResponse(0, "secret-santa.participants", mtest.FirstBatch),
+			bson.D{{Key: "ok", Value: 1}, {Key: "value", Value: groupToBSON(&models.Group{Id: groupID, ParticipantCount: 2, Version: 2})}},
+			mtest.CreateWriteErrorsResponse(mtest.WriteError{Code: 11000, Message: "E11000 duplicate key error collection: secret-santa.participants index: groupId_1_emailKey_1"}),
+			bson.D{{Key: "ok", Value: 1}, {Key: "n", Value: 1}, {Key: "nModified", Value: 1}},
+		)
+
+		_, err := repo.AddParticipant(context.Background(), groupID.Hex(), 1, participant)
+		assert.Equal(t, 409, err.Status)
+		assert.Equal(t, customError.ParticipantDuplicate, err.Kind())
+		assert.Equal(t, []string{"aggregate", "aggregate", "findAndModify", "insert", "update"}, commandNames(mt))
+
+		undo := mt.GetAllStartedEvents()[4].Command.Lookup("updates").Array().Index(0).Value().Document()
+		assert.Equal(t, int64(2), undo.Lookup("q", "version").AsInt64())
+		assert.Equal(t, int64(-1), undo.Lookup("u", "$inc", "participantCount").AsInt64())
+		assert.Equal(t, int64(-1), undo.Lookup("u", "$inc", "version").AsInt64())
+	})
+
+	mt.Run("stale version", func(mt *mtest.T) {
+		repo := standaloneRepository(mt.Client)
+
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, "secret-santa.participants", mtest.FirstBatch),
+			mtest.CreateCursorResponse(0, "secret-santa.participants", mtest.FirstBatch),
+			bson.D{{Key: "ok", Value: 1}, {Key: "value", Value: nil}},
+			mtest.CreateCursorResponse(0, "secret-santa.groups", mtest.FirstBatch, bson.D{{Key: "n", Value: 1}}),
+		)
+
+		_, err := repo.AddParticipant(context.Background(), primitive.NewObjectID().Hex(), 1, participant)
+		assert.Equal(t, 412, err.Status)
+		assert.Equal(t, customError.GroupVersionMismatch, err.Kind())
+	})
+
+	mt.Run("success", func(mt *mtest.T) {
+		repo := standaloneRepository(mt.Client)
+
+		groupID := primitive.NewObjectID()
+		bumped := groupToBSON(&models.Group{Id: groupID, ParticipantCount: 2, Version: 2})
+		details := append(groupToBSON(&models.Group{Id: groupID, ParticipantCount: 2, Version: 2}),
+			bson.E{Key: "participants", Value: bson.A{
+				bson.D{{Key: "groupId", Value: groupID}, {Key: "position", Value: 0}, {Key: "name", Value: "Ana"}, {Key: "email", Value: "ana@gmail.com"}},
+				bson.D{{Key: "groupId", Value: groupID}, {Key: "position", Value: 1}, {Key: "name", Value: "Mari"}, {Key: "email", Value: "MARI@gmail.com"}},
+			}},
+		)
+
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, "secret-santa.participants", mtest.FirstBatch),
+			mtest.CreateCursorResponse(0, "secret-santa.participants", mtest.FirstBatch),
+			bson.D{{Key: "ok", Value: 1}, {Key: "value", Value: bumped}},
+			bson.D{{Key: "ok", Value: 1}, {Key: "n", Value: 1}},
+			mtest.CreateCursorResponse(0, "secret-santa.groups", mtest.FirstBatch, details),
+		)
+
+		group, err := repo.AddParticipant(context.Background(), groupID.Hex(), 1, participant)
+		assert.Nil(t, err)
+		assert.Equal(t, []models.Participant{{Name: "Ana", Email: "ana@gmail.com"}, *participant}, group.Participants)
+		assert.Equal(t, int64(2), group.Version)
+
+		inserted := mt.GetAllStartedEvents()[3]
+		assert.Equal(t, "insert", inserted.CommandName)
+		document := inserted.Command.Lookup("documents").Array().Index(0).Value().Document()
+		assert.Equal(t, int32(1), document.Lookup("position").Int32())
+		assert.Equal(t, "mari@gmail.com", document.Lookup("emailKey").StringValue())
+	})
+}
+
+func TestTranslateError(t *testing.T) {
+	config.LoadConfig()
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("duplicate key", func(mt *mtest.T) {
+		repo := standaloneRepository(mt.Client)
+
+		mt.AddMockResponses(mtest.CreateWriteErrorsResponse(mtest.WriteError{Code: 11000, Message: "E11000 duplicate key"}))
+
+		_, err := repo.CreateGroup(context.Background(), &models.Group{Name: "Amigos"})
+		assert.Equal(t, 409, err.Status)
+		assert.Equal(t, customError.AlreadyExists, err.Kind())
+	})
+
+	mt.Run("document validation", func(mt *mtest.T) {
+		repo := standaloneRepository(mt.Client)
+
+		mt.AddMockResponses(mtest.CreateWriteErrorsResponse(mtest.WriteError{Code: 121, Message: "Document failed validation"}))
+
+		_, err := repo.CreateGroup(context.Background(), &models.Group{Name: "Amigos"})
+		assert.Equal(t, 422, err.Status)
+		assert.Equal(t, customError.DocumentRejected, err.Kind())
+	})
+
+	mt.Run("draw of a missing group", func(mt *mtest.T) {
+		repo := standaloneRepository(mt.Client)
+
+		mt.AddMockResponses(
+			bson.D{{Key: "ok", Value: 1}, {Key: "n", Value: 0}, {Key: "nModified", Value: 0}},
+			mtest.CreateCursorResponse(0, "secret-santa.groups", mtest.FirstBatch),
+		)
+
+		err := repo.UpdateMatches(context.Background(), primitive.NewObjectID().Hex(), 1, []models.Match{{First: "a", Second: "b"}})
+		assert.Equal(t, 404, err.Status)
+		assert.Equal(t, customError.GroupNotFound, err.Kind())
+	})
+
+	mt.Run("deadline exceeded", func(mt *mtest.T) {
+		repo := standaloneRepository(mt.Client)
+
+		ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+		defer cancel()
+
+		_, err := repo.GetGroupByID(ctx, primitive.NewObjectID().Hex())
+		assert.Equal(t, 504, err.Status)
+		assert.Equal(t, customError.DeadlineExceeded, err.Kind())
+	})
+
+	mt.Run("client gone", func(mt *mtest.T) {
+		repo := standaloneRepository(mt.Client)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		err := repo.DeleteGroup(ctx, primitive.NewObjectID().Hex(), 1)
+		assert.Equal(t, customError.StatusClientClosedRequest, err.Status)
+	})
+
+	assert.Equal(t, 504, translateError(context.Background(), context.DeadlineExceeded, "Error finding group").Status)
+	assert.Equal(t, 404, translateError(context.Background(), mongo.ErrNoDocuments, "Error finding group").Status)
+	assert.Equal(t, 500, translateError(context.Background(), errors.New("boom"), "Error finding group").Status)
+}
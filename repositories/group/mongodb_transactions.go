@@ -0,0 +1,135 @@
+package group
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"service-secret-santa/customError"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readconcern"
+	"go.mongodb.org/mongo-driver/mongo/writeconcern"
+)
+
+// Whether the deployment runs multi-document transactions. Replica sets and
+// sharded clusters do; a standalone server does not.
+const (
+	transactionsUnknown int32 = iota
+	transactionsSupported
+	transactionsUnsupported
+)
+
+// transactionOptions make the reads of a transaction see one snapshot and its
+// commit survive a failover.
+var transactionOptions = options.Transaction().
+	SetReadConcern(readconcern.Snapshot()).
+	SetWriteConcern(writeconcern.Majority())
+
+// writeError carries a driver error out of a transaction along with what the
+// write was doing. It is translated after the transaction ends, so that
+// WithTransaction still sees the labels that make it retry.
+type writeError struct {
+	message string
+	err     error
+}
+
+func (e *writeError) Error() string {
+	return e.message + ": " + e.err.Error()
+}
+
+func (e *writeError) Unwrap() error {
+	return e.err
+}
+
+func failed(message string, err error) error {
+	return &writeError{message: message, err: err}
+}
+
+// participantsFailed is failed for writes of participants, where a duplicate
+// key is an email the group already has.
+func participantsFailed(message string, err error) error {
+	if mongo.IsDuplicateKeyError(err) {
+		return participantDuplicate()
+	}
+	return failed(message, err)
+}
+
+// transaction runs a write that touches more than one document, in a
+// transaction when the deployment supports them. WithTransaction runs fn
+// again on TransientTransactionError, e.g. a write conflict with a concurrent
+// draw, and retries the commit on UnknownTransactionCommitResult.
+//
+// On a standalone server fn runs on its own, with atomically false: each write
+// still checks the group version, but the writes of one call can be seen
+// half done and are not undone by a crash.
+//
+// fn returns a *customError.CustomError for outcomes such as a stale version,
+// or a driver error wrapped with failed.
+func (r *resource) transaction(ctx context.Context, fn func(ctx context.Context, atomically bool) error) *customError.CustomError {
+	var err error
+	if r.supportsTransactions(ctx) {
+		err = r.inSession(ctx, fn)
+	} else {
+		err = fn(ctx, false)
+	}
+
+	var custom *customError.CustomError
+	var write *writeError
+	switch {
+	case err == nil:
+		return nil
+	case errors.As(err, &custom):
+		return custom
+	case errors.As(err, &write):
+		return translateError(ctx, write.err, write.message)
+	default:
+		return translateError(ctx, err, "Failed to write group")
+	}
+}
+
+func (r *resource) inSession(ctx context.Context, fn func(ctx context.Context, atomically bool) error) error {
+	session, err := r.db.StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(context.Background())
+
+	_, err = session.WithTransaction(ctx, func(sc mongo.SessionContext) (interface{}, error) {
+		return nil, fn(sc, true)
+	}, transactionOptions)
+	return err
+}
+
+// supportsTransactions asks the server once whether it is part of a replica
+// set or a mongos, and remembers the answer. When it cannot tell, the write
+// goes without a transaction and the next one asks again.
+func (r *resource) supportsTransactions(ctx context.Context) bool {
+	switch r.transactions.Load() {
+	case transactionsSupported:
+		return true
+	case transactionsUnsupported:
+		return false
+	}
+
+	var hello struct {
+		SetName string `bson:"setName"`
+		Msg     string `bson:"msg"`
+	}
+	if err := r.db.Database("admin").RunCommand(ctx, bson.D{{Key: "hello", Value: 1}}).Decode(&hello); err != nil {
+		slog.WarnContext(ctx, "Could not tell whether MongoDB supports transactions", "error", err)
+		return false
+	}
+
+	if hello.SetName != "" || hello.Msg == "isdbgrid" {
+		r.transactions.Store(transactionsSupported)
+		return true
+	}
+
+	if r.transactions.CompareAndSwap(transactionsUnknown, transactionsUnsupported) {
+		slog.WarnContext(ctx, "MongoDB is a standalone server, so writes run without transactions; run a replica set to make them atomic")
+	}
+	return false
+}
@@ -0,0 +1,120 @@
+package group
+
+import (
+	"context"
+	"testing"
+
+	"service-secret-santa/config"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+// standaloneRepository is a repository that writes without transactions and
+// does not ask the server whether it could.
+func standaloneRepository(db *mongo.Client) Repository {
+	r := &resource{db: db}
+	r.transactions.Store(transactionsUnsupported)
+	return r
+}
+
+func transactionalRepository(db *mongo.Client) Repository {
+	r := &resource{db: db}
+	r.transactions.Store(transactionsSupported)
+	return r
+}
+
+func TestTransactions(t *testing.T) {
+	config.LoadConfig()
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	written := bson.D{{Key: "ok", Value: 1}, {Key: "n", Value: 1}}
+
+	mt.Run("asks a standalone server once", func(mt *mtest.T) {
+		repo := NewGroupRepository(mt.Client, nil)
+
+		mt.AddMockResponses(
+			bson.D{{Key: "ok", Value: 1}, {Key: "isWritablePrimary", Value: true}},
+			written, written, written,
+			written, written, written,
+		)
+
+		assert.Nil(t, repo.DeleteGroup(context.Background(), primitive.NewObjectID().Hex(), 1))
+		assert.Nil(t, repo.DeleteGroup(context.Background(), primitive.NewObjectID().Hex(), 1))
+		assert.Equal(t, []string{"hello", "update", "update", "update", "update", "update", "update"}, commandNames(mt))
+	})
+
+	mt.Run("detects a replica set", func(mt *mtest.T) {
+		repo := NewGroupRepository(mt.Client, nil)
+
+		mt.AddMockResponses(
+			bson.D{{Key: "ok", Value: 1}, {Key: "setName", Value: "rs0"}},
+			written, written, written,
+			mtest.CreateSuccessResponse(),
+		)
+
+		assert.Nil(t, repo.DeleteGroup(context.Background(), primitive.NewObjectID().Hex(), 1))
+		assert.Equal(t, []string{"hello", "update", "update", "update", "commitTransaction"}, commandNames(mt))
+	})
+
+	mt.Run("writes in one transaction", func(mt *mtest.T) {
+		repo := transactionalRepository(mt.Client)
+
+		mt.AddMockResponses(written, written, written, mtest.CreateSuccessResponse())
+
+		assert.Nil(t, repo.DeleteGroup(context.Background(), primitive.NewObjectID().Hex(), 1))
+		assert.Equal(t, []string{"update", "update", "update", "commitTransaction"}, commandNames(mt))
+
+		events := mt.GetAllStartedEvents()
+		assert.True(t, events[0].Command.Lookup("startTransaction").Boolean())
+		assert.Equal(t, "snapshot", events[0].Command.Lookup("readConcern", "level").StringValue())
+		for _, event := range events {
+			assert.False(t, event.Command.Lookup("autocommit").Boolean())
+			assert.Equal(t, events[0].Command.Lookup("lsid"), event.Command.Lookup("lsid"))
+		}
+	})
+
+	mt.Run("retries transient errors", func(mt *mtest.T) {
+		repo := transactionalRepository(mt.Client)
+
+		mt.AddMockResponses(
+			mtest.CreateCommandErrorResponse(mtest.CommandError{Code: 112, Name: "WriteConflict", Message: "write conflict", Labels: []string{"TransientTransactionError"}}),
+			mtest.CreateSuccessResponse(),
+			written, written, written,
+			mtest.CreateSuccessResponse(),
+		)
+
+		assert.Nil(t, repo.DeleteGroup(context.Background(), primitive.NewObjectID().Hex(), 1))
+		assert.Equal(t, []string{"update", "abortTransaction", "update", "update", "update", "commitTransaction"}, commandNames(mt))
+	})
+
+	mt.Run("aborts on a stale version", func(mt *mtest.T) {
+		repo := transactionalRepository(mt.Client)
+
+		mt.AddMockResponses(
+			bson.D{{Key: "ok", Value: 1}, {Key: "n", Value: 0}, {Key: "nModified", Value: 0}},
+			mtest.CreateCursorResponse(0, "secret-santa.groups", mtest.FirstBatch, bson.D{{Key: "n", Value: 1}}),
+			mtest.CreateSuccessResponse(),
+		)
+
+		err := repo.UpdateMatches(context.Background(), primitive.NewObjectID().Hex(), 1, nil)
+		assert.Equal(t, 412, err.Status)
+		assert.Equal(t, []string{"update", "aggregate", "abortTransaction"}, commandNames(mt))
+	})
+
+	mt.Run("translates driver errors", func(mt *mtest.T) {
+		repo := transactionalRepository(mt.Client)
+
+		mt.AddMockResponses(
+			mtest.CreateCommandErrorResponse(mtest.CommandError{Code: 121, Message: "Document failed validation"}),
+			mtest.CreateSuccessResponse(),
+		)
+
+		err := repo.UpdateMatches(context.Background(), primitive.NewObjectID().Hex(), 1, nil)
+		assert.Equal(t, 422, err.Status)
+		assert.Equal(t, "Failed to update matches", err.Message)
+	})
+}
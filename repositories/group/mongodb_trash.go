@@ -0,0 +1,159 @@
+package group
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"service-secret-santa/config"
+	"service-secret-santa/customError"
+	"service-secret-santa/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Server error codes of creating an index that exists with other options.
+const (
+	indexOptionsConflict  = 85
+	indexKeySpecsConflict = 86
+)
+
+func (r *resource) GetTrash(ctx context.Context, owner string) ([]*models.Group, *customError.CustomError) {
+	ctx, cancel := readContext(ctx)
+	defer cancel()
+
+	filter := bson.M{"deletedAt": bson.M{"$ne": nil}}
+	if owner != "" {
+		filter["owner"] = exactCaseInsensitive(owner)
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "deletedAt", Value: -1}, {Key: "_id", Value: -1}}).
+		SetLimit(trashLimit)
+
+	cursor, err := r.collection("groups").Find(ctx, filter, opts)
+	if err != nil {
+		return nil, translateError(ctx, err, "Error retrieving trash")
+	}
+	defer cursor.Close(ctx)
+
+	groups := []*models.Group{}
+	if err = cursor.All(ctx, &groups); err != nil {
+		return nil, translateError(ctx, err, "Error decoding groups")
+	}
+
+	return groups, nil
+}
+
+func (r *resource) RestoreGroup(ctx context.Context, id string, version int64) (*models.Group, *customError.CustomError) {
+	ctx, cancel := writeContext(ctx)
+	defer cancel()
+
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, invalidGroupID()
+	}
+
+	var restored *models.Group
+	txErr := r.transaction(ctx, func(ctx context.Context, _ bool) error {
+		collection := r.collection("groups")
+
+		update := bson.M{"$unset": bson.M{"deletedAt": ""}, "$inc": bson.M{"version": 1}}
+		result, err := collection.UpdateOne(ctx, withVersion(trashedFilter(objectID), version), update)
+		if err != nil {
+			return failed("Failed to restore group", err)
+		}
+		if result.MatchedCount == 0 {
+			return r.missingOrStale(ctx, collection, trashedFilter(objectID))
+		}
+
+		if err := r.stampChildren(ctx, objectID, bson.M{"$unset": bson.M{"deletedAt": ""}}); err != nil {
+			return failed("Failed to restore group", err)
+		}
+
+		restored, err = r.details(ctx, objectID)
+		if err != nil {
+			return failed("Error finding group", err)
+		}
+
+		return nil
+	})
+	if txErr != nil {
+		return nil, txErr
+	}
+
+	return restored, nil
+}
+
+// PurgeTrash removes the groups deleted before deletedBefore, with their
+// participants and matches. The TTL indexes set up by ConfigureTrashRetention
+// do the same on their own.
+func (r *resource) PurgeTrash(ctx context.Context, deletedBefore time.Time) (int, *customError.CustomError) {
+	ctx, cancel := writeContext(ctx)
+	defer cancel()
+
+	var purged int
+	err := r.transaction(ctx, func(ctx context.Context, _ bool) error {
+		ids, err := r.collection("groups").Distinct(ctx, "_id", bson.M{"deletedAt": bson.M{"$lt": deletedBefore}})
+		if err != nil {
+			return failed("Failed to purge trash", err)
+		}
+		if len(ids) == 0 {
+			purged = 0
+			return nil
+		}
+
+		for _, name := range []string{"participants", "matches"} {
+			if _, err := r.collection(name).DeleteMany(ctx, bson.M{"groupId": bson.M{"$in": ids}}); err != nil {
+				return failed("Failed to purge trash", err)
+			}
+		}
+
+		result, err := r.collection("groups").DeleteMany(ctx, bson.M{"_id": bson.M{"$in": ids}})
+		if err != nil {
+			return failed("Failed to purge trash", err)
+		}
+		purged = int(result.DeletedCount)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return purged, nil
+}
+
+// ConfigureTrashRetention makes Mongo remove trashed groups, participants and
+// matches retention after they were deleted, through a TTL index on
+// deletedAt in each collection. A changed retention is applied to the
+// existing indexes. Mongo checks the indexes about once a minute.
+func ConfigureTrashRetention(ctx context.Context, db *mongo.Client, retention time.Duration) error {
+	database := db.Database(config.Cfg.MongoDB)
+	seconds := int32(retention / time.Second)
+	keys := bson.D{{Key: "deletedAt", Value: 1}}
+
+	for _, name := range []string{"groups", "participants", "matches"} {
+		index := mongo.IndexModel{Keys: keys, Options: options.Index().SetExpireAfterSeconds(seconds)}
+		_, err := database.Collection(name).Indexes().CreateOne(ctx, index)
+		if err == nil {
+			continue
+		}
+		if !hasErrorCode(err, indexOptionsConflict) && !hasErrorCode(err, indexKeySpecsConflict) {
+			return err
+		}
+
+		modify := bson.D{
+			{Key: "collMod", Value: name},
+			{Key: "index", Value: bson.M{"keyPattern": keys, "expireAfterSeconds": seconds}},
+		}
+		if err := database.RunCommand(ctx, modify).Err(); err != nil {
+			return err
+		}
+		slog.InfoContext(ctx, "Trash retention changed", "collection", name, "retention", retention.String())
+	}
+
+	return nil
+}
@@ -0,0 +1,112 @@
+package group
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"service-secret-santa/config"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func TestRestoreGroup(t *testing.T) {
+	config.LoadConfig()
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("not in the trash", func(mt *mtest.T) {
+		repo := standaloneRepository(mt.Client)
+
+		mt.AddMockResponses(
+			bson.D{{Key: "ok", Value: 1}, {Key: "n", Value: 0}},
+			mtest.CreateCursorResponse(0, "secret-santa.groups", mtest.FirstBatch),
+		)
+
+		_, err := repo.RestoreGroup(context.Background(), primitive.NewObjectID().Hex(), 1)
+		assert.Equal(t, 404, err.Status)
+
+		update := mt.GetStartedEvent().Command.Lookup("updates").Array().Index(0).Value().Document()
+		assert.Equal(t, bson.TypeNull, update.Lookup("q", "deletedAt", "$ne").Type)
+		assert.Equal(t, int64(1), update.Lookup("q", "version").AsInt64())
+	})
+
+	mt.Run("invalid id", func(mt *mtest.T) {
+		repo := standaloneRepository(mt.Client)
+
+		_, err := repo.RestoreGroup(context.Background(), "not-an-id", 1)
+		assert.Equal(t, 400, err.Status)
+	})
+}
+
+func TestPurgeTrash(t *testing.T) {
+	config.LoadConfig()
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("nothing to purge", func(mt *mtest.T) {
+		repo := standaloneRepository(mt.Client)
+
+		mt.AddMockResponses(bson.D{{Key: "ok", Value: 1}, {Key: "values", Value: bson.A{}}})
+
+		purged, err := repo.PurgeTrash(context.Background(), time.Now())
+		assert.Nil(t, err)
+		assert.Equal(t, 0, purged)
+		assert.Equal(t, []string{"distinct"}, commandNames(mt))
+	})
+
+	mt.Run("removes children first", func(mt *mtest.T) {
+		repo := standaloneRepository(mt.Client)
+
+		mt.AddMockResponses(
+			bson.D{{Key: "ok", Value: 1}, {Key: "values", Value: bson.A{primitive.NewObjectID(), primitive.NewObjectID()}}},
+			bson.D{{Key: "ok", Value: 1}, {Key: "n", Value: 5}},
+			bson.D{{Key: "ok", Value: 1}, {Key: "n", Value: 4}},
+			bson.D{{Key: "ok", Value: 1}, {Key: "n", Value: 2}},
+		)
+
+		purged, err := repo.PurgeTrash(context.Background(), time.Now())
+		assert.Nil(t, err)
+		assert.Equal(t, 2, purged)
+
+		var collections []string
+		for _, event := range mt.GetAllStartedEvents()[1:] {
+			collections = append(collections, event.Command.Lookup("delete").StringValue())
+		}
+		assert.Equal(t, []string{"participants", "matches", "groups"}, collections)
+	})
+}
+
+func TestConfigureTrashRetention(t *testing.T) {
+	config.LoadConfig()
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("creates TTL indexes", func(mt *mtest.T) {
+		mt.AddMockResponses(mtest.CreateSuccessResponse(), mtest.CreateSuccessResponse(), mtest.CreateSuccessResponse())
+
+		assert.NoError(t, ConfigureTrashRetention(context.Background(), mt.Client, 48*time.Hour))
+		assert.Equal(t, []string{"createIndexes", "createIndexes", "createIndexes"}, commandNames(mt))
+
+		index := mt.GetStartedEvent().Command.Lookup("indexes").Array().Index(0).Value().Document()
+		assert.Equal(t, int32(48*60*60), index.Lookup("expireAfterSeconds").Int32())
+	})
+
+	mt.Run("changes the retention of existing indexes", func(mt *mtest.T) {
+		conflict := mtest.CreateCommandErrorResponse(mtest.CommandError{Code: indexOptionsConflict, Name: "IndexOptionsConflict", Message: "An equivalent index already exists"})
+		mt.AddMockResponses(
+			conflict, mtest.CreateSuccessResponse(),
+			mtest.CreateSuccessResponse(),
+			conflict, mtest.CreateSuccessResponse(),
+		)
+
+		assert.NoError(t, ConfigureTrashRetention(context.Background(), mt.Client, time.Hour))
+		assert.Equal(t, []string{"createIndexes", "collMod", "createIndexes", "createIndexes", "collMod"}, commandNames(mt))
+	})
+
+	mt.Run("fails on other errors", func(mt *mtest.T) {
+		mt.AddMockResponses(mtest.CreateCommandErrorResponse(mtest.CommandError{Code: 13, Name: "Unauthorized", Message: "not authorized"}))
+
+		assert.Error(t, ConfigureTrashRetention(context.Background(), mt.Client, time.Hour))
+	})
+}
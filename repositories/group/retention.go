@@ -0,0 +1,44 @@
+package group
+
+import (
+	"fmt"
+
+	"service-secret-santa/functions"
+	"service-secret-santa/models"
+)
+
+// pseudonymize replaces the participants of a group by "Participant 1",
+// "Participant 2"... without email, and renames the matches the same way so
+// the shape of the draw is kept. Names are compared normalised, like
+// GetMyMatch does; names of a match that are not a participant get the
+// next pseudonyms.
+func pseudonymize(participants []models.Participant, matches []models.Match) ([]models.Participant, []models.Match) {
+	pseudonyms := make(map[string]string, len(participants))
+	next := 0
+	assign := func(name string) string {
+		next++
+		pseudonym := fmt.Sprintf("Participant %d", next)
+		if _, taken := pseudonyms[functions.NormalizeName(name)]; !taken {
+			pseudonyms[functions.NormalizeName(name)] = pseudonym
+		}
+		return pseudonym
+	}
+	pseudonymOf := func(name string) string {
+		if pseudonym, ok := pseudonyms[functions.NormalizeName(name)]; ok {
+			return pseudonym
+		}
+		return assign(name)
+	}
+
+	anonymized := make([]models.Participant, 0, len(participants))
+	for _, participant := range participants {
+		anonymized = append(anonymized, models.Participant{Name: assign(participant.Name)})
+	}
+
+	var renamed []models.Match
+	for _, match := range matches {
+		renamed = append(renamed, models.Match{First: pseudonymOf(match.First), Second: pseudonymOf(match.Second)})
+	}
+
+	return anonymized, renamed
+}
@@ -0,0 +1,688 @@
+package group
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"strings"
+	"time"
+
+	"service-secret-santa/customError"
+	"service-secret-santa/functions"
+	"service-secret-santa/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// sqlRepository stores groups in the groups, participants and matches tables
+// of SQLite or PostgreSQL. It answers like the Mongo repository: groups keep
+// their ObjectID as a hex key and filters use the same patterns.
+type sqlRepository struct {
+	db      *sql.DB
+	dialect Dialect
+}
+
+func NewSQLRepository(db *sql.DB, dialect Dialect) Repository {
+	return &sqlRepository{db: db, dialect: dialect}
+}
+
+// queryer is satisfied by *sql.DB and *sql.Tx.
+type queryer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// groupColumns are the columns written when a group is created; readColumns
+// add the ones only set later.
+const (
+	groupColumns = `id, name, owner, locale, status, created_at, updated_at, version, exchange_date`
+	readColumns  = groupColumns + `, deleted_at, purge_warned_at, purge_warn_failures, anonymized_at`
+)
+
+// summaryColumns are the columns of a group in listings, which count the
+// participants instead of loading them.
+const summaryColumns = readColumns + `, (SELECT COUNT(*) FROM participants WHERE participants.group_id = groups.id)`
+
+func (r *sqlRepository) CreateGroup(ctx context.Context, group *models.Group) (*models.Group, *customError.CustomError) {
+	ctx, cancel := writeContext(ctx)
+	defer cancel()
+
+	if group.Participants == nil {
+		group.Participants = []models.Participant{}
+	}
+	group.ParticipantCount = len(group.Participants)
+	group.Version = 1
+
+	id := group.Id
+	if id.IsZero() {
+		id = primitive.NewObjectID()
+	}
+
+	err := r.inTx(ctx, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, r.dialect.rebind(`INSERT INTO groups (`+groupColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`),
+			id.Hex(), group.Name, group.Owner, group.Locale, group.Status, toMillis(group.CreatedAt), toMillis(group.UpdatedAt), group.Version, toMillis(group.ExchangeDate))
+		if err != nil {
+			return err
+		}
+		if err := r.insertParticipants(ctx, tx, id, 0, group.Participants); err != nil {
+			return err
+		}
+		return r.insertMatches(ctx, tx, id, group.Matches)
+	})
+	if err != nil {
+		if r.dialect.isDuplicateEmail(err) {
+			return nil, participantDuplicate()
+		}
+		if r.dialect.isUniqueViolation(err) {
+			return nil, customError.NewCustomError(
+				customError.WithCustomError(http.StatusConflict, err.Error(), "Failed to create group"),
+				customError.WithCode(customError.AlreadyExists),
+			)
+		}
+		return nil, translateError(ctx, err, "Failed to create group")
+	}
+
+	group.Id = id
+	return group, nil
+}
+
+func (r *sqlRepository) GetGroupByID(ctx context.Context, id string) (*models.Group, *customError.CustomError) {
+	ctx, cancel := readContext(ctx)
+	defer cancel()
+
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, invalidGroupID()
+	}
+
+	group, err := r.loadGroup(ctx, r.db, objectID)
+	if err != nil {
+		return nil, translateError(ctx, err, "Error finding group")
+	}
+
+	return group, nil
+}
+
+func (r *sqlRepository) UpdateGroup(ctx context.Context, id string, version int64, update *models.GroupUpdate) (*models.Group, *customError.CustomError) {
+	ctx, cancel := writeContext(ctx)
+	defer cancel()
+
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, invalidGroupID()
+	}
+
+	var updated *models.Group
+	var stale *customError.CustomError
+	err = r.inTx(ctx, func(tx *sql.Tx) error {
+		var exchangeDate time.Time
+		if update.ExchangeDate != nil {
+			exchangeDate = *update.ExchangeDate
+		}
+		query, args := r.versioned(`UPDATE groups SET name = ?, owner = ?, locale = CASE WHEN ? = '' THEN locale ELSE ? END, exchange_date = ?, updated_at = ?, version = version + 1`,
+			objectID, version, update.Name, update.Owner, update.Locale, update.Locale, toMillis(exchangeDate), toMillis(update.UpdatedAt))
+		changed, err := r.exec(ctx, tx, query, args...)
+		if err != nil {
+			return err
+		}
+		if !changed {
+			stale, err = r.missingOrStale(ctx, tx, objectID)
+			return err
+		}
+
+		if _, err := tx.ExecContext(ctx, r.dialect.rebind(`DELETE FROM participants WHERE group_id = ?`), objectID.Hex()); err != nil {
+			return err
+		}
+		if err := r.insertParticipants(ctx, tx, objectID, 0, update.Participants); err != nil {
+			return err
+		}
+
+		updated, err = r.loadGroup(ctx, tx, objectID)
+		return err
+	})
+	if err != nil {
+		if r.dialect.isDuplicateEmail(err) {
+			return nil, participantDuplicate()
+		}
+		return nil, translateError(ctx, err, "Failed to update group")
+	}
+	if stale != nil {
+		return nil, stale
+	}
+
+	return updated, nil
+}
+
+func (r *sqlRepository) DeleteGroup(ctx context.Context, id string, version int64) *customError.CustomError {
+	ctx, cancel := writeContext(ctx)
+	defer cancel()
+
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return invalidGroupID()
+	}
+
+	var stale *customError.CustomError
+	err = r.inTx(ctx, func(tx *sql.Tx) error {
+		query, args := r.versioned(`UPDATE groups SET deleted_at = ?, version = version + 1`, objectID, version, toMillis(time.Now()))
+		deleted, err := r.exec(ctx, tx, query, args...)
+		if err != nil {
+			return err
+		}
+		if !deleted {
+			stale, err = r.missingOrStale(ctx, tx, objectID)
+		}
+		return err
+	})
+	if err != nil {
+		return translateError(ctx, err, "Failed to delete group")
+	}
+
+	return stale
+}
+
+func (r *sqlRepository) AddParticipant(ctx context.Context, id string, version int64, participant *models.Participant) (*models.Group, *customError.CustomError) {
+	ctx, cancel := writeContext(ctx)
+	defer cancel()
+
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, invalidGroupID()
+	}
+
+	var updated *models.Group
+	var rejected *customError.CustomError
+	err = r.inTx(ctx, func(tx *sql.Tx) error {
+		// Bumping the version first makes concurrent calls on the group wait
+		// for each other, and the NOT EXISTS check rejects an email the group
+		// already has. A call that waited may still check against the
+		// participants as they were before the wait, so a duplicate from two
+		// concurrent calls is stopped by the unique index of migration 9.
+		query, args := r.versioned(`UPDATE groups SET version = version + 1`, objectID, version)
+		if participant.Email != "" {
+			query += ` AND NOT EXISTS (SELECT 1 FROM participants WHERE group_id = ? AND lower(email) = lower(CAST(? AS TEXT)))`
+			args = append(args, objectID.Hex(), participant.Email)
+		}
+
+		changed, err := r.exec(ctx, tx, query, args...)
+		if err != nil {
+			return err
+		}
+		if !changed {
+			rejected, err = r.missingOrStale(ctx, tx, objectID)
+			if err != nil || rejected.Kind() == customError.GroupNotFound {
+				return err
+			}
+			if participant.Email != "" {
+				var duplicates int
+				err := tx.QueryRowContext(ctx, r.dialect.rebind(`SELECT COUNT(*) FROM participants WHERE group_id = ? AND lower(email) = lower(CAST(? AS TEXT))`), objectID.Hex(), participant.Email).Scan(&duplicates)
+				if err != nil {
+					return err
+				}
+				if duplicates > 0 {
+					rejected = participantDuplicate()
+				}
+			}
+			return nil
+		}
+
+		// Like $addToSet, an identical participant is not added twice.
+		var identical int
+		var next int
+		err = tx.QueryRowContext(ctx, r.dialect.rebind(`SELECT COUNT(CASE WHEN name = ? AND email = ? THEN 1 END), COALESCE(MAX(position) + 1, 0) FROM participants WHERE group_id = ?`),
+			participant.Name, participant.Email, objectID.Hex()).Scan(&identical, &next)
+		if err != nil {
+			return err
+		}
+		if identical == 0 {
+			if err := r.insertParticipants(ctx, tx, objectID, next, []models.Participant{*participant}); err != nil {
+				return err
+			}
+		}
+
+		updated, err = r.loadGroup(ctx, tx, objectID)
+		return err
+	})
+	if err != nil {
+		if r.dialect.isDuplicateEmail(err) {
+			return nil, participantDuplicate()
+		}
+		return nil, translateError(ctx, err, "Failed to add participant")
+	}
+	if rejected != nil {
+		return nil, rejected
+	}
+
+	return updated, nil
+}
+
+// UpdateMatches stores the draw in one transaction: the group is marked as
+// drawn and its matches replaced together, or not at all.
+func (r *sqlRepository) UpdateMatches(ctx context.Context, id string, version int64, matches []models.Match) *customError.CustomError {
+	ctx, cancel := writeContext(ctx)
+	defer cancel()
+
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return invalidGroupID()
+	}
+
+	var stale *customError.CustomError
+	err = r.inTx(ctx, func(tx *sql.Tx) error {
+		query, args := r.versioned(`UPDATE groups SET status = ?, version = version + 1`, objectID, version, models.GroupStatusDrawn)
+		changed, err := r.exec(ctx, tx, query, args...)
+		if err != nil {
+			return err
+		}
+		if !changed {
+			stale, err = r.missingOrStale(ctx, tx, objectID)
+			return err
+		}
+
+		if _, err := tx.ExecContext(ctx, r.dialect.rebind(`DELETE FROM matches WHERE group_id = ?`), objectID.Hex()); err != nil {
+			return err
+		}
+		return r.insertMatches(ctx, tx, objectID, matches)
+	})
+	if err != nil {
+		return translateError(ctx, err, "Failed to update matches")
+	}
+
+	return stale
+}
+
+func (r *sqlRepository) GetMyMatch(ctx context.Context, id string, username string) (string, *customError.CustomError) {
+	group, err := r.GetGroupByID(ctx, id)
+	if err != nil {
+		return "", err
+	}
+
+	return resolveMatch(group.Matches, username)
+}
+
+func (r *sqlRepository) GetAllGroups(ctx context.Context, query models.GroupQuery) (*models.GroupPage, *customError.CustomError) {
+	ctx, cancel := readContext(ctx)
+	defer cancel()
+
+	conditions := []string{"deleted_at IS NULL"}
+	var args []any
+
+	if query.Name != "" {
+		condition, arg := r.dialect.regexMatch("name", regexPattern(functions.ToCaseInsensitiveRegex([]string{query.Name})))
+		conditions, args = append(conditions, condition), append(args, arg)
+	}
+	if query.Status != "" {
+		conditions, args = append(conditions, "status = ?"), append(args, query.Status)
+	}
+	if query.Owner != "" {
+		conditions, args = append(conditions, "lower(owner) = lower(CAST(? AS TEXT))"), append(args, query.Owner)
+	}
+	if query.ParticipantEmail != "" {
+		conditions = append(conditions, "EXISTS (SELECT 1 FROM participants WHERE participants.group_id = groups.id AND lower(participants.email) = lower(CAST(? AS TEXT)))")
+		args = append(args, query.ParticipantEmail)
+	}
+
+	column := "created_at"
+	if query.Sort == models.SortByName {
+		column = "name"
+	}
+	op, direction := ">", "ASC"
+	if query.Order == models.SortDesc {
+		op, direction = "<", "DESC"
+	}
+
+	if query.Cursor != "" {
+		after, err := decodePageCursor(query.Cursor, query)
+		if err != nil {
+			return nil, invalidCursor(err)
+		}
+
+		var value any = toMillis(after.CreatedAt)
+		if query.Sort == models.SortByName {
+			value = after.Name
+		}
+		conditions = append(conditions, "("+column+" "+op+" ? OR ("+column+" = ? AND id "+op+" ?))")
+		args = append(args, value, value, after.Id)
+	}
+
+	statement := `SELECT ` + summaryColumns + ` FROM groups WHERE ` + strings.Join(conditions, " AND ")
+	statement += ` ORDER BY ` + column + ` ` + direction + `, id ` + direction + ` LIMIT ?`
+	args = append(args, query.Limit+1)
+
+	groups, err := r.loadGroups(ctx, statement, args...)
+	if err != nil {
+		return nil, translateError(ctx, err, "Error retrieving groups")
+	}
+
+	return newGroupPage(query, groups), nil
+}
+
+func (r *sqlRepository) SearchGroups(ctx context.Context, term string) ([]*models.Group, *customError.CustomError) {
+	ctx, cancel := readContext(ctx)
+	defer cancel()
+
+	tiers := searchPatterns(term)
+	var args []any
+	matches := func(pattern string) string {
+		byName, nameArg := r.dialect.regexMatch("name", pattern)
+		byOwner, ownerArg := r.dialect.regexMatch("owner", pattern)
+		args = append(args, nameArg, ownerArg)
+		return `(` + byName + ` OR ` + byOwner + `)`
+	}
+
+	// The partial match selects; the exact and prefix matches rank first.
+	statement := `SELECT ` + summaryColumns + ` FROM groups WHERE deleted_at IS NULL AND ` + matches(tiers[2].Pattern) +
+		` ORDER BY CASE WHEN ` + matches(tiers[0].Pattern) + ` THEN 0 WHEN ` + matches(tiers[1].Pattern) + ` THEN 1 ELSE 2 END, id LIMIT ?`
+	groups, err := r.loadGroups(ctx, statement, append(args, searchCandidates)...)
+	if err != nil {
+		return nil, translateError(ctx, err, "Error searching groups")
+	}
+
+	return groups, nil
+}
+
+func (r *sqlRepository) GetTrash(ctx context.Context, owner string) ([]*models.Group, *customError.CustomError) {
+	ctx, cancel := readContext(ctx)
+	defer cancel()
+
+	statement := `SELECT ` + summaryColumns + ` FROM groups WHERE deleted_at IS NOT NULL`
+	var args []any
+	if owner != "" {
+		statement += ` AND lower(owner) = lower(CAST(? AS TEXT))`
+		args = append(args, owner)
+	}
+	statement += ` ORDER BY deleted_at DESC, id DESC LIMIT ?`
+	args = append(args, trashLimit)
+
+	groups, err := r.loadGroups(ctx, statement, args...)
+	if err != nil {
+		return nil, translateError(ctx, err, "Error retrieving trash")
+	}
+
+	return groups, nil
+}
+
+func (r *sqlRepository) RestoreGroup(ctx context.Context, id string, version int64) (*models.Group, *customError.CustomError) {
+	ctx, cancel := writeContext(ctx)
+	defer cancel()
+
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, invalidGroupID()
+	}
+
+	var restored *models.Group
+	var stale *customError.CustomError
+	err = r.inTx(ctx, func(tx *sql.Tx) error {
+		query, args := `UPDATE groups SET deleted_at = NULL, version = version + 1 WHERE id = ? AND deleted_at IS NOT NULL`, []any{objectID.Hex()}
+		if version != models.AnyVersion {
+			query += ` AND version = ?`
+			args = append(args, version)
+		}
+		changed, err := r.exec(ctx, tx, query, args...)
+		if err != nil {
+			return err
+		}
+		if !changed {
+			var count int
+			if err := tx.QueryRowContext(ctx, r.dialect.rebind(`SELECT COUNT(*) FROM groups WHERE id = ? AND deleted_at IS NOT NULL`), objectID.Hex()).Scan(&count); err != nil {
+				return err
+			}
+			stale = groupNotFound()
+			if count > 0 {
+				stale = groupVersionMismatch()
+			}
+			return nil
+		}
+
+		restored, err = r.loadGroup(ctx, tx, objectID)
+		return err
+	})
+	if err != nil {
+		return nil, translateError(ctx, err, "Failed to restore group")
+	}
+	if stale != nil {
+		return nil, stale
+	}
+
+	return restored, nil
+}
+
+// PurgeTrash removes the groups deleted before deletedBefore. Their
+// participants and matches are deleted first rather than left to ON DELETE
+// CASCADE: SQLite only enforces foreign keys when asked to on each
+// connection.
+func (r *sqlRepository) PurgeTrash(ctx context.Context, deletedBefore time.Time) (int, *customError.CustomError) {
+	ctx, cancel := writeContext(ctx)
+	defer cancel()
+
+	var purged int64
+	err := r.inTx(ctx, func(tx *sql.Tx) error {
+		cutoff := toMillis(deletedBefore)
+		for _, table := range []string{"participants", "matches"} {
+			statement := `DELETE FROM ` + table + ` WHERE group_id IN (SELECT id FROM groups WHERE deleted_at < ?)`
+			if _, err := tx.ExecContext(ctx, r.dialect.rebind(statement), cutoff); err != nil {
+				return err
+			}
+		}
+
+		result, err := tx.ExecContext(ctx, r.dialect.rebind(`DELETE FROM groups WHERE deleted_at < ?`), cutoff)
+		if err != nil {
+			return err
+		}
+		purged, err = result.RowsAffected()
+		return err
+	})
+	if err != nil {
+		return 0, translateError(ctx, err, "Failed to purge trash")
+	}
+
+	return int(purged), nil
+}
+
+// versioned adds the id, trash and version conditions of versionFilter to
+// statement, binding them after args.
+func (r *sqlRepository) versioned(statement string, objectID primitive.ObjectID, version int64, args ...any) (string, []any) {
+	statement += ` WHERE id = ? AND deleted_at IS NULL`
+	args = append(args, objectID.Hex())
+	if version != models.AnyVersion {
+		statement += ` AND version = ?`
+		args = append(args, version)
+	}
+	return statement, args
+}
+
+// regexPattern is the pattern of a {"$regex": ...} condition built for Mongo.
+func regexPattern(condition bson.M) string {
+	return condition["$regex"].(primitive.Regex).Pattern
+}
+
+// exec runs a write and reports whether it changed any row.
+func (r *sqlRepository) exec(ctx context.Context, q queryer, query string, args ...any) (bool, error) {
+	result, err := q.ExecContext(ctx, r.dialect.rebind(query), args...)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	return affected > 0, err
+}
+
+// missingOrStale explains why a versioned write changed nothing, like its
+// Mongo counterpart.
+func (r *sqlRepository) missingOrStale(ctx context.Context, q queryer, objectID primitive.ObjectID) (*customError.CustomError, error) {
+	var count int
+	if err := q.QueryRowContext(ctx, r.dialect.rebind(`SELECT COUNT(*) FROM groups WHERE id = ? AND deleted_at IS NULL`), objectID.Hex()).Scan(&count); err != nil {
+		return nil, err
+	}
+
+	if count == 0 {
+		return groupNotFound(), nil
+	}
+	return groupVersionMismatch(), nil
+}
+
+func (r *sqlRepository) inTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *sqlRepository) insertParticipants(ctx context.Context, tx *sql.Tx, objectID primitive.ObjectID, first int, participants []models.Participant) error {
+	for i, participant := range participants {
+		_, err := tx.ExecContext(ctx, r.dialect.rebind(`INSERT INTO participants (group_id, position, name, email) VALUES (?, ?, ?, ?)`),
+			objectID.Hex(), first+i, participant.Name, participant.Email)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *sqlRepository) insertMatches(ctx context.Context, tx *sql.Tx, objectID primitive.ObjectID, matches []models.Match) error {
+	for i, match := range matches {
+		_, err := tx.ExecContext(ctx, r.dialect.rebind(`INSERT INTO matches (group_id, position, first, second) VALUES (?, ?, ?, ?)`),
+			objectID.Hex(), i, match.First, match.Second)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadGroup reads one group out of the trash with its participants and
+// matches. A missing group is sql.ErrNoRows, which translateError turns into
+// 404.
+func (r *sqlRepository) loadGroup(ctx context.Context, q queryer, objectID primitive.ObjectID) (*models.Group, error) {
+	return r.loadGroupWhere(ctx, q, objectID, `deleted_at IS NULL`)
+}
+
+// loadGroupWhere reads one group with its participants and matches, as long
+// as it meets condition.
+func (r *sqlRepository) loadGroupWhere(ctx context.Context, q queryer, objectID primitive.ObjectID, condition string) (*models.Group, error) {
+	group, err := scanGroup(q.QueryRowContext(ctx, r.dialect.rebind(`SELECT `+readColumns+` FROM groups WHERE id = ? AND `+condition), objectID.Hex()), false)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.loadChildren(ctx, q, group); err != nil {
+		return nil, err
+	}
+	return group, nil
+}
+
+// loadGroups reads the groups selected by a query on summaryColumns, without
+// their participants and matches.
+func (r *sqlRepository) loadGroups(ctx context.Context, query string, args ...any) ([]*models.Group, error) {
+	rows, err := r.db.QueryContext(ctx, r.dialect.rebind(query), args...)
+	if err != nil {
+		return nil, err
+	}
+
+	groups := []*models.Group{}
+	for rows.Next() {
+		group, err := scanGroup(rows, true)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		groups = append(groups, group)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return groups, nil
+}
+
+func (r *sqlRepository) loadChildren(ctx context.Context, q queryer, group *models.Group) error {
+	rows, err := q.QueryContext(ctx, r.dialect.rebind(`SELECT name, email FROM participants WHERE group_id = ? ORDER BY position`), group.Id.Hex())
+	if err != nil {
+		return err
+	}
+	group.Participants = []models.Participant{}
+	for rows.Next() {
+		var participant models.Participant
+		if err := rows.Scan(&participant.Name, &participant.Email); err != nil {
+			rows.Close()
+			return err
+		}
+		group.Participants = append(group.Participants, participant)
+	}
+	group.ParticipantCount = len(group.Participants)
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	rows, err = q.QueryContext(ctx, r.dialect.rebind(`SELECT first, second FROM matches WHERE group_id = ? ORDER BY position`), group.Id.Hex())
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var match models.Match
+		if err := rows.Scan(&match.First, &match.Second); err != nil {
+			return err
+		}
+		group.Matches = append(group.Matches, match)
+	}
+	return rows.Err()
+}
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+// scanGroup reads the readColumns of a row, followed by the participant
+// count of summaryColumns when counted is true.
+func scanGroup(row scanner, counted bool) (*models.Group, error) {
+	var group models.Group
+	var id string
+	var createdAt, updatedAt, exchangeDate, deletedAt, purgeWarnedAt, anonymizedAt sql.NullInt64
+	dest := []any{&id, &group.Name, &group.Owner, &group.Locale, &group.Status, &createdAt, &updatedAt, &group.Version, &exchangeDate, &deletedAt, &purgeWarnedAt, &group.PurgeWarnFailures, &anonymizedAt}
+	if counted {
+		dest = append(dest, &group.ParticipantCount)
+	}
+	if err := row.Scan(dest...); err != nil {
+		return nil, err
+	}
+
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+	group.Id = objectID
+	group.CreatedAt = fromMillis(createdAt)
+	group.UpdatedAt = fromMillis(updatedAt)
+	group.ExchangeDate = fromMillis(exchangeDate)
+	group.DeletedAt = fromMillis(deletedAt)
+	group.PurgeWarnedAt = fromMillis(purgeWarnedAt)
+	group.AnonymizedAt = fromMillis(anonymizedAt)
+
+	return &group, nil
+}
+
+func toMillis(t time.Time) sql.NullInt64 {
+	if t.IsZero() {
+		return sql.NullInt64{}
+	}
+	return sql.NullInt64{Int64: t.UnixMilli(), Valid: true}
+}
+
+func fromMillis(ms sql.NullInt64) time.Time {
+	if !ms.Valid {
+		return time.Time{}
+	}
+	return time.UnixMilli(ms.Int64).UTC()
+}
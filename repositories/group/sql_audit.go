@@ -0,0 +1,153 @@
+package group
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"service-secret-santa/customError"
+	"service-secret-santa/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func (r *sqlRepository) AppendAudit(ctx context.Context, event *models.AuditEvent) *customError.CustomError {
+	ctx, cancel := writeContext(ctx)
+	defer cancel()
+
+	if event.Id.IsZero() {
+		event.Id = primitive.NewObjectID()
+	}
+	if event.At.IsZero() {
+		event.At = time.Now()
+	}
+	event.At = storedTime(event.At)
+
+	var changes []byte
+	if len(event.Changes) > 0 {
+		var err error
+		if changes, err = json.Marshal(event.Changes); err != nil {
+			return translateError(ctx, err, "Failed to record audit event")
+		}
+	}
+
+	_, err := r.db.ExecContext(ctx, r.dialect.rebind(`INSERT INTO audit_events (id, group_id, action, actor, at, request_id, detail, changes) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`),
+		event.Id.Hex(), event.GroupId.Hex(), event.Action, event.Actor, event.At.UnixMilli(), event.RequestId, event.Detail, string(changes))
+	if err != nil {
+		return translateError(ctx, err, "Failed to record audit event")
+	}
+
+	return nil
+}
+
+func (r *sqlRepository) GetAuditEvents(ctx context.Context, id string, query models.AuditQuery) ([]models.AuditEvent, *customError.CustomError) {
+	ctx, cancel := readContext(ctx)
+	defer cancel()
+
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, invalidGroupID()
+	}
+
+	statement := `SELECT id, group_id, action, actor, at, request_id, detail, changes FROM audit_events WHERE group_id = ?`
+	args := []interface{}{objectID.Hex()}
+	if query.Action != "" {
+		statement += ` AND action = ?`
+		args = append(args, query.Action)
+	}
+	if query.Actor != "" {
+		statement += ` AND actor = ?`
+		args = append(args, query.Actor)
+	}
+	if !query.Since.IsZero() {
+		statement += ` AND at >= ?`
+		args = append(args, toMillis(query.Since))
+	}
+	if !query.Until.IsZero() {
+		statement += ` AND at < ?`
+		args = append(args, toMillis(query.Until))
+	}
+	statement += ` ORDER BY at, id`
+	if query.Limit > 0 {
+		statement += ` LIMIT ?`
+		args = append(args, query.Limit)
+	}
+
+	return r.auditWhere(ctx, statement, args...)
+}
+
+func (r *sqlRepository) GetAuditEventsByActor(ctx context.Context, actor string) ([]models.AuditEvent, *customError.CustomError) {
+	ctx, cancel := readContext(ctx)
+	defer cancel()
+
+	return r.auditWhere(ctx, `SELECT id, group_id, action, actor, at, request_id, detail, changes FROM audit_events WHERE actor = ? ORDER BY at, id`, actor)
+}
+
+// auditWhere reads the events selected by statement, which lists the columns
+// of an event in the order they are scanned.
+func (r *sqlRepository) auditWhere(ctx context.Context, statement string, args ...interface{}) ([]models.AuditEvent, *customError.CustomError) {
+	rows, err := r.db.QueryContext(ctx, r.dialect.rebind(statement), args...)
+	if err != nil {
+		return nil, translateError(ctx, err, "Error retrieving audit events")
+	}
+	defer rows.Close()
+
+	events := []models.AuditEvent{}
+	for rows.Next() {
+		var event models.AuditEvent
+		var eventID, groupID, changes string
+		var at sql.NullInt64
+		if err := rows.Scan(&eventID, &groupID, &event.Action, &event.Actor, &at, &event.RequestId, &event.Detail, &changes); err != nil {
+			return nil, translateError(ctx, err, "Error retrieving audit events")
+		}
+		var err error
+		if event.Id, err = primitive.ObjectIDFromHex(eventID); err != nil {
+			return nil, translateError(ctx, err, "Error retrieving audit events")
+		}
+		if event.GroupId, err = primitive.ObjectIDFromHex(groupID); err != nil {
+			return nil, translateError(ctx, err, "Error retrieving audit events")
+		}
+		event.At = fromMillis(at)
+		if changes != "" {
+			if err := json.Unmarshal([]byte(changes), &event.Changes); err != nil {
+				return nil, translateError(ctx, err, "Error retrieving audit events")
+			}
+		}
+		events = append(events, event)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, translateError(ctx, err, "Error retrieving audit events")
+	}
+
+	return events, nil
+}
+
+func (r *sqlRepository) RewriteAuditEvents(ctx context.Context, events []models.AuditEvent) *customError.CustomError {
+	ctx, cancel := writeContext(ctx)
+	defer cancel()
+
+	err := r.inTx(ctx, func(tx *sql.Tx) error {
+		for _, event := range events {
+			var changes []byte
+			if len(event.Changes) > 0 {
+				var err error
+				if changes, err = json.Marshal(event.Changes); err != nil {
+					return err
+				}
+			}
+
+			_, err := tx.ExecContext(ctx, r.dialect.rebind(`UPDATE audit_events SET actor = ?, detail = ?, changes = ? WHERE id = ?`),
+				event.Actor, event.Detail, string(changes), event.Id.Hex())
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return translateError(ctx, err, "Failed to rewrite audit events")
+	}
+
+	return nil
+}
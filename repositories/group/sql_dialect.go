@@ -0,0 +1,120 @@
+package group
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// Dialect is a relational database supported by the SQL repository.
+type Dialect string
+
+const (
+	SQLite   Dialect = "sqlite"
+	Postgres Dialect = "postgres"
+)
+
+// DriverName is the database/sql driver of the dialect.
+func (d Dialect) DriverName() string {
+	if d == Postgres {
+		return "pgx"
+	}
+	return "sqlite"
+}
+
+// rebind turns the ? placeholders used in the queries into $1, $2, ... for
+// PostgreSQL.
+func (d Dialect) rebind(query string) string {
+	if d != Postgres {
+		return query
+	}
+
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// regexMatch is the condition matching column against a case-insensitive
+// pattern built for Mongo, and the argument to bind for it.
+func (d Dialect) regexMatch(column, pattern string) (string, string) {
+	if d == Postgres {
+		return column + " ~* ?", pattern
+	}
+	return column + " REGEXP ?", "(?i)" + pattern
+}
+
+func (d Dialect) isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.Code == "23505"
+	}
+
+	var sqliteErr *sqlite.Error
+	if errors.As(err, &sqliteErr) {
+		return sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY || sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE
+	}
+
+	return false
+}
+
+// sqlParticipantEmailIndex is the unique index on the emails of a group, since
+// migration 9.
+const sqlParticipantEmailIndex = "participants_group_email"
+
+// isDuplicateEmail reports whether err is a participant whose email the group
+// already has.
+func (d Dialect) isDuplicateEmail(err error) bool {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.Code == "23505" && pgErr.ConstraintName == sqlParticipantEmailIndex
+	}
+
+	var sqliteErr *sqlite.Error
+	if errors.As(err, &sqliteErr) {
+		return sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE && strings.Contains(sqliteErr.Error(), sqlParticipantEmailIndex)
+	}
+
+	return false
+}
+
+// SQLite has a REGEXP operator but no function behind it; this one uses Go
+// regular expressions, which accept the patterns built for Mongo.
+var sqliteRegexps sync.Map
+
+func init() {
+	err := sqlite.RegisterDeterministicScalarFunction("regexp", 2, func(_ *sqlite.FunctionContext, args []driver.Value) (driver.Value, error) {
+		pattern, _ := args[0].(string)
+		value, _ := args[1].(string)
+
+		cached, found := sqliteRegexps.Load(pattern)
+		if !found {
+			compiled, err := regexp.Compile(pattern)
+			if err != nil {
+				return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+			}
+			cached, _ = sqliteRegexps.LoadOrStore(pattern, compiled)
+		}
+
+		return cached.(*regexp.Regexp).MatchString(value), nil
+	})
+	if err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,133 @@
+package group
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//go:embed migrations
+var migrationFiles embed.FS
+
+// migrationLock is the PostgreSQL advisory lock held while migrating, so
+// instances starting together do not run the same migration twice.
+const migrationLock = 7355608
+
+// MigrateSQL applies the embedded migrations of dialect that the database has
+// not run yet, in order and each in its own transaction. Applied versions are
+// recorded in schema_migrations. It is idempotent.
+func MigrateSQL(ctx context.Context, db *sql.DB, dialect Dialect) error {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if dialect == Postgres {
+		if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", migrationLock); err != nil {
+			return err
+		}
+		defer conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", migrationLock)
+	}
+
+	if _, err := conn.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (version BIGINT PRIMARY KEY, applied_at BIGINT NOT NULL)`); err != nil {
+		return err
+	}
+
+	dir := path.Join("migrations", string(dialect))
+	entries, err := fs.ReadDir(migrationFiles, dir)
+	if err != nil {
+		return err
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+
+	for _, entry := range entries {
+		version, err := strconv.ParseInt(strings.SplitN(entry.Name(), "_", 2)[0], 10, 64)
+		if err != nil {
+			return fmt.Errorf("migration %s has no version prefix", entry.Name())
+		}
+
+		script, err := migrationFiles.ReadFile(path.Join(dir, entry.Name()))
+		if err != nil {
+			return err
+		}
+
+		if err := applyMigration(ctx, conn, dialect, version, string(script)); err != nil {
+			return fmt.Errorf("migration %s: %w", entry.Name(), err)
+		}
+	}
+
+	return nil
+}
+
+func applyMigration(ctx context.Context, conn *sql.Conn, dialect Dialect, version int64, script string) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var applied int
+	if err := tx.QueryRowContext(ctx, dialect.rebind(`SELECT COUNT(*) FROM schema_migrations WHERE version = ?`), version).Scan(&applied); err != nil {
+		return err
+	}
+	if applied > 0 {
+		return nil
+	}
+
+	if check, found := migrationChecks[version]; found {
+		if err := check(ctx, tx); err != nil {
+			return err
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, script); err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, dialect.rebind(`INSERT INTO schema_migrations (version, applied_at) VALUES (?, ?)`), version, time.Now().UnixMilli()); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// migrationChecks refuse to run a migration over data it cannot take, with an
+// error that says what to fix, rather than the one the script would fail with.
+var migrationChecks = map[int64]func(ctx context.Context, tx *sql.Tx) error{
+	9: noDuplicateEmails,
+}
+
+// noDuplicateEmails fails when a group has an email twice, which the unique
+// index of migration 9 cannot take. Like the Mongo migration, it leaves the
+// duplicates to the organizer, the only one who can tell which should go.
+func noDuplicateEmails(ctx context.Context, tx *sql.Tx) error {
+	rows, err := tx.QueryContext(ctx, `SELECT DISTINCT group_id FROM participants WHERE email <> '' GROUP BY group_id, lower(email) HAVING COUNT(*) > 1 ORDER BY group_id`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return err
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if len(ids) > 0 {
+		return fmt.Errorf("groups %s have participants with the same email; remove the duplicates and start again", strings.Join(ids, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,149 @@
+package group
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"service-secret-santa/customError"
+	"service-secret-santa/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func (r *sqlRepository) GetRetainedGroups(ctx context.Context, query models.RetentionQuery) ([]*models.Group, *customError.CustomError) {
+	ctx, cancel := readContext(ctx)
+	defer cancel()
+
+	cutoff := toMillis(query.ExchangedBefore)
+	statement := `SELECT ` + summaryColumns + ` FROM groups WHERE anonymized_at IS NULL` +
+		` AND (exchange_date < ? OR (exchange_date IS NULL AND created_at < ?))`
+	args := []any{cutoff, cutoff}
+	if query.WarnedBefore.IsZero() {
+		statement += ` AND purge_warned_at IS NULL`
+	} else {
+		statement += ` AND purge_warned_at < ?`
+		args = append(args, toMillis(query.WarnedBefore))
+	}
+	statement += ` ORDER BY id LIMIT ?`
+	args = append(args, query.Limit)
+
+	groups, err := r.loadGroups(ctx, statement, args...)
+	if err != nil {
+		return nil, translateError(ctx, err, "Error retrieving retained groups")
+	}
+
+	return groups, nil
+}
+
+// MarkPurgeWarned records the warning like its Mongo counterpart, reporting
+// false when it was already recorded.
+func (r *sqlRepository) MarkPurgeWarned(ctx context.Context, id string) (bool, *customError.CustomError) {
+	ctx, cancel := writeContext(ctx)
+	defer cancel()
+
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return false, invalidGroupID()
+	}
+
+	marked, err := r.exec(ctx, r.db, `UPDATE groups SET purge_warned_at = ? WHERE id = ? AND anonymized_at IS NULL AND purge_warned_at IS NULL`,
+		toMillis(time.Now()), objectID.Hex())
+	if err != nil {
+		return false, translateError(ctx, err, "Failed to mark group as warned")
+	}
+	if marked {
+		return true, nil
+	}
+
+	var count int
+	err = r.db.QueryRowContext(ctx, r.dialect.rebind(`SELECT COUNT(*) FROM groups WHERE id = ? AND anonymized_at IS NULL`), objectID.Hex()).Scan(&count)
+	if err != nil {
+		return false, translateError(ctx, err, "Error finding group")
+	}
+	if count == 0 {
+		return false, groupNotFound()
+	}
+	return false, nil
+}
+
+// UnmarkPurgeWarned takes back a warning that could not be sent and counts
+// the failure, like its Mongo counterpart.
+func (r *sqlRepository) UnmarkPurgeWarned(ctx context.Context, id string) *customError.CustomError {
+	ctx, cancel := writeContext(ctx)
+	defer cancel()
+
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return invalidGroupID()
+	}
+
+	unmarked, err := r.exec(ctx, r.db, `UPDATE groups SET purge_warned_at = NULL, purge_warn_failures = purge_warn_failures + 1 WHERE id = ? AND anonymized_at IS NULL`, objectID.Hex())
+	if err != nil {
+		return translateError(ctx, err, "Failed to unmark group as warned")
+	}
+	if !unmarked {
+		return groupNotFound()
+	}
+	return nil
+}
+
+// AnonymizeGroup replaces the participants and matches of the group by
+// pseudonyms in one transaction, like its Mongo counterpart, whether or not
+// the group is in the trash.
+func (r *sqlRepository) AnonymizeGroup(ctx context.Context, id string) (*models.Group, *customError.CustomError) {
+	ctx, cancel := writeContext(ctx)
+	defer cancel()
+
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, invalidGroupID()
+	}
+
+	var anonymized *models.Group
+	var missing *customError.CustomError
+	err = r.inTx(ctx, func(tx *sql.Tx) error {
+		anonymizedAt := toMillis(time.Now())
+		changed, err := r.exec(ctx, tx, `UPDATE groups SET owner = '', anonymized_at = ?, version = version + 1 WHERE id = ? AND anonymized_at IS NULL`,
+			anonymizedAt, objectID.Hex())
+		if err != nil {
+			return err
+		}
+		if !changed {
+			missing = groupNotFound()
+			return nil
+		}
+
+		group, err := r.loadGroupWhere(ctx, tx, objectID, `1 = 1`)
+		if err != nil {
+			return err
+		}
+
+		participants, matches := pseudonymize(group.Participants, group.Matches)
+		for _, table := range []string{"participants", "matches"} {
+			if _, err := tx.ExecContext(ctx, r.dialect.rebind(`DELETE FROM `+table+` WHERE group_id = ?`), objectID.Hex()); err != nil {
+				return err
+			}
+		}
+		if err := r.insertParticipants(ctx, tx, objectID, 0, participants); err != nil {
+			return err
+		}
+		if err := r.insertMatches(ctx, tx, objectID, matches); err != nil {
+			return err
+		}
+
+		group.Participants = participants
+		group.Matches = matches
+		group.ParticipantCount = len(participants)
+		anonymized = group
+		return nil
+	})
+	if err != nil {
+		return nil, translateError(ctx, err, "Failed to anonymize group")
+	}
+	if missing != nil {
+		return nil, missing
+	}
+
+	return anonymized, nil
+}
@@ -0,0 +1,167 @@
+package group
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+
+	"service-secret-santa/customError"
+	"service-secret-santa/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func (r *sqlRepository) GetGroupsByEmail(ctx context.Context, email string) ([]*models.Group, *customError.CustomError) {
+	ctx, cancel := readContext(ctx)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, r.dialect.rebind(`SELECT id FROM groups WHERE lower(owner) = lower(CAST(? AS TEXT))`+
+		` OR EXISTS (SELECT 1 FROM participants WHERE participants.group_id = groups.id AND lower(participants.email) = lower(CAST(? AS TEXT)))`+
+		` ORDER BY id LIMIT ?`), email, email, subjectGroupsLimit)
+	if err != nil {
+		return nil, translateError(ctx, err, "Error retrieving groups")
+	}
+	var ids []primitive.ObjectID
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, translateError(ctx, err, "Error retrieving groups")
+		}
+		objectID, err := primitive.ObjectIDFromHex(id)
+		if err != nil {
+			rows.Close()
+			return nil, translateError(ctx, err, "Error retrieving groups")
+		}
+		ids = append(ids, objectID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, translateError(ctx, err, "Error retrieving groups")
+	}
+
+	groups := make([]*models.Group, 0, len(ids))
+	for _, objectID := range ids {
+		group, err := r.loadGroupWhere(ctx, r.db, objectID, `1 = 1`)
+		if err == sql.ErrNoRows {
+			continue
+		}
+		if err != nil {
+			return nil, translateError(ctx, err, "Error retrieving groups")
+		}
+		groups = append(groups, group)
+	}
+
+	return groups, nil
+}
+
+// RewriteGroup replaces the owner, status, participants and matches of the
+// group in one transaction, like its Mongo counterpart.
+func (r *sqlRepository) RewriteGroup(ctx context.Context, id string, version int64, rewrite *models.GroupRewrite) (*models.Group, *customError.CustomError) {
+	ctx, cancel := writeContext(ctx)
+	defer cancel()
+
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, invalidGroupID()
+	}
+
+	var rewritten *models.Group
+	var stale *customError.CustomError
+	err = r.inTx(ctx, func(tx *sql.Tx) error {
+		query, args := `UPDATE groups SET owner = ?, status = ?, version = version + 1 WHERE id = ?`, []any{rewrite.Owner, rewrite.Status, objectID.Hex()}
+		if version != models.AnyVersion {
+			query += ` AND version = ?`
+			args = append(args, version)
+		}
+		changed, err := r.exec(ctx, tx, query, args...)
+		if err != nil {
+			return err
+		}
+		if !changed {
+			var count int
+			if err := tx.QueryRowContext(ctx, r.dialect.rebind(`SELECT COUNT(*) FROM groups WHERE id = ?`), objectID.Hex()).Scan(&count); err != nil {
+				return err
+			}
+			stale = groupNotFound()
+			if count > 0 {
+				stale = groupVersionMismatch()
+			}
+			return nil
+		}
+
+		for _, table := range []string{"participants", "matches"} {
+			if _, err := tx.ExecContext(ctx, r.dialect.rebind(`DELETE FROM `+table+` WHERE group_id = ?`), objectID.Hex()); err != nil {
+				return err
+			}
+		}
+		if err := r.insertParticipants(ctx, tx, objectID, 0, rewrite.Participants); err != nil {
+			return err
+		}
+		if err := r.insertMatches(ctx, tx, objectID, rewrite.Matches); err != nil {
+			return err
+		}
+
+		rewritten, err = r.loadGroupWhere(ctx, tx, objectID, `1 = 1`)
+		return err
+	})
+	if err != nil {
+		return nil, translateError(ctx, err, "Failed to rewrite group")
+	}
+	if stale != nil {
+		return nil, stale
+	}
+
+	return rewritten, nil
+}
+
+func (r *sqlRepository) RecordErasure(ctx context.Context, certificate *models.ErasureCertificate) *customError.CustomError {
+	ctx, cancel := writeContext(ctx)
+	defer cancel()
+
+	if certificate.Id.IsZero() {
+		certificate.Id = primitive.NewObjectID()
+	}
+	certificate.ErasedAt = storedTime(certificate.ErasedAt)
+
+	groups, err := json.Marshal(certificate.Groups)
+	if err != nil {
+		return translateError(ctx, err, "Failed to record erasure")
+	}
+
+	_, err = r.db.ExecContext(ctx, r.dialect.rebind(`INSERT INTO erasures (id, subject_hash, erased_at, group_ids, participants_removed, draws_repaired) VALUES (?, ?, ?, ?, ?, ?)`),
+		certificate.Id.Hex(), certificate.SubjectHash, toMillis(certificate.ErasedAt), string(groups), certificate.ParticipantsRemoved, certificate.DrawsRepaired)
+	if err != nil {
+		return translateError(ctx, err, "Failed to record erasure")
+	}
+
+	return nil
+}
+
+func (r *sqlRepository) GetErasure(ctx context.Context, id string) (*models.ErasureCertificate, *customError.CustomError) {
+	ctx, cancel := readContext(ctx)
+	defer cancel()
+
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, invalidErasureID()
+	}
+
+	certificate := models.ErasureCertificate{Id: objectID}
+	var erasedAt sql.NullInt64
+	var groups string
+	err = r.db.QueryRowContext(ctx, r.dialect.rebind(`SELECT subject_hash, erased_at, group_ids, participants_removed, draws_repaired FROM erasures WHERE id = ?`), objectID.Hex()).
+		Scan(&certificate.SubjectHash, &erasedAt, &groups, &certificate.ParticipantsRemoved, &certificate.DrawsRepaired)
+	if err == sql.ErrNoRows {
+		return nil, erasureNotFound()
+	}
+	if err != nil {
+		return nil, translateError(ctx, err, "Error finding erasure")
+	}
+	certificate.ErasedAt = fromMillis(erasedAt)
+	if err := json.Unmarshal([]byte(groups), &certificate.Groups); err != nil {
+		return nil, translateError(ctx, err, "Error finding erasure")
+	}
+
+	return &certificate, nil
+}
@@ -0,0 +1,85 @@
+package group_test
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"service-secret-santa/config"
+	"service-secret-santa/customError"
+	"service-secret-santa/models"
+	"service-secret-santa/repositories/group"
+	"service-secret-santa/repositories/group/grouptest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func openSQLite(t *testing.T) *sql.DB {
+	config.LoadConfig()
+
+	db, err := sql.Open(group.SQLite.DriverName(), filepath.Join(t.TempDir(), "groups.db"))
+	require.NoError(t, err)
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestSQLiteRepositoryContract(t *testing.T) {
+	grouptest.RunContract(t, func(t *testing.T) group.Repository {
+		db := openSQLite(t)
+		require.NoError(t, group.MigrateSQL(context.Background(), db, group.SQLite))
+		return group.NewSQLRepository(db, group.SQLite)
+	})
+}
+
+func TestMigrateSQL_IsIdempotent(t *testing.T) {
+	db := openSQLite(t)
+
+	require.NoError(t, group.MigrateSQL(context.Background(), db, group.SQLite))
+	require.NoError(t, group.MigrateSQL(context.Background(), db, group.SQLite))
+
+	var applied int
+	require.NoError(t, db.QueryRow(`SELECT COUNT(*) FROM schema_migrations`).Scan(&applied))
+	assert.Equal(t, 9, applied)
+}
+
+func TestMigrateSQL_RefusesDuplicateEmails(t *testing.T) {
+	db := openSQLite(t)
+	ctx := context.Background()
+	require.NoError(t, group.MigrateSQL(ctx, db, group.SQLite))
+
+	_, err := db.Exec(`DROP INDEX participants_group_email; DELETE FROM schema_migrations WHERE version = 9`)
+	require.NoError(t, err)
+	_, err = db.Exec(`INSERT INTO groups (id, name, owner, status) VALUES ('g1', 'Family', 'mari@gmail.com', 'open');
+		INSERT INTO participants (group_id, position, name, email) VALUES ('g1', 0, 'Mari', 'mari@gmail.com'), ('g1', 1, 'Mariana', 'MARI@gmail.com'), ('g1', 2, 'Ana', ''), ('g1', 3, 'Bia', '')`)
+	require.NoError(t, err)
+
+	err = group.MigrateSQL(ctx, db, group.SQLite)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "groups g1 have participants with the same email")
+
+	_, err = db.Exec(`DELETE FROM participants WHERE position = 1`)
+	require.NoError(t, err)
+	require.NoError(t, group.MigrateSQL(ctx, db, group.SQLite))
+}
+
+func TestSQLRepository_UniqueEmailIsParticipantDuplicate(t *testing.T) {
+	db := openSQLite(t)
+	ctx := context.Background()
+	require.NoError(t, group.MigrateSQL(ctx, db, group.SQLite))
+	repo := group.NewSQLRepository(db, group.SQLite)
+
+	created, err := repo.CreateGroup(ctx, &models.Group{Name: "Family", Owner: "mari@gmail.com", Status: models.GroupStatusOpen})
+	require.Nil(t, err)
+
+	// The service rejects these before, so only the index stops them here.
+	update := &models.GroupUpdate{Name: "Family", Owner: "mari@gmail.com", Participants: []models.Participant{
+		{Name: "Mari", Email: "mari@gmail.com"},
+		{Name: "Mariana", Email: "Mari@gmail.com"},
+	}}
+	_, err = repo.UpdateGroup(ctx, created.Id.Hex(), created.Version, update)
+	require.NotNil(t, err)
+	assert.Equal(t, customError.ParticipantDuplicate, err.Kind())
+}
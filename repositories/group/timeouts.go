@@ -0,0 +1,19 @@
+package group
+
+import (
+	"context"
+
+	"service-secret-santa/config"
+)
+
+// readContext bounds a read by the configured deadline, on top of whatever
+// deadline or cancellation the request already carries.
+func readContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(ctx, config.Cfg.StorageReadTimeout)
+}
+
+// writeContext bounds a write, including the lookups that explain a write
+// that matched nothing.
+func writeContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(ctx, config.Cfg.StorageWriteTimeout)
+}
@@ -2,40 +2,209 @@ package di
 
 import (
 	"context"
+	"database/sql"
 	"log"
 	. "service-secret-santa/config"
 
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.opentelemetry.io/contrib/instrumentation/go.mongodb.org/mongo-driver/mongo/otelmongo"
 	"go.uber.org/dig"
 
 	groupHandler "service-secret-santa/handlers/group"
+	healthHandler "service-secret-santa/handlers/health"
 	groupRepository "service-secret-santa/repositories/group"
+	"service-secret-santa/resources/encryption"
+	"service-secret-santa/resources/health"
+	"service-secret-santa/resources/lifecycle"
+	"service-secret-santa/resources/mail"
+	adminRoute "service-secret-santa/routes/admin"
 	groupRoute "service-secret-santa/routes/group"
+	healthRoute "service-secret-santa/routes/health"
 	groupService "service-secret-santa/services/group"
 )
 
 var Container *dig.Container
 
-func InitializeDI(client *mongo.Client) {
+// InitializeDI builds the container. Only the client of the configured
+// storage is used: client for mongo, db for sqlite and postgres.
+func InitializeDI(client *mongo.Client, db *sql.DB, manager *lifecycle.Manager) {
 	Container = dig.New()
 
-	Container.Provide(func() *mongo.Client {
+	provide(func() *lifecycle.Manager {
+		return manager
+	})
+
+	provide(health.NewLifecycleCheck, dig.Group(health.ReadinessGroup))
+	provide(healthHandler.NewHealthHandler)
+
+	switch Cfg.Storage {
+	case StorageMemory:
+		provide(groupRepository.NewMemoryRepository)
+	case StorageSQLite, StoragePostgres:
+		provideSQL(db)
+	default:
+		provideMongo(client)
+	}
+	decorate(groupRepository.NewInstrumentedRepository)
+	subjectKey, err := encryption.IndexKey(Cfg.BlindIndexKey)
+	if err != nil {
+		log.Fatalf("Failed to configure encryption: %v", err)
+	}
+	provide(func(repo groupRepository.Repository) groupService.Service {
+		return groupService.NewGroupService(repo, subjectKey)
+	})
+	// dig takes a single decorator per type, so the layers are stacked here:
+	// changes are audited, then counted, then traced.
+	decorate(func(svc groupService.Service, repo groupRepository.Repository) groupService.Service {
+		return groupService.NewTracedService(groupService.NewInstrumentedService(groupService.NewAuditedService(svc, repo)))
+	})
+	provide(groupHandler.NewGroupHandler)
+	provide(func() mail.Sender {
+		return mail.NewSender(Cfg.SMTPAddr, Cfg.SMTPUsername, Cfg.SMTPPassword, Cfg.MailFrom)
+	})
+
+	// MongoDB expires trashed groups through TTL indexes; the other
+	// storages are purged by a background routine. Each routine reports its
+	// runs to the readiness probe.
+	if Cfg.Storage != StorageMongo {
+		if err := Container.Invoke(func(repo groupRepository.Repository, manager *lifecycle.Manager) {
+			purger := groupService.NewTrashPurger(repo, Cfg.TrashRetention, Cfg.TrashPurgeInterval)
+			manager.Append(purger.Component())
+			provide(purger.Check, dig.Group(health.ReadinessGroup))
+		}); err != nil {
+			panic(err)
+		}
+	}
+
+	// Participants and matches under an older master key, or written before
+	// encryption was configured, are encrypted again in the background.
+	if Cfg.Storage == StorageMongo {
+		if err := Container.Invoke(func(client *mongo.Client, keyring *encryption.Keyring, manager *lifecycle.Manager) {
+			if keyring.Enabled() {
+				job := groupRepository.NewReencryptJob(client, keyring, Cfg.ReencryptInterval)
+				manager.Append(job.Component())
+				provide(job.Check, dig.Group(health.ReadinessGroup))
+			}
+		}); err != nil {
+			panic(err)
+		}
+	}
+
+	// Participant data outlives its purpose; every storage anonymizes it
+	// through the same job.
+	if err := Container.Invoke(func(repo groupRepository.Repository, sender mail.Sender, manager *lifecycle.Manager) {
+		job := groupService.NewRetentionJob(repo, sender, Cfg.DefaultLocale, Cfg.PIIRetention, Cfg.PIIRetentionWarning, Cfg.PIIRetentionInterval)
+		manager.Append(job.Component())
+		provide(job.Check, dig.Group(health.ReadinessGroup))
+	}); err != nil {
+		panic(err)
+	}
+}
+
+// provide registers constructor in the container and stops the startup when
+// dig rejects it.
+func provide(constructor interface{}, opts ...dig.ProvideOption) {
+	if err := Container.Provide(constructor, opts...); err != nil {
+		log.Fatalf("Failed to provide %T: %v", constructor, err)
+	}
+}
+
+// decorate registers decorator in the container and stops the startup when
+// dig rejects it, e.g. for a second decorator of the same type.
+func decorate(decorator interface{}) {
+	if err := Container.Decorate(decorator); err != nil {
+		log.Fatalf("Failed to decorate with %T: %v", decorator, err)
+	}
+}
+
+// provideMongo migrates the database, sets the trash retention and registers
+// the client, its readiness check, the encryption keyring and the Mongo
+// repository.
+func provideMongo(client *mongo.Client) {
+	provide(func() *mongo.Client {
 		return client
 	})
 
-	Container.Provide(groupRepository.NewGroupRepository)
-	Container.Provide(groupService.NewGroupService)
-	Container.Provide(groupHandler.NewGroupHandler)
+	if _, err := groupRepository.MigrateMongo(context.Background(), client); err != nil {
+		log.Fatalf("Failed to migrate database: %v", err)
+	}
+	if err := groupRepository.ConfigureTrashRetention(context.Background(), client, Cfg.TrashRetention); err != nil {
+		log.Fatalf("Failed to configure trash retention: %v", err)
+	}
+
+	keyring, err := encryption.NewKeyring(Cfg.EncryptionKeys, Cfg.BlindIndexKey)
+	if err != nil {
+		log.Fatalf("Failed to configure encryption: %v", err)
+	}
+	provide(func() *encryption.Keyring {
+		return keyring
+	})
+
+	provide(health.NewMongoCheck, dig.Group(health.ReadinessGroup))
+	provide(groupRepository.NewGroupRepository)
+}
+
+// provideSQL migrates the database and registers it, its readiness check and
+// the SQL repository.
+func provideSQL(db *sql.DB) {
+	dialect := groupRepository.Dialect(Cfg.Storage)
+	if err := groupRepository.MigrateSQL(context.Background(), db, dialect); err != nil {
+		log.Fatalf("Failed to migrate database: %v", err)
+	}
+
+	provide(func() *sql.DB {
+		return db
+	})
+	provide(health.NewSQLCheck, dig.Group(health.ReadinessGroup))
+	provide(func(db *sql.DB) groupRepository.Repository {
+		return groupRepository.NewSQLRepository(db, dialect)
+	})
 }
 
 func Invoke(defaultGroup *gin.RouterGroup) {
+	if errHealthRoute := Container.Invoke(func(handler healthHandler.Handler) {
+		healthRoute.Routes(defaultGroup, handler)
+	}); errHealthRoute != nil {
+		panic(errHealthRoute)
+	}
+
 	if errGroupRoute := Container.Invoke(func(handler groupHandler.Handler) {
 		groupRoute.Routes(defaultGroup, handler)
 	}); errGroupRoute != nil {
 		panic(errGroupRoute)
 	}
+
+	if errAdminRoute := Container.Invoke(func(handler groupHandler.Handler) {
+		adminRoute.Routes(defaultGroup, handler)
+	}); errAdminRoute != nil {
+		panic(errAdminRoute)
+	}
+}
+
+// InitializeSQL opens the SQLite file or PostgreSQL database of STORAGE.
+func InitializeSQL() *sql.DB {
+	dialect := groupRepository.Dialect(Cfg.Storage)
+
+	dsn := Cfg.PostgresURL
+	if dialect == groupRepository.SQLite {
+		// SQLite has a single writer; one connection keeps the transactions
+		// of the repository from failing with SQLITE_BUSY.
+		dsn = "file:" + Cfg.SQLitePath + "?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)"
+	} else if dsn == "" {
+		log.Fatal("You must set the 'POSTGRES_URL' environment variable to use STORAGE=postgres")
+	}
+
+	db, err := sql.Open(dialect.DriverName(), dsn)
+	if err != nil {
+		panic(err)
+	}
+	if dialect == groupRepository.SQLite {
+		db.SetMaxOpenConns(1)
+	}
+
+	return db
 }
 
 func InitializeMongoClient() *mongo.Client {
@@ -44,7 +213,9 @@ func InitializeMongoClient() *mongo.Client {
 		log.Fatal("You must set your 'MONGO_URI' environment variable. See\n\t https://www.mongodb.com/docs/drivers/go/current/usage-examples/#environment-variable")
 	}
 
-	client, err := mongo.Connect(context.TODO(), options.Client().ApplyURI(uri))
+	// The monitor opens a span per command. Commands themselves are not
+	// recorded, since their filters and documents carry e-mails.
+	client, err := mongo.Connect(context.TODO(), options.Client().ApplyURI(uri).SetMonitor(otelmongo.NewMonitor()))
 	if err != nil {
 		panic(err)
 	}
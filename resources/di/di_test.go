@@ -0,0 +1,25 @@
+package di
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"service-secret-santa/config"
+	"service-secret-santa/resources/lifecycle"
+	groupService "service-secret-santa/services/group"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInitializeDIDecoratesService(t *testing.T) {
+	config.LoadConfig()
+	config.Cfg.Storage = config.StorageMemory
+
+	InitializeDI(nil, nil, lifecycle.NewManager(time.Second, time.Second))
+
+	err := Container.Invoke(func(svc groupService.Service) {
+		assert.Equal(t, "*group.traced", fmt.Sprintf("%T", svc))
+	})
+	assert.NoError(t, err)
+}
@@ -0,0 +1,244 @@
+package encryption
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// keySize is the size of master and data keys: AES-256.
+const keySize = 32
+
+// Envelope is the data key of a document, wrapped by the master key KeyId.
+// Rotating master keys only wraps data keys again; the fields stay as they
+// were encrypted.
+type Envelope struct {
+	KeyId   string `bson:"keyId"`
+	DataKey []byte `bson:"dataKey"`
+}
+
+// Keyring encrypts document fields with AES-256-GCM under a data key of
+// their own, wrapped by the current master key, and computes blind indexes
+// to look up encrypted values. A nil Keyring stores values as they are.
+type Keyring struct {
+	current string
+	masters map[string]cipher.AEAD
+	index   []byte
+}
+
+// NewKeyring reads masterKeys, a comma-separated list of id:key with 32-byte
+// keys in base64, and indexKey, the base64 key of the blind index. The first
+// master key encrypts; the others only decrypt what they encrypted before.
+// Without master keys there is nothing to encrypt with and the keyring is
+// nil.
+func NewKeyring(masterKeys, indexKey string) (*Keyring, error) {
+	if strings.TrimSpace(masterKeys) == "" {
+		return nil, nil
+	}
+
+	k := &Keyring{masters: make(map[string]cipher.AEAD)}
+	for _, entry := range strings.Split(masterKeys, ",") {
+		id, encoded, found := strings.Cut(strings.TrimSpace(entry), ":")
+		if !found || id == "" {
+			return nil, fmt.Errorf("master key %q is not id:key", entry)
+		}
+		if _, taken := k.masters[id]; taken {
+			return nil, fmt.Errorf("master key %q is repeated", id)
+		}
+		key, err := decodeKey(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("master key %q: %w", id, err)
+		}
+		aead, err := newAEAD(key)
+		if err != nil {
+			return nil, err
+		}
+		k.masters[id] = aead
+		if k.current == "" {
+			k.current = id
+		}
+	}
+
+	index, err := decodeKey(indexKey)
+	if err != nil {
+		return nil, fmt.Errorf("blind index key: %w", err)
+	}
+	k.index = index
+
+	return k, nil
+}
+
+// IndexKey reads indexKey, the base64 key of the blind index, for the
+// digests kept outside the keyring. Without one it returns nil.
+func IndexKey(indexKey string) ([]byte, error) {
+	if strings.TrimSpace(indexKey) == "" {
+		return nil, nil
+	}
+	key, err := decodeKey(indexKey)
+	if err != nil {
+		return nil, fmt.Errorf("blind index key: %w", err)
+	}
+	return key, nil
+}
+
+func decodeKey(encoded string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return nil, errors.New("key is not base64")
+	}
+	if len(key) != keySize {
+		return nil, fmt.Errorf("key has %d bytes instead of %d", len(key), keySize)
+	}
+	return key, nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// Enabled reports whether values are encrypted.
+func (k *Keyring) Enabled() bool {
+	return k != nil
+}
+
+// CurrentKey is the ID of the master key that wraps new data keys.
+func (k *Keyring) CurrentKey() string {
+	if k == nil {
+		return ""
+	}
+	return k.current
+}
+
+// BlindIndex is what value is looked up by, ignoring case: an HMAC-SHA256
+// of it, or the value itself in lower case without encryption. An empty
+// value has an empty index.
+func (k *Keyring) BlindIndex(value string) string {
+	value = strings.ToLower(value)
+	if k == nil || value == "" {
+		return value
+	}
+
+	mac := hmac.New(sha256.New, k.index)
+	mac.Write([]byte(value))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Seal encrypts the fields of a document under a new data key. Each
+// ciphertext is bound to its position, so fields cannot be swapped.
+func (k *Keyring) Seal(fields ...string) (*Envelope, [][]byte, error) {
+	if k == nil {
+		return nil, nil, errors.New("encryption is not configured")
+	}
+
+	dataKey := make([]byte, keySize)
+	if _, err := rand.Read(dataKey); err != nil {
+		return nil, nil, err
+	}
+	envelope, err := k.wrap(dataKey)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	aead, err := newAEAD(dataKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	ciphertexts := make([][]byte, 0, len(fields))
+	for i, field := range fields {
+		sealed, err := seal(aead, []byte(field), position(i))
+		if err != nil {
+			return nil, nil, err
+		}
+		ciphertexts = append(ciphertexts, sealed)
+	}
+
+	return envelope, ciphertexts, nil
+}
+
+// Open decrypts the fields that Seal returned with envelope.
+func (k *Keyring) Open(envelope *Envelope, ciphertexts ...[]byte) ([]string, error) {
+	dataKey, err := k.unwrap(envelope)
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := newAEAD(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	fields := make([]string, 0, len(ciphertexts))
+	for i, ciphertext := range ciphertexts {
+		plaintext, err := open(aead, ciphertext, position(i))
+		if err != nil {
+			return nil, fmt.Errorf("field %d: %w", i, err)
+		}
+		fields = append(fields, string(plaintext))
+	}
+
+	return fields, nil
+}
+
+// Rewrap wraps the data key of envelope with the current master key.
+func (k *Keyring) Rewrap(envelope *Envelope) (*Envelope, error) {
+	dataKey, err := k.unwrap(envelope)
+	if err != nil {
+		return nil, err
+	}
+	return k.wrap(dataKey)
+}
+
+func (k *Keyring) wrap(dataKey []byte) (*Envelope, error) {
+	wrapped, err := seal(k.masters[k.current], dataKey, []byte(k.current))
+	if err != nil {
+		return nil, err
+	}
+	return &Envelope{KeyId: k.current, DataKey: wrapped}, nil
+}
+
+func (k *Keyring) unwrap(envelope *Envelope) ([]byte, error) {
+	if k == nil {
+		return nil, errors.New("encryption is not configured")
+	}
+	master, ok := k.masters[envelope.KeyId]
+	if !ok {
+		return nil, fmt.Errorf("unknown master key %q", envelope.KeyId)
+	}
+	dataKey, err := open(master, envelope.DataKey, []byte(envelope.KeyId))
+	if err != nil {
+		return nil, fmt.Errorf("data key: %w", err)
+	}
+	return dataKey, nil
+}
+
+func position(i int) []byte {
+	return []byte("field:" + strconv.Itoa(i))
+}
+
+// seal returns the nonce followed by the ciphertext.
+func seal(aead cipher.AEAD, plaintext, additional []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plaintext, additional), nil
+}
+
+func open(aead cipher.AEAD, sealed, additional []byte) ([]byte, error) {
+	if len(sealed) < aead.NonceSize() {
+		return nil, errors.New("ciphertext is too short")
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	return aead.Open(nil, nonce, ciphertext, additional)
+}
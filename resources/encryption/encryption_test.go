@@ -0,0 +1,102 @@
+package encryption
+
+import (
+	"bytes"
+	"encoding/base64"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testKey(fill byte) string {
+	return base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{fill}, keySize))
+}
+
+func TestNewKeyring(t *testing.T) {
+	keyring, err := NewKeyring("", "")
+	assert.NoError(t, err)
+	assert.False(t, keyring.Enabled())
+
+	keyring, err = NewKeyring("2025:"+testKey(1)+", 2024:"+testKey(2), testKey(9))
+	assert.NoError(t, err)
+	assert.True(t, keyring.Enabled())
+	assert.Equal(t, "2025", keyring.CurrentKey())
+
+	for name, keys := range map[string][2]string{
+		"missing id":        {testKey(1), testKey(9)},
+		"repeated id":       {"a:" + testKey(1) + ",a:" + testKey(2), testKey(9)},
+		"short key":         {"a:" + base64.StdEncoding.EncodeToString([]byte("short")), testKey(9)},
+		"not base64":        {"a:???", testKey(9)},
+		"missing index key": {"a:" + testKey(1), ""},
+	} {
+		_, err := NewKeyring(keys[0], keys[1])
+		assert.Error(t, err, name)
+	}
+}
+
+func TestSealAndOpen(t *testing.T) {
+	keyring, _ := NewKeyring("a:"+testKey(1), testKey(9))
+
+	envelope, ciphertexts, err := keyring.Seal("Ana", "Bia")
+	assert.NoError(t, err)
+	assert.Equal(t, "a", envelope.KeyId)
+	assert.NotContains(t, string(ciphertexts[0]), "Ana")
+
+	fields, err := keyring.Open(envelope, ciphertexts...)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Ana", "Bia"}, fields)
+
+	_, err = keyring.Open(envelope, ciphertexts[1], ciphertexts[0])
+	assert.Error(t, err, "swapped fields")
+
+	other, _ := NewKeyring("a:"+testKey(2), testKey(9))
+	_, err = other.Open(envelope, ciphertexts...)
+	assert.Error(t, err, "wrong master key")
+}
+
+func TestRewrap(t *testing.T) {
+	old, _ := NewKeyring("a:"+testKey(1), testKey(9))
+	envelope, ciphertexts, _ := old.Seal("ana@example.com")
+
+	rotated, _ := NewKeyring("b:"+testKey(2)+",a:"+testKey(1), testKey(9))
+	fields, err := rotated.Open(envelope, ciphertexts...)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"ana@example.com"}, fields)
+
+	rewrapped, err := rotated.Rewrap(envelope)
+	assert.NoError(t, err)
+	assert.Equal(t, "b", rewrapped.KeyId)
+
+	retired, _ := NewKeyring("b:"+testKey(2), testKey(9))
+	fields, err = retired.Open(rewrapped, ciphertexts...)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"ana@example.com"}, fields)
+}
+
+func TestBlindIndex(t *testing.T) {
+	var plaintext *Keyring
+	assert.Equal(t, "ana@example.com", plaintext.BlindIndex("Ana@Example.com"))
+
+	keyring, _ := NewKeyring("a:"+testKey(1), testKey(9))
+	index := keyring.BlindIndex("Ana@Example.com")
+	assert.Len(t, index, 64)
+	assert.Equal(t, index, keyring.BlindIndex("ana@example.com"))
+	assert.NotEqual(t, index, keyring.BlindIndex("bia@example.com"))
+	assert.Equal(t, "", keyring.BlindIndex(""))
+
+	other, _ := NewKeyring("a:"+testKey(1), testKey(8))
+	assert.NotEqual(t, index, other.BlindIndex("ana@example.com"))
+}
+
+func TestIndexKey(t *testing.T) {
+	key, err := IndexKey("")
+	assert.NoError(t, err)
+	assert.Nil(t, key)
+
+	key, err = IndexKey(testKey(9))
+	assert.NoError(t, err)
+	assert.Equal(t, bytes.Repeat([]byte{9}, keySize), key)
+
+	_, err = IndexKey("???")
+	assert.Error(t, err)
+}
@@ -0,0 +1,136 @@
+package health
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+	"time"
+
+	"service-secret-santa/resources/lifecycle"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/dig"
+)
+
+// ReadinessGroup is the DI value group of the readiness checks. A subsystem
+// adds its own check by providing a Check into it:
+//
+//	Container.Provide(NewMyCheck, dig.Group(health.ReadinessGroup))
+const ReadinessGroup = "readiness_checks"
+
+const (
+	StatusUp       = "up"
+	StatusDown     = "down"
+	StatusReady    = "ready"
+	StatusNotReady = "not_ready"
+)
+
+// Check is a dependency the service needs to answer requests. Details, when
+// set, adds what the check knows besides up or down, such as when a
+// background job last ran.
+type Check struct {
+	Name    string
+	Run     func(ctx context.Context) error
+	Details func() map[string]interface{}
+}
+
+// Checks collects every check provided into the readiness group.
+type Checks struct {
+	dig.In
+
+	Readiness []Check `group:"readiness_checks"`
+}
+
+// Result is the outcome of one check.
+type Result struct {
+	Status    string  `json:"status" example:"up" enums:"up,down"`
+	LatencyMs float64 `json:"latencyMs" example:"1.3"`
+	Error     string  `json:"error,omitempty"`
+
+	Details map[string]interface{} `json:"details,omitempty" swaggertype:"object"`
+}
+
+// Liveness is the answer of the liveness probe.
+type Liveness struct {
+	Status string `json:"status" example:"up"`
+}
+
+// Report is the outcome of all checks; the service is ready when all are up.
+type Report struct {
+	Status string            `json:"status" example:"ready" enums:"ready,not_ready"`
+	Checks map[string]Result `json:"checks"`
+}
+
+// Evaluate runs the checks concurrently, each bounded by timeout.
+func Evaluate(ctx context.Context, checks []Check, timeout time.Duration) Report {
+	report := Report{Status: StatusReady, Checks: make(map[string]Result, len(checks))}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, check := range checks {
+		wg.Add(1)
+		go func(check Check) {
+			defer wg.Done()
+			result := run(ctx, check, timeout)
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Checks[check.Name] = result
+			if result.Status != StatusUp {
+				report.Status = StatusNotReady
+			}
+		}(check)
+	}
+	wg.Wait()
+
+	return report
+}
+
+func run(ctx context.Context, check Check, timeout time.Duration) Result {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := check.Run(ctx)
+	result := Result{Status: StatusUp, LatencyMs: float64(time.Since(start).Microseconds()) / 1000}
+	if err != nil {
+		result.Status = StatusDown
+		result.Error = err.Error()
+	}
+	if check.Details != nil {
+		result.Details = check.Details()
+	}
+	return result
+}
+
+// NewMongoCheck pings the primary.
+func NewMongoCheck(client *mongo.Client) Check {
+	return Check{
+		Name: "mongo",
+		Run: func(ctx context.Context) error {
+			return client.Ping(ctx, nil)
+		},
+	}
+}
+
+// NewSQLCheck pings the relational database.
+func NewSQLCheck(db *sql.DB) Check {
+	return Check{
+		Name: "database",
+		Run:  db.PingContext,
+	}
+}
+
+// NewLifecycleCheck is down while the service is starting or shutting down.
+func NewLifecycleCheck(manager *lifecycle.Manager) Check {
+	return Check{
+		Name: "lifecycle",
+		Run: func(ctx context.Context) error {
+			if !manager.Ready() {
+				return errors.New("starting or shutting down")
+			}
+			return nil
+		},
+	}
+}
@@ -0,0 +1,103 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"service-secret-santa/resources/lifecycle"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/dig"
+)
+
+func staticCheck(name string, err error) Check {
+	return Check{Name: name, Run: func(ctx context.Context) error { return err }}
+}
+
+func TestEvaluate(t *testing.T) {
+	report := Evaluate(context.Background(), []Check{staticCheck("mongo", nil), staticCheck("outbox", nil)}, time.Second)
+	assert.Equal(t, StatusReady, report.Status)
+	assert.Equal(t, StatusUp, report.Checks["mongo"].Status)
+	assert.Equal(t, StatusUp, report.Checks["outbox"].Status)
+
+	report = Evaluate(context.Background(), []Check{staticCheck("mongo", errors.New("connection refused")), staticCheck("outbox", nil)}, time.Second)
+	assert.Equal(t, StatusNotReady, report.Status)
+	assert.Equal(t, Result{Status: StatusDown, LatencyMs: report.Checks["mongo"].LatencyMs, Error: "connection refused"}, report.Checks["mongo"])
+	assert.Equal(t, StatusUp, report.Checks["outbox"].Status)
+}
+
+func TestEvaluateTimesOutSlowChecks(t *testing.T) {
+	slow := Check{Name: "mongo", Run: func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}}
+
+	report := Evaluate(context.Background(), []Check{slow}, 10*time.Millisecond)
+	assert.Equal(t, StatusNotReady, report.Status)
+	assert.Equal(t, context.DeadlineExceeded.Error(), report.Checks["mongo"].Error)
+	assert.GreaterOrEqual(t, report.Checks["mongo"].LatencyMs, float64(10))
+}
+
+func TestLifecycleCheck(t *testing.T) {
+	manager := lifecycle.NewManager(0, time.Second)
+	check := NewLifecycleCheck(manager)
+	assert.Error(t, check.Run(context.Background()))
+
+	assert.Nil(t, manager.Start(context.Background()))
+	assert.Nil(t, check.Run(context.Background()))
+
+	assert.Nil(t, manager.Shutdown())
+	assert.Error(t, check.Run(context.Background()))
+}
+
+func TestChecksAreCollectedFromTheContainer(t *testing.T) {
+	container := dig.New()
+	assert.Nil(t, container.Provide(func() Check { return staticCheck("mongo", nil) }, dig.Group(ReadinessGroup)))
+	assert.Nil(t, container.Provide(func() Check { return staticCheck("scheduler", nil) }, dig.Group(ReadinessGroup)))
+
+	var names []string
+	assert.Nil(t, container.Invoke(func(checks Checks) {
+		for _, check := range checks.Readiness {
+			names = append(names, check.Name)
+		}
+	}))
+	assert.ElementsMatch(t, []string{"mongo", "scheduler"}, names)
+}
+
+func TestJobStatus(t *testing.T) {
+	runs := make(chan struct{}, 1)
+	status := NewJobStatus("trash-purge", time.Hour)
+	component := status.Component(func(ctx context.Context) error {
+		defer func() { runs <- struct{}{} }()
+		return errors.New("database is down")
+	})
+	check := status.Check()
+	assert.Equal(t, "trash-purge", check.Name)
+
+	assert.EqualError(t, check.Run(context.Background()), "not scheduled")
+	assert.Equal(t, map[string]interface{}{"running": false}, check.Details())
+
+	assert.Nil(t, component.Start(context.Background()))
+	<-runs
+	assert.Eventually(t, func() bool { return check.Details()["lastError"] == "database is down" }, time.Second, time.Millisecond)
+
+	// A failed run is reported, but the job is still scheduled.
+	assert.Nil(t, check.Run(context.Background()))
+	details := check.Details()
+	assert.Equal(t, false, details["running"])
+	assert.NotEmpty(t, details["lastRun"])
+
+	assert.Nil(t, component.Stop(context.Background()))
+	assert.EqualError(t, check.Run(context.Background()), "not scheduled")
+}
+
+func TestEvaluateReportsDetails(t *testing.T) {
+	check := Check{Name: "trash-purge", Run: func(ctx context.Context) error { return nil }, Details: func() map[string]interface{} {
+		return map[string]interface{}{"running": true}
+	}}
+
+	report := Evaluate(context.Background(), []Check{check}, time.Second)
+	assert.Equal(t, map[string]interface{}{"running": true}, report.Checks["trash-purge"].Details)
+}
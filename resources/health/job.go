@@ -0,0 +1,104 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"service-secret-santa/resources/lifecycle"
+)
+
+// JobStatus follows a background job that runs every interval, for the
+// readiness report: whether it is scheduled, whether a run is in progress, and
+// when the last run started and how it ended.
+//
+// The check is down only while the job is not scheduled. A failed run is
+// reported in the details but leaves the service ready: the next run tries
+// again, the storage checks already cover a database that is down, and every
+// instance runs the same jobs, so a failure would take them all out at once.
+type JobStatus struct {
+	name     string
+	interval time.Duration
+
+	mu        sync.Mutex
+	scheduled bool
+	running   bool
+	lastRun   time.Time
+	lastErr   error
+}
+
+func NewJobStatus(name string, interval time.Duration) *JobStatus {
+	return &JobStatus{name: name, interval: interval}
+}
+
+// Component runs fn once on start and then every interval until stopped, like
+// lifecycle.Every, recording each run.
+func (s *JobStatus) Component(fn func(ctx context.Context) error) lifecycle.Component {
+	component := lifecycle.Every(s.name, s.interval, func(ctx context.Context) {
+		started := s.begin()
+		s.end(started, fn(ctx))
+	})
+
+	start, stop := component.Start, component.Stop
+	component.Start = func(ctx context.Context) error {
+		s.setScheduled(true)
+		return start(ctx)
+	}
+	component.Stop = func(ctx context.Context) error {
+		s.setScheduled(false)
+		return stop(ctx)
+	}
+	return component
+}
+
+// Check reports the job under its name.
+func (s *JobStatus) Check() Check {
+	return Check{
+		Name: s.name,
+		Run: func(ctx context.Context) error {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			if !s.scheduled {
+				return errors.New("not scheduled")
+			}
+			return nil
+		},
+		Details: s.details,
+	}
+}
+
+func (s *JobStatus) begin() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.running = true
+	return time.Now()
+}
+
+func (s *JobStatus) end(started time.Time, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.running = false
+	s.lastRun = started
+	s.lastErr = err
+}
+
+func (s *JobStatus) setScheduled(scheduled bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.scheduled = scheduled
+}
+
+func (s *JobStatus) details() map[string]interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	details := map[string]interface{}{"running": s.running}
+	if !s.lastRun.IsZero() {
+		details["lastRun"] = s.lastRun.UTC().Format(time.RFC3339)
+	}
+	if s.lastErr != nil {
+		details["lastError"] = s.lastErr.Error()
+	}
+	return details
+}
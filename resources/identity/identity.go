@@ -0,0 +1,55 @@
+// Package identity carries who made a request. The service has no accounts:
+// the gateway in front of it authenticates users and forwards their email in
+// the X-User-Email header, proving itself with the shared token of the
+// X-Gateway-Token header.
+package identity
+
+import (
+	"context"
+	"crypto/subtle"
+	"regexp"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	UserHeader  = "X-User-Email"
+	TokenHeader = "X-Gateway-Token"
+)
+
+// validUser bounds what is accepted as an email, so the header cannot put
+// arbitrary text in the audit log.
+var validUser = regexp.MustCompile(`^[^\s@]{1,64}@[^\s@]{1,189}$`)
+
+// Middleware puts the user of the X-User-Email header, in lower case, in the
+// request context when X-Gateway-Token carries token. A missing or malformed
+// header, a wrong token or no token configured leave the request without a
+// user, so nobody can claim an email by sending the header themselves.
+func Middleware(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if fromGateway(c.GetHeader(TokenHeader), token) {
+			if user := strings.ToLower(strings.TrimSpace(c.GetHeader(UserHeader))); validUser.MatchString(user) {
+				c.Request = c.Request.WithContext(WithUser(c.Request.Context(), user))
+			}
+		}
+		c.Next()
+	}
+}
+
+func fromGateway(sent, token string) bool {
+	return token != "" && subtle.ConstantTimeCompare([]byte(sent), []byte(token)) == 1
+}
+
+type userKey struct{}
+
+// WithUser returns a copy of ctx carrying the email of the user.
+func WithUser(ctx context.Context, email string) context.Context {
+	return context.WithValue(ctx, userKey{}, email)
+}
+
+// User returns the email of the user carried by ctx, or "".
+func User(ctx context.Context) string {
+	user, _ := ctx.Value(userKey{}).(string)
+	return user
+}
@@ -0,0 +1,52 @@
+package identity
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMiddleware(t *testing.T) {
+	var seen string
+	serve := func(token string, req *http.Request) {
+		router := gin.New()
+		router.Use(Middleware(token))
+		router.GET("/", func(c *gin.Context) { seen = User(c.Request.Context()) })
+		router.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	tests := []struct {
+		name       string
+		configured string
+		token      string
+		header     string
+		want       string
+	}{
+		{name: "keeps the user in lower case", configured: "secret", token: "secret", header: " Ana@Example.com ", want: "ana@example.com"},
+		{name: "missing header", configured: "secret", token: "secret"},
+		{name: "not an email", configured: "secret", token: "secret", header: "ana"},
+		{name: "injected text", configured: "secret", token: "secret", header: "ana@example.com\nadmin"},
+		{name: "missing token", configured: "secret", header: "ana@example.com"},
+		{name: "wrong token", configured: "secret", token: "guess", header: "ana@example.com"},
+		{name: "no token configured", header: "ana@example.com"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			seen = "unset"
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.token != "" {
+				req.Header.Set(TokenHeader, tt.token)
+			}
+			if tt.header != "" {
+				req.Header[UserHeader] = []string{tt.header}
+			}
+			serve(tt.configured, req)
+
+			assert.Equal(t, tt.want, seen)
+		})
+	}
+}
@@ -0,0 +1,188 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// Component is a part of the application with a start and a stop. Either
+// function may be nil.
+type Component struct {
+	Name  string
+	Start func(ctx context.Context) error
+	Stop  func(ctx context.Context) error
+}
+
+// Manager starts components in the order they were appended and stops them in
+// reverse, so the HTTP server, appended last, stops first and the database,
+// appended first, stops last.
+type Manager struct {
+	components []Component
+	started    []Component
+	ready      atomic.Bool
+	failures   chan error
+
+	readinessDelay time.Duration
+	drainTimeout   time.Duration
+}
+
+// NewManager returns a manager that, on shutdown, reports not ready for
+// readinessDelay before stopping anything, then gives the components
+// drainTimeout to stop.
+func NewManager(readinessDelay, drainTimeout time.Duration) *Manager {
+	return &Manager{
+		failures:       make(chan error, 1),
+		readinessDelay: readinessDelay,
+		drainTimeout:   drainTimeout,
+	}
+}
+
+// Append adds a component after the ones already appended.
+func (m *Manager) Append(component Component) {
+	m.components = append(m.components, component)
+}
+
+// Ready reports whether every component started and shutdown has not begun.
+func (m *Manager) Ready() bool {
+	return m.ready.Load()
+}
+
+// Fail reports that a running component died, which shuts the application
+// down. Only the first failure is kept.
+func (m *Manager) Fail(err error) {
+	select {
+	case m.failures <- err:
+	default:
+	}
+}
+
+// Start starts the components in order. When one fails, the ones already
+// started are stopped again.
+func (m *Manager) Start(ctx context.Context) error {
+	for _, component := range m.components {
+		if component.Start != nil {
+			if err := component.Start(ctx); err != nil {
+				stopErr := m.stop(ctx)
+				return errors.Join(fmt.Errorf("starting %s: %w", component.Name, err), stopErr)
+			}
+		}
+		slog.Info("Started", "component", component.Name)
+		m.started = append(m.started, component)
+	}
+
+	m.ready.Store(true)
+	return nil
+}
+
+// Run starts the components and keeps them running until ctx is done or a
+// component fails, then shuts down.
+func (m *Manager) Run(ctx context.Context) error {
+	if err := m.Start(ctx); err != nil {
+		return err
+	}
+
+	var runErr error
+	select {
+	case <-ctx.Done():
+		slog.Info("Shutting down")
+	case runErr = <-m.failures:
+		slog.Error("Shutting down after failure", "error", runErr)
+	}
+
+	return errors.Join(runErr, m.Shutdown())
+}
+
+// Shutdown flips readiness so load balancers stop routing new requests, waits
+// for them to notice, and then stops the components within the drain timeout.
+func (m *Manager) Shutdown() error {
+	m.ready.Store(false)
+	time.Sleep(m.readinessDelay)
+
+	ctx, cancel := context.WithTimeout(context.Background(), m.drainTimeout)
+	defer cancel()
+
+	return m.stop(ctx)
+}
+
+func (m *Manager) stop(ctx context.Context) error {
+	var errs []error
+	for i := len(m.started) - 1; i >= 0; i-- {
+		component := m.started[i]
+		if component.Stop != nil {
+			if err := component.Stop(ctx); err != nil {
+				errs = append(errs, fmt.Errorf("stopping %s: %w", component.Name, err))
+				continue
+			}
+		}
+		slog.Info("Stopped", "component", component.Name)
+	}
+	m.started = nil
+
+	return errors.Join(errs...)
+}
+
+// HTTPServer serves srv between start and stop. Stopping closes the listener
+// and waits for in-flight requests, such as a running draw, to finish.
+func (m *Manager) HTTPServer(srv *http.Server) Component {
+	return Component{
+		Name: "http",
+		Start: func(ctx context.Context) error {
+			listener, err := net.Listen("tcp", srv.Addr)
+			if err != nil {
+				return err
+			}
+			go func() {
+				if err := srv.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+					m.Fail(fmt.Errorf("http: %w", err))
+				}
+			}()
+			return nil
+		},
+		Stop: srv.Shutdown,
+	}
+}
+
+// Every runs fn once on start and then every interval until stopped.
+// Stopping waits for a run in progress to finish.
+func Every(name string, interval time.Duration, fn func(ctx context.Context)) Component {
+	var stop, done chan struct{}
+
+	return Component{
+		Name: name,
+		Start: func(context.Context) error {
+			stop, done = make(chan struct{}), make(chan struct{})
+			go func() {
+				defer close(done)
+
+				ticker := time.NewTicker(interval)
+				defer ticker.Stop()
+
+				for {
+					fn(context.Background())
+
+					select {
+					case <-stop:
+						return
+					case <-ticker.C:
+					}
+				}
+			}()
+			return nil
+		},
+		Stop: func(ctx context.Context) error {
+			close(stop)
+			select {
+			case <-done:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		},
+	}
+}
@@ -0,0 +1,159 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func recorder(events *[]string, name string, startErr error) Component {
+	return Component{
+		Name: name,
+		Start: func(ctx context.Context) error {
+			*events = append(*events, "start "+name)
+			return startErr
+		},
+		Stop: func(ctx context.Context) error {
+			*events = append(*events, "stop "+name)
+			return nil
+		},
+	}
+}
+
+func TestManagerStartsInOrderAndStopsInReverse(t *testing.T) {
+	var events []string
+	m := NewManager(0, time.Second)
+	m.Append(recorder(&events, "mongo", nil))
+	m.Append(recorder(&events, "scheduler", nil))
+	m.Append(recorder(&events, "http", nil))
+
+	assert.False(t, m.Ready())
+	assert.Nil(t, m.Start(context.Background()))
+	assert.True(t, m.Ready())
+
+	assert.Nil(t, m.Shutdown())
+	assert.False(t, m.Ready())
+	assert.Equal(t, []string{
+		"start mongo", "start scheduler", "start http",
+		"stop http", "stop scheduler", "stop mongo",
+	}, events)
+}
+
+func TestManagerRollsBackFailedStart(t *testing.T) {
+	var events []string
+	m := NewManager(0, time.Second)
+	m.Append(recorder(&events, "mongo", nil))
+	m.Append(recorder(&events, "scheduler", errors.New("boom")))
+	m.Append(recorder(&events, "http", nil))
+
+	err := m.Start(context.Background())
+	assert.ErrorContains(t, err, "starting scheduler: boom")
+	assert.False(t, m.Ready())
+	assert.Equal(t, []string{"start mongo", "start scheduler", "stop mongo"}, events)
+}
+
+func TestManagerIsNotReadyWhileDraining(t *testing.T) {
+	m := NewManager(0, time.Second)
+
+	var readyWhileStopping bool
+	m.Append(Component{Name: "http", Stop: func(ctx context.Context) error {
+		readyWhileStopping = m.Ready()
+		return nil
+	}})
+
+	assert.Nil(t, m.Start(context.Background()))
+	assert.Nil(t, m.Shutdown())
+	assert.False(t, readyWhileStopping)
+}
+
+func TestManagerRunStopsOnFailure(t *testing.T) {
+	var events []string
+	m := NewManager(0, time.Second)
+	m.Append(recorder(&events, "mongo", nil))
+
+	m.Fail(errors.New("listener died"))
+	err := m.Run(context.Background())
+	assert.ErrorContains(t, err, "listener died")
+	assert.Equal(t, []string{"start mongo", "stop mongo"}, events)
+}
+
+func TestHTTPServerDrainsInFlightRequests(t *testing.T) {
+	started := make(chan struct{})
+	mux := http.NewServeMux()
+	mux.HandleFunc("/draw", func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		time.Sleep(100 * time.Millisecond)
+		_, _ = io.WriteString(w, "drawn")
+	})
+
+	addr := freeAddr(t)
+	m := NewManager(0, 5*time.Second)
+	srv := &http.Server{Addr: addr, Handler: mux}
+	component := m.HTTPServer(srv)
+	assert.Nil(t, component.Start(context.Background()))
+
+	body := make(chan string)
+	go func() {
+		resp, err := http.Get("http://" + addr + "/draw")
+		if err != nil {
+			body <- err.Error()
+			return
+		}
+		defer resp.Body.Close()
+		b, _ := io.ReadAll(resp.Body)
+		body <- string(b)
+	}()
+
+	<-started
+	assert.Nil(t, component.Stop(context.Background()))
+	assert.Equal(t, "drawn", <-body)
+}
+
+func freeAddr(t *testing.T) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	return listener.Addr().String()
+}
+
+func TestEveryRunsUntilStopped(t *testing.T) {
+	runs := make(chan struct{}, 10)
+	component := Every("job", 5*time.Millisecond, func(ctx context.Context) {
+		runs <- struct{}{}
+	})
+
+	assert.Nil(t, component.Start(context.Background()))
+	<-runs
+	<-runs
+	assert.Nil(t, component.Stop(context.Background()))
+
+	// No run starts once Stop returned.
+	count := len(runs)
+	time.Sleep(20 * time.Millisecond)
+	assert.Equal(t, count, len(runs))
+}
+
+func TestEveryStopGivesUpOnSlowRun(t *testing.T) {
+	release := make(chan struct{})
+	started := make(chan struct{})
+	component := Every("job", time.Hour, func(ctx context.Context) {
+		close(started)
+		<-release
+	})
+	defer close(release)
+
+	assert.Nil(t, component.Start(context.Background()))
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, component.Stop(ctx), context.DeadlineExceeded)
+}
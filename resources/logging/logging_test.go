@@ -0,0 +1,120 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"service-secret-santa/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func newTestLogger(t *testing.T) (*slog.Logger, *bytes.Buffer) {
+	var buf bytes.Buffer
+	logger, err := New(&buf, "debug", "json")
+	assert.NoError(t, err)
+	return logger, &buf
+}
+
+func TestNewRejectsInvalidConfig(t *testing.T) {
+	_, err := New(&bytes.Buffer{}, "loud", "json")
+	assert.Error(t, err)
+
+	_, err = New(&bytes.Buffer{}, "info", "xml")
+	assert.Error(t, err)
+}
+
+func TestRedaction(t *testing.T) {
+	logger, buf := newTestLogger(t)
+
+	logger.Info("Invite sent to mari@gmail.com",
+		"email", "mari@gmail.com",
+		"note", "contact joao@example.com",
+		"error", errors.New(`duplicate key { email: "ana@example.com" }`),
+		"group", models.CreateMockGroup(),
+	)
+
+	out := buf.String()
+	assert.NotContains(t, out, "@gmail.com")
+	assert.NotContains(t, out, "@example.com")
+	assert.NotContains(t, out, "joao")
+	assert.NotContains(t, out, "Mari")
+	assert.Contains(t, out, `"participants_count":1`)
+	assert.Contains(t, out, Redacted)
+}
+
+func TestRequestAndTraceIDsFromContext(t *testing.T) {
+	logger, buf := newTestLogger(t)
+
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{TraceID: traceID, SpanID: spanID}))
+
+	logger.InfoContext(WithRequestID(ctx, "abc-123"), "Group loaded")
+
+	assert.Contains(t, buf.String(), `"request_id":"abc-123"`)
+	assert.Contains(t, buf.String(), `"trace_id":"4bf92f3577b34da6a3ce929d0e0e4736"`)
+}
+
+func TestRequestIDMiddleware(t *testing.T) {
+	router := gin.New()
+	router.Use(RequestIDMiddleware())
+	var seen string
+	router.GET("/", func(c *gin.Context) { seen = RequestID(c.Request.Context()) })
+
+	tests := []struct {
+		name   string
+		header string
+		keep   bool
+	}{
+		{name: "keeps a valid id", header: "req-42", keep: true},
+		{name: "generates a missing id"},
+		{name: "replaces an invalid id", header: "bad id\nwith newline"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.header != "" {
+				req.Header.Set(RequestIDHeader, tt.header)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			id := w.Header().Get(RequestIDHeader)
+			assert.Equal(t, id, seen)
+			if tt.keep {
+				assert.Equal(t, tt.header, id)
+			} else {
+				assert.Len(t, id, 32)
+			}
+		})
+	}
+}
+
+func TestAccessLogOmitsQuery(t *testing.T) {
+	logger, buf := newTestLogger(t)
+	previous := slog.Default()
+	slog.SetDefault(logger)
+	defer slog.SetDefault(previous)
+
+	router := gin.New()
+	router.Use(RequestIDMiddleware(), AccessLog())
+	router.GET("/group/search", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	req := httptest.NewRequest(http.MethodGet, "/group/search?q=mari@gmail.com", nil)
+	req.Header.Set(RequestIDHeader, "req-7")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	out := buf.String()
+	assert.Contains(t, out, `"route":"/group/search"`)
+	assert.Contains(t, out, `"request_id":"req-7"`)
+	assert.NotContains(t, out, "mari")
+}
@@ -0,0 +1,89 @@
+package mail
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"mime"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// Message is a plain text email.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Sender delivers emails.
+type Sender interface {
+	Send(ctx context.Context, message Message) error
+}
+
+// NewSender sends through the SMTP server at addr (host:port), or only logs
+// the messages when addr is empty.
+func NewSender(addr, username, password, from string) Sender {
+	if addr == "" {
+		return logSender{}
+	}
+	return &smtpSender{addr: addr, username: username, password: password, from: from}
+}
+
+type smtpSender struct {
+	addr     string
+	username string
+	password string
+	from     string
+}
+
+// Send delivers message with net/smtp, which upgrades to TLS when the server
+// offers STARTTLS. It authenticates only when a username is configured.
+func (s *smtpSender) Send(ctx context.Context, message Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if strings.ContainsAny(message.To, "\r\n") {
+		return errors.New("invalid recipient: it contains a line break")
+	}
+
+	var auth smtp.Auth
+	if s.username != "" {
+		host, _, err := net.SplitHostPort(s.addr)
+		if err != nil {
+			return fmt.Errorf("invalid SMTP address %q: %w", s.addr, err)
+		}
+		auth = smtp.PlainAuth("", s.username, s.password, host)
+	}
+
+	return smtp.SendMail(s.addr, auth, s.from, []string{message.To}, compose(s.from, message, time.Now()))
+}
+
+// compose writes message as an RFC 5322 email, with the subject encoded for
+// non-ASCII characters.
+func compose(from string, message Message, date time.Time) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", message.To)
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", message.Subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", date.Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: 8bit\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(strings.ReplaceAll(message.Body, "\r\n", "\n"), "\n", "\r\n"))
+	return b.Bytes()
+}
+
+// logSender stands in for SMTP in development. It logs that a message would
+// have been sent, leaving out the recipient and the body.
+type logSender struct{}
+
+func (logSender) Send(ctx context.Context, message Message) error {
+	slog.InfoContext(ctx, "Email not sent, SMTP_ADDR is not set", "subject", message.Subject)
+	return nil
+}
@@ -0,0 +1,36 @@
+package mail
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompose(t *testing.T) {
+	date := time.Date(2024, 12, 1, 10, 0, 0, 0, time.UTC)
+	message := Message{To: "ana@example.com", Subject: "Aviso de exclusão", Body: "Olá\nAté logo"}
+
+	composed := string(compose("santa@example.com", message, date))
+
+	headers, body, found := strings.Cut(composed, "\r\n\r\n")
+	assert.True(t, found)
+	assert.Contains(t, headers, "From: santa@example.com\r\n")
+	assert.Contains(t, headers, "To: ana@example.com\r\n")
+	assert.Contains(t, headers, "Subject: =?utf-8?q?Aviso_de_exclus=C3=A3o?=\r\n")
+	assert.Contains(t, headers, "Date: Sun, 01 Dec 2024 10:00:00 +0000\r\n")
+	assert.Contains(t, headers, "Content-Type: text/plain; charset=utf-8")
+	assert.Equal(t, "Olá\r\nAté logo", body)
+}
+
+func TestNewSender(t *testing.T) {
+	assert.IsType(t, logSender{}, NewSender("", "", "", "santa@example.com"))
+	assert.IsType(t, &smtpSender{}, NewSender("smtp.example.com:587", "santa", "secret", "santa@example.com"))
+
+	assert.NoError(t, NewSender("", "", "", "").Send(context.Background(), Message{To: "ana@example.com", Subject: "Hi"}))
+
+	err := NewSender("smtp.example.com:587", "", "", "santa@example.com").Send(context.Background(), Message{To: "ana@example.com\r\nBcc: eve@example.com"})
+	assert.ErrorContains(t, err, "invalid recipient")
+}
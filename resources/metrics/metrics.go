@@ -0,0 +1,96 @@
+package metrics
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const namespace = "secret_santa"
+
+var (
+	httpRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests by route and status.",
+	}, []string{"method", "route", "status"})
+
+	httpDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Latency of HTTP requests by route and status.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	mongoDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "mongo_operation_duration_seconds",
+		Help:      "Latency of repository operations by method.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method"})
+
+	mongoErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "mongo_operation_errors_total",
+		Help:      "Failed repository operations by method and error code.",
+	}, []string{"method", "code"})
+
+	draws = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "draws_total",
+		Help:      "Draws by result: drawn, infeasible or failed.",
+	}, []string{"result"})
+
+	drawDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "draw_duration_seconds",
+		Help:      "Time taken by draws, including reading and writing the group.",
+		Buckets:   prometheus.DefBuckets,
+	})
+)
+
+// Results of a draw.
+const (
+	DrawDrawn      = "drawn"
+	DrawInfeasible = "infeasible"
+	DrawFailed     = "failed"
+)
+
+// unmatchedRoute labels requests that matched no route, so probes of random
+// paths cannot blow up the number of series.
+const unmatchedRoute = "unmatched"
+
+// Middleware counts and times every request by its route template, e.g.
+// /secret-santa/group/:id, never by the raw path.
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = unmatchedRoute
+		}
+		status := strconv.Itoa(c.Writer.Status())
+
+		httpRequests.WithLabelValues(c.Request.Method, route, status).Inc()
+		httpDuration.WithLabelValues(c.Request.Method, route, status).Observe(time.Since(start).Seconds())
+	}
+}
+
+// ObserveMongo records one repository operation. code is empty on success.
+func ObserveMongo(method string, start time.Time, code string) {
+	mongoDuration.WithLabelValues(method).Observe(time.Since(start).Seconds())
+	if code != "" {
+		mongoErrors.WithLabelValues(method, code).Inc()
+	}
+}
+
+// ObserveDraw records one draw attempt with its result.
+func ObserveDraw(start time.Time, result string) {
+	draws.WithLabelValues(result).Inc()
+	drawDuration.Observe(time.Since(start).Seconds())
+}
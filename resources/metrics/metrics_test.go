@@ -0,0 +1,45 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMiddlewareLabelsByRouteTemplate(t *testing.T) {
+	router := gin.New()
+	router.Use(Middleware())
+	router.GET("/secret-santa/group/:id", func(c *gin.Context) { c.Status(http.StatusNotFound) })
+
+	before := testutil.ToFloat64(httpRequests.WithLabelValues("GET", "/secret-santa/group/:id", "404"))
+	beforeUnmatched := testutil.ToFloat64(httpRequests.WithLabelValues("GET", unmatchedRoute, "404"))
+
+	for _, path := range []string{"/secret-santa/group/1", "/secret-santa/group/2", "/wp-admin"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+	}
+
+	assert.Equal(t, before+2, testutil.ToFloat64(httpRequests.WithLabelValues("GET", "/secret-santa/group/:id", "404")))
+	assert.Equal(t, beforeUnmatched+1, testutil.ToFloat64(httpRequests.WithLabelValues("GET", unmatchedRoute, "404")))
+}
+
+func TestObserveMongo(t *testing.T) {
+	before := testutil.ToFloat64(mongoErrors.WithLabelValues("GetGroupByID", "GROUP_NOT_FOUND"))
+
+	ObserveMongo("GetGroupByID", time.Now(), "")
+	ObserveMongo("GetGroupByID", time.Now(), "GROUP_NOT_FOUND")
+
+	assert.Equal(t, before+1, testutil.ToFloat64(mongoErrors.WithLabelValues("GetGroupByID", "GROUP_NOT_FOUND")))
+}
+
+func TestObserveDraw(t *testing.T) {
+	before := testutil.ToFloat64(draws.WithLabelValues(DrawInfeasible))
+
+	ObserveDraw(time.Now(), DrawInfeasible)
+
+	assert.Equal(t, before+1, testutil.ToFloat64(draws.WithLabelValues(DrawInfeasible)))
+}
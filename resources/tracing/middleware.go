@@ -0,0 +1,27 @@
+package tracing
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+)
+
+// untracedSuffixes are probes and scrapes, which would only add noise.
+var untracedSuffixes = []string{"/metrics", "/healthz", "/readyz"}
+
+// Middleware starts a server span named after the route for every request,
+// continuing the trace of an incoming traceparent header.
+func Middleware() gin.HandlerFunc {
+	return otelgin.Middleware(ServiceName, otelgin.WithFilter(traced))
+}
+
+func traced(r *http.Request) bool {
+	for _, suffix := range untracedSuffixes {
+		if strings.HasSuffix(r.URL.Path, suffix) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,89 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+
+	"service-secret-santa/customError"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ServiceName names the service in spans and the tracers of its packages.
+const ServiceName = "service-secret-santa"
+
+// Exporters selected with TRACING_EXPORTER. The OTLP exporter reads its
+// endpoint and headers from the standard OTEL_EXPORTER_OTLP_* variables.
+const (
+	ExporterNone   = "none"
+	ExporterStdout = "stdout"
+	ExporterOTLP   = "otlp"
+)
+
+// Tracer returns the tracer of the service, backed by the global provider.
+func Tracer() trace.Tracer {
+	return otel.Tracer(ServiceName)
+}
+
+// NewExporter builds the span exporter named by kind, or nil for "none".
+func NewExporter(ctx context.Context, kind string) (sdktrace.SpanExporter, error) {
+	switch kind {
+	case ExporterNone, "":
+		return nil, nil
+	case ExporterStdout:
+		return stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+	case ExporterOTLP:
+		return otlptracegrpc.New(ctx)
+	default:
+		return nil, fmt.Errorf("invalid tracing exporter %q, use none, stdout or otlp", kind)
+	}
+}
+
+// NewProvider builds a provider that samples ratio of the new traces, follows
+// the sampling decision of incoming ones and sends spans to exporter. A nil
+// exporter records spans for propagation but exports nothing.
+func NewProvider(exporter sdktrace.SpanExporter, ratio float64, environment string) *sdktrace.TracerProvider {
+	opts := []sdktrace.TracerProviderOption{
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(ratio))),
+		sdktrace.WithResource(resource.NewSchemaless(
+			semconv.ServiceName(ServiceName),
+			semconv.DeploymentEnvironment(environment),
+		)),
+	}
+	if exporter != nil {
+		opts = append(opts, sdktrace.WithBatcher(exporter))
+	}
+
+	return sdktrace.NewTracerProvider(opts...)
+}
+
+// Setup makes provider the global one and propagates W3C trace context and
+// baggage.
+func Setup(provider trace.TracerProvider) {
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+}
+
+// End finishes span, recording the code of err. Only failures that are not
+// the client's fault mark the span as failed. The causes of err are left out
+// because driver messages may quote personal data.
+func End(span trace.Span, err *customError.CustomError) {
+	if err != nil {
+		span.SetAttributes(attribute.String("error.code", string(err.Kind())))
+		if err.Status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, err.Message)
+		}
+	}
+	span.End()
+}
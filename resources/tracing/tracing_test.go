@@ -0,0 +1,68 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"service-secret-santa/customError"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func setupTest(t *testing.T) *tracetest.InMemoryExporter {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	Setup(provider)
+	t.Cleanup(func() { _ = provider.Shutdown(context.Background()) })
+	return exporter
+}
+
+func TestMiddlewareContinuesIncomingTrace(t *testing.T) {
+	exporter := setupTest(t)
+
+	router := gin.New()
+	router.Use(Middleware())
+	router.GET("/secret-santa/group/:id", func(c *gin.Context) { c.Status(http.StatusOK) })
+	router.GET("/secret-santa/healthz", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	req := httptest.NewRequest(http.MethodGet, "/secret-santa/group/42", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/secret-santa/healthz", nil))
+
+	spans := exporter.GetSpans()
+	if assert.Len(t, spans, 1) {
+		assert.Equal(t, "/secret-santa/group/:id", spans[0].Name)
+		assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", spans[0].SpanContext.TraceID().String())
+		assert.Equal(t, "00f067aa0ba902b7", spans[0].Parent.SpanID().String())
+	}
+}
+
+func TestEndMarksOnlyServerFailures(t *testing.T) {
+	exporter := setupTest(t)
+
+	_, span := Tracer().Start(context.Background(), "not found")
+	End(span, customError.NewCustomError(customError.WithNotFound("Group not found", "No group found with the given ID"), customError.WithCode(customError.GroupNotFound)))
+	_, span = Tracer().Start(context.Background(), "failed")
+	End(span, customError.NewCustomError(customError.WithInternalServerError("boom", "Failed to update group")))
+
+	spans := exporter.GetSpans()
+	assert.Equal(t, codes.Unset, spans[0].Status.Code)
+	assert.Equal(t, codes.Error, spans[1].Status.Code)
+	assert.Equal(t, "Failed to update group", spans[1].Status.Description)
+}
+
+func TestNewExporterRejectsUnknownKind(t *testing.T) {
+	_, err := NewExporter(context.Background(), "zipkin")
+	assert.Error(t, err)
+
+	exporter, err := NewExporter(context.Background(), ExporterNone)
+	assert.NoError(t, err)
+	assert.Nil(t, exporter)
+}
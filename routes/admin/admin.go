@@ -0,0 +1,22 @@
+package admin
+
+import (
+	groupHandler "service-secret-santa/handlers/group"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Routes sets up the administrative routes, all behind the admin token
+func Routes(defaultGroup *gin.RouterGroup, handler groupHandler.Handler) {
+	adminGroup := defaultGroup.Group("/admin", groupHandler.RequireAdmin)
+	{
+		// Rota para exportar os dados pessoais de um e-mail (LGPD/GDPR)
+		adminGroup.GET("/subject", handler.ExportSubject)
+
+		// Rota para apagar os dados pessoais de um e-mail, refazendo os sorteios afetados
+		adminGroup.DELETE("/subject", handler.EraseSubject)
+
+		// Rota para consultar o certificado de uma remoção
+		adminGroup.GET("/erasures/:id", handler.GetErasure)
+	}
+}
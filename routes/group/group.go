@@ -9,29 +9,49 @@ import (
 // Routes sets up the routes for the group resource
 func Routes(defaultGroup *gin.RouterGroup, handler groupHandler.Handler) {
 	groupsGroup := defaultGroup.Group("/group")
+	// Responde os erros no idioma do grupo quando a requisição já o leu
+	groupsGroup.Use(groupHandler.GroupLocale())
 	{
 		//deafault group = http://localhost:8080/secret-santa/
 		// Rota para criar um grupo
 		groupsGroup.POST("", handler.CreateGroup)
 
+		// Rota para buscar grupos por nome ou dono, ignorando acentos
+		groupsGroup.GET("/search", handler.SearchGroups)
+
+		// Rota para listar os grupos deletados que ainda podem ser restaurados
+		groupsGroup.GET("/trash", handler.GetTrash)
+
 		// Rota para obter um grupo pelo ID
 		groupsGroup.GET("/:id", handler.GetGroup)
 
 		// Rota para atualizar um grupo pelo ID
 		groupsGroup.PUT("/:id", handler.UpdateGroup)
 
+		// Rota para alterar parcialmente um grupo pelo ID (JSON merge patch)
+		groupsGroup.PATCH("/:id", handler.PatchGroup)
+
 		// Rota para deletar um grupo pelo ID
 		groupsGroup.DELETE("/:id", handler.DeleteGroup)
 
+		// Rota para restaurar um grupo deletado
+		groupsGroup.POST("/:id/restore", handler.RestoreGroup)
+
 		// Rota para adicionar um participante ao grupo
 		groupsGroup.POST("/:id/add-participant", handler.AddParticipant)
 
 		// Rota para gerar os matches dos participantes do grupo
 		groupsGroup.POST("/:id/match-participants", handler.MatchParticipants)
 
+		// Rota para buscar participantes do grupo por nome ou e-mail, ignorando acentos
+		groupsGroup.GET("/:id/participants/search", handler.SearchParticipants)
+
 		// Rota para obter o match de um participante
 		groupsGroup.GET("/:id/my-match", handler.GetMyMatch)
 
+		// Rota para o organizador consultar o histórico de alterações do grupo
+		groupsGroup.GET("/:id/audit", handler.GetAudit)
+
 		//Rota para obter todos grupos disponíveis.
 		groupsGroup.GET("", handler.GetAllGroups)
 
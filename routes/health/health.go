@@ -0,0 +1,16 @@
+package health
+
+import (
+	healthHandler "service-secret-santa/handlers/health"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Routes sets up the liveness and readiness probes
+func Routes(defaultGroup *gin.RouterGroup, handler healthHandler.Handler) {
+	// Rota de liveness: responde enquanto o processo estiver de pé
+	defaultGroup.GET("/healthz", handler.Liveness)
+
+	// Rota de readiness: verifica o MongoDB e os demais componentes registrados
+	defaultGroup.GET("/readyz", handler.Readiness)
+}
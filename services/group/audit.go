@@ -0,0 +1,210 @@
+package group
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"service-secret-santa/customError"
+	"service-secret-santa/functions"
+	"service-secret-santa/models"
+	"service-secret-santa/repositories/group"
+)
+
+// GetAudit returns the audit log of a group to its organizer. The log of a
+// group in the trash can still be read by its organizer.
+func (r *resource) GetAudit(ctx context.Context, id string, organizer string, query models.AuditQuery) ([]models.AuditEvent, *customError.CustomError) {
+	if organizer == "" {
+		return nil, notOrganizer()
+	}
+
+	owner, err := r.owner(ctx, id, organizer)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.EqualFold(owner, organizer) {
+		return nil, notOrganizer()
+	}
+
+	query.Actor = strings.ToLower(query.Actor)
+	return r.repo.GetAuditEvents(ctx, id, query)
+}
+
+// owner returns the owner of the group id, looking for it among the groups
+// organizer has in the trash when it is not live.
+func (r *resource) owner(ctx context.Context, id string, organizer string) (string, *customError.CustomError) {
+	group, err := readGroup(ctx, r.repo, id)
+	if err == nil {
+		return group.Owner, nil
+	}
+	if err.Kind() != customError.GroupNotFound {
+		return "", err
+	}
+
+	trashed, trashErr := r.repo.GetTrash(ctx, organizer)
+	if trashErr != nil {
+		return "", trashErr
+	}
+	for _, g := range trashed {
+		if strings.EqualFold(g.Id.Hex(), id) {
+			return g.Owner, nil
+		}
+	}
+	return "", err
+}
+
+func notOrganizer() *customError.CustomError {
+	return customError.NewCustomError(
+		customError.WithForbidden("Not the organizer of the group", "Only the organizer of the group can read its audit log"),
+		customError.WithCode(customError.Forbidden),
+	)
+}
+
+// auditPerson is someone whose personal data is taken out of the audit log:
+// their email, as actor or owner, and the names of their participant entries
+// as the group stored them. With all set it stands for everybody in the log.
+type auditPerson struct {
+	all   bool
+	email string
+	names map[string]bool
+}
+
+// everybody is every person in the audit log, taken out of it once the
+// participant data of the group is anonymized.
+var everybody = auditPerson{all: true}
+
+func subjectPerson(email string, participants []models.Participant) auditPerson {
+	person := auditPerson{email: strings.ToLower(strings.TrimSpace(email)), names: make(map[string]bool)}
+	for _, participant := range participants {
+		person.names[participant.Name] = true
+	}
+	return person
+}
+
+// isEmail reports whether value, an actor or an owner, is the person. The
+// actors that are not people never are.
+func (p auditPerson) isEmail(value string) bool {
+	switch value {
+	case "", models.AuditActorSystem, models.AuditActorAdmin, models.AuditActorAnonymous, models.AuditRedacted:
+		return false
+	}
+	return p.all || strings.EqualFold(value, p.email)
+}
+
+// isName reports whether name is one of the participants of the person.
+// Revealed events keep the name as it was typed, so typed names are compared
+// the way GetMyMatch resolves them.
+func (p auditPerson) isName(name string, typed bool) bool {
+	if name == "" || name == models.AuditRedacted {
+		return false
+	}
+	if p.all || p.names[name] {
+		return true
+	}
+	if typed {
+		for stored := range p.names {
+			if functions.NormalizeName(stored) == functions.NormalizeName(name) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// redact replaces what event holds about the person by models.AuditRedacted
+// and reports whether it changed anything.
+func (p auditPerson) redact(event *models.AuditEvent) bool {
+	changed := false
+	if p.isEmail(event.Actor) {
+		event.Actor = models.AuditRedacted
+		changed = true
+	}
+
+	switch event.Action {
+	case models.AuditParticipantAdded, models.AuditParticipantRemoved, models.AuditRevealed:
+		if p.isName(event.Detail, event.Action == models.AuditRevealed) {
+			event.Detail = models.AuditRedacted
+			changed = true
+		}
+	}
+
+	changes := make([]models.AuditChange, 0, len(event.Changes))
+	for _, change := range event.Changes {
+		var matches func(string) bool
+		switch change.Field {
+		case "owner":
+			matches = p.isEmail
+		case "participants":
+			matches = func(name string) bool { return p.isName(name, false) }
+		}
+		if matches != nil {
+			var before, after bool
+			change.Before, before = redactValue(change.Before, matches)
+			change.After, after = redactValue(change.After, matches)
+			changed = changed || before || after
+		}
+		changes = append(changes, change)
+	}
+	if len(event.Changes) > 0 {
+		event.Changes = changes
+	}
+
+	return changed
+}
+
+// mentions reports whether event holds anything about the person.
+func (p auditPerson) mentions(event models.AuditEvent) bool {
+	return p.redact(&event)
+}
+
+// redactValue replaces value, a JSON string or array of strings, or the
+// strings in it that match, by models.AuditRedacted.
+func redactValue(value string, matches func(string) bool) (string, bool) {
+	var decoded interface{}
+	if value == "" || json.Unmarshal([]byte(value), &decoded) != nil {
+		return value, false
+	}
+
+	switch v := decoded.(type) {
+	case string:
+		if matches(v) {
+			return auditValue(models.AuditRedacted), true
+		}
+	case []interface{}:
+		changed := false
+		for i, item := range v {
+			if s, ok := item.(string); ok && matches(s) {
+				v[i] = models.AuditRedacted
+				changed = true
+			}
+		}
+		if encoded, err := json.Marshal(v); changed && err == nil {
+			return string(encoded), true
+		}
+	}
+	return value, false
+}
+
+// redactAudit takes person out of the audit log of the group id.
+func redactAudit(ctx context.Context, repo group.Repository, id string, person auditPerson) *customError.CustomError {
+	events, err := repo.GetAuditEvents(ctx, id, models.AuditQuery{})
+	if err != nil {
+		return err
+	}
+	return rewriteRedacted(ctx, repo, events, person)
+}
+
+// rewriteRedacted takes person out of events and saves the ones that held
+// anything about them.
+func rewriteRedacted(ctx context.Context, repo group.Repository, events []models.AuditEvent, person auditPerson) *customError.CustomError {
+	var redacted []models.AuditEvent
+	for _, event := range events {
+		if person.redact(&event) {
+			redacted = append(redacted, event)
+		}
+	}
+	if len(redacted) == 0 {
+		return nil
+	}
+	return repo.RewriteAuditEvents(ctx, redacted)
+}
@@ -0,0 +1,277 @@
+package group
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"service-secret-santa/customError"
+	"service-secret-santa/models"
+	"service-secret-santa/repositories/group"
+	"service-secret-santa/resources/identity"
+	"service-secret-santa/resources/logging"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// audited records an event in the audit log of a group for every change made
+// through svc, and for every match revealed. Events name the user of the
+// request and the fields changed, with their values before and after;
+// participants are named but their emails are left out, and draws record
+// the status but never the matches.
+//
+// The group is read before changes that diff it. Failing to record an event
+// is logged and does not fail the change, which is already saved.
+type audited struct {
+	Service
+	repo group.Repository
+}
+
+// NewAuditedService records the changes made through svc in the audit log of
+// repo.
+func NewAuditedService(svc Service, repo group.Repository) Service {
+	return &audited{Service: svc, repo: repo}
+}
+
+func (s *audited) CreateGroup(ctx context.Context, g *models.Group) (*models.Group, *customError.CustomError) {
+	created, err := s.Service.CreateGroup(ctx, g)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := diffGroups(&models.Group{}, created)
+	if names := participantNames(created.Participants); len(names) > 0 {
+		changes = append(changes, models.AuditChange{Field: "participants", After: auditValue(names)})
+	}
+	s.record(ctx, created.Id, models.AuditCreated, "", changes)
+	return created, nil
+}
+
+func (s *audited) UpdateGroup(ctx context.Context, id string, version int64, update *models.GroupUpdate) (*models.Group, *customError.CustomError) {
+	before := s.read(ctx, id)
+	updated, err := s.Service.UpdateGroup(ctx, id, version, update)
+	if err != nil {
+		return nil, err
+	}
+
+	s.recordUpdate(ctx, before, updated)
+	return updated, nil
+}
+
+func (s *audited) PatchGroup(ctx context.Context, id string, version int64, patch GroupPatch) (*models.Group, *customError.CustomError) {
+	before := s.read(ctx, id)
+	patched, err := s.Service.PatchGroup(ctx, id, version, patch)
+	if err != nil {
+		return nil, err
+	}
+
+	s.recordUpdate(ctx, before, patched)
+	return patched, nil
+}
+
+func (s *audited) DeleteGroup(ctx context.Context, id string, version int64) *customError.CustomError {
+	if err := s.Service.DeleteGroup(ctx, id, version); err != nil {
+		return err
+	}
+
+	if objectID, err := primitive.ObjectIDFromHex(id); err == nil {
+		s.record(ctx, objectID, models.AuditDeleted, "", nil)
+	}
+	return nil
+}
+
+func (s *audited) RestoreGroup(ctx context.Context, id string, version int64) (*models.Group, *customError.CustomError) {
+	restored, err := s.Service.RestoreGroup(ctx, id, version)
+	if err != nil {
+		return nil, err
+	}
+
+	s.record(ctx, restored.Id, models.AuditRestored, "", nil)
+	return restored, nil
+}
+
+func (s *audited) AddParticipant(ctx context.Context, id string, version int64, participant *models.Participant) (*models.Group, *customError.CustomError) {
+	updated, err := s.Service.AddParticipant(ctx, id, version, participant)
+	if err != nil {
+		return nil, err
+	}
+
+	s.record(ctx, updated.Id, models.AuditParticipantAdded, participant.Name, []models.AuditChange{
+		{Field: "participants", After: auditValue(participant.Name)},
+	})
+	return updated, nil
+}
+
+func (s *audited) MatchParticipants(ctx context.Context, id string, version int64) (*models.Group, *customError.CustomError) {
+	before := s.read(ctx, id)
+	drawn, err := s.Service.MatchParticipants(ctx, id, version)
+	if err != nil {
+		return nil, err
+	}
+
+	action, status := models.AuditDrawn, models.GroupStatusOpen
+	if before != nil && before.Status == models.GroupStatusDrawn {
+		action, status = models.AuditRedrawn, models.GroupStatusDrawn
+	}
+	var changes []models.AuditChange
+	if status != drawn.Status {
+		changes = []models.AuditChange{{Field: "status", Before: auditValue(status), After: auditValue(drawn.Status)}}
+	}
+	s.record(ctx, drawn.Id, action, fmt.Sprintf("%d matches", len(drawn.Matches)), changes)
+	return drawn, nil
+}
+
+func (s *audited) GetMyMatch(ctx context.Context, id string, username string) (string, *customError.CustomError) {
+	match, err := s.Service.GetMyMatch(ctx, id, username)
+	if err != nil {
+		return "", err
+	}
+
+	if objectID, err := primitive.ObjectIDFromHex(id); err == nil {
+		s.record(ctx, objectID, models.AuditRevealed, username, nil)
+	}
+	return match, nil
+}
+
+// read returns the group as it is before a change, or nil when it cannot be
+// read; the change then fails on its own or is recorded without a diff.
+func (s *audited) read(ctx context.Context, id string) *models.Group {
+	g, err := readGroup(ctx, s.repo, id)
+	if err != nil {
+		return nil
+	}
+	return g
+}
+
+// recordUpdate records the fields of the group that changed and, apart, each
+// participant added or removed.
+func (s *audited) recordUpdate(ctx context.Context, before, after *models.Group) {
+	if before == nil {
+		s.record(ctx, after.Id, models.AuditUpdated, "", nil)
+		return
+	}
+
+	changes := diffGroups(before, after)
+	added, removed := diffParticipants(before.Participants, after.Participants)
+	if len(changes) > 0 || len(added)+len(removed) == 0 {
+		s.record(ctx, after.Id, models.AuditUpdated, "", changes)
+	}
+	for _, name := range removed {
+		s.record(ctx, after.Id, models.AuditParticipantRemoved, name, []models.AuditChange{{Field: "participants", Before: auditValue(name)}})
+	}
+	for _, name := range added {
+		s.record(ctx, after.Id, models.AuditParticipantAdded, name, []models.AuditChange{{Field: "participants", After: auditValue(name)}})
+	}
+}
+
+// record appends an event for the user of the request. It is recorded even
+// if the client went away, since the change it describes was saved.
+func (s *audited) record(ctx context.Context, groupID primitive.ObjectID, action, detail string, changes []models.AuditChange) {
+	actor := identity.User(ctx)
+	if actor == "" {
+		actor = models.AuditActorAnonymous
+	}
+
+	event := &models.AuditEvent{
+		GroupId:   groupID,
+		Action:    action,
+		Actor:     actor,
+		RequestId: logging.RequestID(ctx),
+		Detail:    detail,
+		Changes:   changes,
+	}
+	if err := s.repo.AppendAudit(context.WithoutCancel(ctx), event); err != nil {
+		slog.ErrorContext(ctx, "Failed to record audit event", "group", groupID.Hex(), "action", action, "error", err)
+	}
+}
+
+// diffGroups returns the fields other than participants that differ between
+// before and after.
+func diffGroups(before, after *models.Group) []models.AuditChange {
+	var changes []models.AuditChange
+	diff := func(field string, old, new interface{}) {
+		if o, n := auditValue(old), auditValue(new); o != n {
+			changes = append(changes, models.AuditChange{Field: field, Before: o, After: n})
+		}
+	}
+
+	diff("name", before.Name, after.Name)
+	diff("owner", before.Owner, after.Owner)
+	diff("locale", before.Locale, after.Locale)
+	diff("status", before.Status, after.Status)
+	diff("exchangeDate", before.ExchangeDate, after.ExchangeDate)
+	return changes
+}
+
+// diffParticipants returns the names of the participants in after but not in
+// before, and the other way round. Participants are told apart by email,
+// ignoring case, or by name when they have no email.
+func diffParticipants(before, after []models.Participant) (added, removed []string) {
+	key := func(p models.Participant) string {
+		if p.Email != "" {
+			return "email:" + strings.ToLower(p.Email)
+		}
+		return "name:" + p.Name
+	}
+
+	remaining := make(map[string]int, len(before))
+	for _, p := range before {
+		remaining[key(p)]++
+	}
+	for _, p := range after {
+		if remaining[key(p)] > 0 {
+			remaining[key(p)]--
+			continue
+		}
+		added = append(added, p.Name)
+	}
+
+	kept := make(map[string]int, len(after))
+	for _, p := range after {
+		kept[key(p)]++
+	}
+	for _, p := range before {
+		if kept[key(p)] > 0 {
+			kept[key(p)]--
+			continue
+		}
+		removed = append(removed, p.Name)
+	}
+	return added, removed
+}
+
+func participantNames(participants []models.Participant) []string {
+	names := make([]string, 0, len(participants))
+	for _, p := range participants {
+		names = append(names, p.Name)
+	}
+	return names
+}
+
+// auditValue is value as JSON, or "" when it is the zero value.
+func auditValue(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		if v == "" {
+			return ""
+		}
+	case time.Time:
+		if v.IsZero() {
+			return ""
+		}
+		value = v.UTC()
+	case []string:
+		if len(v) == 0 {
+			return ""
+		}
+	}
+
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return ""
+	}
+	return string(encoded)
+}
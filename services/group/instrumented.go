@@ -0,0 +1,36 @@
+package group
+
+import (
+	"context"
+	"time"
+
+	"service-secret-santa/customError"
+	"service-secret-santa/models"
+	"service-secret-santa/resources/metrics"
+)
+
+// instrumented records draw metrics and passes every other call through.
+type instrumented struct {
+	Service
+}
+
+// NewInstrumentedService counts and times the draws made through svc.
+func NewInstrumentedService(svc Service) Service {
+	return &instrumented{Service: svc}
+}
+
+func (s *instrumented) MatchParticipants(ctx context.Context, id string, version int64) (*models.Group, *customError.CustomError) {
+	start := time.Now()
+	group, err := s.Service.MatchParticipants(ctx, id, version)
+
+	switch {
+	case err == nil:
+		metrics.ObserveDraw(start, metrics.DrawDrawn)
+	case err.Kind() == customError.DrawInfeasible:
+		metrics.ObserveDraw(start, metrics.DrawInfeasible)
+	default:
+		metrics.ObserveDraw(start, metrics.DrawFailed)
+	}
+
+	return group, err
+}
@@ -0,0 +1,183 @@
+package group
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"service-secret-santa/i18n"
+	"service-secret-santa/models"
+	"service-secret-santa/repositories/group"
+	"service-secret-santa/resources/health"
+	"service-secret-santa/resources/lifecycle"
+	"service-secret-santa/resources/mail"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// retentionBatch caps how many groups each step of a run handles; the rest
+// wait for the next run.
+const retentionBatch = 100
+
+// warningAttempts is how many runs try to send the warning of a group before
+// it is anonymized without one.
+const warningAttempts = 3
+
+// RetentionJob anonymizes the participant data of groups once the retention
+// has passed since their exchange date, or their creation without one. The
+// owner gets an email the warning period before, and a group is anonymized
+// no sooner than the warning period after that email, so a late warning
+// pushes the anonymization back. A warning that cannot be sent is tried again
+// on the next runs, holding the anonymization back, until warningAttempts
+// runs failed. Trashed groups are anonymized too, since the trash may be kept
+// longer than their participant data. The names and emails in the audit log
+// of a group are replaced before it is anonymized. Both steps are recorded in
+// the audit log.
+type RetentionJob struct {
+	repo          group.Repository
+	sender        mail.Sender
+	defaultLocale string
+	retention     time.Duration
+	warning       time.Duration
+	status        *health.JobStatus
+}
+
+func NewRetentionJob(repo group.Repository, sender mail.Sender, defaultLocale string, retention, warning, interval time.Duration) *RetentionJob {
+	return &RetentionJob{repo: repo, sender: sender, defaultLocale: defaultLocale, retention: retention, warning: warning, status: health.NewJobStatus("pii-retention", interval)}
+}
+
+// Run warns the owners of the groups due for anonymization and anonymizes
+// those whose warning period ran out. It fails when a step could not find its
+// groups or some of them were left for the next run.
+func (j *RetentionJob) Run(ctx context.Context) error {
+	now := time.Now()
+	return errors.Join(j.warn(ctx, now), j.anonymize(ctx, now))
+}
+
+// Component runs once on start and then every interval until stopped.
+func (j *RetentionJob) Component() lifecycle.Component {
+	return j.status.Component(j.Run)
+}
+
+// Check reports the runs to the readiness probe.
+func (j *RetentionJob) Check() health.Check {
+	return j.status.Check()
+}
+
+func (j *RetentionJob) warn(ctx context.Context, now time.Time) error {
+	groups, err := j.repo.GetRetainedGroups(ctx, models.RetentionQuery{ExchangedBefore: now.Add(j.warning - j.retention), Limit: retentionBatch})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to find groups to warn of anonymization", "error", err)
+		return err
+	}
+
+	failed := 0
+	for _, g := range groups {
+		id := g.Id.Hex()
+
+		// Only the instance that records the warning sends it.
+		claimed, err := j.repo.MarkPurgeWarned(ctx, id)
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to mark group as warned", "group", id, "error", err)
+			failed++
+			continue
+		}
+		if !claimed {
+			continue
+		}
+
+		detail := "owner notified"
+		if g.Owner == "" {
+			detail = "no owner to notify"
+		} else if err := j.sender.Send(ctx, j.warningMessage(g, now)); err != nil {
+			slog.ErrorContext(ctx, "Failed to send anonymization warning", "group", id, "error", err)
+			detail = j.warningFailed(ctx, g)
+			failed++
+		}
+
+		j.audit(ctx, g.Id, models.AuditPurgeWarned, detail)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("groups whose owner could not be warned: %d", failed)
+	}
+	return nil
+}
+
+// warningFailed takes back the warning of g so that the next run sends it
+// again, unless this was its last attempt; then the group is anonymized on
+// schedule without it. It returns the detail of the audit event.
+func (j *RetentionJob) warningFailed(ctx context.Context, g *models.Group) string {
+	attempt := g.PurgeWarnFailures + 1
+	if attempt >= warningAttempts {
+		return fmt.Sprintf("owner notification failed %d times, anonymizing without it", attempt)
+	}
+
+	if err := j.repo.UnmarkPurgeWarned(ctx, g.Id.Hex()); err != nil {
+		slog.ErrorContext(ctx, "Failed to take back anonymization warning", "group", g.Id.Hex(), "error", err)
+		return "owner notification failed"
+	}
+	return fmt.Sprintf("owner notification failed, attempt %d of %d", attempt, warningAttempts)
+}
+
+func (j *RetentionJob) anonymize(ctx context.Context, now time.Time) error {
+	groups, err := j.repo.GetRetainedGroups(ctx, models.RetentionQuery{ExchangedBefore: now.Add(-j.retention), WarnedBefore: now.Add(-j.warning), Limit: retentionBatch})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to find groups to anonymize", "error", err)
+		return err
+	}
+
+	anonymized, failed := 0, 0
+	for _, g := range groups {
+		// A group whose log keeps personal data is not anonymized yet, so
+		// the next run tries both again.
+		if err := redactAudit(ctx, j.repo, g.Id.Hex(), everybody); err != nil {
+			slog.ErrorContext(ctx, "Failed to take personal data out of the audit log", "group", g.Id.Hex(), "error", err)
+			failed++
+			continue
+		}
+
+		result, err := j.repo.AnonymizeGroup(ctx, g.Id.Hex())
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to anonymize group", "group", g.Id.Hex(), "error", err)
+			failed++
+			continue
+		}
+		anonymized++
+
+		j.audit(ctx, g.Id, models.AuditAnonymized, fmt.Sprintf("%d participants, %d matches", len(result.Participants), len(result.Matches)))
+	}
+
+	if anonymized > 0 {
+		slog.InfoContext(ctx, "Participant data anonymized", "groups", anonymized)
+	}
+	if failed > 0 {
+		return fmt.Errorf("groups that could not be anonymized: %d", failed)
+	}
+	return nil
+}
+
+// warningMessage is the email telling the owner of g when its participant
+// data will be anonymized, in the locale of the group.
+func (j *RetentionJob) warningMessage(g *models.Group, now time.Time) mail.Message {
+	locale := i18n.Resolve("", g.Locale, j.defaultLocale)
+
+	// Groups are warned once they are due within the warning period, and
+	// anonymized no sooner than the warning period after.
+	due := now.Add(j.warning)
+
+	params := map[string]interface{}{"Group": g.Name, "Date": due.UTC().Format("2006-01-02")}
+	subject, _ := i18n.Translate(locale, "mail.retentionWarning.subject", params)
+	body, _ := i18n.Translate(locale, "mail.retentionWarning.body", params)
+
+	return mail.Message{To: g.Owner, Subject: subject, Body: body}
+}
+
+func (j *RetentionJob) audit(ctx context.Context, groupID primitive.ObjectID, action, detail string) {
+	event := &models.AuditEvent{GroupId: groupID, Action: action, Actor: models.AuditActorSystem, Detail: detail}
+	if err := j.repo.AppendAudit(ctx, event); err != nil {
+		slog.ErrorContext(ctx, "Failed to record audit event", "group", groupID.Hex(), "action", action, "error", err)
+	}
+}
@@ -1,61 +1,174 @@
 package group
 
 import (
+	"context"
+	"log/slog"
 	"math/rand"
+	"net/http"
 	"service-secret-santa/customError"
 	"service-secret-santa/functions"
+	"service-secret-santa/i18n"
 	"service-secret-santa/models"
 	"service-secret-santa/repositories/group"
+	"sort"
+	"strings"
 	"time"
 )
 
 type Service interface {
-	CreateGroup(group *models.Group) (*models.Group, *customError.CustomError)
-	GetGroupByID(id string) (*models.Group, *customError.CustomError)
-	UpdateGroup(id string, group *models.Group) (*models.Group, *customError.CustomError)
-	DeleteGroup(id string) *customError.CustomError
-	AddParticipant(id string, participant *models.Participant) (*models.Group, *customError.CustomError)
-	MatchParticipants(id string) (*models.Group, *customError.CustomError)
-	GetMyMatch(id string, username string) (string, *customError.CustomError)
-	GetAllGroups() ([]*models.Group, *customError.CustomError)
+	CreateGroup(ctx context.Context, group *models.Group) (*models.Group, *customError.CustomError)
+	GetGroupByID(ctx context.Context, id string) (*models.Group, *customError.CustomError)
+	UpdateGroup(ctx context.Context, id string, version int64, update *models.GroupUpdate) (*models.Group, *customError.CustomError)
+	PatchGroup(ctx context.Context, id string, version int64, patch GroupPatch) (*models.Group, *customError.CustomError)
+	DeleteGroup(ctx context.Context, id string, version int64) *customError.CustomError
+	GetTrash(ctx context.Context, owner string) ([]*models.Group, *customError.CustomError)
+	RestoreGroup(ctx context.Context, id string, version int64) (*models.Group, *customError.CustomError)
+	AddParticipant(ctx context.Context, id string, version int64, participant *models.Participant) (*models.Group, *customError.CustomError)
+	MatchParticipants(ctx context.Context, id string, version int64) (*models.Group, *customError.CustomError)
+	GetMyMatch(ctx context.Context, id string, username string) (string, *customError.CustomError)
+	GetAllGroups(ctx context.Context, query models.GroupQuery) (*models.GroupPage, *customError.CustomError)
+	SearchGroups(ctx context.Context, query models.SearchQuery) ([]*models.Group, *customError.CustomError)
+	SearchParticipants(ctx context.Context, id string, query models.SearchQuery) ([]models.Participant, *customError.CustomError)
+	ExportSubject(ctx context.Context, email string) (*models.SubjectExport, *customError.CustomError)
+	EraseSubject(ctx context.Context, email string) (*models.ErasureCertificate, *customError.CustomError)
+	GetErasure(ctx context.Context, id string) (*models.ErasureCertificate, *customError.CustomError)
+	GetAudit(ctx context.Context, id string, organizer string, query models.AuditQuery) ([]models.AuditEvent, *customError.CustomError)
 }
 
+// GroupPatch computes the new mutable fields of a group from the current ones.
+type GroupPatch func(current models.GroupUpdate) (*models.GroupUpdate, *customError.CustomError)
+
 type resource struct {
-	repo group.Repository
+	repo       group.Repository
+	subjectKey []byte
 }
 
-func (r *resource) CreateGroup(group *models.Group) (*models.Group, *customError.CustomError) {
+func (r *resource) CreateGroup(ctx context.Context, group *models.Group) (*models.Group, *customError.CustomError) {
 	group.CreatedAt = time.Now()
 	group.UpdatedAt = time.Now()
+	group.Status = models.GroupStatusOpen
+
+	if err := duplicateEmail(group.Participants); err != nil {
+		return nil, err
+	}
 
-	return r.repo.CreateGroup(group)
+	return r.repo.CreateGroup(ctx, group)
 }
 
-func (r *resource) GetGroupByID(id string) (*models.Group, *customError.CustomError) {
-	return r.repo.GetGroupByID(id)
+func (r *resource) GetGroupByID(ctx context.Context, id string) (*models.Group, *customError.CustomError) {
+	return readGroup(ctx, r.repo, id)
 }
 
-func (r *resource) UpdateGroup(id string, group *models.Group) (*models.Group, *customError.CustomError) {
-	group.UpdatedAt = time.Now()
-	return r.repo.UpdateGroup(id, group)
+// readGroup reads the group id and notes its locale in ctx, so an error later
+// in the request is told in the language of the group.
+func readGroup(ctx context.Context, repo group.Repository, id string) (*models.Group, *customError.CustomError) {
+	g, err := repo.GetGroupByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	i18n.NoteLocale(ctx, g.Locale)
+	return g, nil
+}
+
+func (r *resource) UpdateGroup(ctx context.Context, id string, version int64, update *models.GroupUpdate) (*models.Group, *customError.CustomError) {
+	if err := duplicateEmail(update.Participants); err != nil {
+		return nil, err
+	}
+
+	update.UpdatedAt = time.Now()
+	return r.repo.UpdateGroup(ctx, id, version, update)
+}
+
+func (r *resource) PatchGroup(ctx context.Context, id string, version int64, patch GroupPatch) (*models.Group, *customError.CustomError) {
+	group, err := readGroup(ctx, r.repo, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if versionErr := checkVersion(group, version); versionErr != nil {
+		return nil, versionErr
+	}
+
+	update, patchErr := patch(models.NewGroupUpdate(group))
+	if patchErr != nil {
+		return nil, patchErr
+	}
+	if err := duplicateEmail(update.Participants); err != nil {
+		return nil, err
+	}
+
+	// Grava sobre a versão lida, para não sobrescrever uma alteração feita entre a leitura e a escrita
+	update.UpdatedAt = time.Now()
+	return r.repo.UpdateGroup(ctx, id, group.Version, update)
+}
+
+// duplicateEmail rejects participants that have an email twice, as
+// AddParticipant does one participant at a time. Emails are compared without
+// case, like the stores compare them.
+func duplicateEmail(participants []models.Participant) *customError.CustomError {
+	seen := make(map[string]struct{}, len(participants))
+	for _, participant := range participants {
+		if participant.Email == "" {
+			continue
+		}
+		email := strings.ToLower(participant.Email)
+		if _, found := seen[email]; found {
+			return customError.NewCustomError(
+				customError.WithCustomError(http.StatusConflict, "Participant already in group", "A participant with this email is already in the group"),
+				customError.WithCode(customError.ParticipantDuplicate),
+			)
+		}
+		seen[email] = struct{}{}
+	}
+	return nil
+}
+
+func (r *resource) DeleteGroup(ctx context.Context, id string, version int64) *customError.CustomError {
+	return r.repo.DeleteGroup(ctx, id, version)
+}
+
+func (r *resource) GetTrash(ctx context.Context, owner string) ([]*models.Group, *customError.CustomError) {
+	return r.repo.GetTrash(ctx, owner)
 }
 
-func (r *resource) DeleteGroup(id string) *customError.CustomError {
-	return r.repo.DeleteGroup(id)
+func (r *resource) RestoreGroup(ctx context.Context, id string, version int64) (*models.Group, *customError.CustomError) {
+	return r.repo.RestoreGroup(ctx, id, version)
 }
 
-func (r *resource) AddParticipant(id string, participant *models.Participant) (*models.Group, *customError.CustomError) {
-	return r.repo.AddParticipant(id, participant)
+func (r *resource) AddParticipant(ctx context.Context, id string, version int64, participant *models.Participant) (*models.Group, *customError.CustomError) {
+	return r.repo.AddParticipant(ctx, id, version, participant)
 }
 
-func (r *resource) MatchParticipants(id string) (*models.Group, *customError.CustomError) {
-	group, err := r.repo.GetGroupByID(id)
+// drawAttempts bounds how many times a draw without If-Match is computed again
+// when the group changes between reading it and saving the matches.
+const drawAttempts = 3
+
+// MatchParticipants draws the group as read and saves the matches only if the
+// group is still at that version, so the draw covers exactly the participants
+// it was computed from. A caller that sent a version gets 412 when it changed;
+// one that did not gets a new draw over the current participants.
+func (r *resource) MatchParticipants(ctx context.Context, id string, version int64) (*models.Group, *customError.CustomError) {
+	for attempt := 1; ; attempt++ {
+		group, err := r.draw(ctx, id, version)
+		if err == nil || version != models.AnyVersion || err.Kind() != customError.GroupVersionMismatch || attempt == drawAttempts {
+			return group, err
+		}
+		slog.InfoContext(ctx, "Group changed during the draw, drawing again", "id", id, "attempt", attempt)
+	}
+}
+
+func (r *resource) draw(ctx context.Context, id string, version int64) (*models.Group, *customError.CustomError) {
+	group, err := readGroup(ctx, r.repo, id)
 	if err != nil {
 		return nil, err
 	}
 
+	if versionErr := checkVersion(group, version); versionErr != nil {
+		return nil, versionErr
+	}
+
 	if len(group.Participants) < 2 {
-		return nil, customError.NewCustomError(customError.WithBadRequest("Not enough participants", "At least two participants are required for matching"))
+		return nil, customError.NewCustomError(customError.WithBadRequest("Not enough participants", "At least two participants are required for matching"), customError.WithCode(customError.DrawInfeasible))
 	}
 
 	// Mapeia os nomes dos participantes
@@ -65,7 +178,7 @@ func (r *resource) MatchParticipants(id string) (*models.Group, *customError.Cus
 	}
 
 	var matches []models.Match
-	matchIndexes := functions.RandomDerangement(len(group.Participants), rand.New(rand.NewSource(time.Now().UnixNano())))
+	matchIndexes := functions.TracedRandomDerangement(ctx, len(group.Participants), rand.New(rand.NewSource(time.Now().UnixNano())))
 
 	for i, participant := range group.Participants {
 		matches = append(matches, models.Match{
@@ -74,26 +187,94 @@ func (r *resource) MatchParticipants(id string) (*models.Group, *customError.Cus
 		})
 	}
 
-	// Atualiza os matches no repositório
-	updateErr := r.repo.UpdateMatches(id, matches)
+	// Atualiza os matches no repositório, desde que o grupo não tenha mudado desde a leitura
+	updateErr := r.repo.UpdateMatches(ctx, id, group.Version, matches)
 	if updateErr != nil {
 		return nil, updateErr
 	}
 
 	// Atualiza os matches no grupo
 	group.Matches = matches
+	group.Status = models.GroupStatusDrawn
+	group.Version++
+
+	slog.InfoContext(ctx, "Participants matched", "group", group)
 
 	return group, nil
 }
 
-func (r *resource) GetMyMatch(id string, username string) (string, *customError.CustomError) {
-	return r.repo.GetMyMatch(id, username)
+func (r *resource) GetMyMatch(ctx context.Context, id string, username string) (string, *customError.CustomError) {
+	return r.repo.GetMyMatch(ctx, id, username)
+}
+
+func (r *resource) GetAllGroups(ctx context.Context, query models.GroupQuery) (*models.GroupPage, *customError.CustomError) {
+	return r.repo.GetAllGroups(ctx, query)
+}
+
+func (r *resource) SearchGroups(ctx context.Context, query models.SearchQuery) ([]*models.Group, *customError.CustomError) {
+	candidates, err := r.repo.SearchGroups(ctx, query.Q)
+	if err != nil {
+		return nil, err
+	}
+
+	var ranked []rankedItem[*models.Group]
+	for _, g := range candidates {
+		if rank, ok := functions.SearchRank(query.Q, g.Name, g.Owner); ok {
+			ranked = append(ranked, rankedItem[*models.Group]{item: g, rank: rank, key: g.Name})
+		}
+	}
+
+	return topRanked(ranked, query.Limit), nil
+}
+
+func (r *resource) SearchParticipants(ctx context.Context, id string, query models.SearchQuery) ([]models.Participant, *customError.CustomError) {
+	group, err := readGroup(ctx, r.repo, id)
+	if err != nil {
+		return nil, err
+	}
+
+	var ranked []rankedItem[models.Participant]
+	for _, p := range group.Participants {
+		if rank, ok := functions.SearchRank(query.Q, p.Name, p.Email); ok {
+			ranked = append(ranked, rankedItem[models.Participant]{item: p, rank: rank, key: p.Name})
+		}
+	}
+
+	return topRanked(ranked, query.Limit), nil
+}
+
+// checkVersion fails when the client based its change on another version of the group.
+func checkVersion(group *models.Group, version int64) *customError.CustomError {
+	if version != models.AnyVersion && group.Version != version {
+		return customError.NewCustomError(customError.WithPreconditionFailed("Group version mismatch", "The group was modified by someone else, reload it and try again"), customError.WithCode(customError.GroupVersionMismatch))
+	}
+	return nil
+}
+
+type rankedItem[T any] struct {
+	item T
+	rank int
+	key  string
 }
 
-func (r *resource) GetAllGroups() ([]*models.Group, *customError.CustomError) {
-	return r.repo.GetAllGroups()
+// topRanked orders the items best match first, then alphabetically, and keeps the first limit.
+func topRanked[T any](ranked []rankedItem[T], limit int) []T {
+	sort.SliceStable(ranked, func(i, j int) bool {
+		if ranked[i].rank != ranked[j].rank {
+			return ranked[i].rank < ranked[j].rank
+		}
+		return functions.FoldAccents(ranked[i].key) < functions.FoldAccents(ranked[j].key)
+	})
+
+	items := make([]T, 0, limit)
+	for i := 0; i < len(ranked) && i < limit; i++ {
+		items = append(items, ranked[i].item)
+	}
+	return items
 }
 
-func NewGroupService(repo group.Repository) Service {
-	return &resource{repo: repo}
+// NewGroupService builds the service over repo. subjectKey keys the subject
+// hash of erasure certificates; without it certificates keep no hash.
+func NewGroupService(repo group.Repository, subjectKey []byte) Service {
+	return &resource{repo: repo, subjectKey: subjectKey}
 }
@@ -1,17 +1,30 @@
 package group
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"strconv"
 	"testing"
 	"time"
 
 	"service-secret-santa/customError"
+	"service-secret-santa/i18n"
 	"service-secret-santa/models"
 	mocks "service-secret-santa/repositories/group/mock"
+	"service-secret-santa/resources/identity"
+	"service-secret-santa/resources/logging"
+	"service-secret-santa/resources/mail"
+	"service-secret-santa/resources/metrics"
+	"service-secret-santa/resources/tracing"
 
 	"github.com/golang/mock/gomock"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/stretchr/testify/assert"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 )
 
 func internalErrorExample() *customError.CustomError {
@@ -31,6 +44,7 @@ func MockUnmatchedGroup(n int) *models.Group {
 		Participants: []models.Participant{},
 		CreatedAt:    time.Date(2023, 12, 11, 0, 0, 0, 0, time.UTC),
 		UpdatedAt:    time.Date(2023, 12, 11, 0, 0, 0, 0, time.UTC),
+		Version:      1,
 	}
 	for i := 0; i < n; i++ {
 		i_str := strconv.Itoa(i)
@@ -45,17 +59,18 @@ func MockUnmatchedGroup(n int) *models.Group {
 func TestMatchParticipants_Success(t *testing.T) {
 	for i := 2; i < 70; i++ {
 		mockCtrl, mockRepo := setupTest(t)
-		service := NewGroupService(mockRepo)
+		service := NewGroupService(mockRepo, nil)
 
 		group := MockUnmatchedGroup(i)
 
-		mockRepo.EXPECT().GetGroupByID(group.Id.Hex()).Return(group, nil)
-		mockRepo.EXPECT().UpdateMatches(group.Id.Hex(), gomock.Any()).Return(nil)
+		mockRepo.EXPECT().GetGroupByID(gomock.Any(), group.Id.Hex()).Return(group, nil)
+		mockRepo.EXPECT().UpdateMatches(gomock.Any(), group.Id.Hex(), int64(1), gomock.Any()).Return(nil)
 
-		_, err := service.MatchParticipants(group.Id.Hex())
+		_, err := service.MatchParticipants(context.Background(), group.Id.Hex(), 1)
 
 		assert.Nil(t, err)
 		assert.Equal(t, len(group.Matches), i)
+		assert.Equal(t, int64(2), group.Version)
 
 		for _, m := range group.Matches {
 			assert.True(t, m.First != m.Second)
@@ -68,28 +83,846 @@ func TestMatchParticipants_Success(t *testing.T) {
 func TestMatchParticipants_NotEnoughParticipants(t *testing.T) {
 	mockCtrl, mockRepo := setupTest(t)
 	defer mockCtrl.Finish()
-	service := NewGroupService(mockRepo)
+	service := NewGroupService(mockRepo, nil)
 
 	group := MockUnmatchedGroup(1)
 
-	mockRepo.EXPECT().GetGroupByID(group.Id.Hex()).Return(group, nil)
+	mockRepo.EXPECT().GetGroupByID(gomock.Any(), group.Id.Hex()).Return(group, nil)
 
-	_, err := service.MatchParticipants(group.Id.Hex())
+	_, err := service.MatchParticipants(context.Background(), group.Id.Hex(), models.AnyVersion)
 
 	assert.Equal(t, err.Status, 400)
 }
 
+func TestMatchParticipants_VersionMismatch(t *testing.T) {
+	mockCtrl, mockRepo := setupTest(t)
+	defer mockCtrl.Finish()
+	service := NewGroupService(mockRepo, nil)
+
+	group := MockUnmatchedGroup(3)
+
+	mockRepo.EXPECT().GetGroupByID(gomock.Any(), group.Id.Hex()).Return(group, nil)
+
+	_, err := service.MatchParticipants(context.Background(), group.Id.Hex(), 7)
+
+	assert.Equal(t, err.Status, 412)
+}
+
+func TestMatchParticipants_NotesGroupLocale(t *testing.T) {
+	mockCtrl, mockRepo := setupTest(t)
+	defer mockCtrl.Finish()
+	service := NewGroupService(mockRepo, nil)
+
+	group := MockUnmatchedGroup(3)
+	group.Locale = "pt-BR"
+
+	mockRepo.EXPECT().GetGroupByID(gomock.Any(), group.Id.Hex()).Return(group, nil)
+
+	ctx := i18n.WithLocaleSlot(context.Background())
+	_, err := service.MatchParticipants(ctx, group.Id.Hex(), 7)
+
+	assert.Equal(t, err.Status, 412)
+	assert.Equal(t, "pt-BR", i18n.NotedLocale(ctx))
+}
+
+func TestMatchParticipants_ConcurrentChange(t *testing.T) {
+	mockCtrl, mockRepo := setupTest(t)
+	defer mockCtrl.Finish()
+	service := NewGroupService(mockRepo, nil)
+
+	group := MockUnmatchedGroup(3)
+	staleErr := customError.NewCustomError(customError.WithPreconditionFailed("Group version mismatch", "Modified"), customError.WithCode(customError.GroupVersionMismatch))
+
+	mockRepo.EXPECT().GetGroupByID(gomock.Any(), group.Id.Hex()).Return(group, nil)
+	mockRepo.EXPECT().UpdateMatches(gomock.Any(), group.Id.Hex(), int64(1), gomock.Any()).Return(staleErr)
+
+	_, err := service.MatchParticipants(context.Background(), group.Id.Hex(), 1)
+
+	assert.Equal(t, err.Status, 412)
+}
+
+func TestMatchParticipants_DrawsAgainAfterConcurrentChange(t *testing.T) {
+	mockCtrl, mockRepo := setupTest(t)
+	defer mockCtrl.Finish()
+	service := NewGroupService(mockRepo, nil)
+
+	stale := MockUnmatchedGroup(3)
+	current := MockUnmatchedGroup(4)
+	current.Id = stale.Id
+	current.Version = 2
+	staleErr := customError.NewCustomError(customError.WithPreconditionFailed("Group version mismatch", "Modified"), customError.WithCode(customError.GroupVersionMismatch))
+
+	gomock.InOrder(
+		mockRepo.EXPECT().GetGroupByID(gomock.Any(), stale.Id.Hex()).Return(stale, nil),
+		mockRepo.EXPECT().UpdateMatches(gomock.Any(), stale.Id.Hex(), int64(1), gomock.Any()).Return(staleErr),
+		mockRepo.EXPECT().GetGroupByID(gomock.Any(), stale.Id.Hex()).Return(current, nil),
+		mockRepo.EXPECT().UpdateMatches(gomock.Any(), stale.Id.Hex(), int64(2), gomock.Len(4)).Return(nil),
+	)
+
+	group, err := service.MatchParticipants(context.Background(), stale.Id.Hex(), models.AnyVersion)
+
+	assert.Nil(t, err)
+	assert.Len(t, group.Matches, 4)
+	assert.Equal(t, int64(3), group.Version)
+}
+
+func TestMatchParticipants_GivesUpAfterRepeatedChanges(t *testing.T) {
+	mockCtrl, mockRepo := setupTest(t)
+	defer mockCtrl.Finish()
+	service := NewGroupService(mockRepo, nil)
+
+	group := MockUnmatchedGroup(3)
+	staleErr := customError.NewCustomError(customError.WithPreconditionFailed("Group version mismatch", "Modified"), customError.WithCode(customError.GroupVersionMismatch))
+
+	mockRepo.EXPECT().GetGroupByID(gomock.Any(), group.Id.Hex()).Return(group, nil).Times(drawAttempts)
+	mockRepo.EXPECT().UpdateMatches(gomock.Any(), group.Id.Hex(), int64(1), gomock.Any()).Return(staleErr).Times(drawAttempts)
+
+	_, err := service.MatchParticipants(context.Background(), group.Id.Hex(), models.AnyVersion)
+
+	assert.Equal(t, err.Status, 412)
+}
+
 func TestMatchParticipants_DBError(t *testing.T) {
 	mockCtrl, mockRepo := setupTest(t)
 	defer mockCtrl.Finish()
-	service := NewGroupService(mockRepo)
+	service := NewGroupService(mockRepo, nil)
 
 	group := MockUnmatchedGroup(2)
 	mockErr := internalErrorExample()
 
-	mockRepo.EXPECT().GetGroupByID(group.Id.Hex()).Return(nil, mockErr)
+	mockRepo.EXPECT().GetGroupByID(gomock.Any(), group.Id.Hex()).Return(nil, mockErr)
 
-	_, err := service.MatchParticipants(group.Id.Hex())
+	_, err := service.MatchParticipants(context.Background(), group.Id.Hex(), models.AnyVersion)
 
 	assert.Equal(t, err.Status, 500)
 }
+
+func TestSearchGroups_RanksExactMatchesFirst(t *testing.T) {
+	mockCtrl, mockRepo := setupTest(t)
+	defer mockCtrl.Finish()
+	service := NewGroupService(mockRepo, nil)
+
+	candidates := []*models.Group{
+		{Name: "Amigos do João Pedro"},
+		{Name: "João e amigos"},
+		{Name: "João"},
+		{Name: "Outro grupo", Owner: "maria@gmail.com"},
+	}
+	mockRepo.EXPECT().SearchGroups(gomock.Any(), "Joao").Return(candidates, nil)
+
+	groups, err := service.SearchGroups(context.Background(), models.SearchQuery{Q: "Joao", Limit: 10})
+
+	assert.Nil(t, err)
+	assert.Len(t, groups, 3)
+	assert.Equal(t, "João", groups[0].Name)
+	assert.Equal(t, "João e amigos", groups[1].Name)
+	assert.Equal(t, "Amigos do João Pedro", groups[2].Name)
+}
+
+func TestSearchParticipants(t *testing.T) {
+	mockCtrl, mockRepo := setupTest(t)
+	defer mockCtrl.Finish()
+	service := NewGroupService(mockRepo, nil)
+
+	group := MockUnmatchedGroup(0)
+	group.Participants = []models.Participant{
+		{Name: "Joana", Email: "joana@gmail.com"},
+		{Name: "João", Email: "joao@gmail.com"},
+		{Name: "Mari", Email: "mari@gmail.com"},
+		{Name: "Conceição", Email: "ceicao@gmail.com"},
+	}
+	mockRepo.EXPECT().GetGroupByID(gomock.Any(), group.Id.Hex()).Return(group, nil).Times(3)
+
+	participants, err := service.SearchParticipants(context.Background(), group.Id.Hex(), models.SearchQuery{Q: "JOAO", Limit: 10})
+	assert.Nil(t, err)
+	assert.Equal(t, []models.Participant{group.Participants[1]}, participants)
+
+	participants, err = service.SearchParticipants(context.Background(), group.Id.Hex(), models.SearchQuery{Q: "gmail", Limit: 2})
+	assert.Nil(t, err)
+	assert.Len(t, participants, 2)
+
+	participants, err = service.SearchParticipants(context.Background(), group.Id.Hex(), models.SearchQuery{Q: "conceicao", Limit: 10})
+	assert.Nil(t, err)
+	assert.Equal(t, "Conceição", participants[0].Name)
+}
+
+func TestPatchGroup_AppliesPatchToCurrentFields(t *testing.T) {
+	mockCtrl, mockRepo := setupTest(t)
+	defer mockCtrl.Finish()
+	service := NewGroupService(mockRepo, nil)
+
+	group := MockUnmatchedGroup(2)
+	group.Owner = "p0@gmail.com"
+	updated := MockUnmatchedGroup(2)
+
+	rename := func(current models.GroupUpdate) (*models.GroupUpdate, *customError.CustomError) {
+		assert.Equal(t, models.NewGroupUpdate(group), current)
+		current.Name = "Renamed"
+		return &current, nil
+	}
+
+	mockRepo.EXPECT().GetGroupByID(gomock.Any(), group.Id.Hex()).Return(group, nil)
+	mockRepo.EXPECT().UpdateGroup(gomock.Any(), group.Id.Hex(), int64(1), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, id string, version int64, update *models.GroupUpdate) (*models.Group, *customError.CustomError) {
+			assert.Equal(t, "Renamed", update.Name)
+			assert.Equal(t, group.Owner, update.Owner)
+			assert.Equal(t, group.Participants, update.Participants)
+			assert.False(t, update.UpdatedAt.IsZero())
+			return updated, nil
+		})
+
+	result, err := service.PatchGroup(context.Background(), group.Id.Hex(), models.AnyVersion, rename)
+
+	assert.Nil(t, err)
+	assert.Equal(t, updated, result)
+}
+
+func TestPatchGroup_PatchError(t *testing.T) {
+	mockCtrl, mockRepo := setupTest(t)
+	defer mockCtrl.Finish()
+	service := NewGroupService(mockRepo, nil)
+
+	group := MockUnmatchedGroup(2)
+	invalid := func(current models.GroupUpdate) (*models.GroupUpdate, *customError.CustomError) {
+		return nil, customError.NewCustomError(customError.WithBadRequest("name: cannot be blank.", "Validation error"))
+	}
+
+	mockRepo.EXPECT().GetGroupByID(gomock.Any(), group.Id.Hex()).Return(group, nil)
+
+	_, err := service.PatchGroup(context.Background(), group.Id.Hex(), 1, invalid)
+	assert.Equal(t, err.Status, 400)
+}
+
+func TestPatchGroup_VersionMismatch(t *testing.T) {
+	mockCtrl, mockRepo := setupTest(t)
+	defer mockCtrl.Finish()
+	service := NewGroupService(mockRepo, nil)
+
+	group := MockUnmatchedGroup(2)
+
+	mockRepo.EXPECT().GetGroupByID(gomock.Any(), group.Id.Hex()).Return(group, nil)
+
+	_, err := service.PatchGroup(context.Background(), group.Id.Hex(), 5, nil)
+	assert.Equal(t, err.Status, 412)
+}
+
+func TestCreateGroup_RejectsDuplicateEmails(t *testing.T) {
+	mockCtrl, mockRepo := setupTest(t)
+	defer mockCtrl.Finish()
+	service := NewGroupService(mockRepo, nil)
+
+	group := MockUnmatchedGroup(2)
+	group.Participants = append(group.Participants, models.Participant{Name: "Pedro", Email: "P0@gmail.com"})
+
+	_, err := service.CreateGroup(context.Background(), group)
+	assert.Equal(t, 409, err.Status)
+	assert.Equal(t, customError.ParticipantDuplicate, err.Kind())
+}
+
+func TestUpdateGroup_RejectsDuplicateEmails(t *testing.T) {
+	mockCtrl, mockRepo := setupTest(t)
+	defer mockCtrl.Finish()
+	service := NewGroupService(mockRepo, nil)
+
+	update := &models.GroupUpdate{Name: "Test Group", Participants: []models.Participant{
+		{Name: "Ana", Email: "ana@gmail.com"},
+		{Name: "Bia"},
+		{Name: "Caio"},
+		{Name: "Ana Clara", Email: "ANA@gmail.com"},
+	}}
+
+	_, err := service.UpdateGroup(context.Background(), primitive.NewObjectID().Hex(), 1, update)
+	assert.Equal(t, customError.ParticipantDuplicate, err.Kind())
+}
+
+func drawsCounted(t *testing.T, result string) float64 {
+	families, err := prometheus.DefaultGatherer.Gather()
+	assert.NoError(t, err)
+
+	for _, family := range families {
+		if family.GetName() != "secret_santa_draws_total" {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			if metric.GetLabel()[0].GetValue() == result {
+				return metric.GetCounter().GetValue()
+			}
+		}
+	}
+	return 0
+}
+
+func TestInstrumentedService_CountsDrawsByResult(t *testing.T) {
+	mockCtrl, mockRepo := setupTest(t)
+	defer mockCtrl.Finish()
+	service := NewInstrumentedService(NewGroupService(mockRepo, nil))
+
+	drawn := MockUnmatchedGroup(3)
+	infeasible := MockUnmatchedGroup(1)
+	mockRepo.EXPECT().GetGroupByID(gomock.Any(), drawn.Id.Hex()).Return(drawn, nil)
+	mockRepo.EXPECT().UpdateMatches(gomock.Any(), drawn.Id.Hex(), gomock.Any(), gomock.Any()).Return(nil)
+	mockRepo.EXPECT().GetGroupByID(gomock.Any(), infeasible.Id.Hex()).Return(infeasible, nil)
+
+	drawnBefore, infeasibleBefore := drawsCounted(t, metrics.DrawDrawn), drawsCounted(t, metrics.DrawInfeasible)
+
+	_, err := service.MatchParticipants(context.Background(), drawn.Id.Hex(), models.AnyVersion)
+	assert.Nil(t, err)
+	_, err = service.MatchParticipants(context.Background(), infeasible.Id.Hex(), models.AnyVersion)
+	assert.NotNil(t, err)
+
+	assert.Equal(t, drawnBefore+1, drawsCounted(t, metrics.DrawDrawn))
+	assert.Equal(t, infeasibleBefore+1, drawsCounted(t, metrics.DrawInfeasible))
+}
+
+func TestTracedService_MatchParticipantsSpans(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	tracing.Setup(provider)
+	defer provider.Shutdown(context.Background())
+
+	mockCtrl, mockRepo := setupTest(t)
+	defer mockCtrl.Finish()
+	service := NewTracedService(NewGroupService(mockRepo, nil))
+
+	group := MockUnmatchedGroup(4)
+	mockRepo.EXPECT().GetGroupByID(gomock.Any(), group.Id.Hex()).Return(group, nil)
+	mockRepo.EXPECT().UpdateMatches(gomock.Any(), group.Id.Hex(), gomock.Any(), gomock.Any()).Return(nil)
+
+	_, err := service.MatchParticipants(context.Background(), group.Id.Hex(), models.AnyVersion)
+	assert.Nil(t, err)
+
+	spans := exporter.GetSpans()
+	if assert.Len(t, spans, 2) {
+		matcher, draw := spans[0], spans[1]
+		assert.Equal(t, "functions.RandomDerangement", matcher.Name)
+		assert.Equal(t, "group.Service/MatchParticipants", draw.Name)
+		assert.Equal(t, draw.SpanContext.SpanID(), matcher.Parent.SpanID())
+		assert.Contains(t, draw.Attributes, attribute.String("group.id", group.Id.Hex()))
+		assert.Contains(t, matcher.Attributes, attribute.Int("participants.count", 4))
+	}
+}
+
+func TestTrashPurger_PurgesPastRetention(t *testing.T) {
+	mockCtrl, mockRepo := setupTest(t)
+	defer mockCtrl.Finish()
+
+	purger := NewTrashPurger(mockRepo, 24*time.Hour, time.Hour)
+
+	mockRepo.EXPECT().PurgeTrash(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, deletedBefore time.Time) (int, *customError.CustomError) {
+		assert.WithinDuration(t, time.Now().Add(-24*time.Hour), deletedBefore, time.Minute)
+		return 2, nil
+	})
+	assert.NoError(t, purger.Purge(context.Background()))
+
+	mockRepo.EXPECT().PurgeTrash(gomock.Any(), gomock.Any()).Return(0, internalErrorExample())
+	assert.Error(t, purger.Purge(context.Background()))
+}
+
+func TestTrashPurger_RunsUntilStopped(t *testing.T) {
+	mockCtrl, mockRepo := setupTest(t)
+	defer mockCtrl.Finish()
+
+	purged := make(chan struct{}, 10)
+	mockRepo.EXPECT().PurgeTrash(gomock.Any(), gomock.Any()).DoAndReturn(func(context.Context, time.Time) (int, *customError.CustomError) {
+		purged <- struct{}{}
+		return 0, nil
+	}).MinTimes(2)
+
+	component := NewTrashPurger(mockRepo, time.Hour, 10*time.Millisecond).Component()
+	assert.NoError(t, component.Start(context.Background()))
+	<-purged
+	<-purged
+	assert.NoError(t, component.Stop(context.Background()))
+}
+
+type recordingSender struct {
+	sent []mail.Message
+	err  error
+}
+
+func (s *recordingSender) Send(_ context.Context, message mail.Message) error {
+	s.sent = append(s.sent, message)
+	return s.err
+}
+
+func TestRetentionJob_WarnsOwnersOnce(t *testing.T) {
+	mockCtrl, mockRepo := setupTest(t)
+	defer mockCtrl.Finish()
+
+	sender := &recordingSender{}
+	job := NewRetentionJob(mockRepo, sender, "en", 90*24*time.Hour, 7*24*time.Hour, time.Hour)
+
+	warned := &models.Group{Id: primitive.NewObjectID(), Name: "Amigos", Owner: "ana@example.com", Locale: "pt-BR", ExchangeDate: time.Now().Add(-85 * 24 * time.Hour)}
+	taken := &models.Group{Id: primitive.NewObjectID(), Name: "Outros", Owner: "bia@example.com"}
+	ownerless := &models.Group{Id: primitive.NewObjectID(), Name: "Sem dono"}
+
+	mockRepo.EXPECT().GetRetainedGroups(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, query models.RetentionQuery) ([]*models.Group, *customError.CustomError) {
+		assert.WithinDuration(t, time.Now().Add(-83*24*time.Hour), query.ExchangedBefore, time.Minute)
+		assert.True(t, query.WarnedBefore.IsZero())
+		return []*models.Group{warned, taken, ownerless}, nil
+	})
+	mockRepo.EXPECT().MarkPurgeWarned(gomock.Any(), warned.Id.Hex()).Return(true, nil)
+	mockRepo.EXPECT().MarkPurgeWarned(gomock.Any(), taken.Id.Hex()).Return(false, nil)
+	mockRepo.EXPECT().MarkPurgeWarned(gomock.Any(), ownerless.Id.Hex()).Return(true, nil)
+
+	var details []string
+	mockRepo.EXPECT().AppendAudit(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, event *models.AuditEvent) *customError.CustomError {
+		assert.Equal(t, models.AuditPurgeWarned, event.Action)
+		assert.Equal(t, models.AuditActorSystem, event.Actor)
+		details = append(details, event.Detail)
+		return nil
+	}).Times(2)
+
+	mockRepo.EXPECT().GetRetainedGroups(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, query models.RetentionQuery) ([]*models.Group, *customError.CustomError) {
+		assert.WithinDuration(t, time.Now().Add(-90*24*time.Hour), query.ExchangedBefore, time.Minute)
+		assert.WithinDuration(t, time.Now().Add(-7*24*time.Hour), query.WarnedBefore, time.Minute)
+		return []*models.Group{}, nil
+	})
+
+	assert.NoError(t, job.Run(context.Background()))
+
+	if assert.Len(t, sender.sent, 1) {
+		assert.Equal(t, "ana@example.com", sender.sent[0].To)
+		assert.Contains(t, sender.sent[0].Subject, "Amigos")
+		assert.Contains(t, sender.sent[0].Body, "pseudônimos")
+		assert.Contains(t, sender.sent[0].Body, time.Now().Add(7*24*time.Hour).UTC().Format("2006-01-02"))
+	}
+	assert.Equal(t, []string{"owner notified", "no owner to notify"}, details)
+}
+
+func TestRetentionJob_FailedWarningIsRetried(t *testing.T) {
+	mockCtrl, mockRepo := setupTest(t)
+	defer mockCtrl.Finish()
+
+	sender := &recordingSender{err: errors.New("connection refused")}
+	job := NewRetentionJob(mockRepo, sender, "en", 90*24*time.Hour, 7*24*time.Hour, time.Hour)
+
+	g := &models.Group{Id: primitive.NewObjectID(), Name: "Amigos", Owner: "ana@example.com", CreatedAt: time.Now().Add(-100 * 24 * time.Hour), PurgeWarnFailures: 1}
+	mockRepo.EXPECT().GetRetainedGroups(gomock.Any(), gomock.Any()).Return([]*models.Group{g}, nil)
+	mockRepo.EXPECT().MarkPurgeWarned(gomock.Any(), g.Id.Hex()).Return(true, nil)
+	mockRepo.EXPECT().UnmarkPurgeWarned(gomock.Any(), g.Id.Hex()).Return(nil)
+	mockRepo.EXPECT().AppendAudit(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, event *models.AuditEvent) *customError.CustomError {
+		assert.Equal(t, "owner notification failed, attempt 2 of 3", event.Detail)
+		return nil
+	})
+	mockRepo.EXPECT().GetRetainedGroups(gomock.Any(), gomock.Any()).Return([]*models.Group{}, nil)
+
+	assert.EqualError(t, job.Run(context.Background()), "groups whose owner could not be warned: 1")
+
+	if assert.Len(t, sender.sent, 1) {
+		assert.Contains(t, sender.sent[0].Body, "replaced by pseudonyms")
+	}
+}
+
+func TestRetentionJob_GivesUpWarningAfterAttempts(t *testing.T) {
+	mockCtrl, mockRepo := setupTest(t)
+	defer mockCtrl.Finish()
+
+	sender := &recordingSender{err: errors.New("connection refused")}
+	job := NewRetentionJob(mockRepo, sender, "en", 90*24*time.Hour, 7*24*time.Hour, time.Hour)
+
+	g := &models.Group{Id: primitive.NewObjectID(), Name: "Amigos", Owner: "ana@example.com", CreatedAt: time.Now().Add(-100 * 24 * time.Hour), PurgeWarnFailures: warningAttempts - 1}
+	mockRepo.EXPECT().GetRetainedGroups(gomock.Any(), gomock.Any()).Return([]*models.Group{g}, nil)
+	mockRepo.EXPECT().MarkPurgeWarned(gomock.Any(), g.Id.Hex()).Return(true, nil)
+	mockRepo.EXPECT().AppendAudit(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, event *models.AuditEvent) *customError.CustomError {
+		assert.Equal(t, "owner notification failed 3 times, anonymizing without it", event.Detail)
+		return nil
+	})
+	mockRepo.EXPECT().GetRetainedGroups(gomock.Any(), gomock.Any()).Return([]*models.Group{}, nil)
+
+	job.Run(context.Background())
+
+	assert.Len(t, sender.sent, 1)
+}
+
+func TestRetentionJob_AnonymizesAndAudits(t *testing.T) {
+	mockCtrl, mockRepo := setupTest(t)
+	defer mockCtrl.Finish()
+
+	job := NewRetentionJob(mockRepo, &recordingSender{}, "en", 90*24*time.Hour, 7*24*time.Hour, time.Hour)
+
+	done := &models.Group{Id: primitive.NewObjectID()}
+	failed := &models.Group{Id: primitive.NewObjectID()}
+	unredacted := &models.Group{Id: primitive.NewObjectID()}
+
+	mockRepo.EXPECT().GetRetainedGroups(gomock.Any(), gomock.Any()).Return(nil, internalErrorExample())
+	mockRepo.EXPECT().GetRetainedGroups(gomock.Any(), gomock.Any()).Return([]*models.Group{done, failed, unredacted}, nil)
+	created := models.AuditEvent{Id: primitive.NewObjectID(), GroupId: done.Id, Action: models.AuditCreated, Actor: "ana@example.com",
+		Changes: []models.AuditChange{{Field: "name", After: `"Amigos"`}, {Field: "owner", After: `"ana@example.com"`}, {Field: "participants", After: `["Ana","Bia"]`}}}
+	warned := models.AuditEvent{Id: primitive.NewObjectID(), GroupId: done.Id, Action: models.AuditPurgeWarned, Actor: models.AuditActorSystem, Detail: "owner notified"}
+	mockRepo.EXPECT().GetAuditEvents(gomock.Any(), done.Id.Hex(), models.AuditQuery{}).Return([]models.AuditEvent{created, warned}, nil)
+	mockRepo.EXPECT().RewriteAuditEvents(gomock.Any(), []models.AuditEvent{{Id: created.Id, GroupId: done.Id, Action: models.AuditCreated, Actor: models.AuditRedacted,
+		Changes: []models.AuditChange{{Field: "name", After: `"Amigos"`}, {Field: "owner", After: `"redacted"`}, {Field: "participants", After: `["redacted","redacted"]`}}}}).Return(nil)
+	mockRepo.EXPECT().GetAuditEvents(gomock.Any(), failed.Id.Hex(), models.AuditQuery{}).Return([]models.AuditEvent{}, nil)
+	mockRepo.EXPECT().GetAuditEvents(gomock.Any(), unredacted.Id.Hex(), models.AuditQuery{}).Return(nil, internalErrorExample())
+	mockRepo.EXPECT().AnonymizeGroup(gomock.Any(), done.Id.Hex()).Return(&models.Group{
+		Id:           done.Id,
+		Participants: []models.Participant{{Name: "Participant 1"}, {Name: "Participant 2"}},
+		Matches:      []models.Match{{First: "Participant 1", Second: "Participant 2"}, {First: "Participant 2", Second: "Participant 1"}},
+	}, nil)
+	mockRepo.EXPECT().AnonymizeGroup(gomock.Any(), failed.Id.Hex()).Return(nil, internalErrorExample())
+	mockRepo.EXPECT().AppendAudit(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, event *models.AuditEvent) *customError.CustomError {
+		assert.Equal(t, done.Id, event.GroupId)
+		assert.Equal(t, models.AuditAnonymized, event.Action)
+		assert.Equal(t, "2 participants, 2 matches", event.Detail)
+		return nil
+	})
+
+	err := job.Run(context.Background())
+	assert.ErrorContains(t, err, "groups that could not be anonymized: 2")
+	assert.ErrorContains(t, err, "generic service error")
+}
+
+// assertDerangement checks that matches give every participant exactly one
+// receiver other than themselves and exactly one giver.
+func assertDerangement(t *testing.T, participants []string, matches []models.Match) {
+	t.Helper()
+	gives, receives := map[string]int{}, map[string]int{}
+	for _, m := range matches {
+		assert.NotEqual(t, m.First, m.Second)
+		gives[m.First]++
+		receives[m.Second]++
+	}
+	for _, name := range participants {
+		assert.Equal(t, 1, gives[name], "%s gives", name)
+		assert.Equal(t, 1, receives[name], "%s receives", name)
+	}
+	assert.Len(t, matches, len(participants))
+}
+
+func TestRepairDraw(t *testing.T) {
+	for name, tc := range map[string]struct {
+		matches   []models.Match
+		erased    []string
+		remaining []string
+		kept      []models.Match
+	}{
+		"giver of the erased gives to their receiver": {
+			matches:   []models.Match{{First: "A", Second: "B"}, {First: "B", Second: "C"}, {First: "C", Second: "D"}, {First: "D", Second: "A"}},
+			erased:    []string{"B"},
+			remaining: []string{"A", "C", "D"},
+			kept:      []models.Match{{First: "C", Second: "D"}, {First: "D", Second: "A"}},
+		},
+		"chains of erased participants": {
+			matches:   []models.Match{{First: "A", Second: "B"}, {First: "B", Second: "C"}, {First: "C", Second: "D"}, {First: "D", Second: "A"}},
+			erased:    []string{"B", "C"},
+			remaining: []string{"A", "D"},
+			kept:      []models.Match{{First: "D", Second: "A"}},
+		},
+		"self match swaps with another giver": {
+			matches:   []models.Match{{First: "A", Second: "B"}, {First: "B", Second: "A"}, {First: "C", Second: "D"}, {First: "D", Second: "C"}},
+			erased:    []string{"B"},
+			remaining: []string{"A", "C", "D"},
+			kept:      []models.Match{{First: "D", Second: "C"}},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			erased := map[string]bool{}
+			for _, e := range tc.erased {
+				erased[e] = true
+			}
+
+			repaired := repairDraw(tc.matches, erased)
+
+			assertDerangement(t, tc.remaining, repaired)
+			for _, m := range tc.kept {
+				assert.Contains(t, repaired, m)
+			}
+		})
+	}
+}
+
+func TestEraseSubject_RepairsDrawsAndRecordsCertificate(t *testing.T) {
+	mockCtrl, mockRepo := setupTest(t)
+	defer mockCtrl.Finish()
+	key := bytes.Repeat([]byte{9}, 32)
+	service := NewGroupService(mockRepo, key)
+
+	drawn := &models.Group{Id: primitive.NewObjectID(), Owner: "ANA@example.com", Status: models.GroupStatusDrawn, Version: 3,
+		Participants: []models.Participant{{Name: "Ana", Email: "ana@example.com"}, {Name: "Bia", Email: "bia@example.com"}, {Name: "Caio", Email: "caio@example.com"}},
+		Matches:      []models.Match{{First: "Ana", Second: "Bia"}, {First: "Bia", Second: "Caio"}, {First: "Caio", Second: "Ana"}},
+	}
+	pair := &models.Group{Id: primitive.NewObjectID(), Owner: "bia@example.com", Status: models.GroupStatusDrawn, Version: 1,
+		Participants: []models.Participant{{Name: "Ana", Email: "ana@example.com"}, {Name: "Bia", Email: "bia@example.com"}},
+		Matches:      []models.Match{{First: "Ana", Second: "Bia"}, {First: "Bia", Second: "Ana"}},
+	}
+
+	mockRepo.EXPECT().GetGroupsByEmail(gomock.Any(), "ana@example.com").Return([]*models.Group{drawn, pair}, nil)
+	added := models.AuditEvent{Id: primitive.NewObjectID(), GroupId: drawn.Id, Action: models.AuditParticipantAdded, Actor: "caio@example.com", Detail: "Ana",
+		Changes: []models.AuditChange{{Field: "participants", After: `"Ana"`}}}
+	renamed := models.AuditEvent{Id: primitive.NewObjectID(), GroupId: drawn.Id, Action: models.AuditUpdated, Actor: "bia@example.com",
+		Changes: []models.AuditChange{{Field: "owner", Before: `"bia@example.com"`, After: `"ana@example.com"`}}}
+	mockRepo.EXPECT().GetAuditEvents(gomock.Any(), drawn.Id.Hex(), models.AuditQuery{}).Return([]models.AuditEvent{added, renamed}, nil)
+	mockRepo.EXPECT().RewriteAuditEvents(gomock.Any(), []models.AuditEvent{
+		{Id: added.Id, GroupId: drawn.Id, Action: models.AuditParticipantAdded, Actor: "caio@example.com", Detail: models.AuditRedacted,
+			Changes: []models.AuditChange{{Field: "participants", After: `"redacted"`}}},
+		{Id: renamed.Id, GroupId: drawn.Id, Action: models.AuditUpdated, Actor: "bia@example.com",
+			Changes: []models.AuditChange{{Field: "owner", Before: `"bia@example.com"`, After: `"redacted"`}}},
+	}).Return(nil)
+	mockRepo.EXPECT().GetAuditEvents(gomock.Any(), pair.Id.Hex(), models.AuditQuery{}).Return([]models.AuditEvent{}, nil)
+	elsewhere := models.AuditEvent{Id: primitive.NewObjectID(), GroupId: primitive.NewObjectID(), Action: models.AuditDeleted, Actor: "ana@example.com"}
+	mockRepo.EXPECT().GetAuditEventsByActor(gomock.Any(), "ana@example.com").Return([]models.AuditEvent{elsewhere}, nil)
+	mockRepo.EXPECT().RewriteAuditEvents(gomock.Any(), []models.AuditEvent{{Id: elsewhere.Id, GroupId: elsewhere.GroupId, Action: models.AuditDeleted, Actor: models.AuditRedacted}}).Return(nil)
+	mockRepo.EXPECT().RewriteGroup(gomock.Any(), drawn.Id.Hex(), int64(3), gomock.Any()).DoAndReturn(func(_ context.Context, _ string, _ int64, rewrite *models.GroupRewrite) (*models.Group, *customError.CustomError) {
+		assert.Equal(t, "", rewrite.Owner)
+		assert.Equal(t, models.GroupStatusDrawn, rewrite.Status)
+		assert.Equal(t, []models.Participant{{Name: "Bia", Email: "bia@example.com"}, {Name: "Caio", Email: "caio@example.com"}}, rewrite.Participants)
+		assert.ElementsMatch(t, []models.Match{{First: "Bia", Second: "Caio"}, {First: "Caio", Second: "Bia"}}, rewrite.Matches)
+		return &models.Group{}, nil
+	})
+	mockRepo.EXPECT().RewriteGroup(gomock.Any(), pair.Id.Hex(), int64(1), gomock.Any()).DoAndReturn(func(_ context.Context, _ string, _ int64, rewrite *models.GroupRewrite) (*models.Group, *customError.CustomError) {
+		assert.Equal(t, "bia@example.com", rewrite.Owner)
+		assert.Equal(t, models.GroupStatusOpen, rewrite.Status)
+		assert.Empty(t, rewrite.Matches)
+		return &models.Group{}, nil
+	})
+	mockRepo.EXPECT().RecordErasure(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, certificate *models.ErasureCertificate) *customError.CustomError {
+		assert.Equal(t, SubjectHash(key, "Ana@Example.com"), certificate.SubjectHash)
+		assert.Equal(t, []string{drawn.Id.Hex(), pair.Id.Hex()}, certificate.Groups)
+		assert.Equal(t, 2, certificate.ParticipantsRemoved)
+		assert.Equal(t, 2, certificate.DrawsRepaired)
+		certificate.Id = primitive.NewObjectID()
+		return nil
+	})
+	mockRepo.EXPECT().AppendAudit(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, event *models.AuditEvent) *customError.CustomError {
+		assert.Equal(t, models.AuditErased, event.Action)
+		assert.Equal(t, models.AuditActorAdmin, event.Actor)
+		return nil
+	}).Times(2)
+
+	certificate, err := service.EraseSubject(context.Background(), "ana@example.com")
+
+	assert.Nil(t, err)
+	assert.False(t, certificate.ErasedAt.IsZero())
+}
+
+func TestSubjectHash(t *testing.T) {
+	key := bytes.Repeat([]byte{9}, 32)
+	hash := SubjectHash(key, " Ana@Example.com")
+	assert.Len(t, hash, 64)
+	assert.Equal(t, hash, SubjectHash(key, "ana@example.com"))
+	assert.NotEqual(t, hash, SubjectHash(bytes.Repeat([]byte{8}, 32), "ana@example.com"))
+
+	// The plain SHA-256 of the email, which anyone could compute.
+	assert.NotEqual(t, "8e43ca37701228e74983efdbd0cff5c16b3b1e5d4e29a7c05626d4d25a018e11", hash)
+	assert.Equal(t, "", SubjectHash(nil, "ana@example.com"))
+}
+
+func TestEraseSubject_ReadsChangedGroupsAgain(t *testing.T) {
+	mockCtrl, mockRepo := setupTest(t)
+	defer mockCtrl.Finish()
+	service := NewGroupService(mockRepo, nil)
+
+	stale := &models.Group{Id: primitive.NewObjectID(), Status: models.GroupStatusOpen, Version: 1, Participants: []models.Participant{{Name: "Ana", Email: "ana@example.com"}}}
+	fresh := &models.Group{Id: stale.Id, Status: models.GroupStatusOpen, Version: 2, Participants: []models.Participant{{Name: "Ana", Email: "ana@example.com"}, {Name: "Bia", Email: "bia@example.com"}}}
+	staleErr := customError.NewCustomError(customError.WithPreconditionFailed("version 1", "Group was modified"), customError.WithCode(customError.GroupVersionMismatch))
+
+	mockRepo.EXPECT().GetAuditEvents(gomock.Any(), stale.Id.Hex(), models.AuditQuery{}).Return([]models.AuditEvent{}, nil).Times(2)
+	mockRepo.EXPECT().GetAuditEventsByActor(gomock.Any(), "ana@example.com").Return([]models.AuditEvent{}, nil)
+	gomock.InOrder(
+		mockRepo.EXPECT().GetGroupsByEmail(gomock.Any(), "ana@example.com").Return([]*models.Group{stale}, nil),
+		mockRepo.EXPECT().RewriteGroup(gomock.Any(), stale.Id.Hex(), int64(1), gomock.Any()).Return(nil, staleErr),
+		mockRepo.EXPECT().GetGroupsByEmail(gomock.Any(), "ana@example.com").Return([]*models.Group{fresh}, nil),
+		mockRepo.EXPECT().RewriteGroup(gomock.Any(), stale.Id.Hex(), int64(2), gomock.Any()).Return(&models.Group{}, nil),
+	)
+	mockRepo.EXPECT().RecordErasure(gomock.Any(), gomock.Any()).Return(nil)
+	mockRepo.EXPECT().AppendAudit(gomock.Any(), gomock.Any()).Return(nil)
+
+	certificate, err := service.EraseSubject(context.Background(), "ana@example.com")
+
+	assert.Nil(t, err)
+	assert.Equal(t, []string{stale.Id.Hex()}, certificate.Groups)
+	assert.Equal(t, 1, certificate.ParticipantsRemoved)
+}
+
+func TestExportSubject_PicksEntriesOfTheEmail(t *testing.T) {
+	mockCtrl, mockRepo := setupTest(t)
+	defer mockCtrl.Finish()
+	service := NewGroupService(mockRepo, nil)
+
+	g := &models.Group{Id: primitive.NewObjectID(), Owner: "bia@example.com",
+		Participants: []models.Participant{{Name: "Ána", Email: "Ana@example.com"}, {Name: "Bia", Email: "bia@example.com"}, {Name: "Caio", Email: "caio@example.com"}},
+		Matches:      []models.Match{{First: "Ána", Second: "Bia"}, {First: "Bia", Second: "Caio"}, {First: "Caio", Second: "Ána"}},
+	}
+	mockRepo.EXPECT().GetGroupsByEmail(gomock.Any(), "ana@example.com").Return([]*models.Group{g}, nil)
+	start := time.Now().Add(-time.Hour)
+	created := models.AuditEvent{Id: primitive.NewObjectID(), GroupId: g.Id, Action: models.AuditCreated, Actor: "bia@example.com", At: start,
+		Changes: []models.AuditChange{{Field: "participants", After: `["Ána","Bia"]`}}}
+	drawn := models.AuditEvent{Id: primitive.NewObjectID(), GroupId: g.Id, Action: models.AuditDrawn, Actor: "bia@example.com", At: start.Add(time.Minute)}
+	revealed := models.AuditEvent{Id: primitive.NewObjectID(), GroupId: g.Id, Action: models.AuditRevealed, Actor: "ana@example.com", At: start.Add(2 * time.Minute), Detail: "ana"}
+	elsewhere := models.AuditEvent{Id: primitive.NewObjectID(), GroupId: primitive.NewObjectID(), Action: models.AuditUpdated, Actor: "ana@example.com", At: start.Add(3 * time.Minute)}
+	mockRepo.EXPECT().GetAuditEvents(gomock.Any(), g.Id.Hex(), models.AuditQuery{}).Return([]models.AuditEvent{created, drawn, revealed}, nil)
+	mockRepo.EXPECT().GetAuditEventsByActor(gomock.Any(), "ana@example.com").Return([]models.AuditEvent{revealed, elsewhere}, nil)
+
+	export, err := service.ExportSubject(context.Background(), "ana@example.com")
+
+	assert.Nil(t, err)
+	if assert.Len(t, export.Groups, 1) {
+		assert.False(t, export.Groups[0].Owner)
+		assert.Equal(t, []models.Participant{{Name: "Ána", Email: "Ana@example.com"}}, export.Groups[0].Participants)
+		assert.Equal(t, []models.Match{{First: "Ána", Second: "Bia"}, {First: "Caio", Second: "Ána"}}, export.Groups[0].Matches)
+	}
+	assert.Equal(t, []models.AuditEvent{created, revealed, elsewhere}, export.Audit)
+}
+
+func TestAuditPerson_Redact(t *testing.T) {
+	person := subjectPerson("Ana@Example.com", []models.Participant{{Name: "João Pedro", Email: "ana@example.com"}})
+
+	created := models.AuditEvent{Action: models.AuditCreated, Actor: models.AuditActorAnonymous,
+		Changes: []models.AuditChange{{Field: "participants", After: `["João Pedro","Joao Pedro"]`}, {Field: "name", After: `"João Pedro"`}}}
+	assert.True(t, person.redact(&created))
+	assert.Equal(t, models.AuditActorAnonymous, created.Actor)
+	assert.Equal(t, []models.AuditChange{{Field: "participants", After: `["redacted","Joao Pedro"]`}, {Field: "name", After: `"João Pedro"`}}, created.Changes)
+
+	other := models.AuditEvent{Action: models.AuditParticipantRemoved, Actor: "bia@example.com", Detail: "Joao Pedro"}
+	assert.False(t, person.mentions(other))
+	assert.False(t, person.redact(&other))
+	assert.Equal(t, "Joao Pedro", other.Detail)
+
+	// A revealed event keeps the name as typed.
+	revealed := models.AuditEvent{Action: models.AuditRevealed, Actor: "ana@example.com", Detail: "joão  pedro"}
+	assert.True(t, person.redact(&revealed))
+	assert.Equal(t, models.AuditEvent{Action: models.AuditRevealed, Actor: models.AuditRedacted, Detail: models.AuditRedacted}, revealed)
+
+	anyone := models.AuditEvent{Action: models.AuditParticipantAdded, Actor: "bia@example.com", Detail: "Caio", Changes: []models.AuditChange{{Field: "participants", After: `"Caio"`}}}
+	assert.True(t, everybody.redact(&anyone))
+	assert.Equal(t, models.AuditEvent{Action: models.AuditParticipantAdded, Actor: models.AuditRedacted, Detail: models.AuditRedacted,
+		Changes: []models.AuditChange{{Field: "participants", After: `"redacted"`}}}, anyone)
+	assert.False(t, everybody.redact(&anyone), "redacting twice changes nothing")
+}
+
+func TestSubjectEntries_NamesDifferingOnlyByAccent(t *testing.T) {
+	g := &models.Group{Id: primitive.NewObjectID(), Status: models.GroupStatusDrawn,
+		Participants: []models.Participant{{Name: "João Pedro", Email: "a@x"}, {Name: "Joao Pedro", Email: "b@x"}, {Name: "Caio", Email: "c@x"}, {Name: "Dani", Email: "d@x"}},
+		Matches: []models.Match{
+			{First: "João Pedro", Second: "Caio"}, {First: "Caio", Second: "Joao Pedro"},
+			{First: "Joao Pedro", Second: "Dani"}, {First: "Dani", Second: "João Pedro"},
+		},
+	}
+
+	subject := subjectGroup(g, "a@x")
+	assert.Equal(t, []models.Participant{{Name: "João Pedro", Email: "a@x"}}, subject.Participants)
+	assert.Equal(t, []models.Match{{First: "João Pedro", Second: "Caio"}, {First: "Dani", Second: "João Pedro"}}, subject.Matches)
+
+	rewrite, removed := eraseFrom(g, "a@x")
+	assert.Equal(t, 1, removed)
+	assert.ElementsMatch(t, []models.Match{{First: "Caio", Second: "Joao Pedro"}, {First: "Joao Pedro", Second: "Dani"}, {First: "Dani", Second: "Caio"}}, rewrite.Matches)
+}
+
+// recordAudit collects the events appended to mockRepo.
+func recordAudit(mockRepo *mocks.MockRepository) *[]models.AuditEvent {
+	var events []models.AuditEvent
+	mockRepo.EXPECT().AppendAudit(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, event *models.AuditEvent) *customError.CustomError {
+		events = append(events, *event)
+		return nil
+	}).AnyTimes()
+	return &events
+}
+
+func TestAuditedService_RecordsUpdateDiff(t *testing.T) {
+	mockCtrl, mockRepo := setupTest(t)
+	defer mockCtrl.Finish()
+
+	service := NewAuditedService(NewGroupService(mockRepo, nil), mockRepo)
+	events := recordAudit(mockRepo)
+
+	before := MockUnmatchedGroup(3)
+	after := *before
+	after.Name = "Família"
+	after.Participants = []models.Participant{before.Participants[0], before.Participants[2], {Name: "Novo", Email: "novo@gmail.com"}}
+	after.Version = 2
+	mockRepo.EXPECT().GetGroupByID(gomock.Any(), before.Id.Hex()).Return(before, nil)
+	mockRepo.EXPECT().UpdateGroup(gomock.Any(), before.Id.Hex(), int64(1), gomock.Any()).Return(&after, nil)
+
+	ctx := identity.WithUser(logging.WithRequestID(context.Background(), "req-1"), "owner@gmail.com")
+	_, err := service.UpdateGroup(ctx, before.Id.Hex(), 1, &models.GroupUpdate{Name: after.Name, Participants: after.Participants})
+	assert.Nil(t, err)
+
+	if assert.Len(t, *events, 3) {
+		updated := (*events)[0]
+		assert.Equal(t, models.AuditUpdated, updated.Action)
+		assert.Equal(t, "owner@gmail.com", updated.Actor)
+		assert.Equal(t, "req-1", updated.RequestId)
+		assert.Equal(t, []models.AuditChange{{Field: "name", Before: `"Test Group"`, After: `"Família"`}}, updated.Changes)
+
+		assert.Equal(t, models.AuditParticipantRemoved, (*events)[1].Action)
+		assert.Equal(t, "P1", (*events)[1].Detail)
+		assert.Equal(t, models.AuditParticipantAdded, (*events)[2].Action)
+		assert.Equal(t, []models.AuditChange{{Field: "participants", After: `"Novo"`}}, (*events)[2].Changes)
+	}
+	for _, event := range *events {
+		assert.NotContains(t, event.Detail+event.Changes[0].Before+event.Changes[0].After, "@gmail.com")
+	}
+}
+
+func TestAuditedService_RecordsRedrawWithoutMatches(t *testing.T) {
+	mockCtrl, mockRepo := setupTest(t)
+	defer mockCtrl.Finish()
+
+	service := NewAuditedService(NewGroupService(mockRepo, nil), mockRepo)
+	events := recordAudit(mockRepo)
+
+	group := MockUnmatchedGroup(3)
+	group.Status = models.GroupStatusDrawn
+	mockRepo.EXPECT().GetGroupByID(gomock.Any(), group.Id.Hex()).Return(group, nil).Times(2)
+	mockRepo.EXPECT().UpdateMatches(gomock.Any(), group.Id.Hex(), int64(1), gomock.Any()).Return(nil)
+
+	_, err := service.MatchParticipants(context.Background(), group.Id.Hex(), models.AnyVersion)
+	assert.Nil(t, err)
+
+	if assert.Len(t, *events, 1) {
+		assert.Equal(t, models.AuditRedrawn, (*events)[0].Action)
+		assert.Equal(t, models.AuditActorAnonymous, (*events)[0].Actor)
+		assert.Equal(t, "3 matches", (*events)[0].Detail)
+		assert.Empty(t, (*events)[0].Changes)
+	}
+}
+
+func TestAuditedService_FailedChangesAreNotRecorded(t *testing.T) {
+	mockCtrl, mockRepo := setupTest(t)
+	defer mockCtrl.Finish()
+
+	service := NewAuditedService(NewGroupService(mockRepo, nil), mockRepo)
+	events := recordAudit(mockRepo)
+
+	id := primitive.NewObjectID().Hex()
+	mockRepo.EXPECT().DeleteGroup(gomock.Any(), id, int64(1)).Return(internalErrorExample())
+	mockRepo.EXPECT().GetMyMatch(gomock.Any(), id, "Ana").Return("Bia", nil)
+
+	assert.NotNil(t, service.DeleteGroup(context.Background(), id, 1))
+	_, err := service.GetMyMatch(context.Background(), id, "Ana")
+	assert.Nil(t, err)
+
+	if assert.Len(t, *events, 1) {
+		assert.Equal(t, models.AuditRevealed, (*events)[0].Action)
+		assert.Equal(t, "Ana", (*events)[0].Detail)
+	}
+}
+
+func TestGetAudit_OnlyTheOrganizer(t *testing.T) {
+	mockCtrl, mockRepo := setupTest(t)
+	defer mockCtrl.Finish()
+
+	service := NewGroupService(mockRepo, nil)
+	live := &models.Group{Id: primitive.NewObjectID(), Owner: "Owner@gmail.com"}
+	trashed := &models.Group{Id: primitive.NewObjectID(), Owner: "owner@gmail.com"}
+	query := models.AuditQuery{Actor: "Owner@gmail.com", Limit: 10}
+
+	_, err := service.GetAudit(context.Background(), live.Id.Hex(), "", query)
+	assert.Equal(t, customError.Forbidden, err.Kind())
+
+	mockRepo.EXPECT().GetGroupByID(gomock.Any(), live.Id.Hex()).Return(live, nil).Times(2)
+	_, err = service.GetAudit(context.Background(), live.Id.Hex(), "other@gmail.com", query)
+	assert.Equal(t, customError.Forbidden, err.Kind())
+
+	mockRepo.EXPECT().GetAuditEvents(gomock.Any(), live.Id.Hex(), models.AuditQuery{Actor: "owner@gmail.com", Limit: 10}).Return([]models.AuditEvent{}, nil)
+	_, err = service.GetAudit(context.Background(), live.Id.Hex(), "owner@gmail.com", query)
+	assert.Nil(t, err)
+
+	notFound := customError.NewCustomError(customError.WithNotFound("Group not found", "No group found with the given ID"), customError.WithCode(customError.GroupNotFound))
+	mockRepo.EXPECT().GetGroupByID(gomock.Any(), trashed.Id.Hex()).Return(nil, notFound)
+	mockRepo.EXPECT().GetTrash(gomock.Any(), "owner@gmail.com").Return([]*models.Group{trashed}, nil)
+	mockRepo.EXPECT().GetAuditEvents(gomock.Any(), trashed.Id.Hex(), gomock.Any()).Return([]models.AuditEvent{}, nil)
+	_, err = service.GetAudit(context.Background(), trashed.Id.Hex(), "owner@gmail.com", query)
+	assert.Nil(t, err)
+}
@@ -0,0 +1,258 @@
+package group
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"log/slog"
+	"sort"
+	"strings"
+	"time"
+
+	"service-secret-santa/customError"
+	"service-secret-santa/models"
+	"service-secret-santa/resources/logging"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ExportSubject gathers what every group, in the trash or not, holds about
+// the person behind email, and the audit events that name them or that they
+// made, in any group.
+func (r *resource) ExportSubject(ctx context.Context, email string) (*models.SubjectExport, *customError.CustomError) {
+	groups, err := r.repo.GetGroupsByEmail(ctx, email)
+	if err != nil {
+		return nil, err
+	}
+
+	export := &models.SubjectExport{Email: email, ExportedAt: time.Now(), Groups: make([]models.SubjectGroup, 0, len(groups)), Audit: []models.AuditEvent{}}
+	seen := make(map[primitive.ObjectID]bool)
+	for _, g := range groups {
+		subject := subjectGroup(g, email)
+		export.Groups = append(export.Groups, subject)
+
+		events, err := r.repo.GetAuditEvents(ctx, g.Id.Hex(), models.AuditQuery{})
+		if err != nil {
+			return nil, err
+		}
+		person := subjectPerson(email, subject.Participants)
+		for _, event := range events {
+			if person.mentions(event) {
+				export.Audit = append(export.Audit, event)
+				seen[event.Id] = true
+			}
+		}
+	}
+
+	acted, err := r.repo.GetAuditEventsByActor(ctx, strings.ToLower(strings.TrimSpace(email)))
+	if err != nil {
+		return nil, err
+	}
+	for _, event := range acted {
+		if !seen[event.Id] {
+			export.Audit = append(export.Audit, event)
+		}
+	}
+	sort.SliceStable(export.Audit, func(i, j int) bool { return export.Audit[i].At.Before(export.Audit[j].At) })
+
+	return export, nil
+}
+
+// subjectGroup picks out of g the entries about email. Matches refer to
+// participants by the name the draw stored, compared exactly: names that
+// only normalise alike, e.g. "João" and "Joao", are different people.
+func subjectGroup(g *models.Group, email string) models.SubjectGroup {
+	subject := models.SubjectGroup{Group: g, Owner: strings.EqualFold(g.Owner, email)}
+
+	names := make(map[string]bool)
+	for _, participant := range g.Participants {
+		if strings.EqualFold(participant.Email, email) {
+			subject.Participants = append(subject.Participants, participant)
+			names[participant.Name] = true
+		}
+	}
+	for _, match := range g.Matches {
+		if names[match.First] || names[match.Second] {
+			subject.Matches = append(subject.Matches, match)
+		}
+	}
+
+	return subject
+}
+
+// EraseSubject removes the person behind email from every group, in the
+// trash or not, and clears them as owner. A drawn group keeps the matches of
+// everyone else where it can: whoever gave to the erased person now gives to
+// whom they gave. Their email and names are replaced in the audit log of
+// those groups, and their email in the events they made in any other. The
+// erasure is recorded in a certificate and in the audit log of each group
+// changed.
+func (r *resource) EraseSubject(ctx context.Context, email string) (*models.ErasureCertificate, *customError.CustomError) {
+	certificate := &models.ErasureCertificate{SubjectHash: SubjectHash(r.subjectKey, email), Groups: []string{}}
+
+	// A group that changes between reading and rewriting it is read again.
+	for attempt := 1; ; attempt++ {
+		groups, err := r.repo.GetGroupsByEmail(ctx, email)
+		if err != nil {
+			return nil, err
+		}
+
+		var stale *customError.CustomError
+		for _, g := range groups {
+			// The log goes first: the group is not found again once the
+			// person is gone from it.
+			if err := redactAudit(ctx, r.repo, g.Id.Hex(), subjectPerson(email, subjectGroup(g, email).Participants)); err != nil {
+				return nil, err
+			}
+
+			rewrite, removed := eraseFrom(g, email)
+			if _, err := r.repo.RewriteGroup(ctx, g.Id.Hex(), g.Version, rewrite); err != nil {
+				if err.Kind() == customError.GroupVersionMismatch {
+					stale = err
+					continue
+				}
+				return nil, err
+			}
+
+			certificate.Groups = append(certificate.Groups, g.Id.Hex())
+			certificate.ParticipantsRemoved += removed
+			if len(g.Matches) > 0 && removed > 0 {
+				certificate.DrawsRepaired++
+			}
+		}
+
+		if stale == nil {
+			break
+		}
+		if attempt == drawAttempts {
+			return nil, stale
+		}
+		slog.InfoContext(ctx, "Group changed during the erasure, erasing again", "attempt", attempt)
+	}
+
+	acted, err := r.repo.GetAuditEventsByActor(ctx, strings.ToLower(strings.TrimSpace(email)))
+	if err != nil {
+		return nil, err
+	}
+	if err := rewriteRedacted(ctx, r.repo, acted, subjectPerson(email, nil)); err != nil {
+		return nil, err
+	}
+
+	certificate.ErasedAt = time.Now()
+	if err := r.repo.RecordErasure(ctx, certificate); err != nil {
+		return nil, err
+	}
+
+	for _, id := range certificate.Groups {
+		objectID, _ := primitive.ObjectIDFromHex(id)
+		event := &models.AuditEvent{GroupId: objectID, Action: models.AuditErased, Actor: models.AuditActorAdmin, RequestId: logging.RequestID(ctx), Detail: "certificate " + certificate.Id.Hex()}
+		if err := r.repo.AppendAudit(ctx, event); err != nil {
+			slog.ErrorContext(ctx, "Failed to record audit event", "group", id, "action", event.Action, "error", err)
+		}
+	}
+
+	slog.InfoContext(ctx, "Data subject erased", "certificate", certificate.Id.Hex(), "groups", len(certificate.Groups))
+	return certificate, nil
+}
+
+func (r *resource) GetErasure(ctx context.Context, id string) (*models.ErasureCertificate, *customError.CustomError) {
+	return r.repo.GetErasure(ctx, id)
+}
+
+// SubjectHash identifies a data subject in erasure certificates without
+// keeping their email: an HMAC-SHA256 of it in lower case under key, the
+// blind index key, so that it cannot be reversed by hashing candidate emails.
+// Without a key it is empty and the certificate is known by its ID alone.
+func SubjectHash(key []byte, email string) string {
+	if len(key) == 0 {
+		return ""
+	}
+
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(strings.ToLower(strings.TrimSpace(email))))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// eraseFrom returns g without the participants of email and without email as
+// owner, and how many participants it removed. A group left with fewer than
+// two participants loses its draw and is open again.
+func eraseFrom(g *models.Group, email string) (*models.GroupRewrite, int) {
+	rewrite := &models.GroupRewrite{Owner: g.Owner, Status: g.Status, Participants: []models.Participant{}}
+	if strings.EqualFold(g.Owner, email) {
+		rewrite.Owner = ""
+	}
+
+	erased := make(map[string]bool)
+	for _, participant := range g.Participants {
+		if strings.EqualFold(participant.Email, email) {
+			erased[participant.Name] = true
+			continue
+		}
+		rewrite.Participants = append(rewrite.Participants, participant)
+	}
+
+	rewrite.Matches = g.Matches
+	if len(erased) > 0 && len(g.Matches) > 0 {
+		rewrite.Matches = repairDraw(g.Matches, erased)
+	}
+	if len(rewrite.Matches) < 2 {
+		rewrite.Matches = nil
+		if g.Status == models.GroupStatusDrawn {
+			rewrite.Status = models.GroupStatusOpen
+		}
+	}
+
+	return rewrite, len(g.Participants) - len(rewrite.Participants)
+}
+
+// repairDraw takes the erased names out of matches, compared exactly as the
+// draw stored them. Whoever gave to an erased person gives to whom that
+// person gave. When that leaves someone giving to themselves, they swap
+// receivers with another giver, so only two matches change.
+func repairDraw(matches []models.Match, erased map[string]bool) []models.Match {
+	isErased := func(name string) bool { return erased[name] }
+
+	receiverOf := make(map[string]string)
+	for _, match := range matches {
+		if isErased(match.First) {
+			receiverOf[match.First] = match.Second
+		}
+	}
+
+	repaired := make([]models.Match, 0, len(matches))
+	for _, match := range matches {
+		if isErased(match.First) {
+			continue
+		}
+		second := match.Second
+		for hops := 0; isErased(second) && hops < len(matches); hops++ {
+			second = receiverOf[second]
+		}
+		if second == "" || isErased(second) {
+			second = match.First
+		}
+		repaired = append(repaired, models.Match{First: match.First, Second: second})
+	}
+
+	for i := 0; i < len(repaired); i++ {
+		if repaired[i].First != repaired[i].Second {
+			continue
+		}
+		other := -1
+		for j := range repaired {
+			if j != i && repaired[j].First != repaired[j].Second {
+				other = j
+				break
+			}
+		}
+		if other < 0 {
+			repaired = append(repaired[:i], repaired[i+1:]...)
+			i--
+			continue
+		}
+		repaired[i].Second, repaired[other].Second = repaired[other].Second, repaired[i].First
+	}
+
+	return repaired
+}
@@ -0,0 +1,149 @@
+package group
+
+import (
+	"context"
+
+	"service-secret-santa/customError"
+	"service-secret-santa/models"
+	"service-secret-santa/resources/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type traced struct {
+	next Service
+}
+
+// NewTracedService opens a span around every call to svc. Spans carry the
+// group ID but never names, e-mails or matches.
+func NewTracedService(svc Service) Service {
+	return &traced{next: svc}
+}
+
+func startSpan(ctx context.Context, method string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return tracing.Tracer().Start(ctx, "group.Service/"+method, trace.WithAttributes(attrs...))
+}
+
+func groupID(id string) attribute.KeyValue {
+	return attribute.String("group.id", id)
+}
+
+func (s *traced) CreateGroup(ctx context.Context, group *models.Group) (*models.Group, *customError.CustomError) {
+	ctx, span := startSpan(ctx, "CreateGroup")
+	res, err := s.next.CreateGroup(ctx, group)
+	tracing.End(span, err)
+	return res, err
+}
+
+func (s *traced) GetGroupByID(ctx context.Context, id string) (*models.Group, *customError.CustomError) {
+	ctx, span := startSpan(ctx, "GetGroupByID", groupID(id))
+	res, err := s.next.GetGroupByID(ctx, id)
+	tracing.End(span, err)
+	return res, err
+}
+
+func (s *traced) UpdateGroup(ctx context.Context, id string, version int64, update *models.GroupUpdate) (*models.Group, *customError.CustomError) {
+	ctx, span := startSpan(ctx, "UpdateGroup", groupID(id))
+	res, err := s.next.UpdateGroup(ctx, id, version, update)
+	tracing.End(span, err)
+	return res, err
+}
+
+func (s *traced) PatchGroup(ctx context.Context, id string, version int64, patch GroupPatch) (*models.Group, *customError.CustomError) {
+	ctx, span := startSpan(ctx, "PatchGroup", groupID(id))
+	res, err := s.next.PatchGroup(ctx, id, version, patch)
+	tracing.End(span, err)
+	return res, err
+}
+
+func (s *traced) DeleteGroup(ctx context.Context, id string, version int64) *customError.CustomError {
+	ctx, span := startSpan(ctx, "DeleteGroup", groupID(id))
+	err := s.next.DeleteGroup(ctx, id, version)
+	tracing.End(span, err)
+	return err
+}
+
+func (s *traced) GetTrash(ctx context.Context, owner string) ([]*models.Group, *customError.CustomError) {
+	ctx, span := startSpan(ctx, "GetTrash")
+	res, err := s.next.GetTrash(ctx, owner)
+	tracing.End(span, err)
+	return res, err
+}
+
+func (s *traced) RestoreGroup(ctx context.Context, id string, version int64) (*models.Group, *customError.CustomError) {
+	ctx, span := startSpan(ctx, "RestoreGroup", groupID(id))
+	res, err := s.next.RestoreGroup(ctx, id, version)
+	tracing.End(span, err)
+	return res, err
+}
+
+func (s *traced) AddParticipant(ctx context.Context, id string, version int64, participant *models.Participant) (*models.Group, *customError.CustomError) {
+	ctx, span := startSpan(ctx, "AddParticipant", groupID(id))
+	res, err := s.next.AddParticipant(ctx, id, version, participant)
+	tracing.End(span, err)
+	return res, err
+}
+
+func (s *traced) MatchParticipants(ctx context.Context, id string, version int64) (*models.Group, *customError.CustomError) {
+	ctx, span := startSpan(ctx, "MatchParticipants", groupID(id))
+	res, err := s.next.MatchParticipants(ctx, id, version)
+	tracing.End(span, err)
+	return res, err
+}
+
+func (s *traced) GetMyMatch(ctx context.Context, id string, username string) (string, *customError.CustomError) {
+	ctx, span := startSpan(ctx, "GetMyMatch", groupID(id))
+	res, err := s.next.GetMyMatch(ctx, id, username)
+	tracing.End(span, err)
+	return res, err
+}
+
+func (s *traced) GetAllGroups(ctx context.Context, query models.GroupQuery) (*models.GroupPage, *customError.CustomError) {
+	ctx, span := startSpan(ctx, "GetAllGroups")
+	res, err := s.next.GetAllGroups(ctx, query)
+	tracing.End(span, err)
+	return res, err
+}
+
+func (s *traced) SearchGroups(ctx context.Context, query models.SearchQuery) ([]*models.Group, *customError.CustomError) {
+	ctx, span := startSpan(ctx, "SearchGroups")
+	res, err := s.next.SearchGroups(ctx, query)
+	tracing.End(span, err)
+	return res, err
+}
+
+func (s *traced) SearchParticipants(ctx context.Context, id string, query models.SearchQuery) ([]models.Participant, *customError.CustomError) {
+	ctx, span := startSpan(ctx, "SearchParticipants", groupID(id))
+	res, err := s.next.SearchParticipants(ctx, id, query)
+	tracing.End(span, err)
+	return res, err
+}
+
+func (s *traced) ExportSubject(ctx context.Context, email string) (*models.SubjectExport, *customError.CustomError) {
+	ctx, span := startSpan(ctx, "ExportSubject")
+	res, err := s.next.ExportSubject(ctx, email)
+	tracing.End(span, err)
+	return res, err
+}
+
+func (s *traced) EraseSubject(ctx context.Context, email string) (*models.ErasureCertificate, *customError.CustomError) {
+	ctx, span := startSpan(ctx, "EraseSubject")
+	res, err := s.next.EraseSubject(ctx, email)
+	tracing.End(span, err)
+	return res, err
+}
+
+func (s *traced) GetErasure(ctx context.Context, id string) (*models.ErasureCertificate, *customError.CustomError) {
+	ctx, span := startSpan(ctx, "GetErasure", attribute.String("erasure.id", id))
+	res, err := s.next.GetErasure(ctx, id)
+	tracing.End(span, err)
+	return res, err
+}
+
+func (s *traced) GetAudit(ctx context.Context, id string, organizer string, query models.AuditQuery) ([]models.AuditEvent, *customError.CustomError) {
+	ctx, span := startSpan(ctx, "GetAudit", groupID(id))
+	res, err := s.next.GetAudit(ctx, id, organizer, query)
+	tracing.End(span, err)
+	return res, err
+}
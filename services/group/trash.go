@@ -0,0 +1,47 @@
+package group
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"service-secret-santa/repositories/group"
+	"service-secret-santa/resources/health"
+	"service-secret-santa/resources/lifecycle"
+)
+
+// TrashPurger removes, every interval, the groups that have been in the trash
+// for longer than the retention. It is meant for storages without TTL
+// indexes; MongoDB expires trashed groups on its own.
+type TrashPurger struct {
+	repo      group.Repository
+	retention time.Duration
+	status    *health.JobStatus
+}
+
+func NewTrashPurger(repo group.Repository, retention, interval time.Duration) *TrashPurger {
+	return &TrashPurger{repo: repo, retention: retention, status: health.NewJobStatus("trash-purge", interval)}
+}
+
+// Purge removes the groups deleted more than the retention ago.
+func (p *TrashPurger) Purge(ctx context.Context) error {
+	purged, err := p.repo.PurgeTrash(ctx, time.Now().Add(-p.retention))
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to purge trash", "error", err)
+		return err
+	}
+	if purged > 0 {
+		slog.InfoContext(ctx, "Trash purged", "groups", purged)
+	}
+	return nil
+}
+
+// Component purges once on start and then every interval until stopped.
+func (p *TrashPurger) Component() lifecycle.Component {
+	return p.status.Component(p.Purge)
+}
+
+// Check reports the purges to the readiness probe.
+func (p *TrashPurger) Check() health.Check {
+	return p.status.Check()
+}